@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -43,12 +46,14 @@ func init() {
 
 func main() {
 	var port int
+	var configFile string
 
 	flag.IntVar(&port, "port", 8080, "HTTP gateway port")
+	flag.StringVar(&configFile, "config", os.Getenv("CONFIG_FILE"), "YAML config file keyed by environment variable name; environment variables override it")
 	flag.Parse()
 
-	cfg := config.LoadFromEnv()
-	if err := cfg.Validate(); err != nil {
+	cfg, err := loadConfig(configFile)
+	if err != nil {
 		log.Fatalf("invalid configuration: %v", err)
 	}
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -76,23 +81,45 @@ func main() {
 	}
 
 	// Create executor client (TCP framed protocol, direct to executor agent)
-	executorClient := client.NewExecutorClient(cfg.ExecutorPort, cfg.HTTPClientTimeout)
+	var executorAuthToken string
+	if cfg.ExecutorAuthEnabled {
+		executorAuthToken = cfg.GRPCAuthToken
+	}
+	var executorTLS *tls.Config
+	if cfg.ExecutorTLSEnabled {
+		executorTLS, err = client.LoadExecutorTLSConfig(cfg.ExecutorTLSCertFile, cfg.ExecutorTLSKeyFile, cfg.ExecutorTLSCAFile, cfg.ExecutorTLSServerName)
+		if err != nil {
+			log.Fatalf("Failed to load executor TLS config: %v", err)
+		}
+	}
+	executorClient := client.NewExecutorClient(cfg.ExecutorPort, cfg.HTTPClientTimeout, client.ExecutorClientOptions{
+		DialTimeout:      cfg.ExecutorDialTimeout,
+		KeepAlive:        cfg.ExecutorKeepAlive,
+		DialAttempts:     cfg.ExecutorDialAttempts,
+		DialRetryBackoff: cfg.ExecutorDialRetryBackoff,
+		AuthToken:        executorAuthToken,
+		TLSConfig:        executorTLS,
+	})
 
 	// Create the sandbox runtime allocator backed by agent-sandbox CRDs.
 	metricsCollector := metrics.NewPrometheusCollector()
 	runtimeAllocator := gateway.NewSandboxClaimRuntimeAllocator(k8sClient, cfg.GatewayNamespace)
+	runtimeAllocator.SetPollInterval(cfg.SessionPollInterval)
 	log.Println("Runtime allocator backend: sandboxclaim")
 
 	// Trajectory writer is connected asynchronously so ClickHouse startup
-	// ordering never blocks the gateway health endpoint.
+	// ordering never blocks the gateway health endpoint. The file backend
+	// is opened synchronously after the gateway is created.
 	var trajectoryConfig *audit.TrajectoryConfig
-	if cfg.TrajectoryEnabled {
+	if cfg.TrajectoryEnabled && cfg.TrajectoryBackend == "clickhouse" {
 		trajectoryConfig = &audit.TrajectoryConfig{
-			Addr:     cfg.ClickHouseAddr,
-			Database: cfg.ClickHouseDatabase,
-			Username: cfg.ClickHouseUsername,
-			Password: cfg.ClickHousePassword,
-			Debug:    cfg.TrajectoryDebug,
+			Addr:              cfg.ClickHouseAddr,
+			Database:          cfg.ClickHouseDatabase,
+			Username:          cfg.ClickHouseUsername,
+			Password:          cfg.ClickHousePassword,
+			Debug:             cfg.TrajectoryDebug,
+			DedupObservations: cfg.TrajectoryDedupObservations,
+			RetentionDays:     cfg.TrajectoryRetentionDays,
 		}
 	}
 
@@ -123,23 +150,39 @@ func main() {
 		}
 	}
 
+	// Already validated with the rest of the config.
+	executorAgentSecurityContext, _ := cfg.ExecutorAgentSecurityContextSpec()
 	gw := gateway.New(k8sClient, runtimeAllocator, executorClient, metricsCollector, nil, gateway.GatewayConfig{
 		IdleTimeout:                     cfg.GatewayIdleTimeout,
 		DevboxIdleTimeout:               cfg.DevboxIdleTimeout,
 		DevboxStorageClassName:          cfg.DevboxStorageClassName,
 		SweepInterval:                   cfg.GatewaySweepInterval,
+		MaxActiveSessions:               cfg.MaxActiveSessions,
 		Namespace:                       cfg.GatewayNamespace,
 		ExecutorAgentImage:              cfg.ExecutorAgentImage,
 		ExecutorPort:                    cfg.ExecutorPort,
+		ExecutorMaxConcurrentCalls:      cfg.ExecutorMaxConcurrentCalls,
 		IrohRelayURL:                    cfg.IrohRelayURL,
 		IrohRelayExternalURL:            cfg.IrohRelayExternalURL,
 		ImagePullPolicy:                 cfg.ImagePullPolicy,
+		ExecutorAgentImagePullSecret:    cfg.ExecutorAgentImagePullSecret,
+		ExecutorAgentSecurityContext:    executorAgentSecurityContext,
 		GRPCAuthToken:                   cfg.GRPCAuthToken,
 		GRPCAuthSecretName:              cfg.GRPCAuthSecretName,
+		ExecutorAuthEnabled:             cfg.ExecutorAuthEnabled,
+		ExecutorTLSEnabled:              cfg.ExecutorTLSEnabled,
+		ExecutorTLSSecretName:           cfg.ExecutorTLSSecretName,
+		ExecutorStartupTimeout:          cfg.ExecutorStartupTimeout,
+		ExecutorReadinessPeriod:         cfg.ExecutorReadinessPeriod,
+		ExecutorLivenessPeriod:          cfg.ExecutorLivenessPeriod,
+		ExecutorLivenessFailures:        int32(cfg.ExecutorLivenessFailures),
+		RestoreBackend:                  cfg.RestoreBackend,
 		PodHTTPProxy:                    cfg.PodHTTPProxy,
 		PodNoProxy:                      cfg.PodNoProxy,
 		AdmissionQueueTimeout:           cfg.AdmissionQueueTimeout,
 		AdmissionQueuePollInterval:      cfg.AdmissionQueuePollInterval,
+		SandboxFairBinding:              cfg.SandboxFairBinding,
+		SessionReadyTimeout:             cfg.SessionReadyTimeout,
 		PoolAutoscalerEnabled:           cfg.PoolAutoscalerEnabled,
 		PoolAutoscalerInterval:          cfg.PoolAutoscalerInterval,
 		PoolAutoscalerBuffer:            cfg.PoolAutoscalerBuffer,
@@ -169,6 +212,14 @@ func main() {
 		CheckpointGCInterval:            cfg.CheckpointGCInterval,
 		FullObservationEnabled:          cfg.FullObservationEnabled,
 		ObservationPreviewBytes:         cfg.ObservationPreviewBytes,
+		ExecEnvDenyList:                 cfg.ExecEnvDenyList,
+		ExecEnvAllowList:                cfg.ExecEnvAllowList,
+		ExecCommandDenyList:             cfg.ExecCommandDenyList,
+		WorkspaceHostPathPrefixes:       cfg.WorkspaceHostPathPrefixes,
+		ExecMaxOutputBytes:              cfg.ExecMaxOutputBytes,
+		ExecKillOnOutputLimit:           cfg.ExecKillOnOutputLimit,
+		ResourceSamplingEnabled:         cfg.ResourceSamplingEnabled,
+		TrajectoryQueueSize:             cfg.TrajectoryQueueSize,
 		BuildEnabled:                    cfg.BuildEnabled,
 		BuildKanikoImage:                cfg.BuildKanikoImage,
 		BuildRegistrySecret:             cfg.BuildRegistrySecret,
@@ -208,6 +259,16 @@ func main() {
 	gw.StartCheckpointGC()
 	if trajectoryConfig != nil {
 		startTrajectoryConnector(ctx, gw, *trajectoryConfig)
+	} else if cfg.TrajectoryEnabled && cfg.TrajectoryBackend == "file" {
+		tw, err := audit.NewFileTrajectoryWriter(audit.FileTrajectoryConfig{
+			Dir:          cfg.TrajectoryFileDir,
+			MaxFileBytes: cfg.TrajectoryFileMaxBytes,
+		})
+		if err != nil {
+			log.Fatalf("Failed to open trajectory file store: %v", err)
+		}
+		gw.SetTrajectoryWriter(tw)
+		log.Printf("Trajectory writer enabled (file backend, dir=%s)", cfg.TrajectoryFileDir)
 	}
 
 	// Start health checker
@@ -263,6 +324,8 @@ func main() {
 
 	rateLimiter := gateway.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
 
+	stopReload := watchConfigReload(configFile, gw, rateLimiter)
+
 	// --- Public server (authenticated, rate-limited) ---
 	publicRouter := gateway.SetupRoutes(gw, authCfg)
 	publicHandler := rateLimiter.Middleware(gateway.GzipMiddleware(publicRouter))
@@ -289,13 +352,25 @@ func main() {
 	internalRouter := gateway.SetupInternalRoutes(healthChecker)
 
 	internalServer := &http.Server{
-		Addr:         fmt.Sprintf("127.0.0.1:%d", cfg.InternalPort),
+		Addr:         net.JoinHostPort(cfg.InternalBindAddress, strconv.Itoa(cfg.InternalPort)),
 		Handler:      internalRouter,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// --- Metrics server (/metrics only, scraped through a Service) ---
+	var metricsServer *http.Server
+	if cfg.MetricsPort > 0 {
+		metricsServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.MetricsPort),
+			Handler:      gateway.SetupMetricsRoutes(),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+	}
+
 	go func() {
 		log.Printf("Gateway listening on :%d (public)", port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -304,15 +379,32 @@ func main() {
 	}()
 
 	go func() {
-		log.Printf("Internal server listening on :%d (metrics, debug)", cfg.InternalPort)
+		log.Printf("Internal server listening on %s (metrics, debug)", internalServer.Addr)
 		if err := internalServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Internal server error: %v", err)
 		}
 	}()
 
+	if metricsServer != nil {
+		go func() {
+			log.Printf("Metrics server listening on %s", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
 	<-ctx.Done()
 	log.Println("Shutting down gateway...")
 
+	// Let in-flight executions and shells finish before their executor
+	// connections and the trajectory writer are closed.
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.GatewayDrainTimeout)
+	if err := gw.Drain(drainCtx); err != nil {
+		log.Printf("Warning: drain incomplete: %v", err)
+	}
+	drainCancel()
+
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -322,6 +414,12 @@ func main() {
 	if err := internalServer.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Warning: internal server shutdown: %v", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Warning: metrics server shutdown: %v", err)
+		}
+	}
+	stopReload()
 	if stopKeyWatcher != nil {
 		stopKeyWatcher()
 	}
@@ -341,6 +439,60 @@ func main() {
 	log.Println("Gateway stopped")
 }
 
+// loadConfig reads the config file when one is given, with environment
+// overrides, and the environment alone otherwise.
+func loadConfig(configFile string) (*config.Config, error) {
+	if configFile != "" {
+		return config.LoadFromFile(configFile)
+	}
+	cfg := config.LoadFromEnv()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// watchConfigReload re-reads the configuration on SIGHUP and applies the
+// settings that are safe to change at runtime: session idle timeouts, the
+// active session cap, the exec env deny/allow lists, the exec command
+// denylist and the public rate limit. Everything else still needs a
+// restart. The environment of a running process is fixed, so a reload only
+// picks up changes made to the config file. An invalid config is logged and ignored.
+func watchConfigReload(configFile string, gw *gateway.Gateway, rateLimiter *gateway.RateLimiter) func() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+			}
+			cfg, err := loadConfig(configFile)
+			if err != nil {
+				log.Printf("Warning: config reload rejected: %v", err)
+				continue
+			}
+			gw.ApplyReloadableConfig(gateway.ReloadableConfig{
+				IdleTimeout:         cfg.GatewayIdleTimeout,
+				DevboxIdleTimeout:   cfg.DevboxIdleTimeout,
+				MaxActiveSessions:   cfg.MaxActiveSessions,
+				ExecEnvDenyList:     cfg.ExecEnvDenyList,
+				ExecEnvAllowList:    cfg.ExecEnvAllowList,
+				ExecCommandDenyList: cfg.ExecCommandDenyList,
+			})
+			rateLimiter.SetLimit(cfg.RateLimitRPS, cfg.RateLimitBurst)
+			log.Printf("Config reloaded (idle=%s devboxIdle=%s maxActiveSessions=%d rateLimit=%.0f/%d)",
+				cfg.GatewayIdleTimeout, cfg.DevboxIdleTimeout, cfg.MaxActiveSessions, cfg.RateLimitRPS, cfg.RateLimitBurst)
+		}
+	}()
+	return func() {
+		signal.Stop(hup)
+		close(done)
+	}
+}
+
 func startTrajectoryConnector(ctx context.Context, gw *gateway.Gateway, cfg audit.TrajectoryConfig) {
 	go func() {
 		for attempt := 1; ; attempt++ {
@@ -1,2 +1,3 @@
-// Package audit provides ClickHouse-backed trajectory storage.
+// Package audit provides trajectory storage backed by ClickHouse or by local
+// JSONL files.
 package audit
@@ -0,0 +1,287 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	activeTrajectoryFile       = "trajectory.jsonl"
+	defaultTrajectoryFileBytes = 100 * 1024 * 1024
+)
+
+// FileTrajectoryConfig holds configuration for file-backed trajectory storage.
+type FileTrajectoryConfig struct {
+	// Dir holds trajectory JSONL files and a blobs/ subdirectory.
+	Dir string
+	// MaxFileBytes rotates the active file once it grows past this size.
+	// Defaults to 100MiB.
+	MaxFileBytes int64
+}
+
+// FileTrajectoryWriter appends trajectory entries as JSON lines to a local
+// file, rotating by size. It is intended for local clusters and tests where
+// running ClickHouse is not worth the overhead; reads scan every file, so it
+// is not meant for large volumes.
+type FileTrajectoryWriter struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileTrajectoryWriter opens (or creates) the trajectory directory.
+func NewFileTrajectoryWriter(cfg FileTrajectoryConfig) (*FileTrajectoryWriter, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("trajectory directory is required")
+	}
+	if err := os.MkdirAll(filepath.Join(cfg.Dir, "blobs"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create trajectory directory: %w", err)
+	}
+	maxBytes := cfg.MaxFileBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultTrajectoryFileBytes
+	}
+	w := &FileTrajectoryWriter{dir: cfg.Dir, maxBytes: maxBytes}
+	if err := w.openActive(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileTrajectoryWriter) openActive() error {
+	f, err := os.OpenFile(filepath.Join(w.dir, activeTrajectoryFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open trajectory file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat trajectory file: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+// rotate renames the active file with a timestamp suffix and opens a fresh
+// one. Callers must hold w.mu.
+func (w *FileTrajectoryWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close trajectory file: %w", err)
+	}
+	rotated := fmt.Sprintf("trajectory-%d.jsonl", time.Now().UnixNano())
+	if err := os.Rename(filepath.Join(w.dir, activeTrajectoryFile), filepath.Join(w.dir, rotated)); err != nil {
+		return fmt.Errorf("failed to rotate trajectory file: %w", err)
+	}
+	return w.openActive()
+}
+
+// WriteEntry appends a single trajectory entry.
+func (w *FileTrajectoryWriter) WriteEntry(ctx context.Context, entry TrajectoryEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trajectory entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return fmt.Errorf("trajectory writer is closed")
+	}
+	if w.size > 0 && w.size+int64(len(line)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write trajectory entry: %w", err)
+	}
+	return nil
+}
+
+// GetTrajectory retrieves trajectory entries for a session.
+func (w *FileTrajectoryWriter) GetTrajectory(ctx context.Context, sessionID string) ([]TrajectoryEntry, error) {
+	return w.readEntries(sessionID, 0, -1)
+}
+
+// GetTrajectoryUpTo retrieves trajectory entries up to a specific step.
+func (w *FileTrajectoryWriter) GetTrajectoryUpTo(ctx context.Context, sessionID string, maxStep int) ([]TrajectoryEntry, error) {
+	entries, err := w.readEntries(sessionID, 0, maxStep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trajectory up to step %d: %w", maxStep, err)
+	}
+	return entries, nil
+}
+
+// GetTrajectoryPaged retrieves at most limit entries for a session, skipping
+// the first offset in step order. A non-positive limit returns the rest. The
+// files are still scanned in full; only the returned slice is bounded.
+func (w *FileTrajectoryWriter) GetTrajectoryPaged(ctx context.Context, sessionID string, offset, limit int) ([]TrajectoryEntry, error) {
+	entries, err := w.readEntries(sessionID, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trajectory page: %w", err)
+	}
+	if offset >= len(entries) {
+		return nil, nil
+	}
+	entries = entries[offset:]
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// GetTrajectoryRange retrieves trajectory entries with fromStep <= step <= toStep.
+func (w *FileTrajectoryWriter) GetTrajectoryRange(ctx context.Context, sessionID string, fromStep, toStep int) ([]TrajectoryEntry, error) {
+	entries, err := w.readEntries(sessionID, fromStep, toStep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trajectory steps %d-%d: %w", fromStep, toStep, err)
+	}
+	return entries, nil
+}
+
+// readEntries scans all trajectory files for sessionID, keeping steps at or
+// above minStep and at or below maxStep when maxStep is non-negative.
+func (w *FileTrajectoryWriter) readEntries(sessionID string, minStep, maxStep int) ([]TrajectoryEntry, error) {
+	readers, err := w.openReaders()
+	if err != nil {
+		return nil, err
+	}
+	defer closeTrajectoryReaders(readers)
+
+	var entries []TrajectoryEntry
+	for _, r := range readers {
+		scanner := bufio.NewScanner(io.LimitReader(r.file, r.size))
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if !bytes.Contains(line, []byte(sessionID)) {
+				continue
+			}
+			var entry TrajectoryEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				continue
+			}
+			if entry.SessionID != sessionID || entry.Step < minStep || (maxStep >= 0 && entry.Step > maxStep) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(r.file.Name()), err)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Step < entries[j].Step })
+	return entries, nil
+}
+
+type trajectoryFileReader struct {
+	file *os.File
+	size int64
+}
+
+// openReaders opens a read handle on every trajectory file under w.mu and
+// records how many bytes each holds, so the scan itself runs without the
+// lock. Open handles survive a concurrent rotation, and bounding each scan
+// to the recorded size keeps it off lines written afterwards.
+func (w *FileTrajectoryWriter) openReaders() ([]trajectoryFileReader, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(w.dir, "trajectory*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trajectory files: %w", err)
+	}
+	readers := make([]trajectoryFileReader, 0, len(paths))
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			closeTrajectoryReaders(readers)
+			return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			closeTrajectoryReaders(readers)
+			return nil, fmt.Errorf("failed to stat %s: %w", filepath.Base(path), err)
+		}
+		readers = append(readers, trajectoryFileReader{file: f, size: info.Size()})
+	}
+	return readers, nil
+}
+
+func closeTrajectoryReaders(readers []trajectoryFileReader) {
+	for _, r := range readers {
+		r.file.Close()
+	}
+}
+
+// StoreBlob stores file content keyed by SHA256 for later replay retrieval.
+func (w *FileTrajectoryWriter) StoreBlob(ctx context.Context, sha256 string, content []byte) error {
+	path, err := w.blobPath(sha256)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return fmt.Errorf("failed to store file blob: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to store file blob: %w", err)
+	}
+	return nil
+}
+
+// GetBlob retrieves file content by SHA256 hash.
+func (w *FileTrajectoryWriter) GetBlob(ctx context.Context, sha256 string) ([]byte, error) {
+	path, err := w.blobPath(sha256)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file blob: %w", err)
+	}
+	return content, nil
+}
+
+func (w *FileTrajectoryWriter) blobPath(sha256 string) (string, error) {
+	if len(sha256) != 64 {
+		return "", fmt.Errorf("invalid blob sha256 %q", sha256)
+	}
+	if _, err := hex.DecodeString(sha256); err != nil {
+		return "", fmt.Errorf("invalid blob sha256 %q", sha256)
+	}
+	return filepath.Join(w.dir, "blobs", sha256), nil
+}
+
+// Close closes the active trajectory file.
+func (w *FileTrajectoryWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileTrajectoryWriterRotatesAndReadsBack(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewFileTrajectoryWriter(FileTrajectoryConfig{Dir: dir, MaxFileBytes: 256})
+	if err != nil {
+		t.Fatalf("NewFileTrajectoryWriter returned error: %v", err)
+	}
+	defer w.Close()
+
+	ctx := context.Background()
+	for step := 0; step < 5; step++ {
+		for _, sessionID := range []string{"gw-a", "gw-b"} {
+			entry := TrajectoryEntry{SessionID: sessionID, Step: step, Name: "run", Action: json.RawMessage(`{}`), Observation: json.RawMessage(`{}`)}
+			if err := w.WriteEntry(ctx, entry); err != nil {
+				t.Fatalf("WriteEntry returned error: %v", err)
+			}
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "trajectory*.jsonl"))
+	if len(files) < 2 {
+		t.Fatalf("trajectory files = %d, want rotation to produce several", len(files))
+	}
+
+	entries, err := w.GetTrajectoryUpTo(ctx, "gw-a", 3)
+	if err != nil {
+		t.Fatalf("GetTrajectoryUpTo returned error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("entries = %d, want 4", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.SessionID != "gw-a" || entry.Step != i {
+			t.Fatalf("entries[%d] = %s/%d", i, entry.SessionID, entry.Step)
+		}
+	}
+}
+
+func TestFileTrajectoryWriterReadsWhileWriting(t *testing.T) {
+	w, err := NewFileTrajectoryWriter(FileTrajectoryConfig{Dir: t.TempDir(), MaxFileBytes: 512})
+	if err != nil {
+		t.Fatalf("NewFileTrajectoryWriter returned error: %v", err)
+	}
+	defer w.Close()
+
+	ctx := context.Background()
+	const steps = 200
+	done := make(chan error, 1)
+	go func() {
+		for step := 0; step < steps; step++ {
+			entry := TrajectoryEntry{SessionID: "gw-a", Step: step, Name: "run", Action: json.RawMessage(`{}`), Observation: json.RawMessage(`{}`)}
+			if err := w.WriteEntry(ctx, entry); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	for {
+		entries, err := w.GetTrajectory(ctx, "gw-a")
+		if err != nil {
+			t.Fatalf("GetTrajectory returned error: %v", err)
+		}
+		for i, entry := range entries {
+			if entry.Step != i {
+				t.Fatalf("entries[%d].Step = %d: a read skipped a step during rotation", i, entry.Step)
+			}
+		}
+		if len(entries) == steps {
+			break
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("WriteEntry returned error: %v", err)
+	}
+}
+
+func TestFileTrajectoryWriterBlobs(t *testing.T) {
+	w, err := NewFileTrajectoryWriter(FileTrajectoryConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFileTrajectoryWriter returned error: %v", err)
+	}
+	defer w.Close()
+
+	sha := strings.Repeat("ab", 32)
+	if err := w.StoreBlob(context.Background(), sha, []byte("hello")); err != nil {
+		t.Fatalf("StoreBlob returned error: %v", err)
+	}
+	got, err := w.GetBlob(context.Background(), sha)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("GetBlob = %q, %v", got, err)
+	}
+	if err := w.StoreBlob(context.Background(), "../escape", nil); err == nil {
+		t.Fatal("StoreBlob accepted a non-sha256 key")
+	}
+}
+
+func TestFileTrajectoryWriterPagesAndRanges(t *testing.T) {
+	w, err := NewFileTrajectoryWriter(FileTrajectoryConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFileTrajectoryWriter returned error: %v", err)
+	}
+	defer w.Close()
+
+	ctx := context.Background()
+	for step := 0; step < 10; step++ {
+		entry := TrajectoryEntry{SessionID: "gw-a", Step: step, Action: json.RawMessage(`{}`), Observation: json.RawMessage(`{}`)}
+		if err := w.WriteEntry(ctx, entry); err != nil {
+			t.Fatalf("WriteEntry returned error: %v", err)
+		}
+	}
+
+	page, err := w.GetTrajectoryPaged(ctx, "gw-a", 4, 3)
+	if err != nil {
+		t.Fatalf("GetTrajectoryPaged returned error: %v", err)
+	}
+	if len(page) != 3 || page[0].Step != 4 || page[2].Step != 6 {
+		t.Fatalf("page = %+v, want steps 4-6", page)
+	}
+	if rest, _ := w.GetTrajectoryPaged(ctx, "gw-a", 8, 0); len(rest) != 2 {
+		t.Fatalf("unlimited page = %d entries, want 2", len(rest))
+	}
+	if past, _ := w.GetTrajectoryPaged(ctx, "gw-a", 20, 5); len(past) != 0 {
+		t.Fatalf("page past the end = %d entries, want 0", len(past))
+	}
+
+	ranged, err := w.GetTrajectoryRange(ctx, "gw-a", 2, 5)
+	if err != nil {
+		t.Fatalf("GetTrajectoryRange returned error: %v", err)
+	}
+	if len(ranged) != 4 || ranged[0].Step != 2 || ranged[3].Step != 5 {
+		t.Fatalf("range = %+v, want steps 2-5", ranged)
+	}
+}
@@ -0,0 +1,22 @@
+package audit
+
+import "context"
+
+// TrajectoryStore is the storage surface the gateway uses for trajectories
+// and uploaded file blobs. TrajectoryWriter (ClickHouse) and
+// FileTrajectoryWriter (local JSONL) implement it.
+type TrajectoryStore interface {
+	WriteEntry(ctx context.Context, entry TrajectoryEntry) error
+	GetTrajectory(ctx context.Context, sessionID string) ([]TrajectoryEntry, error)
+	GetTrajectoryUpTo(ctx context.Context, sessionID string, maxStep int) ([]TrajectoryEntry, error)
+	GetTrajectoryPaged(ctx context.Context, sessionID string, offset, limit int) ([]TrajectoryEntry, error)
+	GetTrajectoryRange(ctx context.Context, sessionID string, fromStep, toStep int) ([]TrajectoryEntry, error)
+	StoreBlob(ctx context.Context, sha256 string, content []byte) error
+	GetBlob(ctx context.Context, sha256 string) ([]byte, error)
+	Close() error
+}
+
+var (
+	_ TrajectoryStore = (*TrajectoryWriter)(nil)
+	_ TrajectoryStore = (*FileTrajectoryWriter)(nil)
+)
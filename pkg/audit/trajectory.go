@@ -2,8 +2,12 @@ package audit
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/driver/clickhouse"
@@ -21,7 +25,11 @@ type TrajectoryEntry struct {
 	SnapshotID  string          `gorm:"column:snapshot_id;type:String" json:"snapshot_id"`
 	DurationMs  int64           `gorm:"column:duration_ms;type:Int64" json:"duration_ms"`
 	Timestamp   time.Time       `gorm:"column:timestamp;type:DateTime64(3)" json:"timestamp"`
-	CreatedAt   time.Time       `gorm:"column:created_at;type:DateTime64(3);autoCreateTime:milli" json:"created_at"`
+	TraceID     string          `gorm:"column:trace_id;type:String" json:"trace_id,omitempty"`
+	// ObservationSHA256 references the observation in observation_blobs when
+	// the writer deduplicates observations; Observation is then stored empty.
+	ObservationSHA256 string    `gorm:"column:observation_sha256;type:String" json:"-"`
+	CreatedAt         time.Time `gorm:"column:created_at;type:DateTime64(3);autoCreateTime:milli" json:"created_at"`
 }
 
 // TableName specifies the table name for GORM
@@ -41,9 +49,22 @@ func (FileBlob) TableName() string {
 	return "file_blobs"
 }
 
+// ObservationBlob stores a trajectory observation body keyed by SHA256, so
+// identical observations across steps and sessions are kept once.
+type ObservationBlob struct {
+	SHA256      string    `gorm:"column:sha256;type:String;primaryKey" json:"sha256"`
+	Observation string    `gorm:"column:observation;type:String" json:"observation"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:DateTime64(3);autoCreateTime:milli" json:"created_at"`
+}
+
+func (ObservationBlob) TableName() string {
+	return "observation_blobs"
+}
+
 // TrajectoryWriter manages trajectory storage in ClickHouse using GORM
 type TrajectoryWriter struct {
-	db *gorm.DB
+	db                *gorm.DB
+	dedupObservations bool
 }
 
 // TrajectoryConfig holds configuration for trajectory storage
@@ -53,8 +74,16 @@ type TrajectoryConfig struct {
 	Username string
 	Password string
 	Debug    bool
+	// DedupObservations stores observation bodies once in observation_blobs
+	// and references them by hash from trajectory rows.
+	DedupObservations bool
+	// RetentionDays is the TTL applied to trajectory rows. Zero means
+	// defaultTrajectoryRetentionDays.
+	RetentionDays int
 }
 
+const defaultTrajectoryRetentionDays = 90
+
 // NewTrajectoryWriter creates a new trajectory writer with GORM
 func NewTrajectoryWriter(cfg TrajectoryConfig) (*TrajectoryWriter, error) {
 	dsn := fmt.Sprintf("clickhouse://%s:%s@%s/%s?dial_timeout=10s&read_timeout=20s",
@@ -84,6 +113,11 @@ func NewTrajectoryWriter(cfg TrajectoryConfig) (*TrajectoryWriter, error) {
 		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
 	}
 
+	retentionDays := cfg.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultTrajectoryRetentionDays
+	}
+
 	// Check if table exists and recreate with proper engine if needed
 	createTableSQL := `
 	CREATE TABLE IF NOT EXISTS trajectory (
@@ -95,16 +129,29 @@ func NewTrajectoryWriter(cfg TrajectoryConfig) (*TrajectoryWriter, error) {
 		snapshot_id String,
 		duration_ms Int64,
 		timestamp DateTime64(3),
+		trace_id String DEFAULT '',
+		observation_sha256 String DEFAULT '',
 		created_at DateTime64(3) DEFAULT now64(3)
 	) ENGINE = MergeTree()
 	PARTITION BY toYYYYMMDD(created_at)
 	ORDER BY (created_at, session_id, step)
-	TTL toDateTime(created_at) + INTERVAL 90 DAY
-	`
+	TTL ` + trajectoryTTL(retentionDays)
 	if _, err := sqlDB.Exec(createTableSQL); err != nil {
 		return nil, fmt.Errorf("failed to create trajectory table: %w", err)
 	}
 
+	// CREATE TABLE IF NOT EXISTS leaves a table from an older release
+	// without columns added since, so add them in place.
+	for _, column := range trajectoryAddedColumns {
+		if _, err := sqlDB.Exec("ALTER TABLE trajectory ADD COLUMN IF NOT EXISTS " + column); err != nil {
+			return nil, fmt.Errorf("failed to add trajectory column %q: %w", column, err)
+		}
+	}
+
+	if err := applyTableTTL(sqlDB, "trajectory", retentionDays); err != nil {
+		return nil, err
+	}
+
 	createBlobsSQL := `
 	CREATE TABLE IF NOT EXISTS file_blobs (
 		sha256 String,
@@ -118,11 +165,89 @@ func NewTrajectoryWriter(cfg TrajectoryConfig) (*TrajectoryWriter, error) {
 		return nil, fmt.Errorf("failed to create file_blobs table: %w", err)
 	}
 
-	return &TrajectoryWriter{db: db}, nil
+	if cfg.DedupObservations {
+		if _, err := sqlDB.Exec(observationBlobsTableSQL(retentionDays)); err != nil {
+			return nil, fmt.Errorf("failed to create observation_blobs table: %w", err)
+		}
+		if err := applyTableTTL(sqlDB, "observation_blobs", retentionDays); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TrajectoryWriter{db: db, dedupObservations: cfg.DedupObservations}, nil
+}
+
+// observationBlobsTableSQL creates the observation dedup table. Blobs expire
+// with the same retention as the trajectory rows that reference them; a
+// blob is re-inserted when a new row reuses it after observationBlobRefresh,
+// and the merge keeps that latest copy.
+func observationBlobsTableSQL(retentionDays int) string {
+	return `
+	CREATE TABLE IF NOT EXISTS observation_blobs (
+		sha256 String,
+		observation String,
+		created_at DateTime64(3) DEFAULT now64(3)
+	) ENGINE = ReplacingMergeTree()
+	ORDER BY sha256
+	TTL ` + trajectoryTTL(retentionDays)
+}
+
+// observationBlobRefresh is how old a stored observation blob may be and
+// still be reused by a new trajectory row. Older blobs are written again so
+// their TTL is renewed: a referencing row can outlive its blob by at most
+// this long.
+const observationBlobRefresh = 24 * time.Hour
+
+// applyTableTTL sets table's TTL to retentionDays when it differs. CREATE
+// TABLE IF NOT EXISTS keeps the TTL of an existing table, so a changed
+// retention, or a table created before it had a TTL, needs an ALTER.
+func applyTableTTL(sqlDB *sql.DB, table string, retentionDays int) error {
+	var engineFull string
+	if err := sqlDB.QueryRow("SELECT engine_full FROM system.tables WHERE database = currentDatabase() AND name = ?", table).Scan(&engineFull); err != nil {
+		return fmt.Errorf("failed to read %s table TTL: %w", table, err)
+	}
+	if stmt := modifyTTLStatement(table, engineFull, retentionDays); stmt != "" {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to update %s table TTL: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// modifyTTLStatement returns the ALTER that gives table a retentionDays TTL,
+// or "" when its engine_full already has it.
+func modifyTTLStatement(table, engineFull string, retentionDays int) string {
+	if hasRetentionDays(engineFull, retentionDays) {
+		return ""
+	}
+	return "ALTER TABLE " + table + " MODIFY TTL " + trajectoryTTL(retentionDays)
+}
+
+// trajectoryAddedColumns are the trajectory columns added after the table
+// was first released, as ClickHouse column definitions.
+var trajectoryAddedColumns = []string{
+	"trace_id String DEFAULT ''",
+	"observation_sha256 String DEFAULT ''",
+}
+
+func trajectoryTTL(days int) string {
+	return fmt.Sprintf("toDateTime(created_at) + INTERVAL %d DAY", days)
+}
+
+// hasRetentionDays reports whether a table's engine_full already carries a
+// TTL of days. ClickHouse normalizes "INTERVAL N DAY" to toIntervalDay(N).
+func hasRetentionDays(engineFull string, days int) bool {
+	return strings.Contains(engineFull, fmt.Sprintf("toIntervalDay(%d)", days)) ||
+		strings.Contains(engineFull, fmt.Sprintf("INTERVAL %d DAY", days))
 }
 
 // WriteEntry writes a single trajectory entry
 func (w *TrajectoryWriter) WriteEntry(ctx context.Context, entry TrajectoryEntry) error {
+	entries := []TrajectoryEntry{entry}
+	if err := w.dedupBatch(ctx, entries); err != nil {
+		return err
+	}
+	entry = entries[0]
 	if err := w.db.WithContext(ctx).Create(&entry).Error; err != nil {
 		return fmt.Errorf("failed to write trajectory entry: %w", err)
 	}
@@ -134,6 +259,9 @@ func (w *TrajectoryWriter) WriteBatch(ctx context.Context, entries []TrajectoryE
 	if len(entries) == 0 {
 		return nil
 	}
+	if err := w.dedupBatch(ctx, entries); err != nil {
+		return err
+	}
 
 	if err := w.db.WithContext(ctx).CreateInBatches(entries, 100).Error; err != nil {
 		return fmt.Errorf("failed to write trajectory batch: %w", err)
@@ -150,7 +278,7 @@ func (w *TrajectoryWriter) GetTrajectory(ctx context.Context, sessionID string)
 		Find(&entries).Error; err != nil {
 		return nil, fmt.Errorf("failed to get trajectory: %w", err)
 	}
-	return entries, nil
+	return w.resolveObservations(ctx, entries)
 }
 
 // GetTrajectoryUpTo retrieves trajectory entries up to a specific step
@@ -162,6 +290,124 @@ func (w *TrajectoryWriter) GetTrajectoryUpTo(ctx context.Context, sessionID stri
 		Find(&entries).Error; err != nil {
 		return nil, fmt.Errorf("failed to get trajectory up to step %d: %w", maxStep, err)
 	}
+	return w.resolveObservations(ctx, entries)
+}
+
+// GetTrajectoryPaged retrieves at most limit entries for a session, skipping
+// the first offset in step order. A non-positive limit returns the rest.
+func (w *TrajectoryWriter) GetTrajectoryPaged(ctx context.Context, sessionID string, offset, limit int) ([]TrajectoryEntry, error) {
+	var entries []TrajectoryEntry
+	q := w.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("step ASC").
+		Offset(offset)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get trajectory page: %w", err)
+	}
+	return w.resolveObservations(ctx, entries)
+}
+
+// GetTrajectoryRange retrieves trajectory entries with fromStep <= step <= toStep
+func (w *TrajectoryWriter) GetTrajectoryRange(ctx context.Context, sessionID string, fromStep, toStep int) ([]TrajectoryEntry, error) {
+	var entries []TrajectoryEntry
+	if err := w.db.WithContext(ctx).
+		Where("session_id = ? AND step >= ? AND step <= ?", sessionID, fromStep, toStep).
+		Order("step ASC").
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get trajectory steps %d-%d: %w", fromStep, toStep, err)
+	}
+	return w.resolveObservations(ctx, entries)
+}
+
+// dedupBatch moves each entry's observation into observation_blobs and
+// leaves a reference. The batch is hashed up front and checked with a single
+// lookup, and only blobs not stored yet are written. Entries are rewritten
+// only once their blobs are stored, so a failed call can be retried.
+func (w *TrajectoryWriter) dedupBatch(ctx context.Context, entries []TrajectoryEntry) error {
+	if !w.dedupObservations {
+		return nil
+	}
+	entryHashes := make([]string, len(entries))
+	bodies := make(map[string]string)
+	var hashes []string
+	for i, e := range entries {
+		if len(e.Observation) == 0 {
+			continue
+		}
+		sum := sha256.Sum256(e.Observation)
+		hash := hex.EncodeToString(sum[:])
+		entryHashes[i] = hash
+		if _, ok := bodies[hash]; !ok {
+			bodies[hash] = string(e.Observation)
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	// Only blobs stored recently count as present, so reuse keeps renewing
+	// a blob's TTL for as long as new rows reference it.
+	var stored []string
+	if err := w.db.WithContext(ctx).Model(&ObservationBlob{}).
+		Where("sha256 IN ? AND created_at > ?", hashes, time.Now().Add(-observationBlobRefresh)).
+		Pluck("sha256", &stored).Error; err != nil {
+		return fmt.Errorf("failed to look up observation blobs: %w", err)
+	}
+	for _, hash := range stored {
+		delete(bodies, hash)
+	}
+	var blobs []ObservationBlob
+	for _, hash := range hashes {
+		if body, ok := bodies[hash]; ok {
+			blobs = append(blobs, ObservationBlob{SHA256: hash, Observation: body})
+		}
+	}
+	if len(blobs) > 0 {
+		if err := w.db.WithContext(ctx).Create(&blobs).Error; err != nil {
+			return fmt.Errorf("failed to store observation blobs: %w", err)
+		}
+	}
+
+	for i, hash := range entryHashes {
+		if hash != "" {
+			entries[i].ObservationSHA256 = hash
+			entries[i].Observation = nil
+		}
+	}
+	return nil
+}
+
+// resolveObservations joins deduplicated entries with their observation
+// bodies. Entries written without dedup are returned unchanged, so reads
+// work whether or not the flag was on when a row was written.
+func (w *TrajectoryWriter) resolveObservations(ctx context.Context, entries []TrajectoryEntry) ([]TrajectoryEntry, error) {
+	var hashes []string
+	for _, e := range entries {
+		if e.ObservationSHA256 != "" {
+			hashes = append(hashes, e.ObservationSHA256)
+		}
+	}
+	if len(hashes) == 0 {
+		return entries, nil
+	}
+
+	var blobs []ObservationBlob
+	if err := w.db.WithContext(ctx).Where("sha256 IN ?", hashes).Find(&blobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get observation blobs: %w", err)
+	}
+	bodies := make(map[string]string, len(blobs))
+	for _, b := range blobs {
+		bodies[b.SHA256] = b.Observation
+	}
+	for i := range entries {
+		if body, ok := bodies[entries[i].ObservationSHA256]; ok {
+			entries[i].Observation = json.RawMessage(body)
+		}
+	}
 	return entries, nil
 }
 
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasRetentionDays(t *testing.T) {
+	engine := "MergeTree PARTITION BY toYYYYMMDD(created_at) ORDER BY (created_at, session_id, step) TTL toDateTime(created_at) + toIntervalDay(90) SETTINGS index_granularity = 8192"
+	if !hasRetentionDays(engine, 90) {
+		t.Fatal("hasRetentionDays(90) = false for a 90-day TTL")
+	}
+	if hasRetentionDays(engine, 9) {
+		t.Fatal("hasRetentionDays(9) = true for a 90-day TTL")
+	}
+	if hasRetentionDays("MergeTree ORDER BY session_id", 90) {
+		t.Fatal("hasRetentionDays = true for a table without TTL")
+	}
+}
+
+func TestObservationBlobsTableHasRetentionTTL(t *testing.T) {
+	if sql := observationBlobsTableSQL(30); !strings.Contains(sql, "TTL toDateTime(created_at) + INTERVAL 30 DAY") {
+		t.Fatalf("observation_blobs DDL = %q, want a 30-day TTL", sql)
+	}
+}
+
+func TestModifyTTLStatement(t *testing.T) {
+	withTTL := "ReplacingMergeTree ORDER BY sha256 TTL toDateTime(created_at) + toIntervalDay(90) SETTINGS index_granularity = 8192"
+	if stmt := modifyTTLStatement("observation_blobs", withTTL, 90); stmt != "" {
+		t.Fatalf("statement = %q, want none for a table that already has the TTL", stmt)
+	}
+	for _, engine := range []string{withTTL, "ReplacingMergeTree ORDER BY sha256 SETTINGS index_granularity = 8192"} {
+		stmt := modifyTTLStatement("observation_blobs", engine, 30)
+		if stmt != "ALTER TABLE observation_blobs MODIFY TTL toDateTime(created_at) + INTERVAL 30 DAY" {
+			t.Fatalf("statement for %q = %q", engine, stmt)
+		}
+	}
+}
@@ -2,13 +2,19 @@ package client
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Lincyaw/agent-env/pkg/interfaces"
@@ -23,13 +29,57 @@ const (
 	msgTypeRequest  byte = 0x01
 	msgTypeResponse byte = 0x02
 	msgTypeEvent    byte = 0x03
+	msgTypeAuth     byte = 0x04
+	// msgTypeAuthChallenge is sent by agents that require auth, carrying the
+	// identity (pod UID) the connection's token is derived for.
+	msgTypeAuthChallenge byte = 0x05
 )
 
+const (
+	defaultDialTimeout      = 5 * time.Second
+	defaultDialRetryBackoff = 100 * time.Millisecond
+)
+
+// ExecutorClientOptions tunes the TCP connections opened to executor agents.
+// Zero values keep the defaults.
+type ExecutorClientOptions struct {
+	// DialTimeout bounds connection establishment. Defaults to 5s.
+	DialTimeout time.Duration
+	// KeepAlive is the TCP keep-alive probe interval used to keep
+	// long-running shells and streams alive behind load balancers.
+	// Zero uses the Go default (15s); negative disables keep-alives.
+	KeepAlive time.Duration
+	// DialAttempts is the total number of connection attempts made when a
+	// dial fails transiently (refused, unreachable, timed out), which covers
+	// pod networking that is still settling right after a sandbox turns
+	// Ready. Only the connect is retried, so no request is ever sent twice.
+	// Values below 2 disable retries.
+	DialAttempts int
+	// DialRetryBackoff is the wait before the first retry; it doubles on
+	// each further attempt. Defaults to 100ms.
+	DialRetryBackoff time.Duration
+	// AuthToken, when set, is the master token for executor auth. Agents
+	// started with ARL_EXECUTOR_TOKEN_FILE open each connection with a
+	// challenge naming their pod UID and drop the connection unless the
+	// first frame carries DeriveExecutorToken(AuthToken, uid).
+	AuthToken string
+	// TLSConfig, when set, wraps every connection in TLS (see
+	// LoadExecutorTLSConfig). Agents must be started with a matching
+	// server certificate.
+	TLSConfig *tls.Config
+}
+
 // TCPExecutorClient speaks the executor framed protocol over TCP,
 // connecting directly to executor agents.
 type TCPExecutorClient struct {
 	port    int
 	timeout time.Duration
+	dialer  net.Dialer
+
+	dialAttempts     int
+	dialRetryBackoff time.Duration
+	authToken        string
+	tlsConfig        *tls.Config
 
 	mu    sync.RWMutex
 	conns map[string]net.Conn
@@ -37,24 +87,113 @@ type TCPExecutorClient struct {
 
 // NewExecutorClient creates a new executor client that connects directly
 // to executor agents over TCP using the framed protobuf protocol.
-func NewExecutorClient(port int, timeout time.Duration) interfaces.ExecutorClient {
+func NewExecutorClient(port int, timeout time.Duration, opts ExecutorClientOptions) interfaces.ExecutorClient {
+	dialTimeout := opts.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
+	}
+	dialAttempts := opts.DialAttempts
+	if dialAttempts < 1 {
+		dialAttempts = 1
+	}
+	dialRetryBackoff := opts.DialRetryBackoff
+	if dialRetryBackoff <= 0 {
+		dialRetryBackoff = defaultDialRetryBackoff
+	}
 	return &TCPExecutorClient{
-		port:    port,
-		timeout: timeout,
-		conns:   make(map[string]net.Conn),
+		port:             port,
+		timeout:          timeout,
+		dialer:           net.Dialer{Timeout: dialTimeout, KeepAlive: opts.KeepAlive},
+		dialAttempts:     dialAttempts,
+		dialRetryBackoff: dialRetryBackoff,
+		authToken:        opts.AuthToken,
+		tlsConfig:        opts.TLSConfig,
+		conns:            make(map[string]net.Conn),
 	}
 }
 
-// dial opens a fresh TCP connection to the executor at podIP:port.
-func (c *TCPExecutorClient) dial(podIP string) (net.Conn, error) {
+// dial opens a fresh TCP connection to the executor at podIP:port, retrying
+// transient connect failures with exponential backoff, then runs the TLS
+// handshake and sends the auth frame when those are configured.
+func (c *TCPExecutorClient) dial(ctx context.Context, podIP string) (net.Conn, error) {
 	addr := net.JoinHostPort(podIP, strconv.Itoa(c.port))
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("connect to executor at %s: %w", addr, err)
+	backoff := c.dialRetryBackoff
+	for attempt := 1; ; attempt++ {
+		conn, err := c.dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return c.secure(ctx, conn, addr)
+		}
+		if attempt >= c.dialAttempts || ctx.Err() != nil || !isTransientDialError(err) {
+			return nil, fmt.Errorf("connect to executor at %s: %w", addr, err)
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("connect to executor at %s: %w", addr, err)
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (c *TCPExecutorClient) secure(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	if c.tlsConfig != nil {
+		tlsConn := tls.Client(conn, c.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with executor at %s: %w", addr, err)
+		}
+		conn = tlsConn
+	}
+	if c.authToken != "" {
+		if err := c.authenticate(ctx, conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("authenticate to executor at %s: %w", addr, err)
+		}
 	}
 	return conn, nil
 }
 
+// authenticate answers the agent's challenge with the token derived for the
+// identity it names, so a token leaked from one sandbox opens no other.
+func (c *TCPExecutorClient) authenticate(ctx context.Context, conn net.Conn) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.dialer.Timeout)
+	}
+	conn.SetReadDeadline(deadline)
+	msgType, identity, err := readFrame(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return fmt.Errorf("read auth challenge: %w", err)
+	}
+	if msgType != msgTypeAuthChallenge {
+		return fmt.Errorf("unexpected message type 0x%02x, want auth challenge", msgType)
+	}
+	return writeFrame(conn, msgTypeAuth, []byte(DeriveExecutorToken(c.authToken, string(identity))))
+}
+
+// DeriveExecutorToken returns the per-pod executor token for identity (the
+// pod UID): hex(HMAC-SHA256(master, identity)). The executor agent's
+// --derive-token-from mode computes the same value inside the pod.
+func DeriveExecutorToken(master, identity string) string {
+	mac := hmac.New(sha256.New, []byte(master))
+	mac.Write([]byte(identity))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isTransientDialError reports whether a failed connect is worth retrying:
+// the agent not listening yet, the route not programmed yet, or a timeout.
+func isTransientDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // ---------------------------------------------------------------------------
 // Wire protocol helpers
 // Frame format: [1B type][4B big-endian length][protobuf bytes]
@@ -219,7 +358,7 @@ func (c *TCPExecutorClient) callTimeout(ctx context.Context, requested int32) ti
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) Execute(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return nil, err
 	}
@@ -231,10 +370,13 @@ func (c *TCPExecutorClient) Execute(ctx context.Context, podIP string, req *inte
 
 	var tag uint32 = 1
 	spawnReq := &pb.SpawnRequest{
-		Command:        req.Command,
-		Env:            req.Env,
-		WorkingDir:     req.WorkingDir,
-		TimeoutSeconds: req.TimeoutSeconds,
+		Command:           req.Command,
+		Env:               req.Env,
+		WorkingDir:        req.WorkingDir,
+		TimeoutSeconds:    req.TimeoutSeconds,
+		StdinData:         []byte(req.Stdin),
+		MaxOutputBytes:    uint64(max(req.MaxOutputBytes, 0)),
+		KillOnOutputLimit: req.KillOnOutputLimit,
 	}
 
 	if err := sendRequest(conn, &pb.Request{
@@ -244,13 +386,23 @@ func (c *TCPExecutorClient) Execute(ctx context.Context, podIP string, req *inte
 		return nil, fmt.Errorf("send spawn request: %w", err)
 	}
 
+	// Closing the connection on cancellation unblocks the read below and
+	// makes the executor kill the spawned process.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
 	var stdout, stderr strings.Builder
 	var exitCode int32
 	var done bool
+	var startLatency time.Duration
+	var truncated bool
 
 	for {
 		msg, err := readServerMessage(conn)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			return nil, fmt.Errorf("read executor message: %w", err)
 		}
 
@@ -258,7 +410,7 @@ func (c *TCPExecutorClient) Execute(ctx context.Context, podIP string, req *inte
 			r := msg.Response
 			switch result := r.GetKind().(type) {
 			case *pb.Response_Spawn:
-				_ = result.Spawn.GetProcessTag()
+				startLatency = time.Duration(result.Spawn.GetStartLatencyUs()) * time.Microsecond
 				continue
 			case *pb.Response_Error:
 				return nil, fmt.Errorf("executor error: [%d] %s", result.Error.GetCode(), result.Error.GetMessage())
@@ -270,11 +422,14 @@ func (c *TCPExecutorClient) Execute(ctx context.Context, podIP string, req *inte
 		if msg.Event != nil {
 			switch ev := msg.Event.GetKind().(type) {
 			case *pb.Event_Stdout:
-				stdout.Write(ev.Stdout.GetData())
+				// The agent enforces the cap too; this guards against agents
+				// that predate it.
+				truncated = appendLimited(&stdout, ev.Stdout.GetData(), stdout.Len()+stderr.Len(), req.MaxOutputBytes) || truncated
 			case *pb.Event_Stderr:
-				stderr.Write(ev.Stderr.GetData())
+				truncated = appendLimited(&stderr, ev.Stderr.GetData(), stdout.Len()+stderr.Len(), req.MaxOutputBytes) || truncated
 			case *pb.Event_Exit:
 				exitCode = ev.Exit.ExitCode
+				truncated = truncated || ev.Exit.GetOutputTruncated()
 				done = true
 			default:
 				continue
@@ -293,19 +448,33 @@ func (c *TCPExecutorClient) Execute(ctx context.Context, podIP string, req *inte
 	}
 
 	return &interfaces.ExecResponse{
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
-		ExitCode: exitCode,
-		Done:     true,
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		ExitCode:        exitCode,
+		Done:            true,
+		StartLatency:    startLatency,
+		OutputTruncated: truncated,
 	}, nil
 }
 
+// appendLimited appends data to b, keeping the used bytes already collected
+// plus data within limit (0 means no limit). It reports whether any of data
+// was dropped.
+func appendLimited(b *strings.Builder, data []byte, used int, limit int64) bool {
+	if limit > 0 && int64(used+len(data)) > limit {
+		b.Write(data[:max(limit-int64(used), 0)])
+		return true
+	}
+	b.Write(data)
+	return false
+}
+
 // ---------------------------------------------------------------------------
 // ExecuteStream
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) ExecuteStream(ctx context.Context, podIP string, req *interfaces.ExecRequest) (<-chan interfaces.ExecResponse, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return nil, err
 	}
@@ -316,10 +485,13 @@ func (c *TCPExecutorClient) ExecuteStream(ctx context.Context, podIP string, req
 
 	var tag uint32 = 1
 	spawnReq := &pb.SpawnRequest{
-		Command:        req.Command,
-		Env:            req.Env,
-		WorkingDir:     req.WorkingDir,
-		TimeoutSeconds: req.TimeoutSeconds,
+		Command:           req.Command,
+		Env:               req.Env,
+		WorkingDir:        req.WorkingDir,
+		TimeoutSeconds:    req.TimeoutSeconds,
+		StdinData:         []byte(req.Stdin),
+		MaxOutputBytes:    uint64(max(req.MaxOutputBytes, 0)),
+		KillOnOutputLimit: req.KillOnOutputLimit,
 	}
 
 	if err := sendRequest(conn, &pb.Request{
@@ -335,10 +507,18 @@ func (c *TCPExecutorClient) ExecuteStream(ctx context.Context, podIP string, req
 	go func() {
 		defer close(resultChan)
 		defer conn.Close()
+		var startLatency time.Duration
+		// Closing the connection on cancellation unblocks the read below and
+		// makes the executor kill the spawned process.
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
 
 		for {
 			msg, err := readServerMessage(conn)
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				resultChan <- interfaces.ExecResponse{
 					Stderr:   fmt.Sprintf("read: %v", err),
 					ExitCode: 1,
@@ -355,7 +535,7 @@ func (c *TCPExecutorClient) ExecuteStream(ctx context.Context, podIP string, req
 				r := msg.Response
 				switch result := r.GetKind().(type) {
 				case *pb.Response_Spawn:
-					_ = result.Spawn.GetProcessTag()
+					startLatency = time.Duration(result.Spawn.GetStartLatencyUs()) * time.Microsecond
 					continue
 				case *pb.Response_Error:
 					resp = interfaces.ExecResponse{
@@ -376,7 +556,12 @@ func (c *TCPExecutorClient) ExecuteStream(ctx context.Context, podIP string, req
 					resp = interfaces.ExecResponse{Stderr: string(ev.Stderr.GetData())}
 					hasResp = true
 				case *pb.Event_Exit:
-					resp = interfaces.ExecResponse{ExitCode: ev.Exit.ExitCode, Done: true}
+					resp = interfaces.ExecResponse{
+						ExitCode:        ev.Exit.ExitCode,
+						Done:            true,
+						StartLatency:    startLatency,
+						OutputTruncated: ev.Exit.GetOutputTruncated(),
+					}
 					hasResp = true
 				default:
 					continue
@@ -407,7 +592,7 @@ func (c *TCPExecutorClient) ExecuteStream(ctx context.Context, podIP string, req
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) WriteFile(ctx context.Context, podIP string, path string, content io.Reader, expectedSHA256 string) (*interfaces.FileWriteResult, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return nil, err
 	}
@@ -451,7 +636,7 @@ func (c *TCPExecutorClient) WriteFile(ctx context.Context, podIP string, path st
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) ReadFile(ctx context.Context, podIP string, path string, dst io.Writer) (*interfaces.FileReadResult, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return nil, err
 	}
@@ -501,7 +686,7 @@ func (c *TCPExecutorClient) DownloadCheckpointStep(ctx context.Context, podIP st
 }
 
 func (c *TCPExecutorClient) downloadCheckpoint(ctx context.Context, podIP string, through int, singleStep bool, dst io.Writer) error {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return err
 	}
@@ -543,7 +728,7 @@ func (c *TCPExecutorClient) downloadCheckpoint(ctx context.Context, podIP string
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) ListCheckpointSteps(ctx context.Context, podIP string) ([]int, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return nil, err
 	}
@@ -654,7 +839,7 @@ func (s *executorShellStream) Close() error {
 }
 
 func (c *TCPExecutorClient) InteractiveShell(ctx context.Context, podIP string) (interfaces.ShellStream, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return nil, err
 	}
@@ -702,7 +887,7 @@ func (c *TCPExecutorClient) InteractiveShell(ctx context.Context, podIP string)
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) GetIrohAddr(ctx context.Context, podIP string) (string, error) {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return "", err
 	}
@@ -735,7 +920,7 @@ func (c *TCPExecutorClient) GetIrohAddr(ctx context.Context, podIP string) (stri
 // ---------------------------------------------------------------------------
 
 func (c *TCPExecutorClient) HealthCheck(ctx context.Context, podIP string) error {
-	conn, err := c.dial(podIP)
+	conn, err := c.dial(ctx, podIP)
 	if err != nil {
 		return fmt.Errorf("health check failed: cannot connect to %s: %w", podIP, err)
 	}
@@ -0,0 +1,306 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+	pb "github.com/Lincyaw/agent-env/pkg/pb/executorv2"
+	"google.golang.org/protobuf/proto"
+)
+
+// freePort returns a loopback port with nothing listening on it.
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+// servePings answers one ping per connection until the listener closes.
+func servePings(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			if _, _, err := readFrame(conn); err != nil {
+				return
+			}
+			data, _ := proto.Marshal(&pb.Response{Kind: &pb.Response_Ping{Ping: &pb.PingResponse{}}})
+			writeFrame(conn, msgTypeResponse, data)
+		}()
+	}
+}
+
+func TestDialRetriesUntilExecutorListens(t *testing.T) {
+	port := freePort(t)
+	// The agent comes up only after the first connects were refused.
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		ln, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { ln.Close() })
+		servePings(ln)
+	}()
+
+	c := NewExecutorClient(port, time.Second, ExecutorClientOptions{
+		DialAttempts:     6,
+		DialRetryBackoff: 20 * time.Millisecond,
+	})
+	if err := c.HealthCheck(context.Background(), "127.0.0.1"); err != nil {
+		t.Fatalf("HealthCheck with retries = %v, want success once the agent listens", err)
+	}
+}
+
+func TestDialWithoutRetriesFailsFast(t *testing.T) {
+	c := NewExecutorClient(freePort(t), time.Second, ExecutorClientOptions{})
+	start := time.Now()
+	if err := c.HealthCheck(context.Background(), "127.0.0.1"); err == nil {
+		t.Fatal("HealthCheck against a closed port succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("HealthCheck without retries took %s", elapsed)
+	}
+}
+
+func TestDialRetryStopsWhenContextEnds(t *testing.T) {
+	c := NewExecutorClient(freePort(t), time.Second, ExecutorClientOptions{
+		DialAttempts:     100,
+		DialRetryBackoff: 50 * time.Millisecond,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.HealthCheck(ctx, "127.0.0.1"); err == nil {
+		t.Fatal("HealthCheck against a closed port succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("retry loop ignored context cancellation, took %s", elapsed)
+	}
+}
+
+func TestExecuteReturnsWhenContextIsCancelled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	// Accept the spawn and never answer, like a long-running command.
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	c := NewExecutorClient(ln.Addr().(*net.TCPAddr).Port, time.Second, ExecutorClientOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := c.Execute(ctx, "127.0.0.1", &interfaces.ExecRequest{Command: []string{"sleep", "600"}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Execute after cancel = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Execute ignored cancellation, took %s", elapsed)
+	}
+}
+
+func TestDialSendsAuthFrameFirst(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	// Challenge with a pod UID and answer the ping only if the reply carries
+	// the token derived for it, as an agent started with
+	// ARL_EXECUTOR_TOKEN_FILE does.
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := writeFrame(conn, msgTypeAuthChallenge, []byte("pod-uid-1")); err != nil {
+			return
+		}
+		msgType, data, err := readFrame(conn)
+		if err != nil || msgType != msgTypeAuth || string(data) != DeriveExecutorToken("s3cret", "pod-uid-1") {
+			return
+		}
+		if _, _, err := readFrame(conn); err != nil {
+			return
+		}
+		resp, _ := proto.Marshal(&pb.Response{Kind: &pb.Response_Ping{Ping: &pb.PingResponse{}}})
+		writeFrame(conn, msgTypeResponse, resp)
+	}()
+
+	c := NewExecutorClient(ln.Addr().(*net.TCPAddr).Port, time.Second, ExecutorClientOptions{AuthToken: "s3cret"})
+	if err := c.HealthCheck(context.Background(), "127.0.0.1"); err != nil {
+		t.Fatalf("HealthCheck with auth token = %v, want success", err)
+	}
+}
+
+func TestDeriveExecutorToken(t *testing.T) {
+	// Matches test_derive_token in the Rust executor agent.
+	got := DeriveExecutorToken("master-secret", "3f2c9a1e-pod-uid")
+	if want := "5fde7075008096b976e34482e63a2de1e56cac91af43e13ec885aae4accae0c6"; got != want {
+		t.Fatalf("DeriveExecutorToken = %q, want %q", got, want)
+	}
+	if DeriveExecutorToken("master-secret", "other-pod-uid") == got {
+		t.Fatal("DeriveExecutorToken returned the same token for different pods")
+	}
+}
+
+func TestExecuteReportsAgentStartLatency(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, _, err := readFrame(conn); err != nil {
+			return
+		}
+		spawn, _ := proto.Marshal(&pb.Response{Tag: 1, Kind: &pb.Response_Spawn{Spawn: &pb.SpawnResponse{ProcessTag: 1, StartLatencyUs: 2500}}})
+		writeFrame(conn, msgTypeResponse, spawn)
+		exit, _ := proto.Marshal(&pb.Event{Kind: &pb.Event_Exit{Exit: &pb.ExitEvent{ExitCode: 0}}})
+		writeFrame(conn, msgTypeEvent, exit)
+	}()
+
+	c := NewExecutorClient(ln.Addr().(*net.TCPAddr).Port, time.Second, ExecutorClientOptions{})
+	resp, err := c.Execute(context.Background(), "127.0.0.1", &interfaces.ExecRequest{Command: []string{"true"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if resp.StartLatency != 2500*time.Microsecond {
+		t.Fatalf("StartLatency = %s, want 2.5ms", resp.StartLatency)
+	}
+}
+
+func TestExecuteSendsStdinWithSpawn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	got := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, data, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		var req pb.Request
+		if err := proto.Unmarshal(data, &req); err != nil {
+			return
+		}
+		got <- req.GetSpawn().GetStdinData()
+		exit, _ := proto.Marshal(&pb.Event{Kind: &pb.Event_Exit{Exit: &pb.ExitEvent{ExitCode: 0}}})
+		writeFrame(conn, msgTypeEvent, exit)
+	}()
+
+	c := NewExecutorClient(ln.Addr().(*net.TCPAddr).Port, time.Second, ExecutorClientOptions{})
+	req := &interfaces.ExecRequest{Command: []string{"python3", "-"}, Stdin: "print(1)\n"}
+	if _, err := c.Execute(context.Background(), "127.0.0.1", req); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if stdin := string(<-got); stdin != "print(1)\n" {
+		t.Fatalf("spawn stdin_data = %q, want %q", stdin, "print(1)\n")
+	}
+}
+
+func TestExecuteCapsOutputFromAgent(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	// Behave like an agent that ignores max_output_bytes.
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, _, err := readFrame(conn); err != nil {
+			return
+		}
+		for _, ev := range []*pb.Event{
+			{Kind: &pb.Event_Stdout{Stdout: &pb.StdoutEvent{Data: []byte("0123456789")}}},
+			{Kind: &pb.Event_Stderr{Stderr: &pb.StderrEvent{Data: []byte("abcdef")}}},
+			{Kind: &pb.Event_Exit{Exit: &pb.ExitEvent{ExitCode: 0}}},
+		} {
+			data, _ := proto.Marshal(ev)
+			writeFrame(conn, msgTypeEvent, data)
+		}
+	}()
+
+	c := NewExecutorClient(ln.Addr().(*net.TCPAddr).Port, time.Second, ExecutorClientOptions{})
+	resp, err := c.Execute(context.Background(), "127.0.0.1", &interfaces.ExecRequest{Command: []string{"yes"}, MaxOutputBytes: 12})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if resp.Stdout != "0123456789" || resp.Stderr != "ab" || !resp.OutputTruncated {
+		t.Fatalf("stdout = %q, stderr = %q, truncated = %v", resp.Stdout, resp.Stderr, resp.OutputTruncated)
+	}
+}
+
+func TestExecuteStreamReportsAgentStartLatency(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, _, err := readFrame(conn); err != nil {
+			return
+		}
+		spawn, _ := proto.Marshal(&pb.Response{Tag: 1, Kind: &pb.Response_Spawn{Spawn: &pb.SpawnResponse{ProcessTag: 1, StartLatencyUs: 750}}})
+		writeFrame(conn, msgTypeResponse, spawn)
+		exit, _ := proto.Marshal(&pb.Event{Kind: &pb.Event_Exit{Exit: &pb.ExitEvent{ExitCode: 0}}})
+		writeFrame(conn, msgTypeEvent, exit)
+	}()
+
+	c := NewExecutorClient(ln.Addr().(*net.TCPAddr).Port, time.Second, ExecutorClientOptions{})
+	ch, err := c.ExecuteStream(context.Background(), "127.0.0.1", &interfaces.ExecRequest{Command: []string{"true"}})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var last interfaces.ExecResponse
+	for resp := range ch {
+		last = resp
+	}
+	if !last.Done || last.StartLatency != 750*time.Microsecond {
+		t.Fatalf("final response = %+v, want Done with a 750µs StartLatency", last)
+	}
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issueTestCert(t *testing.T, tmpl *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) writePEM(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestExecutorClientMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := issueTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "executor-agent"},
+		DNSNames:     []string{"executor-agent"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	gatewayCert := issueTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "gateway"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	caFile, _ := ca.writePEM(t, dir, "ca")
+	certFile, keyFile := gatewayCert.writePEM(t, dir, "gateway")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.der}, PrivateKey: server.key}},
+		ClientCAs:    roots,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go servePings(ln)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	tlsConfig, err := LoadExecutorTLSConfig(certFile, keyFile, caFile, "executor-agent")
+	if err != nil {
+		t.Fatalf("LoadExecutorTLSConfig: %v", err)
+	}
+	c := NewExecutorClient(port, time.Second, ExecutorClientOptions{TLSConfig: tlsConfig})
+	if err := c.HealthCheck(context.Background(), "127.0.0.1"); err != nil {
+		t.Fatalf("HealthCheck over mTLS = %v, want success", err)
+	}
+
+	wrongName, err := LoadExecutorTLSConfig(certFile, keyFile, caFile, "someone-else")
+	if err != nil {
+		t.Fatalf("LoadExecutorTLSConfig: %v", err)
+	}
+	c = NewExecutorClient(port, time.Second, ExecutorClientOptions{TLSConfig: wrongName})
+	if err := c.HealthCheck(context.Background(), "127.0.0.1"); err == nil {
+		t.Fatal("HealthCheck accepted an agent certificate for the wrong server name")
+	}
+}
+
+func TestLoadExecutorTLSConfigRejectsEmptyCA(t *testing.T) {
+	dir := t.TempDir()
+	cert := issueTestCert(t, &x509.Certificate{SerialNumber: big.NewInt(1)}, nil)
+	certFile, keyFile := cert.writePEM(t, dir, "gateway")
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadExecutorTLSConfig(certFile, keyFile, caFile, "executor-agent"); err == nil {
+		t.Fatal("LoadExecutorTLSConfig accepted a CA file without certificates")
+	}
+}
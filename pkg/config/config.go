@@ -1,13 +1,17 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -23,31 +27,171 @@ type Config struct {
 	ClickHouseUsername string
 	ClickHousePassword string
 
-	// Trajectory storage configuration (uses ClickHouse with GORM)
+	// Trajectory storage configuration
 	TrajectoryEnabled bool
 	TrajectoryDebug   bool
+	// TrajectoryBackend selects where trajectories are stored: "clickhouse"
+	// (default) or "file" for local JSONL files without a database.
+	// Env: TRAJECTORY_BACKEND.
+	TrajectoryBackend string
+	// TrajectoryFileDir and TrajectoryFileMaxBytes configure the "file"
+	// backend. Env: TRAJECTORY_FILE_DIR, TRAJECTORY_FILE_MAX_BYTES
+	// (default 100MiB per file before rotation).
+	TrajectoryFileDir      string
+	TrajectoryFileMaxBytes int64
+	// TrajectoryQueueSize bounds trajectory entries buffered in memory while
+	// ClickHouse is slow or unreachable; entries beyond it are dropped and
+	// counted. Env: TRAJECTORY_QUEUE_SIZE, default 4096.
+	TrajectoryQueueSize int
+	// TrajectoryDedupObservations stores each distinct observation body once
+	// in a ClickHouse observation_blobs table keyed by SHA-256.
+	// Env: TRAJECTORY_DEDUP_OBSERVATIONS, default false.
+	TrajectoryDedupObservations bool
+	// TrajectoryRetentionDays is the ClickHouse TTL for trajectory rows;
+	// changing it alters the existing table on the next start.
+	// Env: TRAJECTORY_RETENTION_DAYS, default 90.
+	TrajectoryRetentionDays int
 
 	// Observation retention controls whether stdout/stderr observations are
 	// retained in full in session history and trajectory storage.
 	FullObservationEnabled  bool
 	ObservationPreviewBytes int
 
+	// ExecEnvDenyList and ExecEnvAllowList are comma-separated environment
+	// variable names filtered from step env before execution (e.g.
+	// "LD_PRELOAD,LD_LIBRARY_PATH"). When the allowlist is set only listed
+	// names pass. Both default to empty (no filtering).
+	// Env: EXEC_ENV_DENYLIST, EXEC_ENV_ALLOWLIST.
+	ExecEnvDenyList  string
+	ExecEnvAllowList string
+
+	// ExecCommandDenyList is a comma-separated list of glob patterns (e.g.
+	// "kubectl,curl,nc*") matched against the basename of each step's
+	// program; matching steps are rejected with exit code 126 instead of
+	// running. Default empty. Env: EXEC_COMMAND_DENYLIST.
+	ExecCommandDenyList string
+
+	// WorkspaceHostPathPrefixes is a comma-separated list of node
+	// directories under which pools may mount a hostPath workspace. Default
+	// empty, which rejects hostPath workspaces.
+	// Env: WORKSPACE_HOST_PATH_PREFIXES.
+	WorkspaceHostPathPrefixes string
+
+	// ExecMaxOutputBytes caps the combined stdout and stderr kept for one
+	// step; output past it is discarded and the step is marked truncated.
+	// Steps may ask for a lower cap. 0 disables the cap.
+	// Env: EXEC_MAX_OUTPUT_BYTES, default 64 MiB.
+	ExecMaxOutputBytes int64
+	// ExecKillOnOutputLimit kills a step once it reaches the output cap
+	// instead of letting it run to completion.
+	// Env: EXEC_KILL_ON_OUTPUT_LIMIT, default false.
+	ExecKillOnOutputLimit bool
+
+	// ResourceSamplingEnabled reads the executor container's CPU and memory
+	// counters from the kubelet stats summary after each execute and reports
+	// them as the session's resourceUsage. Costs a pod lookup and a node
+	// proxy call per execute request and needs get on nodes/proxy.
+	// Env: SESSION_RESOURCE_SAMPLING_ENABLED, default false.
+	ResourceSamplingEnabled bool
+
 	// gRPC authentication token (shared between gateway and executor)
 	GRPCAuthToken      string
 	GRPCAuthSecretName string
 
+	// ExecutorAuthEnabled makes every executor agent drop unauthenticated
+	// connections. Each sandbox pod derives its own token from GRPCAuthToken
+	// (mounted from GRPCAuthSecretName into an init container only) and the
+	// pod UID, and the gateway derives the same token per connection.
+	// Requires GRPCAuthToken. Env: EXECUTOR_AUTH_ENABLED, default false.
+	ExecutorAuthEnabled bool
+
+	// ExecutorTLSEnabled wraps gateway -> executor connections in mutual
+	// TLS. The gateway presents ExecutorTLSCertFile/ExecutorTLSKeyFile and
+	// verifies agents against ExecutorTLSCAFile and ExecutorTLSServerName;
+	// sandbox pods mount ExecutorTLSSecretName (tls.crt, tls.key, ca.crt)
+	// for the agent's server certificate. Env: EXECUTOR_TLS_ENABLED,
+	// default false.
+	ExecutorTLSEnabled bool
+	// Env: EXECUTOR_TLS_CERT_FILE, EXECUTOR_TLS_KEY_FILE, EXECUTOR_TLS_CA_FILE.
+	ExecutorTLSCertFile string
+	ExecutorTLSKeyFile  string
+	ExecutorTLSCAFile   string
+	// ExecutorTLSServerName is the name agent certificates are issued for,
+	// since executors are dialed by pod IP. Env: EXECUTOR_TLS_SERVER_NAME,
+	// default "executor-agent".
+	ExecutorTLSServerName string
+	// ExecutorTLSSecretName is the secret in each sandbox namespace holding
+	// the agent's certificate. Env: EXECUTOR_TLS_SECRET_NAME, default
+	// "agent-env-executor-tls".
+	ExecutorTLSSecretName string
+
 	// Executor agent configuration
 	ExecutorAgentImage string
 	ExecutorPort       int
 	IrohRelayURL         string
 	IrohRelayExternalURL string
 
+	// ExecutorDialTimeout bounds TCP connects to executor agents.
+	// Env: EXECUTOR_DIAL_TIMEOUT, default "5s".
+	ExecutorDialTimeout time.Duration
+
+	// ExecutorKeepAlive is the TCP keep-alive interval for executor
+	// connections, which keeps long-running shells and streams alive behind
+	// load balancers. Zero uses the Go default (15s); negative disables.
+	// Env: EXECUTOR_KEEPALIVE.
+	ExecutorKeepAlive time.Duration
+
+	// ExecutorDialAttempts is the total number of connects tried when an
+	// executor dial fails transiently; 1 disables retries.
+	// Env: EXECUTOR_DIAL_ATTEMPTS, default 3.
+	ExecutorDialAttempts int
+
+	// ExecutorDialRetryBackoff is the wait before the first dial retry,
+	// doubled on each further attempt.
+	// Env: EXECUTOR_DIAL_RETRY_BACKOFF, default "200ms".
+	ExecutorDialRetryBackoff time.Duration
+
+	// ExecutorStartupTimeout is how long the executor agent may take to
+	// start listening before its container is restarted.
+	// Env: EXECUTOR_STARTUP_TIMEOUT, default "60s".
+	ExecutorStartupTimeout time.Duration
+
+	// ExecutorReadinessPeriod and ExecutorLivenessPeriod are the intervals
+	// of the executor container's readiness and liveness probes; after
+	// ExecutorLivenessFailures consecutive failed liveness probes the
+	// container is restarted. Pools may override each via executorProbes.
+	// Env: EXECUTOR_READINESS_PERIOD, default "5s";
+	// EXECUTOR_LIVENESS_PERIOD, default "10s";
+	// EXECUTOR_LIVENESS_FAILURES, default 3.
+	ExecutorReadinessPeriod  time.Duration
+	ExecutorLivenessPeriod   time.Duration
+	ExecutorLivenessFailures int
+
+	// ExecutorMaxConcurrentCalls caps executor calls in flight across all
+	// sessions; calls beyond it wait for a slot. 0 disables the limit.
+	// Env: EXECUTOR_MAX_CONCURRENT_CALLS, default 1024.
+	ExecutorMaxConcurrentCalls int
+
 	// ImagePullPolicy is applied to the gateway-injected executor-agent
 	// init container. Defaults to "Always". Set to "IfNotPresent" for
 	// local clusters (kind/minikube) where images are side-loaded and never
 	// pushed to a registry. Env: IMAGE_PULL_POLICY.
 	ImagePullPolicy string
 
+	// ExecutorAgentImagePullSecret names a secret in the sandbox namespace
+	// for pulling the executor-agent image from a private registry. It is
+	// appended to the sandbox pod's imagePullSecrets.
+	// Env: EXECUTOR_AGENT_IMAGE_PULL_SECRET.
+	ExecutorAgentImagePullSecret string
+
+	// ExecutorAgentSecurityContext is a JSON container securityContext for
+	// the executor-agent init container, e.g.
+	// {"runAsNonRoot":true,"runAsUser":65532,"readOnlyRootFilesystem":true}.
+	// allowPrivilegeEscalation still follows
+	// SANDBOX_ALLOW_PRIVILEGE_ESCALATION.
+	// Env: EXECUTOR_AGENT_SECURITY_CONTEXT.
+	ExecutorAgentSecurityContext string
+
 	// Gateway configuration
 	GatewayPort      int
 	GatewayNamespace string
@@ -60,6 +204,16 @@ type Config struct {
 	GatewayIdleTimeout   time.Duration
 	GatewaySweepInterval time.Duration
 	GatewayWriteTimeout  time.Duration
+	// GatewayDrainTimeout bounds how long shutdown waits for in-flight
+	// executions and shells before closing executor connections. New
+	// sessions are rejected with 503 meanwhile. Env: GATEWAY_DRAIN_TIMEOUT,
+	// default 30s.
+	GatewayDrainTimeout time.Duration
+
+	// MaxActiveSessions caps the sessions the gateway holds at once; creates
+	// beyond it are rejected with 429 until sessions are deleted. 0 disables
+	// the limit. Env: MAX_ACTIVE_SESSIONS, default 0.
+	MaxActiveSessions int
 
 	// Devbox session lifecycle defaults (longer-lived development environments)
 	DevboxIdleTimeout time.Duration
@@ -79,14 +233,30 @@ type Config struct {
 	RateLimitBurst int
 	AllowedOrigins string
 
+	// InternalBindAddress is the host the internal server (metrics, debug,
+	// AlertManager webhook) listens on. It serves unauthenticated pprof and
+	// debug endpoints, so the default keeps it pod-local; scrape /metrics
+	// through MetricsPort instead of widening this.
+	// Env: INTERNAL_BIND_ADDRESS, default "127.0.0.1".
+	InternalBindAddress string
+
+	// MetricsPort, when non-zero, serves only /metrics on its own listener
+	// on all interfaces so Prometheus can scrape it through a Service while
+	// the internal server stays on loopback.
+	// Env: METRICS_PORT, default 0 (disabled).
+	MetricsPort int
+
 	// HTTP proxy injected into warm pool pods (all containers).
 	// When non-empty, HTTP_PROXY/HTTPS_PROXY/NO_PROXY env vars are set.
 	PodHTTPProxy string
 	PodNoProxy   string
 
-	// Admission control and warm-pool autoscaling.
+	// Admission control and warm-pool autoscaling. SandboxFairBinding
+	// (SANDBOX_FAIR_BINDING) serves queued session requests for a pool in
+	// arrival order instead of letting any waiter take freed capacity.
 	AdmissionQueueTimeout      time.Duration
 	AdmissionQueuePollInterval time.Duration
+	SandboxFairBinding         bool
 	PoolAutoscalerEnabled      bool
 	PoolAutoscalerInterval     time.Duration
 	PoolAutoscalerBuffer       int32
@@ -97,6 +267,13 @@ type Config struct {
 	ManagedPoolGCMinIdleAge    time.Duration
 	ManagedPoolGCMaxStopped    int
 
+	// Sandbox readiness waits. SessionReadyTimeout bounds the wait for a
+	// replacement sandbox during restore and reset (SESSION_READY_TIMEOUT);
+	// SessionPollInterval is how often a pending sandbox claim is checked
+	// (SESSION_POLL_INTERVAL).
+	SessionReadyTimeout time.Duration
+	SessionPollInterval time.Duration
+
 	// Scheduler integration.
 	SchedulerName        string
 	ImageLocalityEnabled bool
@@ -150,6 +327,13 @@ type Config struct {
 	// Env: CHECKPOINT_GC_INTERVAL, default "10m".
 	CheckpointGCInterval time.Duration
 
+	// RestoreBackend selects how snapshots are captured and restored:
+	// "replay" re-runs recorded steps, "tarball" persists workspace tars to
+	// the checkpoint store and extracts them. Empty picks tarball when
+	// checkpointing and a checkpoint store are configured, replay otherwise.
+	// Env: RESTORE_BACKEND, default empty.
+	RestoreBackend string
+
 	// BuildEnabled enables the POST /v1/build image build API.
 	// Env: BUILD_ENABLED, default false.
 	BuildEnabled bool
@@ -186,9 +370,17 @@ func DefaultConfig() *Config {
 		ClickHousePassword:      "",
 		GRPCAuthToken:           "",
 		GRPCAuthSecretName:      "agent-env-grpc-token",
+		ExecutorTLSServerName:   "executor-agent",
+		ExecutorTLSSecretName:   "agent-env-executor-tls",
 		TrajectoryEnabled:       false,
 		TrajectoryDebug:         false,
+		TrajectoryQueueSize:     4096,
+		TrajectoryRetentionDays: 90,
+		TrajectoryBackend:       "clickhouse",
+		TrajectoryFileDir:       "/var/lib/arl/trajectory",
+		TrajectoryFileMaxBytes:  100 * 1024 * 1024,
 		ObservationPreviewBytes: 4096,
+		ExecMaxOutputBytes:      64 * 1024 * 1024,
 		ExecutorAgentImage: "arl-executor-agent:latest",
 		ExecutorPort:       9090,
 		ImagePullPolicy:         "Always",
@@ -197,9 +389,19 @@ func DefaultConfig() *Config {
 		K8sClientQPS:            10000,
 		K8sClientBurst:          20000,
 
+		ExecutorDialTimeout:        5 * time.Second,
+		ExecutorDialAttempts:       3,
+		ExecutorDialRetryBackoff:   200 * time.Millisecond,
+		ExecutorStartupTimeout:     60 * time.Second,
+		ExecutorReadinessPeriod:    5 * time.Second,
+		ExecutorLivenessPeriod:     10 * time.Second,
+		ExecutorLivenessFailures:   3,
+		ExecutorMaxConcurrentCalls: 1024,
+
 		GatewayIdleTimeout:   600 * time.Second,
 		GatewaySweepInterval: 30 * time.Second,
 		GatewayWriteTimeout:  0,
+		GatewayDrainTimeout:  30 * time.Second,
 
 		DevboxIdleTimeout: 4 * time.Hour,
 
@@ -209,15 +411,19 @@ func DefaultConfig() *Config {
 		RedisDB:         0,
 		RedisSessionTTL: 72 * time.Hour,
 
-		AuthEnabled:    true,
-		AuthAPIKeys:    "",
-		InternalPort:   9091,
-		RateLimitRPS:   2048,
-		RateLimitBurst: 4096,
-		AllowedOrigins: "",
+		AuthEnabled:         true,
+		AuthAPIKeys:         "",
+		InternalPort:        9091,
+		InternalBindAddress: "127.0.0.1",
+		RateLimitRPS:        2048,
+		RateLimitBurst:      4096,
+		AllowedOrigins:      "",
 
 		AdmissionQueueTimeout:           0,
 		AdmissionQueuePollInterval:      500 * time.Millisecond,
+		SandboxFairBinding:              false,
+		SessionReadyTimeout:             5 * time.Minute,
+		SessionPollInterval:             500 * time.Millisecond,
 		PoolAutoscalerEnabled:           false,
 		PoolAutoscalerInterval:          30 * time.Second,
 		PoolAutoscalerBuffer:            1,
@@ -253,149 +459,296 @@ func DefaultConfig() *Config {
 
 // LoadFromEnv loads configuration from environment variables
 func LoadFromEnv() *Config {
+	return load(os.Getenv)
+}
+
+// load builds a Config from DefaultConfig, overriding each field whose
+// variable getenv returns non-empty.
+func load(getenv func(string) string) *Config {
 	cfg := DefaultConfig()
 
-	if timeout := os.Getenv("HTTP_CLIENT_TIMEOUT"); timeout != "" {
+	if timeout := getenv("HTTP_CLIENT_TIMEOUT"); timeout != "" {
 		if d, err := time.ParseDuration(timeout); err == nil {
 			cfg.HTTPClientTimeout = d
 		}
 	}
 
 	// ClickHouse configuration
-	if enable := os.Getenv("CLICKHOUSE_ENABLED"); enable == "true" {
+	if enable := getenv("CLICKHOUSE_ENABLED"); enable == "true" {
 		cfg.ClickHouseEnabled = true
 	}
 
-	if addr := os.Getenv("CLICKHOUSE_ADDR"); addr != "" {
+	if addr := getenv("CLICKHOUSE_ADDR"); addr != "" {
 		cfg.ClickHouseAddr = addr
 	}
 
-	if db := os.Getenv("CLICKHOUSE_DATABASE"); db != "" {
+	if db := getenv("CLICKHOUSE_DATABASE"); db != "" {
 		cfg.ClickHouseDatabase = db
 	}
 
-	if user := os.Getenv("CLICKHOUSE_USERNAME"); user != "" {
+	if user := getenv("CLICKHOUSE_USERNAME"); user != "" {
 		cfg.ClickHouseUsername = user
 	}
 
-	if pass := os.Getenv("CLICKHOUSE_PASSWORD"); pass != "" {
+	if pass := getenv("CLICKHOUSE_PASSWORD"); pass != "" {
 		cfg.ClickHousePassword = pass
 	}
 
 	// Trajectory configuration
-	if enable := os.Getenv("TRAJECTORY_ENABLED"); enable == "true" {
+	if enable := getenv("TRAJECTORY_ENABLED"); enable == "true" {
 		cfg.TrajectoryEnabled = true
 	}
 
-	if debug := os.Getenv("TRAJECTORY_DEBUG"); debug == "true" {
+	if debug := getenv("TRAJECTORY_DEBUG"); debug == "true" {
 		cfg.TrajectoryDebug = true
 	}
-	if v := os.Getenv("FULL_OBSERVATION_ENABLED"); v != "" {
+	if v := getenv("TRAJECTORY_BACKEND"); v != "" {
+		cfg.TrajectoryBackend = v
+	}
+	if v := getenv("TRAJECTORY_FILE_DIR"); v != "" {
+		cfg.TrajectoryFileDir = v
+	}
+	if v := getenv("TRAJECTORY_FILE_MAX_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cfg.TrajectoryFileMaxBytes = n
+		}
+	}
+	if v := getenv("TRAJECTORY_QUEUE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.TrajectoryQueueSize = n
+		}
+	}
+	if v := getenv("TRAJECTORY_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.TrajectoryRetentionDays = n
+		}
+	}
+	if v := getenv("TRAJECTORY_DEDUP_OBSERVATIONS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.TrajectoryDedupObservations = b
+		}
+	}
+	if v := getenv("FULL_OBSERVATION_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.FullObservationEnabled = b
 		}
 	}
-	if v := os.Getenv("OBSERVATION_PREVIEW_BYTES"); v != "" {
+	if v := getenv("EXEC_ENV_DENYLIST"); v != "" {
+		cfg.ExecEnvDenyList = v
+	}
+	if v := getenv("EXEC_ENV_ALLOWLIST"); v != "" {
+		cfg.ExecEnvAllowList = v
+	}
+	if v := getenv("EXEC_COMMAND_DENYLIST"); v != "" {
+		cfg.ExecCommandDenyList = v
+	}
+	if v := getenv("WORKSPACE_HOST_PATH_PREFIXES"); v != "" {
+		cfg.WorkspaceHostPathPrefixes = v
+	}
+	if v := getenv("EXEC_MAX_OUTPUT_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cfg.ExecMaxOutputBytes = n
+		}
+	}
+	if v := getenv("EXEC_KILL_ON_OUTPUT_LIMIT"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ExecKillOnOutputLimit = b
+		}
+	}
+	if v := getenv("SESSION_RESOURCE_SAMPLING_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ResourceSamplingEnabled = b
+		}
+	}
+	if v := getenv("OBSERVATION_PREVIEW_BYTES"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.ObservationPreviewBytes = n
 		}
 	}
 
-	if v := os.Getenv("GRPC_AUTH_TOKEN"); v != "" {
+	if v := getenv("GRPC_AUTH_TOKEN"); v != "" {
 		cfg.GRPCAuthToken = v
 	}
-	if v := os.Getenv("GRPC_AUTH_SECRET_NAME"); v != "" {
+	if v := getenv("GRPC_AUTH_SECRET_NAME"); v != "" {
 		cfg.GRPCAuthSecretName = v
 	}
+	if v := getenv("EXECUTOR_AUTH_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ExecutorAuthEnabled = b
+		}
+	}
+	if v := getenv("EXECUTOR_TLS_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ExecutorTLSEnabled = b
+		}
+	}
+	if v := getenv("EXECUTOR_TLS_CERT_FILE"); v != "" {
+		cfg.ExecutorTLSCertFile = v
+	}
+	if v := getenv("EXECUTOR_TLS_KEY_FILE"); v != "" {
+		cfg.ExecutorTLSKeyFile = v
+	}
+	if v := getenv("EXECUTOR_TLS_CA_FILE"); v != "" {
+		cfg.ExecutorTLSCAFile = v
+	}
+	if v := getenv("EXECUTOR_TLS_SERVER_NAME"); v != "" {
+		cfg.ExecutorTLSServerName = v
+	}
+	if v := getenv("EXECUTOR_TLS_SECRET_NAME"); v != "" {
+		cfg.ExecutorTLSSecretName = v
+	}
 
 	// Executor agent configuration
-	if image := os.Getenv("EXECUTOR_AGENT_IMAGE"); image != "" {
+	if image := getenv("EXECUTOR_AGENT_IMAGE"); image != "" {
 		cfg.ExecutorAgentImage = image
 	}
-	if v := os.Getenv("EXECUTOR_PORT"); v != "" {
+	if v := getenv("EXECUTOR_PORT"); v != "" {
 		if p, err := strconv.Atoi(v); err == nil {
 			cfg.ExecutorPort = p
 		}
 	}
-	if v := os.Getenv("IROH_RELAY_URL"); v != "" {
+	if v := getenv("EXECUTOR_DIAL_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExecutorDialTimeout = d
+		}
+	}
+	if v := getenv("EXECUTOR_KEEPALIVE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExecutorKeepAlive = d
+		}
+	}
+	if v := getenv("EXECUTOR_DIAL_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ExecutorDialAttempts = n
+		}
+	}
+	if v := getenv("EXECUTOR_DIAL_RETRY_BACKOFF"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExecutorDialRetryBackoff = d
+		}
+	}
+	if v := getenv("EXECUTOR_STARTUP_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExecutorStartupTimeout = d
+		}
+	}
+	if v := getenv("EXECUTOR_READINESS_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExecutorReadinessPeriod = d
+		}
+	}
+	if v := getenv("EXECUTOR_LIVENESS_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExecutorLivenessPeriod = d
+		}
+	}
+	if v := getenv("EXECUTOR_LIVENESS_FAILURES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ExecutorLivenessFailures = n
+		}
+	}
+	if v := getenv("EXECUTOR_MAX_CONCURRENT_CALLS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ExecutorMaxConcurrentCalls = n
+		}
+	}
+	if v := getenv("IROH_RELAY_URL"); v != "" {
 		cfg.IrohRelayURL = v
 	}
-	if v := os.Getenv("IROH_RELAY_EXTERNAL_URL"); v != "" {
+	if v := getenv("IROH_RELAY_EXTERNAL_URL"); v != "" {
 		cfg.IrohRelayExternalURL = v
 	}
 
-	if v := os.Getenv("IMAGE_PULL_POLICY"); v != "" {
+	if v := getenv("IMAGE_PULL_POLICY"); v != "" {
 		cfg.ImagePullPolicy = v
 	}
+	if v := getenv("EXECUTOR_AGENT_IMAGE_PULL_SECRET"); v != "" {
+		cfg.ExecutorAgentImagePullSecret = v
+	}
+	if v := getenv("EXECUTOR_AGENT_SECURITY_CONTEXT"); v != "" {
+		cfg.ExecutorAgentSecurityContext = v
+	}
 
 	// Gateway configuration
-	if port := os.Getenv("GATEWAY_PORT"); port != "" {
+	if port := getenv("GATEWAY_PORT"); port != "" {
 		if p, err := strconv.Atoi(port); err == nil {
 			cfg.GatewayPort = p
 		}
 	}
-	if v := os.Getenv("GATEWAY_NAMESPACE"); v != "" {
+	if v := getenv("GATEWAY_NAMESPACE"); v != "" {
 		cfg.GatewayNamespace = v
-	} else if v := os.Getenv("POD_NAMESPACE"); v != "" {
+	} else if v := getenv("POD_NAMESPACE"); v != "" {
 		cfg.GatewayNamespace = v
 	}
-	if v := os.Getenv("K8S_CLIENT_QPS"); v != "" {
+	if v := getenv("K8S_CLIENT_QPS"); v != "" {
 		if f, err := strconv.ParseFloat(v, 32); err == nil {
 			cfg.K8sClientQPS = float32(f)
 		}
 	}
 
-	if v := os.Getenv("K8S_CLIENT_BURST"); v != "" {
+	if v := getenv("K8S_CLIENT_BURST"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.K8sClientBurst = n
 		}
 	}
 
 	// Gateway session lifecycle configuration
-	if v := os.Getenv("GATEWAY_IDLE_TIMEOUT"); v != "" {
+	if v := getenv("GATEWAY_IDLE_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.GatewayIdleTimeout = d
 		}
 	}
 
-	if v := os.Getenv("GATEWAY_SWEEP_INTERVAL"); v != "" {
+	if v := getenv("GATEWAY_SWEEP_INTERVAL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.GatewaySweepInterval = d
 		}
 	}
 
-	if v := os.Getenv("GATEWAY_WRITE_TIMEOUT"); v != "" {
+	if v := getenv("GATEWAY_WRITE_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.GatewayWriteTimeout = d
 		}
 	}
 
-	if v := os.Getenv("DEVBOX_IDLE_TIMEOUT"); v != "" {
+	if v := getenv("GATEWAY_DRAIN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.GatewayDrainTimeout = d
+		}
+	}
+
+	if v := getenv("MAX_ACTIVE_SESSIONS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxActiveSessions = n
+		}
+	}
+
+	if v := getenv("DEVBOX_IDLE_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.DevboxIdleTimeout = d
 		}
 	}
 
 	// Redis session store configuration
-	if enable := os.Getenv("REDIS_ENABLED"); enable == "true" {
+	if enable := getenv("REDIS_ENABLED"); enable == "true" {
 		cfg.RedisEnabled = true
 	}
 
-	if v := os.Getenv("REDIS_ADDR"); v != "" {
+	if v := getenv("REDIS_ADDR"); v != "" {
 		cfg.RedisAddr = v
 	}
 
-	if v := os.Getenv("REDIS_PASSWORD"); v != "" {
+	if v := getenv("REDIS_PASSWORD"); v != "" {
 		cfg.RedisPassword = v
 	}
 
-	if v := os.Getenv("REDIS_DB"); v != "" {
+	if v := getenv("REDIS_DB"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.RedisDB = n
 		}
 	}
 
-	if v := os.Getenv("REDIS_SESSION_TTL"); v != "" {
+	if v := getenv("REDIS_SESSION_TTL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil && d > 0 {
 			cfg.RedisSessionTTL = d
 		}
@@ -404,188 +757,214 @@ func LoadFromEnv() *Config {
 	// Authentication configuration.
 	// Auth is on by default (fail-closed); disabling it requires an explicit
 	// AUTH_ENABLED=false, never an omitted or malformed value.
-	if v := os.Getenv("AUTH_ENABLED"); v != "" {
+	if v := getenv("AUTH_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.AuthEnabled = b
 		}
 	}
 
-	if v := os.Getenv("AUTH_API_KEYS"); v != "" {
+	if v := getenv("AUTH_API_KEYS"); v != "" {
 		cfg.AuthAPIKeys = v
 	}
 
-	if v := os.Getenv("INTERNAL_PORT"); v != "" {
+	if v := getenv("INTERNAL_PORT"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.InternalPort = n
 		}
 	}
+	if v := getenv("INTERNAL_BIND_ADDRESS"); v != "" {
+		cfg.InternalBindAddress = v
+	}
+	if v := getenv("METRICS_PORT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MetricsPort = n
+		}
+	}
 
-	if v := os.Getenv("RATE_LIMIT_RPS"); v != "" {
+	if v := getenv("RATE_LIMIT_RPS"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			cfg.RateLimitRPS = f
 		}
 	}
 
-	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+	if v := getenv("RATE_LIMIT_BURST"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.RateLimitBurst = n
 		}
 	}
 
-	if v := os.Getenv("ALLOWED_ORIGINS"); v != "" {
+	if v := getenv("ALLOWED_ORIGINS"); v != "" {
 		cfg.AllowedOrigins = v
 	}
 
-	if v := os.Getenv("POD_HTTP_PROXY"); v != "" {
+	if v := getenv("POD_HTTP_PROXY"); v != "" {
 		cfg.PodHTTPProxy = v
 	}
-	if v := os.Getenv("POD_NO_PROXY"); v != "" {
+	if v := getenv("POD_NO_PROXY"); v != "" {
 		cfg.PodNoProxy = v
 	}
 
-	if v := os.Getenv("ADMISSION_QUEUE_TIMEOUT"); v != "" {
+	if v := getenv("ADMISSION_QUEUE_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.AdmissionQueueTimeout = d
 		}
 	}
-	if v := os.Getenv("ADMISSION_QUEUE_POLL_INTERVAL"); v != "" {
+	if v := getenv("ADMISSION_QUEUE_POLL_INTERVAL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.AdmissionQueuePollInterval = d
 		}
 	}
-	if v := os.Getenv("POOL_AUTOSCALER_ENABLED"); v != "" {
+	if v := getenv("SANDBOX_FAIR_BINDING"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.SandboxFairBinding = b
+		}
+	}
+	if v := getenv("SESSION_READY_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.SessionReadyTimeout = d
+		}
+	}
+	if v := getenv("SESSION_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.SessionPollInterval = d
+		}
+	}
+	if v := getenv("POOL_AUTOSCALER_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.PoolAutoscalerEnabled = b
 		}
 	}
-	if v := os.Getenv("POOL_AUTOSCALER_INTERVAL"); v != "" {
+	if v := getenv("POOL_AUTOSCALER_INTERVAL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.PoolAutoscalerInterval = d
 		}
 	}
-	if v := os.Getenv("POOL_AUTOSCALER_BUFFER"); v != "" {
+	if v := getenv("POOL_AUTOSCALER_BUFFER"); v != "" {
 		if n, err := strconv.ParseInt(v, 10, 32); err == nil {
 			cfg.PoolAutoscalerBuffer = int32(n)
 		}
 	}
-	if v := os.Getenv("POOL_AUTOSCALER_MIN_REPLICAS"); v != "" {
+	if v := getenv("POOL_AUTOSCALER_MIN_REPLICAS"); v != "" {
 		if n, err := strconv.ParseInt(v, 10, 32); err == nil {
 			cfg.PoolAutoscalerMinReplicas = int32(n)
 		}
 	}
-	if v := os.Getenv("POOL_AUTOSCALER_MAX_REPLICAS"); v != "" {
+	if v := getenv("POOL_AUTOSCALER_MAX_REPLICAS"); v != "" {
 		if n, err := strconv.ParseInt(v, 10, 32); err == nil {
 			cfg.PoolAutoscalerMaxReplicas = int32(n)
 		}
 	}
-	if v := os.Getenv("MANAGED_POOL_GC_ENABLED"); v != "" {
+	if v := getenv("MANAGED_POOL_GC_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.ManagedPoolGCEnabled = b
 		}
 	}
-	if v := os.Getenv("MANAGED_POOL_GC_INTERVAL"); v != "" {
+	if v := getenv("MANAGED_POOL_GC_INTERVAL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.ManagedPoolGCInterval = d
 		}
 	}
-	if v := os.Getenv("MANAGED_POOL_GC_MIN_IDLE_AGE"); v != "" {
+	if v := getenv("MANAGED_POOL_GC_MIN_IDLE_AGE"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.ManagedPoolGCMinIdleAge = d
 		}
 	}
-	if v := os.Getenv("MANAGED_POOL_GC_MAX_STOPPED"); v != "" {
+	if v := getenv("MANAGED_POOL_GC_MAX_STOPPED"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			cfg.ManagedPoolGCMaxStopped = n
 		}
 	}
-	if v := os.Getenv("SCHEDULER_NAME"); v != "" {
+	if v := getenv("SCHEDULER_NAME"); v != "" {
 		cfg.SchedulerName = v
 	}
-	if v := os.Getenv("IMAGE_LOCALITY_ENABLED"); v != "" {
+	if v := getenv("IMAGE_LOCALITY_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.ImageLocalityEnabled = b
 		}
 	}
-	if v := os.Getenv("DEVBOX_STORAGE_CLASS_NAME"); v != "" {
+	if v := getenv("DEVBOX_STORAGE_CLASS_NAME"); v != "" {
 		cfg.DevboxStorageClassName = v
 	}
-	if v := os.Getenv("SANDBOX_DEFAULT_REQUEST_CPU"); v != "" {
+	if v := getenv("SANDBOX_DEFAULT_REQUEST_CPU"); v != "" {
 		cfg.DefaultSandboxRequestCPU = v
 	}
-	if v := os.Getenv("SANDBOX_DEFAULT_REQUEST_MEMORY"); v != "" {
+	if v := getenv("SANDBOX_DEFAULT_REQUEST_MEMORY"); v != "" {
 		cfg.DefaultSandboxRequestMemory = v
 	}
-	if v := os.Getenv("SANDBOX_DEFAULT_LIMIT_CPU"); v != "" {
+	if v := getenv("SANDBOX_DEFAULT_LIMIT_CPU"); v != "" {
 		cfg.DefaultSandboxLimitCPU = v
 	}
-	if v := os.Getenv("SANDBOX_DEFAULT_LIMIT_MEMORY"); v != "" {
+	if v := getenv("SANDBOX_DEFAULT_LIMIT_MEMORY"); v != "" {
 		cfg.DefaultSandboxLimitMemory = v
 	}
-	if v := os.Getenv("SANDBOX_DEFAULT_EPHEMERAL_STORAGE_LIMIT"); v != "" {
+	if v := getenv("SANDBOX_DEFAULT_EPHEMERAL_STORAGE_LIMIT"); v != "" {
 		cfg.DefaultEphemeralStorageLimit = v
 	}
-	if v := os.Getenv("SANDBOX_DEFAULT_EPHEMERAL_STORAGE_REQUEST"); v != "" {
+	if v := getenv("SANDBOX_DEFAULT_EPHEMERAL_STORAGE_REQUEST"); v != "" {
 		cfg.DefaultEphemeralStorageRequest = v
 	}
-	if v := os.Getenv("SANDBOX_NETWORK_POLICY_MANAGEMENT"); v != "" {
+	if v := getenv("SANDBOX_NETWORK_POLICY_MANAGEMENT"); v != "" {
 		cfg.SandboxNetworkPolicyManagement = v
 	}
-	if v := os.Getenv("SANDBOX_EGRESS_ALLOW_CIDRS"); v != "" {
+	if v := getenv("SANDBOX_EGRESS_ALLOW_CIDRS"); v != "" {
 		cfg.SandboxEgressAllowCIDRs = v
 	}
-	if v := os.Getenv("SANDBOX_RUNTIME_CLASS_NAME"); v != "" {
+	if v := getenv("SANDBOX_RUNTIME_CLASS_NAME"); v != "" {
 		cfg.SandboxRuntimeClassName = v
 	}
-	if v := os.Getenv("SANDBOX_SECCOMP_PROFILE_TYPE"); v != "" {
+	if v := getenv("SANDBOX_SECCOMP_PROFILE_TYPE"); v != "" {
 		cfg.SandboxSeccompProfileType = v
 	}
-	if v := os.Getenv("SANDBOX_SECCOMP_LOCALHOST_PROFILE"); v != "" {
+	if v := getenv("SANDBOX_SECCOMP_LOCALHOST_PROFILE"); v != "" {
 		cfg.SandboxSeccompLocalhostProfile = v
 	}
-	if v := os.Getenv("SANDBOX_ALLOW_PRIVILEGE_ESCALATION"); v != "" {
+	if v := getenv("SANDBOX_ALLOW_PRIVILEGE_ESCALATION"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.SandboxAllowPrivilegeEscalation = b
 		}
 	}
-	if v := os.Getenv("SANDBOX_CHECKPOINT_ENABLED"); v != "" {
+	if v := getenv("SANDBOX_CHECKPOINT_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.SandboxCheckpointEnabled = b
 		}
 	}
-	if v := os.Getenv("CHECKPOINT_STORE_PATH"); v != "" {
+	if v := getenv("CHECKPOINT_STORE_PATH"); v != "" {
 		cfg.CheckpointStorePath = v
 	}
-	if v := os.Getenv("CHECKPOINT_STORE_PVC"); v != "" {
+	if v := getenv("CHECKPOINT_STORE_PVC"); v != "" {
 		cfg.CheckpointStorePVC = v
 	}
-	if v := os.Getenv("CHECKPOINT_GC_TTL"); v != "" {
+	if v := getenv("CHECKPOINT_GC_TTL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.CheckpointGCTTL = d
 		}
 	}
-	if v := os.Getenv("CHECKPOINT_GC_INTERVAL"); v != "" {
+	if v := getenv("CHECKPOINT_GC_INTERVAL"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.CheckpointGCInterval = d
 		}
 	}
+	if v := getenv("RESTORE_BACKEND"); v != "" {
+		cfg.RestoreBackend = v
+	}
 
-	if v := os.Getenv("BUILD_ENABLED"); v != "" {
+	if v := getenv("BUILD_ENABLED"); v != "" {
 		if b, err := strconv.ParseBool(v); err == nil {
 			cfg.BuildEnabled = b
 		}
 	}
-	if v := os.Getenv("BUILD_KANIKO_IMAGE"); v != "" {
+	if v := getenv("BUILD_KANIKO_IMAGE"); v != "" {
 		cfg.BuildKanikoImage = v
 	}
-	if v := os.Getenv("BUILD_REGISTRY_SECRET"); v != "" {
+	if v := getenv("BUILD_REGISTRY_SECRET"); v != "" {
 		cfg.BuildRegistrySecret = v
 	}
-	if v := os.Getenv("BUILD_DEFAULT_TIMEOUT"); v != "" {
+	if v := getenv("BUILD_DEFAULT_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.BuildDefaultTimeout = d
 		}
 	}
-	if v := os.Getenv("BUILD_REGISTRY"); v != "" {
+	if v := getenv("BUILD_REGISTRY"); v != "" {
 		cfg.BuildRegistry = v
 	}
 
@@ -598,9 +977,53 @@ func (c *Config) Validate() error {
 	if c.HTTPClientTimeout <= 0 {
 		return fmt.Errorf("HTTP client timeout must be positive: %v", c.HTTPClientTimeout)
 	}
+	if c.ExecutorDialTimeout <= 0 {
+		return fmt.Errorf("executor dial timeout must be positive: %v", c.ExecutorDialTimeout)
+	}
+	if c.ExecutorDialAttempts < 1 {
+		return fmt.Errorf("executor dial attempts must be at least 1: %d", c.ExecutorDialAttempts)
+	}
+	if c.ExecutorDialAttempts > 1 && c.ExecutorDialRetryBackoff <= 0 {
+		return fmt.Errorf("executor dial retry backoff must be positive when retries are enabled: %v", c.ExecutorDialRetryBackoff)
+	}
+	switch c.RestoreBackend {
+	case "", "replay":
+	case "tarball":
+		if !c.SandboxCheckpointEnabled || c.CheckpointStorePath == "" {
+			return fmt.Errorf("tarball restore backend requires SANDBOX_CHECKPOINT_ENABLED and CHECKPOINT_STORE_PATH")
+		}
+	default:
+		return fmt.Errorf("invalid restore backend %q: must be replay or tarball", c.RestoreBackend)
+	}
+	if c.ExecutorStartupTimeout < time.Second {
+		return fmt.Errorf("executor startup timeout must be at least 1s: %v", c.ExecutorStartupTimeout)
+	}
+	if c.ExecutorReadinessPeriod < time.Second || c.ExecutorLivenessPeriod < time.Second {
+		return fmt.Errorf("executor probe periods must be at least 1s: readiness %v, liveness %v", c.ExecutorReadinessPeriod, c.ExecutorLivenessPeriod)
+	}
+	if c.ExecutorLivenessFailures < 1 {
+		return fmt.Errorf("executor liveness failures must be at least 1: %d", c.ExecutorLivenessFailures)
+	}
+	if c.ExecutorMaxConcurrentCalls < 0 {
+		return fmt.Errorf("executor max concurrent calls cannot be negative: %d", c.ExecutorMaxConcurrentCalls)
+	}
 	if c.GRPCAuthSecretName == "" {
 		return fmt.Errorf("gRPC auth secret name is required")
 	}
+	if c.ExecutorAuthEnabled && c.GRPCAuthToken == "" {
+		return fmt.Errorf("executor auth requires GRPC_AUTH_TOKEN")
+	}
+	if c.ExecutorTLSEnabled {
+		if c.ExecutorTLSCertFile == "" || c.ExecutorTLSKeyFile == "" || c.ExecutorTLSCAFile == "" {
+			return fmt.Errorf("executor TLS requires EXECUTOR_TLS_CERT_FILE, EXECUTOR_TLS_KEY_FILE and EXECUTOR_TLS_CA_FILE")
+		}
+		if c.ExecutorTLSServerName == "" {
+			return fmt.Errorf("executor TLS server name is required")
+		}
+		if c.ExecutorTLSSecretName == "" {
+			return fmt.Errorf("executor TLS secret name is required")
+		}
+	}
 
 	if c.IrohRelayURL != "" {
 		if _, err := url.Parse(c.IrohRelayURL); err != nil {
@@ -647,6 +1070,12 @@ func (c *Config) Validate() error {
 	if c.GatewayWriteTimeout < 0 {
 		return fmt.Errorf("gateway write timeout cannot be negative: %v", c.GatewayWriteTimeout)
 	}
+	if c.GatewayDrainTimeout < 0 {
+		return fmt.Errorf("gateway drain timeout cannot be negative: %v", c.GatewayDrainTimeout)
+	}
+	if c.MaxActiveSessions < 0 {
+		return fmt.Errorf("max active sessions cannot be negative: %d", c.MaxActiveSessions)
+	}
 
 	if c.GatewaySweepInterval <= 0 {
 		return fmt.Errorf("gateway sweep interval must be positive: %v", c.GatewaySweepInterval)
@@ -655,6 +1084,27 @@ func (c *Config) Validate() error {
 	if c.ObservationPreviewBytes < 0 {
 		return fmt.Errorf("observation preview bytes cannot be negative: %d", c.ObservationPreviewBytes)
 	}
+	if c.ExecMaxOutputBytes < 0 {
+		return fmt.Errorf("exec max output bytes cannot be negative: %d", c.ExecMaxOutputBytes)
+	}
+	if c.TrajectoryQueueSize <= 0 {
+		return fmt.Errorf("trajectory queue size must be positive: %d", c.TrajectoryQueueSize)
+	}
+	if c.TrajectoryRetentionDays <= 0 {
+		return fmt.Errorf("trajectory retention days must be positive: %d", c.TrajectoryRetentionDays)
+	}
+	switch c.TrajectoryBackend {
+	case "clickhouse":
+	case "file":
+		if c.TrajectoryFileDir == "" {
+			return fmt.Errorf("trajectory file dir is required for the file backend")
+		}
+		if c.TrajectoryFileMaxBytes <= 0 {
+			return fmt.Errorf("trajectory file max bytes must be positive: %d", c.TrajectoryFileMaxBytes)
+		}
+	default:
+		return fmt.Errorf("invalid trajectory backend: %q (must be clickhouse or file)", c.TrajectoryBackend)
+	}
 
 	if c.DevboxIdleTimeout < 0 {
 		return fmt.Errorf("devbox idle timeout cannot be negative: %v", c.DevboxIdleTimeout)
@@ -666,10 +1116,22 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid internal port: %d (must be 1-65535)", c.InternalPort)
 	}
 
+	if c.InternalBindAddress != "" && net.ParseIP(c.InternalBindAddress) == nil {
+		return fmt.Errorf("invalid internal bind address: %q (must be an IP address)", c.InternalBindAddress)
+	}
+
 	if c.InternalPort == c.GatewayPort {
 		return fmt.Errorf("internal port (%d) must differ from gateway port (%d)", c.InternalPort, c.GatewayPort)
 	}
 
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		return fmt.Errorf("invalid metrics port: %d (must be 0-65535)", c.MetricsPort)
+	}
+
+	if c.MetricsPort != 0 && (c.MetricsPort == c.GatewayPort || c.MetricsPort == c.InternalPort) {
+		return fmt.Errorf("metrics port (%d) must differ from gateway port (%d) and internal port (%d)", c.MetricsPort, c.GatewayPort, c.InternalPort)
+	}
+
 	if c.RateLimitRPS <= 0 {
 		return fmt.Errorf("rate limit RPS must be > 0: %v", c.RateLimitRPS)
 	}
@@ -684,6 +1146,15 @@ func (c *Config) Validate() error {
 	if c.AdmissionQueuePollInterval <= 0 {
 		return fmt.Errorf("admission queue poll interval must be positive: %v", c.AdmissionQueuePollInterval)
 	}
+	if c.SessionReadyTimeout <= 0 {
+		return fmt.Errorf("session ready timeout must be positive: %v", c.SessionReadyTimeout)
+	}
+	if c.SessionPollInterval <= 0 {
+		return fmt.Errorf("session poll interval must be positive: %v", c.SessionPollInterval)
+	}
+	if c.SessionPollInterval >= c.SessionReadyTimeout {
+		return fmt.Errorf("session poll interval (%v) must be shorter than session ready timeout (%v)", c.SessionPollInterval, c.SessionReadyTimeout)
+	}
 	if c.PoolAutoscalerInterval <= 0 {
 		return fmt.Errorf("pool autoscaler interval must be positive: %v", c.PoolAutoscalerInterval)
 	}
@@ -728,6 +1199,21 @@ func (c *Config) Validate() error {
 	default:
 		return fmt.Errorf("image pull policy must be Always, IfNotPresent, or Never: %q", c.ImagePullPolicy)
 	}
+	if _, err := c.ExecutorAgentSecurityContextSpec(); err != nil {
+		return err
+	}
+	for _, part := range strings.Split(c.ExecCommandDenyList, ",") {
+		pattern := strings.TrimSpace(part)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("exec command denylist pattern %q is invalid: %w", pattern, err)
+		}
+	}
+	for _, part := range strings.Split(c.WorkspaceHostPathPrefixes, ",") {
+		prefix := strings.TrimSpace(part)
+		if prefix != "" && (!strings.HasPrefix(prefix, "/") || prefix == "/") {
+			return fmt.Errorf("workspace hostPath prefix %q must be an absolute directory other than /", prefix)
+		}
+	}
 
 	if c.RedisEnabled && strings.TrimSpace(c.RedisAddr) == "" {
 		return fmt.Errorf("Redis address is required when Redis is enabled")
@@ -763,3 +1249,19 @@ func validatePositiveQuantity(name, value string) error {
 	}
 	return nil
 }
+
+// ExecutorAgentSecurityContextSpec decodes ExecutorAgentSecurityContext. It
+// returns nil when the setting is empty.
+func (c *Config) ExecutorAgentSecurityContextSpec() (*corev1.SecurityContext, error) {
+	raw := strings.TrimSpace(c.ExecutorAgentSecurityContext)
+	if raw == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var sc corev1.SecurityContext
+	if err := dec.Decode(&sc); err != nil {
+		return nil, fmt.Errorf("executor agent security context must be a JSON container securityContext: %w", err)
+	}
+	return &sc, nil
+}
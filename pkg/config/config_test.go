@@ -28,6 +28,105 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: "HTTP client timeout must be positive",
 		},
+		{
+			name: "invalid trajectory backend",
+			mutate: func(cfg *Config) {
+				cfg.TrajectoryBackend = "s3"
+			},
+			wantErr: "invalid trajectory backend",
+		},
+		{
+			name: "invalid trajectory queue size",
+			mutate: func(cfg *Config) {
+				cfg.TrajectoryQueueSize = 0
+			},
+			wantErr: "trajectory queue size must be positive",
+		},
+		{
+			name: "invalid trajectory retention days",
+			mutate: func(cfg *Config) {
+				cfg.TrajectoryRetentionDays = 0
+			},
+			wantErr: "trajectory retention days must be positive",
+		},
+		{
+			name: "invalid executor dial timeout",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorDialTimeout = 0
+			},
+			wantErr: "executor dial timeout must be positive",
+		},
+		{
+			name: "invalid executor dial attempts",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorDialAttempts = 0
+			},
+			wantErr: "executor dial attempts must be at least 1",
+		},
+		{
+			name: "executor dial retries without backoff",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorDialRetryBackoff = 0
+			},
+			wantErr: "executor dial retry backoff must be positive",
+		},
+		{
+			name: "negative executor concurrency limit",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorMaxConcurrentCalls = -1
+			},
+			wantErr: "executor max concurrent calls cannot be negative",
+		},
+		{
+			name: "negative max active sessions",
+			mutate: func(cfg *Config) {
+				cfg.MaxActiveSessions = -1
+			},
+			wantErr: "max active sessions cannot be negative",
+		},
+		{
+			name: "root workspace hostPath prefix",
+			mutate: func(cfg *Config) {
+				cfg.WorkspaceHostPathPrefixes = "/mnt/scratch,/"
+			},
+			wantErr: "workspace hostPath prefix",
+		},
+		{
+			name: "negative gateway drain timeout",
+			mutate: func(cfg *Config) {
+				cfg.GatewayDrainTimeout = -time.Second
+			},
+			wantErr: "gateway drain timeout cannot be negative",
+		},
+		{
+			name: "unknown executor agent security context field",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorAgentSecurityContext = `{"runAsUsr":1000}`
+			},
+			wantErr: "executor agent security context must be a JSON container securityContext",
+		},
+		{
+			name: "malformed exec command denylist pattern",
+			mutate: func(cfg *Config) {
+				cfg.ExecCommandDenyList = "kubectl,[curl"
+			},
+			wantErr: "exec command denylist pattern \"[curl\" is invalid",
+		},
+		{
+			name: "invalid session ready timeout",
+			mutate: func(cfg *Config) {
+				cfg.SessionReadyTimeout = 0
+			},
+			wantErr: "session ready timeout must be positive",
+		},
+		{
+			name: "session poll interval not shorter than ready timeout",
+			mutate: func(cfg *Config) {
+				cfg.SessionReadyTimeout = time.Second
+				cfg.SessionPollInterval = 2 * time.Second
+			},
+			wantErr: "session poll interval (2s) must be shorter than session ready timeout (1s)",
+		},
 		{
 			name: "missing gRPC auth secret name",
 			mutate: func(cfg *Config) {
@@ -35,6 +134,38 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: "gRPC auth secret name is required",
 		},
+		{
+			name: "tarball restore backend without checkpoint store",
+			mutate: func(cfg *Config) {
+				cfg.RestoreBackend = "tarball"
+				cfg.SandboxCheckpointEnabled = true
+			},
+			wantErr: "tarball restore backend requires SANDBOX_CHECKPOINT_ENABLED and CHECKPOINT_STORE_PATH",
+		},
+		{
+			name: "zero executor liveness failures",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorLivenessFailures = 0
+			},
+			wantErr: "executor liveness failures must be at least 1: 0",
+		},
+		{
+			name: "executor auth without token",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorAuthEnabled = true
+				cfg.GRPCAuthToken = ""
+			},
+			wantErr: "executor auth requires GRPC_AUTH_TOKEN",
+		},
+		{
+			name: "executor TLS without CA",
+			mutate: func(cfg *Config) {
+				cfg.ExecutorTLSEnabled = true
+				cfg.ExecutorTLSCertFile = "/etc/arl/tls/tls.crt"
+				cfg.ExecutorTLSKeyFile = "/etc/arl/tls/tls.key"
+			},
+			wantErr: "executor TLS requires EXECUTOR_TLS_CERT_FILE, EXECUTOR_TLS_KEY_FILE and EXECUTOR_TLS_CA_FILE",
+		},
 		{
 			name: "ClickHouse enabled without address",
 			mutate: func(cfg *Config) {
@@ -94,6 +225,13 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: "observation preview bytes",
 		},
+		{
+			name: "negative exec max output bytes",
+			mutate: func(cfg *Config) {
+				cfg.ExecMaxOutputBytes = -1
+			},
+			wantErr: "exec max output bytes",
+		},
 		{
 			name: "invalid internal port conflict",
 			mutate: func(cfg *Config) {
@@ -101,6 +239,20 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: "internal port",
 		},
+		{
+			name: "invalid internal bind address",
+			mutate: func(cfg *Config) {
+				cfg.InternalBindAddress = "localhost"
+			},
+			wantErr: "invalid internal bind address",
+		},
+		{
+			name: "metrics port collides with internal port",
+			mutate: func(cfg *Config) {
+				cfg.MetricsPort = cfg.InternalPort
+			},
+			wantErr: "metrics port",
+		},
 		{
 			name: "invalid admission queue timeout",
 			mutate: func(cfg *Config) {
@@ -215,6 +367,12 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.AdmissionQueuePollInterval != 500*time.Millisecond {
 		t.Errorf("AdmissionQueuePollInterval = %v, want 500ms", cfg.AdmissionQueuePollInterval)
 	}
+	if cfg.SessionReadyTimeout != 5*time.Minute {
+		t.Errorf("SessionReadyTimeout = %v, want 5m", cfg.SessionReadyTimeout)
+	}
+	if cfg.SessionPollInterval != 500*time.Millisecond {
+		t.Errorf("SessionPollInterval = %v, want 500ms", cfg.SessionPollInterval)
+	}
 	if cfg.PoolAutoscalerEnabled {
 		t.Error("PoolAutoscalerEnabled = true, want false")
 	}
@@ -260,6 +418,12 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.ObservationPreviewBytes != 4096 {
 		t.Errorf("ObservationPreviewBytes = %d, want 4096", cfg.ObservationPreviewBytes)
 	}
+	if cfg.ExecMaxOutputBytes != 64*1024*1024 {
+		t.Errorf("ExecMaxOutputBytes = %d, want 64 MiB", cfg.ExecMaxOutputBytes)
+	}
+	if cfg.GatewayDrainTimeout != 30*time.Second {
+		t.Errorf("GatewayDrainTimeout = %s, want 30s", cfg.GatewayDrainTimeout)
+	}
 }
 
 func TestLoadFromEnvImagePullPolicy(t *testing.T) {
@@ -292,6 +456,9 @@ func TestLoadFromEnvGatewaySettings(t *testing.T) {
 	t.Setenv("GRPC_AUTH_SECRET_NAME", "custom-grpc-token")
 	t.Setenv("ADMISSION_QUEUE_TIMEOUT", "2s")
 	t.Setenv("ADMISSION_QUEUE_POLL_INTERVAL", "100ms")
+	t.Setenv("SESSION_READY_TIMEOUT", "15m")
+	t.Setenv("SESSION_POLL_INTERVAL", "200ms")
+	t.Setenv("SANDBOX_FAIR_BINDING", "true")
 	t.Setenv("POOL_AUTOSCALER_ENABLED", "true")
 	t.Setenv("POOL_AUTOSCALER_INTERVAL", "15s")
 	t.Setenv("POOL_AUTOSCALER_BUFFER", "4")
@@ -314,6 +481,7 @@ func TestLoadFromEnvGatewaySettings(t *testing.T) {
 	t.Setenv("SANDBOX_ALLOW_PRIVILEGE_ESCALATION", "true")
 	t.Setenv("FULL_OBSERVATION_ENABLED", "true")
 	t.Setenv("OBSERVATION_PREVIEW_BYTES", "1024")
+	t.Setenv("TRAJECTORY_DEDUP_OBSERVATIONS", "true")
 
 	cfg := LoadFromEnv()
 	if cfg.AuthEnabled {
@@ -340,6 +508,15 @@ func TestLoadFromEnvGatewaySettings(t *testing.T) {
 	if cfg.AdmissionQueuePollInterval != 100*time.Millisecond {
 		t.Fatalf("AdmissionQueuePollInterval = %v, want 100ms", cfg.AdmissionQueuePollInterval)
 	}
+	if cfg.SessionReadyTimeout != 15*time.Minute {
+		t.Fatalf("SessionReadyTimeout = %v, want 15m", cfg.SessionReadyTimeout)
+	}
+	if cfg.SessionPollInterval != 200*time.Millisecond {
+		t.Fatalf("SessionPollInterval = %v, want 200ms", cfg.SessionPollInterval)
+	}
+	if !cfg.SandboxFairBinding {
+		t.Fatal("SandboxFairBinding = false, want true")
+	}
 	if !cfg.PoolAutoscalerEnabled {
 		t.Fatal("PoolAutoscalerEnabled = false, want true")
 	}
@@ -406,4 +583,7 @@ func TestLoadFromEnvGatewaySettings(t *testing.T) {
 	if cfg.ObservationPreviewBytes != 1024 {
 		t.Fatalf("ObservationPreviewBytes = %d, want 1024", cfg.ObservationPreviewBytes)
 	}
+	if !cfg.TrajectoryDedupObservations {
+		t.Fatal("TrajectoryDedupObservations = false, want true")
+	}
 }
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFromFile loads configuration from a YAML (or JSON) file whose keys are
+// the environment variable names LoadFromEnv reads, e.g.
+//
+//	GATEWAY_PORT: 8080
+//	EXECUTOR_DIAL_TIMEOUT: 5s
+//	AUTH_API_KEYS: [key-a, key-b]
+//
+// Environment variables take precedence over the file, so a mounted file can
+// carry the defaults for a deployment while single values stay overridable.
+// Lists are joined with commas. Unknown keys are rejected, and the merged
+// result is validated.
+func LoadFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	fileValues := make(map[string]string, len(raw))
+	for key, value := range raw {
+		s, err := fileValueString(value)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		fileValues[key] = s
+	}
+
+	// With every variable unset, load reads each key it knows about.
+	known := make(map[string]bool)
+	load(func(key string) string {
+		known[key] = true
+		return ""
+	})
+	var unknown []string
+	for key := range fileValues {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("config file %s: unknown keys: %s", path, strings.Join(unknown, ", "))
+	}
+
+	cfg := load(func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fileValues[key]
+	})
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// fileValueString renders a YAML scalar or list the way it would be written
+// in the environment.
+func fileValueString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, nested := item.([]any); nested {
+				return "", fmt.Errorf("nested lists are not supported")
+			}
+			s, err := fileValueString(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", value)
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFromFileEnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, `
+AUTH_API_KEYS: [a:admin, b:user]
+GATEWAY_IDLE_TIMEOUT: 45s
+EXECUTOR_MAX_CONCURRENT_CALLS: 64
+POOL_AUTOSCALER_ENABLED: true
+K8S_CLIENT_QPS: 12.5
+`)
+	t.Setenv("EXECUTOR_MAX_CONCURRENT_CALLS", "8")
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile returned error: %v", err)
+	}
+	if cfg.AuthAPIKeys != "a:admin,b:user" {
+		t.Fatalf("AuthAPIKeys = %q", cfg.AuthAPIKeys)
+	}
+	if cfg.GatewayIdleTimeout != 45*time.Second {
+		t.Fatalf("GatewayIdleTimeout = %v, want 45s", cfg.GatewayIdleTimeout)
+	}
+	if cfg.ExecutorMaxConcurrentCalls != 8 {
+		t.Fatalf("ExecutorMaxConcurrentCalls = %d, want env value 8", cfg.ExecutorMaxConcurrentCalls)
+	}
+	if !cfg.PoolAutoscalerEnabled || cfg.K8sClientQPS != 12.5 {
+		t.Fatalf("PoolAutoscalerEnabled = %v, K8sClientQPS = %v", cfg.PoolAutoscalerEnabled, cfg.K8sClientQPS)
+	}
+}
+
+func TestLoadFromFileRejectsUnknownKeysAndInvalidConfig(t *testing.T) {
+	if _, err := LoadFromFile(writeConfigFile(t, "AUTH_API_KEYS: a:admin\nGATEWAY_PROT: 8080\n")); err == nil || !strings.Contains(err.Error(), "GATEWAY_PROT") {
+		t.Fatalf("unknown key error = %v", err)
+	}
+	if _, err := LoadFromFile(writeConfigFile(t, "AUTH_API_KEYS: a:admin\nMAX_ACTIVE_SESSIONS: -1\n")); err == nil || !strings.Contains(err.Error(), "max active sessions") {
+		t.Fatalf("validation error = %v", err)
+	}
+	if _, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("LoadFromFile of a missing file succeeded")
+	}
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// gzipMagic is the two-byte header that identifies a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ErrInvalidArchive is wrapped by upload errors caused by the archive
+// itself: corrupt tar or gzip data and entries with unsafe paths.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// UploadArchive extracts a tar or tar.gz stream into the session workspace.
+// Each regular file is written through the executor and recorded as an
+// upload_file step, so restore and replay treat it like an individual
+// upload. Directories are created implicitly; symlinks and other special
+// entries are skipped. Entries with absolute paths or ".." components are
+// rejected before anything outside the workspace can be touched. When
+// extraction stops partway, the files already written are returned with
+// the error.
+func (g *Gateway) UploadArchive(ctx context.Context, sessionID string, archive io.Reader) (*UploadArchiveResponse, error) {
+	tr, err := newArchiveReader(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	s, podIP, releaseSession, err := g.acquireSessionPodIP(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseSession()
+	defer g.store.SyncHistory(sessionID)
+
+	resp := &UploadArchiveResponse{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return resp, fmt.Errorf("%w: read archive: %w", ErrInvalidArchive, err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		name, err := sanitizeArchivePath(hdr.Name)
+		if err != nil {
+			return resp, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			resp.Skipped = append(resp.Skipped, name)
+			continue
+		}
+
+		file, err := g.writeUploadedFile(ctx, s, podIP, name, tr, "")
+		if err != nil {
+			return resp, fmt.Errorf("write %s: %w", name, err)
+		}
+		resp.Files = append(resp.Files, *file)
+		resp.BytesWritten += int64(file.BytesWritten)
+	}
+
+	g.touchLastTaskTime(sessionID)
+	return resp, nil
+}
+
+// newArchiveReader wraps r in a tar reader, transparently decompressing
+// gzip input.
+func newArchiveReader(r io.Reader) (*tar.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: read archive: %w", ErrInvalidArchive, err)
+	}
+	if bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: gzip: %w", ErrInvalidArchive, err)
+		}
+		return tar.NewReader(zr), nil
+	}
+	return tar.NewReader(br), nil
+}
+
+// sanitizeArchivePath normalizes an archive entry name to a workspace
+// relative path, rejecting absolute paths and parent traversal.
+func sanitizeArchivePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: entry %q: absolute paths are not allowed", ErrInvalidArchive, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: entry %q: parent directory references are not allowed", ErrInvalidArchive, name)
+		}
+	}
+	cleaned := path.Clean(name)
+	if cleaned == "." {
+		return "", fmt.Errorf("%w: entry %q: empty path", ErrInvalidArchive, name)
+	}
+	return cleaned, nil
+}
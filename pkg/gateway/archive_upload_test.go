@@ -0,0 +1,130 @@
+package gateway
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestUploadArchiveExtractsGzipTar(t *testing.T) {
+	store := newTestSessionStore("gw-archive")
+	written := map[string]string{}
+	gw := New(nil, &operationRuntimeAllocator{}, &client.MockExecutorClient{
+		WriteFileFunc: func(ctx context.Context, podIP string, path string, content io.Reader, expectedSHA256 string) (*interfaces.FileWriteResult, error) {
+			data, err := io.ReadAll(content)
+			if err != nil {
+				return nil, err
+			}
+			written[path] = string(data)
+			return &interfaces.FileWriteResult{Path: path, BytesWritten: int64(len(data))}, nil
+		},
+	}, nil, nil, GatewayConfig{}, store)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	writeTestTarEntry(t, tw, &tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0o755})
+	writeTestTarEntry(t, tw, &tar.Header{Name: "./src/main.go", Typeflag: tar.TypeReg, Mode: 0o644}, "package main\n")
+	writeTestTarEntry(t, tw, &tar.Header{Name: "README.md", Typeflag: tar.TypeReg, Mode: 0o644}, "hi")
+	writeTestTarEntry(t, tw, &tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "README.md"})
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := gw.UploadArchive(context.Background(), "gw-archive", &buf)
+	if err != nil {
+		t.Fatalf("UploadArchive returned error: %v", err)
+	}
+	if len(resp.Files) != 2 || resp.BytesWritten != 15 {
+		t.Fatalf("response = %#v, want 2 files / 15 bytes", resp)
+	}
+	if written["src/main.go"] != "package main\n" || written["README.md"] != "hi" {
+		t.Fatalf("written = %#v", written)
+	}
+	if len(resp.Skipped) != 1 || resp.Skipped[0] != "link" {
+		t.Fatalf("skipped = %#v, want [link]", resp.Skipped)
+	}
+	sess, _ := store.Get("gw-archive")
+	if got := sess.History.Len(); got != 2 {
+		t.Fatalf("history length = %d, want 2", got)
+	}
+}
+
+func TestUploadArchiveRejectsBadArchivesWithPartialResults(t *testing.T) {
+	store := newTestSessionStore("gw-archive")
+	gw := New(nil, &operationRuntimeAllocator{}, &client.MockExecutorClient{
+		WriteFileFunc: func(ctx context.Context, podIP string, path string, content io.Reader, expectedSHA256 string) (*interfaces.FileWriteResult, error) {
+			data, err := io.ReadAll(content)
+			if err != nil {
+				return nil, err
+			}
+			return &interfaces.FileWriteResult{Path: path, BytesWritten: int64(len(data))}, nil
+		},
+	}, nil, nil, GatewayConfig{}, store)
+	router := SetupRoutes(gw, nil)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	writeTestTarEntry(t, tw, &tar.Header{Name: "ok.txt", Typeflag: tar.TypeReg, Mode: 0o644}, "ok")
+	writeTestTarEntry(t, tw, &tar.Header{Name: "../escape.txt", Typeflag: tar.TypeReg, Mode: 0o644}, "no")
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions/gw-archive/upload-archive", &buf)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+	var resp UploadArchiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error == "" || len(resp.Files) != 1 || resp.Files[0].Path != "ok.txt" {
+		t.Fatalf("response = %#v, want the error and the one file written before it", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/sessions/gw-archive/upload-archive", strings.NewReader("\x1f\x8bnot gzip"))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("corrupt gzip status = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSanitizeArchivePathRejectsEscapes(t *testing.T) {
+	for _, name := range []string{"/etc/passwd", "../outside", "a/../../b", "..\\win"} {
+		if _, err := sanitizeArchivePath(name); err == nil {
+			t.Fatalf("sanitizeArchivePath(%q) accepted unsafe path", name)
+		}
+	}
+	if got, err := sanitizeArchivePath("./a//b/c.txt"); err != nil || got != "a/b/c.txt" {
+		t.Fatalf("sanitizeArchivePath = %q, %v; want a/b/c.txt", got, err)
+	}
+}
+
+func writeTestTarEntry(t *testing.T, tw *tar.Writer, hdr *tar.Header, content ...string) {
+	t.Helper()
+	body := strings.Join(content, "")
+	hdr.Size = int64(len(body))
+	if err := tw.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(tw, body); err != nil {
+		t.Fatal(err)
+	}
+}
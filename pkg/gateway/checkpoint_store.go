@@ -17,6 +17,7 @@ import (
 // Directory layout:
 //
 //	{basePath}/{sessionID}/step-{N}.tar   (one incremental tar per checkpoint step)
+//	{basePath}/{sessionID}/snapshot-{I}   (checkpoint step of the snapshot at history index I)
 type CheckpointStore struct {
 	basePath string
 }
@@ -71,6 +72,30 @@ func (s *CheckpointStore) HasStep(sessionID string, checkpointStep int) bool {
 	return err == nil
 }
 
+func (s *CheckpointStore) snapshotPath(sessionID string, stepIndex int) string {
+	return filepath.Join(s.basePath, sessionID, fmt.Sprintf("snapshot-%d", stepIndex))
+}
+
+// SaveSnapshot records that the snapshot taken at history index stepIndex
+// is the workspace as of checkpointStep. The two drift apart once a step
+// runs without creating a checkpoint.
+func (s *CheckpointStore) SaveSnapshot(sessionID string, stepIndex, checkpointStep int) error {
+	if err := os.MkdirAll(filepath.Join(s.basePath, sessionID), 0o755); err != nil {
+		return fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	return os.WriteFile(s.snapshotPath(sessionID, stepIndex), []byte(strconv.Itoa(checkpointStep)), 0o644)
+}
+
+// SnapshotCheckpointStep returns the checkpoint step SaveSnapshot recorded
+// for the snapshot at history index stepIndex.
+func (s *CheckpointStore) SnapshotCheckpointStep(sessionID string, stepIndex int) (int, error) {
+	data, err := os.ReadFile(s.snapshotPath(sessionID, stepIndex))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
 // ListSteps returns sorted checkpoint step numbers available for a session.
 func (s *CheckpointStore) ListSteps(sessionID string) ([]int, error) {
 	dir := filepath.Join(s.basePath, sessionID)
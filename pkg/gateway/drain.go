@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const drainPollInterval = 100 * time.Millisecond
+
+// Drain stops the gateway from accepting new sessions and waits until no
+// execution, upload, shell or tunnel holds a session, or until ctx ends.
+// Work that starts on an existing session while draining is still waited
+// for, so agents can finish the step they are on.
+func (g *Gateway) Drain(ctx context.Context) error {
+	g.draining.Store(true)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		n := g.inflight.Load()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d session operation(s) still in flight: %w", n, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Draining reports whether Drain has been called.
+func (g *Gateway) Draining() bool {
+	return g.draining.Load()
+}
+
+// checkNotDraining rejects a new session while the gateway shuts down.
+func (g *Gateway) checkNotDraining() error {
+	if g.draining.Load() {
+		return ErrGatewayDraining
+	}
+	return nil
+}
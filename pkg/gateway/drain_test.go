@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+)
+
+func TestDrainWaitsForInflightSessionWork(t *testing.T) {
+	store := newTestSessionStore("gw-drain")
+	gw := New(nil, &operationRuntimeAllocator{}, &mockclient.MockExecutorClient{}, nil, nil, GatewayConfig{}, store)
+
+	_, _, release, err := gw.acquireSessionPodIP(context.Background(), "gw-drain")
+	if err != nil {
+		t.Fatalf("acquireSessionPodIP: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- gw.Drain(context.Background()) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Drain returned %v while an execution was in flight", err)
+	case <-time.After(3 * drainPollInterval):
+	}
+	if _, err := gw.CreateSession(context.Background(), CreateSessionRequest{Image: "python:3.12"}); !errors.Is(err, ErrGatewayDraining) {
+		t.Fatalf("CreateSession while draining = %v, want ErrGatewayDraining", err)
+	}
+	if status := httpStatusForError(ErrGatewayDraining); status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", status)
+	}
+
+	release()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Drain = %v, want nil once work finished", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Drain did not return after the execution finished")
+	}
+}
+
+func TestDrainGivesUpAtDeadline(t *testing.T) {
+	store := newTestSessionStore("gw-drain-timeout")
+	gw := New(nil, &operationRuntimeAllocator{}, &mockclient.MockExecutorClient{}, nil, nil, GatewayConfig{}, store)
+	_, _, release, err := gw.acquireSessionPodIP(context.Background(), "gw-drain-timeout")
+	if err != nil {
+		t.Fatalf("acquireSessionPodIP: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := gw.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want deadline exceeded", err)
+	}
+}
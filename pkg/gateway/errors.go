@@ -9,6 +9,35 @@ import (
 
 var ErrNamespaceNotAllowed = errors.New("namespace not allowed")
 
+// Sentinels behind the machine-readable ErrorResponse codes. Errors that
+// carry more context wrap them or match them via an Is method.
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrPoolNotFound    = errors.New("pool not found")
+	ErrPoolUnhealthy   = errors.New("pool unhealthy")
+	ErrSandboxTimeout  = errors.New("timed out waiting for sandbox")
+	ErrTooManySessions = errors.New("too many active sessions")
+	ErrGatewayDraining = errors.New("gateway is shutting down")
+)
+
+// Error codes reported in ErrorResponse.Code.
+const (
+	ErrorCodeSessionNotFound     = "SESSION_NOT_FOUND"
+	ErrorCodePoolNotFound        = "POOL_NOT_FOUND"
+	ErrorCodePoolUnhealthy       = "POOL_UNHEALTHY"
+	ErrorCodePoolAtCapacity      = "POOL_AT_CAPACITY"
+	ErrorCodeSandboxTimeout      = "SANDBOX_TIMEOUT"
+	ErrorCodeSandboxNotReady     = "SANDBOX_NOT_READY"
+	ErrorCodeNamespaceNotAllowed = "NAMESPACE_NOT_ALLOWED"
+	ErrorCodeSessionNameInUse    = "SESSION_NAME_IN_USE"
+	ErrorCodeTooManySessions     = "TOO_MANY_SESSIONS"
+	ErrorCodeGatewayDraining     = "GATEWAY_DRAINING"
+)
+
+// ErrSessionNameInUse is returned when a caller-chosen session name is
+// already taken by a live or in-flight session.
+var ErrSessionNameInUse = errors.New("session name already in use")
+
 // RuntimeNotReadyError indicates the sandbox claim exists but is not yet
 // ready (e.g., sandbox still binding, WarmPool not found). Callers should
 // retry instead of treating this as a permanent failure.
@@ -22,6 +51,65 @@ func (e *RuntimeNotReadyError) Error() string {
 	return fmt.Sprintf("session %s sandbox claim %s/%s is not ready", e.SessionID, e.Namespace, e.ClaimName)
 }
 
+// SessionNotFoundError reports a session ID unknown to the gateway.
+type SessionNotFoundError struct {
+	SessionID string
+}
+
+func (e *SessionNotFoundError) Error() string {
+	return fmt.Sprintf("session %s not found", e.SessionID)
+}
+
+func (e *SessionNotFoundError) Is(target error) bool { return target == ErrSessionNotFound }
+
+func isSessionNotFoundError(err error, sessionID string) bool {
+	var notFound *SessionNotFoundError
+	return errors.As(err, &notFound) && notFound.SessionID == sessionID
+}
+
+// PoolNotFoundError reports a SandboxWarmPool that does not exist.
+type PoolNotFoundError struct {
+	Name      string
+	Namespace string
+}
+
+func (e *PoolNotFoundError) Error() string {
+	return fmt.Sprintf("pool %q not found in namespace %q", e.Name, e.Namespace)
+}
+
+func (e *PoolNotFoundError) Is(target error) bool { return target == ErrPoolNotFound }
+
+// errorCodeForError returns the ErrorResponse code for err, or "" when err
+// has no stable classification.
+func errorCodeForError(err error) string {
+	var notReady *RuntimeNotReadyError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrSessionNotFound):
+		return ErrorCodeSessionNotFound
+	case errors.Is(err, ErrPoolNotFound):
+		return ErrorCodePoolNotFound
+	case errors.Is(err, ErrPoolUnhealthy):
+		return ErrorCodePoolUnhealthy
+	case errors.Is(err, ErrSandboxTimeout):
+		return ErrorCodeSandboxTimeout
+	case errors.Is(err, ErrPoolAtCapacity):
+		return ErrorCodePoolAtCapacity
+	case errors.As(err, &notReady):
+		return ErrorCodeSandboxNotReady
+	case errors.Is(err, ErrNamespaceNotAllowed):
+		return ErrorCodeNamespaceNotAllowed
+	case errors.Is(err, ErrSessionNameInUse):
+		return ErrorCodeSessionNameInUse
+	case errors.Is(err, ErrTooManySessions):
+		return ErrorCodeTooManySessions
+	case errors.Is(err, ErrGatewayDraining):
+		return ErrorCodeGatewayDraining
+	}
+	return ""
+}
+
 // httpStatusForError maps common gateway error patterns to HTTP status codes.
 func httpStatusForError(err error) int {
 	if err == nil {
@@ -35,11 +123,29 @@ func httpStatusForError(err error) int {
 	if errors.Is(err, ErrNamespaceNotAllowed) {
 		return http.StatusForbidden
 	}
+	if errors.Is(err, ErrSessionNameInUse) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, ErrInvalidArchive) || errors.Is(err, ErrMalformedPatch) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrSessionNotFound) || errors.Is(err, ErrPoolNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, ErrPoolUnhealthy) || errors.Is(err, ErrGatewayDraining) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, ErrSandboxTimeout) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, ErrPoolAtCapacity) || errors.Is(err, ErrTooManySessions) {
+		return http.StatusTooManyRequests
+	}
 	if strings.Contains(msg, "not found") {
 		return http.StatusNotFound
 	}
 	if strings.Contains(msg, "only devbox sessions") ||
-		strings.Contains(msg, "invalid session mode") ||
+		strings.Contains(msg, "invalid session") ||
 		strings.Contains(msg, "is required") {
 		return http.StatusBadRequest
 	}
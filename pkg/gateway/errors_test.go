@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorCodeAndStatusForSentinels(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+	}{
+		{"session", fmt.Errorf("execute: %w", &SessionNotFoundError{SessionID: "s1"}), ErrorCodeSessionNotFound, http.StatusNotFound},
+		{"pool", &PoolNotFoundError{Name: "code", Namespace: "arl"}, ErrorCodePoolNotFound, http.StatusNotFound},
+		{"unhealthy", &doomedPoolError{reason: "ImagePullBackOff", err: ErrPoolAtCapacity}, ErrorCodePoolUnhealthy, http.StatusServiceUnavailable},
+		{"timeout", fmt.Errorf("allocate runtime: %w", fmt.Errorf("%w: %w", ErrSandboxTimeout, context.DeadlineExceeded)), ErrorCodeSandboxTimeout, http.StatusGatewayTimeout},
+		{"capacity", fmt.Errorf("%w: pool_at_capacity", ErrPoolAtCapacity), ErrorCodePoolAtCapacity, http.StatusTooManyRequests},
+		{"session limit", fmt.Errorf("%w: limit is 10", ErrTooManySessions), ErrorCodeTooManySessions, http.StatusTooManyRequests},
+		{"unclassified", errors.New("boom"), "", http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := errorCodeForError(tc.err); got != tc.wantCode {
+				t.Fatalf("errorCodeForError = %q, want %q", got, tc.wantCode)
+			}
+			if got := httpStatusForError(tc.err); got != tc.wantStatus {
+				t.Fatalf("httpStatusForError = %d, want %d", got, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWriteGatewayErrorIncludesCode(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeGatewayError(rec, &SessionNotFoundError{SessionID: "gw-missing"})
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if resp.Code != ErrorCodeSessionNotFound || resp.Error != "session gw-missing not found" {
+		t.Fatalf("response = %#v", resp)
+	}
+}
+
+func TestWriteGatewayErrorSetsRetryAfterForSessionLimit(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeGatewayError(rec, fmt.Errorf("%w: limit is 1", ErrTooManySessions))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != sessionLimitRetryAfter {
+		t.Fatalf("Retry-After = %q, want %q", got, sessionLimitRetryAfter)
+	}
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// commandDeniedExitCode is the exit code reported for a command rejected by
+// the command denylist, matching the shell's "cannot execute".
+const commandDeniedExitCode = 126
+
+// commandDeniedText appears in the stderr of every denied step, which lets
+// replay tell a recorded denial apart from a command that exited 126.
+const commandDeniedText = "is not permitted by the gateway command policy"
+
+// commandDenial checks the basename of command[0] against the operator's
+// command denylist and returns the stderr line to report in place of running
+// it, or "" when the command may run. Only the program itself is checked:
+// a wrapper such as "sh -c 'kubectl ...'" or an interactive shell is not
+// inspected, so this complements network policy rather than replacing it.
+func (g *Gateway) commandDenial(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	patterns := splitCommandPatterns(g.reloadable().ExecCommandDenyList)
+	if len(patterns) == 0 {
+		return ""
+	}
+	program := path.Base(command[0])
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, program); ok {
+			return fmt.Sprintf("command %q %s (matches %q)\n", program, commandDeniedText, pattern)
+		}
+	}
+	return ""
+}
+
+// applyStepPolicy applies the operator's command denylist and env allow/deny
+// lists to step. It returns the step with its env filtered, the warning to
+// prepend to stderr for dropped env vars, and the stderr line to report in
+// place of running a denied command ("" when it may run). Every path that
+// turns a step into a command, in the executor or a private container,
+// calls it.
+func (g *Gateway) applyStepPolicy(step StepRequest) (StepRequest, string, string) {
+	env, envWarning := g.filterStepEnv(step.Env)
+	step.Env = env
+	return step, envWarning, g.commandDenial(step.Command)
+}
+
+// deniedStepRecord reports whether record is a step the command denylist
+// rejected when it was recorded, so it never ran.
+func deniedStepRecord(record StepRecord) bool {
+	return record.Output.ExitCode == commandDeniedExitCode && strings.Contains(record.Output.Stderr, commandDeniedText)
+}
+
+func splitCommandPatterns(raw string) []string {
+	var patterns []string
+	for _, part := range strings.Split(raw, ",") {
+		if pattern := strings.TrimSpace(part); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestCommandDenialMatchesProgramBasename(t *testing.T) {
+	gw := &Gateway{gwConfig: GatewayConfig{ExecCommandDenyList: "kubectl, nc*"}}
+	for _, tc := range []struct {
+		command []string
+		denied  bool
+	}{
+		{[]string{"kubectl", "get", "pods"}, true},
+		{[]string{"/usr/local/bin/kubectl"}, true},
+		{[]string{"ncat", "-l"}, true},
+		{[]string{"python", "kubectl"}, false},
+		{[]string{"sh", "-c", "kubectl get pods"}, false},
+		{nil, false},
+	} {
+		if got := gw.commandDenial(tc.command) != ""; got != tc.denied {
+			t.Fatalf("commandDenial(%v) denied = %v, want %v", tc.command, got, tc.denied)
+		}
+	}
+}
+
+func TestExecuteStepsRejectsDeniedCommand(t *testing.T) {
+	store := newTestSessionStore("gw-deny")
+	var ran []string
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			ran = append(ran, req.Command[0])
+			return &interfaces.ExecResponse{}, nil
+		},
+	}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, GatewayConfig{ExecCommandDenyList: "curl"}, store)
+
+	resp, err := gw.ExecuteSteps(context.Background(), "gw-deny", ExecuteRequest{Steps: []StepRequest{
+		{Name: "fetch", Command: []string{"curl", "http://169.254.169.254/"}},
+		{Name: "list", Command: []string{"ls"}},
+	}})
+	if err != nil {
+		t.Fatalf("ExecuteSteps returned error: %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "ls" {
+		t.Fatalf("executor ran %v, want only ls", ran)
+	}
+	denied := resp.Results[0].Output
+	if denied.ExitCode != commandDeniedExitCode || !strings.Contains(denied.Stderr, `"curl" is not permitted`) {
+		t.Fatalf("denied step output = %#v", denied)
+	}
+	if resp.Results[1].Output.ExitCode != 0 {
+		t.Fatalf("allowed step exit = %d", resp.Results[1].Output.ExitCode)
+	}
+}
@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// filterStepEnv applies the operator's env allow/deny lists to a step's
+// environment. Denied keys always lose; when an allowlist is configured only
+// listed keys pass. It returns the filtered env and a warning to prepend to
+// the step's stderr, which is empty when nothing was dropped.
+func (g *Gateway) filterStepEnv(env map[string]string) (map[string]string, string) {
+	if len(env) == 0 {
+		return env, ""
+	}
+	rc := g.reloadable()
+	deny := splitEnvKeyList(rc.ExecEnvDenyList)
+	allow := splitEnvKeyList(rc.ExecEnvAllowList)
+	if len(deny) == 0 && len(allow) == 0 {
+		return env, ""
+	}
+
+	filtered := make(map[string]string, len(env))
+	var rejected []string
+	for key, value := range env {
+		_, denied := deny[key]
+		_, allowed := allow[key]
+		if denied || (len(allow) > 0 && !allowed) {
+			rejected = append(rejected, key)
+			continue
+		}
+		filtered[key] = value
+	}
+	if len(rejected) == 0 {
+		return env, ""
+	}
+	sort.Strings(rejected)
+	return filtered, fmt.Sprintf("warning: environment variable(s) not permitted and dropped: %s\n", strings.Join(rejected, ", "))
+}
+
+func splitEnvKeyList(raw string) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		if key := strings.TrimSpace(part); key != "" {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
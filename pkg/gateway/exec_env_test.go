@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestFilterStepEnv(t *testing.T) {
+	env := map[string]string{"LD_PRELOAD": "/tmp/x.so", "PATH": "/bin", "HOME": "/root"}
+
+	gw := &Gateway{gwConfig: GatewayConfig{}}
+	if got, warning := gw.filterStepEnv(env); len(got) != 3 || warning != "" {
+		t.Fatalf("no lists: env = %v, warning = %q", got, warning)
+	}
+
+	gw.gwConfig.ExecEnvDenyList = "LD_PRELOAD, LD_LIBRARY_PATH"
+	got, warning := gw.filterStepEnv(env)
+	if _, ok := got["LD_PRELOAD"]; ok || len(got) != 2 {
+		t.Fatalf("denylist: env = %v", got)
+	}
+	if !strings.Contains(warning, "LD_PRELOAD") {
+		t.Fatalf("denylist: warning = %q", warning)
+	}
+
+	gw.gwConfig.ExecEnvDenyList = ""
+	gw.gwConfig.ExecEnvAllowList = "PATH"
+	got, warning = gw.filterStepEnv(env)
+	if len(got) != 1 || got["PATH"] != "/bin" {
+		t.Fatalf("allowlist: env = %v", got)
+	}
+	if !strings.Contains(warning, "HOME, LD_PRELOAD") {
+		t.Fatalf("allowlist: warning = %q", warning)
+	}
+}
+
+func TestExecuteStepsDropsDeniedEnvWithWarning(t *testing.T) {
+	store := newTestSessionStore("gw-env")
+	var gotEnv map[string]string
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			gotEnv = req.Env
+			return &interfaces.ExecResponse{Stderr: "boom\n", ExitCode: 0}, nil
+		},
+	}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, GatewayConfig{ExecEnvDenyList: "LD_PRELOAD"}, store)
+
+	resp, err := gw.ExecuteSteps(context.Background(), "gw-env", ExecuteRequest{Steps: []StepRequest{{
+		Name:    "run",
+		Command: []string{"true"},
+		Env:     map[string]string{"LD_PRELOAD": "/tmp/x.so", "FOO": "bar"},
+	}}})
+	if err != nil {
+		t.Fatalf("ExecuteSteps returned error: %v", err)
+	}
+	if _, ok := gotEnv["LD_PRELOAD"]; ok || gotEnv["FOO"] != "bar" {
+		t.Fatalf("executor env = %v", gotEnv)
+	}
+	stderr := resp.Results[0].Output.Stderr
+	if !strings.HasPrefix(stderr, "warning: ") || !strings.HasSuffix(stderr, "boom\n") {
+		t.Fatalf("stderr = %q", stderr)
+	}
+}
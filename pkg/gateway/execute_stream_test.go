@@ -0,0 +1,150 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestExecuteStreamEmitsOutputAndResultEvents(t *testing.T) {
+	store := newTestSessionStore("gw-stream")
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteStreamFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (<-chan interfaces.ExecResponse, error) {
+			ch := make(chan interfaces.ExecResponse, 3)
+			ch <- interfaces.ExecResponse{Stdout: "building\n"}
+			ch <- interfaces.ExecResponse{Stderr: "warning\n"}
+			ch <- interfaces.ExecResponse{ExitCode: 0, Done: true, StartLatency: 750 * time.Microsecond}
+			close(ch)
+			return ch, nil
+		},
+	}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, GatewayConfig{}, store)
+	router := SetupRoutes(gw, nil)
+
+	body := strings.NewReader(`{"steps":[{"name":"build","command":["make"]}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions/gw-stream/execute/stream", body)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	out := rec.Body.String()
+	if strings.Count(out, "event: output\n") != 2 {
+		t.Fatalf("output events missing in %q", out)
+	}
+	if strings.Count(out, "event: result\n") != 1 {
+		t.Fatalf("result event missing in %q", out)
+	}
+	if !strings.Contains(out, `"overhead_us":750`) {
+		t.Fatalf("result event lacks the agent start latency: %q", out)
+	}
+}
+
+func TestExecuteStreamRejectsOperationID(t *testing.T) {
+	store := newTestSessionStore("gw-stream")
+	gw := New(nil, &operationRuntimeAllocator{}, &mockclient.MockExecutorClient{}, nil, nil, GatewayConfig{}, store)
+	router := SetupRoutes(gw, nil)
+
+	body := strings.NewReader(`{"operationID":"op-1","steps":[{"name":"build","command":["make"]}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions/gw-stream/execute/stream", body)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestExecuteEntryPointsValidateSteps(t *testing.T) {
+	store := newTestSessionStore("gw-stream")
+	gw := New(nil, &operationRuntimeAllocator{}, &mockclient.MockExecutorClient{}, nil, nil, GatewayConfig{}, store)
+	router := SetupRoutes(gw, nil)
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		accept string
+	}{
+		{"stream", "/v1/sessions/gw-stream/execute/stream", ""},
+		{"execute SSE", "/v1/sessions/gw-stream/execute", "text/event-stream"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.NewReader(`{"steps":[{"name":"probe","http":{"port":8080,"path":"/x","headers":{"Host":"evil"}}}]}`)
+			req := httptest.NewRequest(http.MethodPost, tt.path, body)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", rec.Code)
+			}
+		})
+	}
+}
+
+func TestExecuteStreamCapsOutputAtGateway(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		kill     bool
+		wantExit string
+		wantFail string
+	}{
+		{"truncate", false, `"exit_code":0`, ""},
+		{"kill", true, `"exit_code":1`, `"failure_reason":"output_limit_exceeded"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestSessionStore("gw-stream")
+			executorClient := &mockclient.MockExecutorClient{
+				// Behaves like an agent that ignores the cap: it sends
+				// everything and never reports truncation.
+				ExecuteStreamFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (<-chan interfaces.ExecResponse, error) {
+					ch := make(chan interfaces.ExecResponse)
+					go func() {
+						defer close(ch)
+						for _, resp := range []interfaces.ExecResponse{
+							{Stdout: "0123456789"},
+							{Stderr: "abcdefghij"},
+							{Done: true},
+						} {
+							select {
+							case ch <- resp:
+							case <-ctx.Done():
+								return
+							}
+						}
+					}()
+					return ch, nil
+				},
+			}
+			gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil,
+				GatewayConfig{ExecMaxOutputBytes: 14, ExecKillOnOutputLimit: tt.kill}, store)
+			router := SetupRoutes(gw, nil)
+
+			body := strings.NewReader(`{"steps":[{"name":"spam","command":["yes"]}]}`)
+			req := httptest.NewRequest(http.MethodPost, "/v1/sessions/gw-stream/execute/stream", body)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			out := rec.Body.String()
+			for _, want := range []string{`"stdout":"0123456789"`, `"stderr":"abcd[output truncated`, `"output_truncated":true`, tt.wantExit, tt.wantFail} {
+				if !strings.Contains(out, want) {
+					t.Fatalf("stream lacks %s: %q", want, out)
+				}
+			}
+			if strings.Contains(out, "efghij") {
+				t.Fatalf("output past the cap was forwarded: %q", out)
+			}
+		})
+	}
+}
@@ -35,7 +35,7 @@ const runtimeReadyPollInterval = 2 * time.Second
 func (g *Gateway) resolveSessionPodIP(ctx context.Context, sessionID string) (*session, string, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, "", fmt.Errorf("session %s not found", sessionID)
+		return nil, "", &SessionNotFoundError{SessionID: sessionID}
 	}
 
 	s.mu.RLock()
@@ -43,7 +43,7 @@ func (g *Gateway) resolveSessionPodIP(ctx context.Context, sessionID string) (*s
 	info := s.Info
 	s.mu.RUnlock()
 	if closed {
-		return nil, "", fmt.Errorf("session %s not found", sessionID)
+		return nil, "", &SessionNotFoundError{SessionID: sessionID}
 	}
 	if g.runtimeAllocator == nil {
 		return nil, "", fmt.Errorf("runtime allocator not configured")
@@ -93,19 +93,37 @@ func (g *Gateway) resolveWithRetry(ctx context.Context, s *session, sessionID st
 	}
 }
 
+// acquireSessionExec is acquireSessionPodIP for command execution. The
+// returned context is also cancelled when the session's execs are aborted
+// (see session.cancelExecs), and the release func must still be called.
+func (g *Gateway) acquireSessionExec(ctx context.Context, sessionID string) (context.Context, *session, string, func(), error) {
+	s, podIP, releaseSession, err := g.acquireSessionPodIP(ctx, sessionID)
+	if err != nil {
+		return ctx, nil, "", releaseSession, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.execContext(), cancel)
+	return ctx, s, podIP, func() {
+		stop()
+		cancel()
+		releaseSession()
+	}, nil
+}
+
 func (g *Gateway) acquireSessionPodIP(ctx context.Context, sessionID string) (*session, string, func(), error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, "", func() {}, fmt.Errorf("session %s not found", sessionID)
+		return nil, "", func() {}, &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	closed := s.closed
 	s.mu.RUnlock()
 	if closed {
-		return nil, "", func() {}, fmt.Errorf("session %s not found", sessionID)
+		return nil, "", func() {}, &SessionNotFoundError{SessionID: sessionID}
 	}
 
 	atomic.AddInt32(&s.activeExecs, 1)
+	g.inflight.Add(1)
 	stopHeartbeat := func() {}
 	var releaseOnce sync.Once
 	release := func() {
@@ -113,6 +131,7 @@ func (g *Gateway) acquireSessionPodIP(ctx context.Context, sessionID string) (*s
 			stopHeartbeat()
 			g.touchLastTaskTime(sessionID)
 			atomic.AddInt32(&s.activeExecs, -1)
+			g.inflight.Add(-1)
 		})
 	}
 
@@ -126,28 +145,47 @@ func (g *Gateway) acquireSessionPodIP(ctx context.Context, sessionID string) (*s
 	return validated, podIP, release, nil
 }
 
+// Step type labels for the gateway step metrics. Step names are caller
+// supplied, so they are not used as labels.
+const (
+	stepTypeCommand = "command"
+	stepTypeFile    = "file"
+	stepTypeHTTP    = "http"
+)
+
+func (g *Gateway) recordStepMetrics(stepType string, duration time.Duration, exitCode int32) {
+	if g.metrics == nil {
+		return
+	}
+	g.metrics.RecordGatewayStepDuration(stepType, duration)
+	outcome := "success"
+	if exitCode != 0 {
+		outcome = "error"
+	}
+	g.metrics.IncrementGatewayStepResult(stepType, outcome)
+}
+
 // recordStepResult handles the common post-execution bookkeeping for a completed step:
 // metrics, history recording, and trajectory enqueueing.
 func (g *Gateway) recordStepResult(s *session, sessionID string, result *StepResult, start time.Time) {
+	g.recordStepResultElapsed(s, sessionID, result, time.Since(start))
+}
+
+// recordStepResultElapsed is recordStepResult for a step that finished
+// earlier, such as one of a parallel batch recorded in step order.
+func (g *Gateway) recordStepResultElapsed(s *session, sessionID string, result *StepResult, elapsed time.Duration) {
 	storedOutput, outputBytes, outputTruncated := g.retainedStepOutput(result.Output)
-	g.recordRetainedStepResult(s, sessionID, result, start, storedOutput, outputBytes, outputTruncated)
+	g.recordRetainedStepResult(s, sessionID, result, elapsed, storedOutput, outputBytes, outputTruncated)
 }
 
-func (g *Gateway) recordRetainedStepResult(s *session, sessionID string, result *StepResult, start time.Time, storedOutput StepOutput, outputBytes int, outputTruncated bool) {
-	result.DurationMs = time.Since(start).Milliseconds()
+func (g *Gateway) recordRetainedStepResult(s *session, sessionID string, result *StepResult, elapsed time.Duration, storedOutput StepOutput, outputBytes int, outputTruncated bool) {
+	result.DurationMs = elapsed.Milliseconds()
 
-	if g.metrics != nil {
-		stepType := result.Name
-		if stepType == "" {
-			stepType = "unnamed"
-		}
-		g.metrics.RecordGatewayStepDuration(stepType, time.Since(start))
-		outcome := "success"
-		if result.Output.ExitCode != 0 {
-			outcome = "error"
-		}
-		g.metrics.IncrementGatewayStepResult(stepType, outcome)
+	stepType := stepTypeCommand
+	if result.HTTP != nil {
+		stepType = stepTypeHTTP
 	}
+	g.recordStepMetrics(stepType, elapsed, result.Output.ExitCode)
 
 	stepRecord := StepRecord{
 		Name:            result.Name,
@@ -157,6 +195,7 @@ func (g *Gateway) recordRetainedStepResult(s *session, sessionID string, result
 		OutputTruncated: outputTruncated,
 		DurationMs:      result.DurationMs,
 		Timestamp:       result.Timestamp,
+		TraceID:         result.TraceID,
 	}
 	globalIdx := s.History.Add(stepRecord)
 
@@ -173,6 +212,7 @@ func (g *Gateway) recordRetainedStepResult(s *session, sessionID string, result
 		SnapshotID:  result.SnapshotID,
 		DurationMs:  result.DurationMs,
 		Timestamp:   result.Timestamp,
+		TraceID:     result.TraceID,
 	}, sessionID, globalIdx)
 }
 
@@ -188,7 +228,7 @@ func (g *Gateway) executeStepsNow(ctx context.Context, sessionID string, req Exe
 	span.SetAttributes(attribute.Int("steps.count", len(req.Steps)))
 	defer span.End()
 
-	s, podIP, releaseSession, err := g.acquireSessionPodIP(ctx, sessionID)
+	ctx, s, podIP, releaseSession, err := g.acquireSessionExec(ctx, sessionID)
 	if err != nil {
 		recordSpanErr(span, err)
 		return nil, err
@@ -203,44 +243,32 @@ func (g *Gateway) executeStepsNow(ctx context.Context, sessionID string, req Exe
 	}
 	totalStart := time.Now()
 
-	for i, step := range req.Steps {
-		start := time.Now()
-		inputJSON, _ := json.Marshal(step)
-
-		result := StepResult{Name: step.Name, Input: inputJSON, Timestamp: start}
-
-		execReq := &interfaces.ExecRequest{
-			Command:        step.Command,
-			Env:            step.Env,
-			WorkingDir:     step.WorkDir,
-			TimeoutSeconds: resolveStepTimeoutSeconds(step),
-		}
-		log.Printf("Exec %s [%d/%d] step=%q cmd=%v workdir=%q timeout=%ds pod=%s",
-			sessionID, i+1, len(req.Steps), step.Name, step.Command, step.WorkDir, execReq.TimeoutSeconds, podIP)
-		execStart := time.Now()
-		execResp, err := g.executorClient.Execute(ctx, podIP, execReq)
-		execDur := time.Since(execStart)
-		if g.metrics != nil {
-			g.metrics.RecordExecutorCallDuration("Execute", execDur)
-		}
+	if req.Parallel || hasStepDependencies(req.Steps) {
+		resp.Results, err = g.runStepsParallel(ctx, s, sessionID, podIP, req)
 		if err != nil {
-			log.Printf("Exec %s step=%q failed after %s: %v", sessionID, step.Name, execDur, err)
-			result.Output.Stderr = err.Error()
-			result.Output.ExitCode = 1
-		} else {
-			log.Printf("Exec %s step=%q exit=%d duration=%s stdout=%d stderr=%d",
-				sessionID, step.Name, execResp.ExitCode, execDur, len(execResp.Stdout), len(execResp.Stderr))
-			result.Output.Stdout = execResp.Stdout
-			result.Output.Stderr = execResp.Stderr
-			result.Output.ExitCode = execResp.ExitCode
+			recordSpanErr(span, err)
+			return nil, err
+		}
+	} else {
+		for i, step := range req.Steps {
+			if ctx.Err() != nil {
+				// Cancelled: leave the remaining steps unrun rather than
+				// recording a dial failure for each.
+				break
+			}
+			start := time.Now()
+			result := g.runStep(ctx, sessionID, podIP, i, len(req.Steps), step)
+			g.recordStepResult(s, sessionID, &result, start)
+			resp.Results = append(resp.Results, result)
 		}
-		g.recordStepResult(s, sessionID, &result, start)
-		resp.Results = append(resp.Results, result)
 	}
 
 	resp.TotalDurationMs = time.Since(totalStart).Milliseconds()
 	g.touchLastTaskTime(sessionID)
 	g.store.SyncHistory(sessionID)
+	if g.gwConfig.ResourceSamplingEnabled {
+		go g.sampleResourceUsage(s, sessionID)
+	}
 
 	if g.checkpointStore != nil && g.gwConfig.SandboxCheckpointEnabled && len(resp.Results) > 0 {
 		steps := make([]int, len(resp.Results))
@@ -253,6 +281,163 @@ func (g *Gateway) executeStepsNow(ctx context.Context, sessionID string, req Exe
 	return resp, nil
 }
 
+// buildStepExecRequest applies the operator's step policy to a command step
+// and builds the executor request for it: resource limits wrap its command
+// and the output cap is set. When the command denylist rejects the step, req
+// is nil and denial is the stderr line to report instead. envWarning is
+// prepended to the step's stderr. Every path that sends a step to the
+// executor goes through here so none of them bypasses the policy.
+func (g *Gateway) buildStepExecRequest(ctx context.Context, step StepRequest) (req *interfaces.ExecRequest, envWarning, denial string) {
+	step, envWarning, denial = g.applyStepPolicy(step)
+	if denial != "" {
+		return nil, envWarning, denial
+	}
+	req = &interfaces.ExecRequest{
+		Command:        applyStepLimits(step),
+		Env:            withTraceContextEnv(ctx, step.Env),
+		WorkingDir:     step.WorkDir,
+		TimeoutSeconds: resolveStepTimeoutSeconds(step),
+		Stdin:          step.Stdin,
+	}
+	g.applyOutputLimit(req, step)
+	return req, envWarning, ""
+}
+
+// runStep executes one step of an execute request on podIP and returns its
+// result without recording it in the session history.
+func (g *Gateway) runStep(ctx context.Context, sessionID, podIP string, i, total int, step StepRequest) StepResult {
+	start := time.Now()
+	inputJSON, _ := json.Marshal(step)
+
+	result := StepResult{Name: step.Name, Input: inputJSON, Timestamp: start}
+	stepCtx, stepSpan := startStepSpan(ctx, i, step)
+	result.TraceID = spanTraceID(stepSpan)
+
+	if step.HTTP != nil {
+		log.Printf("Exec %s [%d/%d] step=%q http=%s :%d%s pod=%s",
+			sessionID, i+1, total, step.Name, step.HTTP.Method, step.HTTP.Port, step.HTTP.Path, podIP)
+		g.runHTTPStep(stepCtx, podIP, step, &result)
+		endStepSpan(stepSpan, &result, nil)
+		return result
+	}
+
+	execReq, envWarning, denial := g.buildStepExecRequest(stepCtx, step)
+	if denial != "" {
+		log.Printf("Exec %s step=%q rejected by command denylist: %v", sessionID, step.Name, step.Command)
+		result.Output.Stderr = envWarning + denial
+		result.Output.ExitCode = commandDeniedExitCode
+		endStepSpan(stepSpan, &result, nil)
+		return result
+	}
+	log.Printf("Exec %s [%d/%d] step=%q cmd=%v workdir=%q timeout=%ds pod=%s",
+		sessionID, i+1, total, step.Name, step.Command, step.WorkDir, execReq.TimeoutSeconds, podIP)
+	execStart := time.Now()
+	execResp, err := g.executorClient.Execute(stepCtx, podIP, execReq)
+	execDur := time.Since(execStart)
+	if g.metrics != nil {
+		g.metrics.RecordExecutorCallDuration("Execute", execDur)
+	}
+	if err != nil {
+		log.Printf("Exec %s step=%q failed after %s: %v", sessionID, step.Name, execDur, err)
+		result.Output.Stderr = err.Error()
+		result.Output.ExitCode = 1
+	} else {
+		log.Printf("Exec %s step=%q exit=%d duration=%s stdout=%d stderr=%d",
+			sessionID, step.Name, execResp.ExitCode, execDur, len(execResp.Stdout), len(execResp.Stderr))
+		result.Output.Stdout = execResp.Stdout
+		result.Output.Stderr = execResp.Stderr
+		result.Output.ExitCode = execResp.ExitCode
+		result.OverheadUs = execResp.StartLatency.Microseconds()
+		if reason, msg := stepLimitFailure(step, execResp.ExitCode); reason != "" {
+			result.FailureReason = reason
+			result.Output.Stderr += msg
+		}
+		if execResp.OutputTruncated {
+			reason, msg := g.outputLimitFailure(execReq.MaxOutputBytes)
+			result.OutputTruncated = true
+			if reason != "" && result.FailureReason == "" {
+				result.FailureReason = reason
+			}
+			result.Output.Stderr += msg
+		}
+	}
+	result.Output.Stderr = envWarning + result.Output.Stderr
+	endStepSpan(stepSpan, &result, err)
+	return result
+}
+
+// runStepsParallel runs the request's steps concurrently, at most
+// ParallelismLimit at a time, starting each once the steps it depends on
+// have finished. A step whose dependency failed is reported without running.
+// Results are recorded in dependency order once all steps have finished,
+// so history indices do not depend on timing. Steps not yet started when
+// ctx is cancelled are left unrun.
+func (g *Gateway) runStepsParallel(ctx context.Context, s *session, sessionID, podIP string, req ExecuteRequest) ([]StepResult, error) {
+	deps, order, err := stepGraph(req.Steps)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.ParallelismLimit
+	if limit <= 0 || limit > len(req.Steps) {
+		limit = len(req.Steps)
+	}
+	results := make([]StepResult, len(req.Steps))
+	elapsed := make([]time.Duration, len(req.Steps))
+	ran := make([]bool, len(req.Steps))
+	finished := make([]chan struct{}, len(req.Steps))
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, step := range req.Steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(finished[i])
+			for _, j := range deps[i] {
+				<-finished[j]
+				if !ran[j] {
+					return
+				}
+				if results[j].Output.ExitCode != 0 {
+					start := time.Now()
+					inputJSON, _ := json.Marshal(step)
+					results[i] = StepResult{Name: step.Name, Input: inputJSON, Timestamp: start, FailureReason: StepFailureDependency}
+					results[i].Output.Stderr = fmt.Sprintf("skipped: dependency %q failed\n", req.Steps[j].Name)
+					results[i].Output.ExitCode = 1
+					ran[i] = true
+					return
+				}
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-slots }()
+			if ctx.Err() != nil {
+				return
+			}
+			start := time.Now()
+			results[i] = g.runStep(ctx, sessionID, podIP, i, len(req.Steps), step)
+			elapsed[i] = time.Since(start)
+			ran[i] = true
+		}()
+	}
+	wg.Wait()
+
+	recorded := make([]StepResult, 0, len(req.Steps))
+	for _, i := range order {
+		if !ran[i] {
+			continue
+		}
+		g.recordStepResultElapsed(s, sessionID, &results[i], elapsed[i])
+		recorded = append(recorded, results[i])
+	}
+	return recorded, nil
+}
+
 type sseOutputEvent struct {
 	Stdout string `json:"stdout"`
 	Stderr string `json:"stderr"`
@@ -266,7 +451,7 @@ func (g *Gateway) ExecuteStepsSSE(w http.ResponseWriter, ctx context.Context, se
 	span.SetAttributes(attribute.Int("steps.count", len(req.Steps)))
 	defer span.End()
 
-	s, podIP, releaseSession, err := g.acquireSessionPodIP(ctx, sessionID)
+	ctx, s, podIP, releaseSession, err := g.acquireSessionExec(ctx, sessionID)
 	if err != nil {
 		recordSpanErr(span, err)
 		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusNotFound)
@@ -291,22 +476,63 @@ func (g *Gateway) ExecuteStepsSSE(w http.ResponseWriter, ctx context.Context, se
 
 	var persistSteps []int
 	for i, step := range req.Steps {
+		if ctx.Err() != nil {
+			log.Printf("ExecSSE %s: client disconnected, skipping remaining %d steps", sessionID, len(req.Steps)-i)
+			break
+		}
 		start := time.Now()
 		inputJSON, _ := json.Marshal(step)
 
 		result := StepResult{Name: step.Name, Input: inputJSON, Timestamp: start}
+		stepCtx, stepSpan := startStepSpan(ctx, i, step)
+		result.TraceID = spanTraceID(stepSpan)
+
+		if step.HTTP != nil {
+			log.Printf("ExecSSE %s [%d/%d] step=%q http=%s :%d%s pod=%s",
+				sessionID, i+1, len(req.Steps), step.Name, step.HTTP.Method, step.HTTP.Port, step.HTTP.Path, podIP)
+			g.runHTTPStep(stepCtx, podIP, step, &result)
+			endStepSpan(stepSpan, &result, nil)
+			if result.Output.Stdout != "" || result.Output.Stderr != "" {
+				data, _ := json.Marshal(sseOutputEvent{Stdout: result.Output.Stdout, Stderr: result.Output.Stderr})
+				fmt.Fprintf(w, "event: output\ndata: %s\n\n", data)
+			}
+			g.recordStepResult(s, sessionID, &result, start)
+			persistSteps = append(persistSteps, result.Index)
+			resultData, _ := json.Marshal(result)
+			fmt.Fprintf(w, "event: result\ndata: %s\n\n", resultData)
+			flusher.Flush()
+			continue
+		}
 
-		execReq := &interfaces.ExecRequest{
-			Command:        step.Command,
-			Env:            step.Env,
-			WorkingDir:     step.WorkDir,
-			TimeoutSeconds: resolveStepTimeoutSeconds(step),
+		execReq, envWarning, denial := g.buildStepExecRequest(stepCtx, step)
+		if envWarning != "" {
+			data, _ := json.Marshal(sseOutputEvent{Stderr: envWarning})
+			fmt.Fprintf(w, "event: output\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+
+		if denial != "" {
+			log.Printf("ExecSSE %s step=%q rejected by command denylist: %v", sessionID, step.Name, step.Command)
+			data, _ := json.Marshal(sseOutputEvent{Stderr: denial})
+			fmt.Fprintf(w, "event: output\ndata: %s\n\n", data)
+			result.Output.Stderr = envWarning + denial
+			result.Output.ExitCode = commandDeniedExitCode
+			endStepSpan(stepSpan, &result, nil)
+			g.recordStepResult(s, sessionID, &result, start)
+			persistSteps = append(persistSteps, result.Index)
+			resultData, _ := json.Marshal(result)
+			fmt.Fprintf(w, "event: result\ndata: %s\n\n", resultData)
+			flusher.Flush()
+			continue
 		}
 
 		log.Printf("ExecSSE %s [%d/%d] step=%q cmd=%v workdir=%q timeout=%ds pod=%s",
 			sessionID, i+1, len(req.Steps), step.Name, step.Command, step.WorkDir, execReq.TimeoutSeconds, podIP)
 		execStart := time.Now()
-		streamCh, err := g.executorClient.ExecuteStream(ctx, podIP, execReq)
+		// Cancelling the stream closes the executor connection, which kills
+		// the command when it overruns its output cap.
+		streamCtx, cancelStream := context.WithCancel(stepCtx)
+		streamCh, err := g.executorClient.ExecuteStream(streamCtx, podIP, execReq)
 		if g.metrics != nil {
 			g.metrics.RecordExecutorCallDuration("ExecuteStream", time.Since(execStart))
 		}
@@ -318,9 +544,17 @@ func (g *Gateway) ExecuteStepsSSE(w http.ResponseWriter, ctx context.Context, se
 			result.Output.ExitCode = 1
 		} else {
 			var stdout, stderr strings.Builder
+			var used int64
+			var killed bool
 			for chunk := range streamCh {
-				stdoutChunk := chunk.Stdout
-				stderrChunk := chunk.Stderr
+				stdoutChunk, clippedOut := clipOutput(chunk.Stdout, used, execReq.MaxOutputBytes)
+				used += int64(len(stdoutChunk))
+				stderrChunk, clippedErr := clipOutput(chunk.Stderr, used, execReq.MaxOutputBytes)
+				used += int64(len(stderrChunk))
+				if (clippedOut || clippedErr) && !result.OutputTruncated {
+					result.OutputTruncated = true
+					killed = execReq.KillOnOutputLimit
+				}
 
 				if stdoutChunk != "" {
 					stdout.WriteString(stdoutChunk)
@@ -336,15 +570,42 @@ func (g *Gateway) ExecuteStepsSSE(w http.ResponseWriter, ctx context.Context, se
 					flusher.Flush()
 				}
 
+				if killed {
+					cancelStream()
+					result.Output.ExitCode = 1
+					break
+				}
 				if chunk.Done {
 					result.Output.ExitCode = chunk.ExitCode
+					result.OverheadUs = chunk.StartLatency.Microseconds()
+					result.OutputTruncated = result.OutputTruncated || chunk.OutputTruncated
 				}
 			}
+			if reason, msg := stepLimitFailure(step, result.Output.ExitCode); reason != "" {
+				result.FailureReason = reason
+				data, _ := json.Marshal(sseOutputEvent{Stderr: msg})
+				fmt.Fprintf(w, "event: output\ndata: %s\n\n", data)
+				flusher.Flush()
+				stderr.WriteString(msg)
+			}
+			if result.OutputTruncated {
+				reason, msg := g.outputLimitFailure(execReq.MaxOutputBytes)
+				if reason != "" && result.FailureReason == "" {
+					result.FailureReason = reason
+				}
+				data, _ := json.Marshal(sseOutputEvent{Stderr: msg})
+				fmt.Fprintf(w, "event: output\ndata: %s\n\n", data)
+				flusher.Flush()
+				stderr.WriteString(msg)
+			}
 			result.Output.Stdout = stdout.String()
 			result.Output.Stderr = stderr.String()
 			log.Printf("ExecSSE %s step=%q exit=%d duration=%s stdout=%d stderr=%d",
 				sessionID, step.Name, result.Output.ExitCode, time.Since(start), len(result.Output.Stdout), len(result.Output.Stderr))
 		}
+		cancelStream()
+		result.Output.Stderr = envWarning + result.Output.Stderr
+		endStepSpan(stepSpan, &result, err)
 
 		g.recordStepResult(s, sessionID, &result, start)
 		persistSteps = append(persistSteps, result.Index)
@@ -356,6 +617,9 @@ func (g *Gateway) ExecuteStepsSSE(w http.ResponseWriter, ctx context.Context, se
 
 	g.touchLastTaskTime(sessionID)
 	g.store.SyncHistory(sessionID)
+	if g.gwConfig.ResourceSamplingEnabled {
+		go g.sampleResourceUsage(s, sessionID)
+	}
 
 	if g.checkpointStore != nil && g.gwConfig.SandboxCheckpointEnabled && len(persistSteps) > 0 {
 		go g.persistCheckpointSteps(sessionID, podIP, persistSteps)
@@ -0,0 +1,115 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestExecuteStepsParallelBoundsConcurrencyAndKeepsOrder(t *testing.T) {
+	store := newTestSessionStore("gw-par")
+	exec := &blockingExecutorClient{release: make(chan struct{})}
+	gw := New(nil, &operationRuntimeAllocator{}, exec, nil, nil, GatewayConfig{}, store)
+
+	req := ExecuteRequest{
+		Parallel:         true,
+		ParallelismLimit: 2,
+		Steps: []StepRequest{
+			{Name: "lint", Command: []string{"make", "lint"}},
+			{Name: "typecheck", Command: []string{"make", "typecheck"}},
+			{Name: "test", Command: []string{"make", "test"}},
+		},
+	}
+	done := make(chan *ExecuteResponse, 1)
+	go func() {
+		resp, err := gw.ExecuteSteps(context.Background(), "gw-par", req)
+		if err != nil {
+			t.Errorf("ExecuteSteps returned error: %v", err)
+		}
+		done <- resp
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if got := exec.inFlight.Load(); got != 2 {
+		t.Fatalf("in-flight steps = %d, want 2", got)
+	}
+	close(exec.release)
+
+	resp := <-done
+	if resp == nil || len(resp.Results) != 3 {
+		t.Fatalf("results = %+v, want 3", resp)
+	}
+	for i, want := range []string{"lint", "typecheck", "test"} {
+		if r := resp.Results[i]; r.Name != want || r.Index != i {
+			t.Fatalf("result %d = %s (index %d), want %s at index %d", i, r.Name, r.Index, want, i)
+		}
+	}
+	if peak := exec.peak.Load(); peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+// failingExecutorClient fails every command whose first word is "false".
+type failingExecutorClient struct {
+	interfaces.ExecutorClient
+}
+
+func (failingExecutorClient) Execute(_ context.Context, _ string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+	if req.Command[0] == "false" {
+		return &interfaces.ExecResponse{Done: true, ExitCode: 1}, nil
+	}
+	return &interfaces.ExecResponse{Done: true}, nil
+}
+
+func TestExecuteStepsSkipsDependentsOfFailedStep(t *testing.T) {
+	store := newTestSessionStore("gw-dag")
+	gw := New(nil, &operationRuntimeAllocator{}, failingExecutorClient{}, nil, nil, GatewayConfig{}, store)
+
+	resp, err := gw.ExecuteSteps(context.Background(), "gw-dag", ExecuteRequest{Steps: []StepRequest{
+		{Name: "test", Command: []string{"true"}, DependsOn: []string{"build"}},
+		{Name: "build", Command: []string{"false"}},
+		{Name: "lint", Command: []string{"true"}},
+	}})
+	if err != nil {
+		t.Fatalf("ExecuteSteps returned error: %v", err)
+	}
+	var names []string
+	for _, r := range resp.Results {
+		names = append(names, r.Name)
+	}
+	if got := strings.Join(names, ","); got != "build,test,lint" {
+		t.Fatalf("result order = %s, want build,test,lint", got)
+	}
+	if test := resp.Results[1]; test.FailureReason != StepFailureDependency || test.Output.ExitCode == 0 {
+		t.Fatalf("test result = %+v, want skipped for failed dependency", test)
+	}
+	if lint := resp.Results[2]; lint.Output.ExitCode != 0 {
+		t.Fatalf("lint exit = %d, want 0", lint.Output.ExitCode)
+	}
+}
+
+func TestExecuteStepsRecordsStepMetricsByType(t *testing.T) {
+	store := newTestSessionStore("gw-metrics")
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			return &interfaces.ExecResponse{ExitCode: int32(len(req.Command) - 1)}, nil
+		},
+	}
+	metrics := &recordingMetricsCollector{}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, metrics, nil, GatewayConfig{}, store)
+
+	_, err := gw.ExecuteSteps(context.Background(), "gw-metrics", ExecuteRequest{Steps: []StepRequest{
+		{Name: "build-1234", Command: []string{"true"}},
+		{Name: "", Command: []string{"false", "x"}},
+	}})
+	if err != nil {
+		t.Fatalf("ExecuteSteps returned error: %v", err)
+	}
+	want := []string{"command/success", "command/error"}
+	if strings.Join(metrics.stepResults, ",") != strings.Join(want, ",") {
+		t.Fatalf("step results = %v, want %v", metrics.stepResults, want)
+	}
+}
@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+// limitedExecutorClient bounds the number of executor calls in flight across
+// all sessions, so a burst of steps cannot exhaust file descriptors or flood
+// the cluster network. Calls within a session keep their order; the limit is
+// global. A streamed exec holds its slot until the stream is drained.
+// Interactive shells and connection bookkeeping are not limited: a shell is
+// held open for minutes and would pin a slot for its whole lifetime.
+type limitedExecutorClient struct {
+	interfaces.ExecutorClient
+	slots   chan struct{}
+	metrics interfaces.MetricsCollector
+}
+
+func newLimitedExecutorClient(inner interfaces.ExecutorClient, limit int, metrics interfaces.MetricsCollector) *limitedExecutorClient {
+	return &limitedExecutorClient{
+		ExecutorClient: inner,
+		slots:          make(chan struct{}, limit),
+		metrics:        metrics,
+	}
+}
+
+// acquire waits for a free slot, recording the wait, and returns the func
+// that gives it back.
+func (c *limitedExecutorClient) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if c.metrics != nil {
+		c.metrics.RecordExecutorSemaphoreWait(time.Since(start))
+	}
+	return func() { <-c.slots }, nil
+}
+
+func (c *limitedExecutorClient) Execute(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.ExecutorClient.Execute(ctx, podIP, req)
+}
+
+func (c *limitedExecutorClient) ExecuteStream(ctx context.Context, podIP string, req *interfaces.ExecRequest) (<-chan interfaces.ExecResponse, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.ExecutorClient.ExecuteStream(ctx, podIP, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	out := make(chan interfaces.ExecResponse, cap(stream))
+	go func() {
+		defer close(out)
+		defer release()
+		for chunk := range stream {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *limitedExecutorClient) WriteFile(ctx context.Context, podIP string, path string, content io.Reader, expectedSHA256 string) (*interfaces.FileWriteResult, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.ExecutorClient.WriteFile(ctx, podIP, path, content, expectedSHA256)
+}
+
+func (c *limitedExecutorClient) ReadFile(ctx context.Context, podIP string, path string, dst io.Writer) (*interfaces.FileReadResult, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.ExecutorClient.ReadFile(ctx, podIP, path, dst)
+}
+
+func (c *limitedExecutorClient) DownloadCheckpoint(ctx context.Context, podIP string, through int, dst io.Writer) error {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return c.ExecutorClient.DownloadCheckpoint(ctx, podIP, through, dst)
+}
+
+func (c *limitedExecutorClient) DownloadCheckpointStep(ctx context.Context, podIP string, step int, dst io.Writer) error {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return c.ExecutorClient.DownloadCheckpointStep(ctx, podIP, step, dst)
+}
@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+// blockingExecutorClient holds every Execute until release is closed and
+// tracks the peak number of concurrent calls.
+type blockingExecutorClient struct {
+	interfaces.ExecutorClient
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (c *blockingExecutorClient) Execute(_ context.Context, _ string, _ *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		p := c.peak.Load()
+		if n <= p || c.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	<-c.release
+	return &interfaces.ExecResponse{Done: true}, nil
+}
+
+func TestLimitedExecutorClientBoundsConcurrentCalls(t *testing.T) {
+	inner := &blockingExecutorClient{release: make(chan struct{})}
+	limited := newLimitedExecutorClient(inner, 2, nil)
+
+	done := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := limited.Execute(context.Background(), "10.0.0.1", &interfaces.ExecRequest{})
+			done <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := inner.inFlight.Load(); got != 2 {
+		t.Fatalf("in-flight calls = %d, want 2", got)
+	}
+
+	close(inner.release)
+	for i := 0; i < 5; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+	}
+	if peak := inner.peak.Load(); peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+func TestLimitedExecutorClientWaitHonorsContext(t *testing.T) {
+	inner := &blockingExecutorClient{release: make(chan struct{})}
+	defer close(inner.release)
+	limited := newLimitedExecutorClient(inner, 1, nil)
+
+	go limited.Execute(context.Background(), "10.0.0.1", &interfaces.ExecRequest{})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limited.Execute(ctx, "10.0.0.1", &interfaces.ExecRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute while saturated = %v, want deadline exceeded", err)
+	}
+}
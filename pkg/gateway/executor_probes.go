@@ -0,0 +1,101 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	executorStartupProbePeriod              = 2 * time.Second
+	defaultExecutorStartupTimeout           = 60 * time.Second
+	defaultExecutorReadinessPeriod          = 5 * time.Second
+	defaultExecutorLivenessPeriod           = 10 * time.Second
+	defaultExecutorLivenessFailureThreshold = 3
+)
+
+// executorProbeSettings resolves the executor container's probe timings from
+// the gateway config, overridden field by field by a pool's spec.
+func (g *Gateway) executorProbeSettings(spec *ExecutorProbeSpec) ExecutorProbeSpec {
+	settings := ExecutorProbeSpec{
+		StartupTimeoutSeconds:    durationSeconds(g.gwConfig.ExecutorStartupTimeout, defaultExecutorStartupTimeout),
+		ReadinessPeriodSeconds:   durationSeconds(g.gwConfig.ExecutorReadinessPeriod, defaultExecutorReadinessPeriod),
+		LivenessPeriodSeconds:    durationSeconds(g.gwConfig.ExecutorLivenessPeriod, defaultExecutorLivenessPeriod),
+		LivenessFailureThreshold: g.gwConfig.ExecutorLivenessFailures,
+	}
+	if settings.LivenessFailureThreshold <= 0 {
+		settings.LivenessFailureThreshold = defaultExecutorLivenessFailureThreshold
+	}
+	if spec != nil {
+		if spec.StartupTimeoutSeconds > 0 {
+			settings.StartupTimeoutSeconds = spec.StartupTimeoutSeconds
+		}
+		if spec.ReadinessPeriodSeconds > 0 {
+			settings.ReadinessPeriodSeconds = spec.ReadinessPeriodSeconds
+		}
+		if spec.LivenessPeriodSeconds > 0 {
+			settings.LivenessPeriodSeconds = spec.LivenessPeriodSeconds
+		}
+		if spec.LivenessFailureThreshold > 0 {
+			settings.LivenessFailureThreshold = spec.LivenessFailureThreshold
+		}
+	}
+	return settings
+}
+
+func durationSeconds(d, fallback time.Duration) int32 {
+	if d <= 0 {
+		d = fallback
+	}
+	if s := int32(d / time.Second); s > 0 {
+		return s
+	}
+	return 1
+}
+
+func validateExecutorProbes(spec *ExecutorProbeSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.StartupTimeoutSeconds < 0 || spec.ReadinessPeriodSeconds < 0 ||
+		spec.LivenessPeriodSeconds < 0 || spec.LivenessFailureThreshold < 0 {
+		return fmt.Errorf("executorProbes values must not be negative")
+	}
+	return nil
+}
+
+// applyExecutorProbes sets the executor container's startup, readiness and
+// liveness probes. All three are TCP checks on the agent port: the agent
+// serves no HTTP health endpoints, and a connect succeeds without the auth
+// token or a TLS handshake. Liveness restarts an agent that stops accepting
+// connections so the pool replaces it instead of binding sessions to it.
+func (g *Gateway) applyExecutorProbes(pod *corev1.PodSpec, executorPort int32, spec *ExecutorProbeSpec) {
+	settings := g.executorProbeSettings(spec)
+	handler := corev1.ProbeHandler{
+		TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(executorPort)},
+	}
+	startupPeriod := int32(executorStartupProbePeriod / time.Second)
+	startupFailures := (settings.StartupTimeoutSeconds + startupPeriod - 1) / startupPeriod
+	for i := range pod.Containers {
+		if pod.Containers[i].Name != "executor" {
+			continue
+		}
+		pod.Containers[i].StartupProbe = &corev1.Probe{
+			ProbeHandler:     handler,
+			PeriodSeconds:    startupPeriod,
+			FailureThreshold: startupFailures,
+		}
+		pod.Containers[i].ReadinessProbe = &corev1.Probe{
+			ProbeHandler:     handler,
+			PeriodSeconds:    settings.ReadinessPeriodSeconds,
+			FailureThreshold: 3,
+		}
+		pod.Containers[i].LivenessProbe = &corev1.Probe{
+			ProbeHandler:     handler,
+			PeriodSeconds:    settings.LivenessPeriodSeconds,
+			FailureThreshold: settings.LivenessFailureThreshold,
+		}
+	}
+}
@@ -333,6 +333,14 @@ func sessionMatchesListOptionsLocked(s *session, opts SessionListOptions) bool {
 	if experimentID != "" && s.experimentID != experimentID {
 		return false
 	}
+	poolRef := strings.TrimSpace(opts.PoolRef)
+	if poolRef != "" && s.Info.PoolRef != poolRef {
+		return false
+	}
+	namespace := strings.TrimSpace(opts.Namespace)
+	if namespace != "" && s.Info.Namespace != namespace {
+		return false
+	}
 	status := strings.TrimSpace(opts.Status)
 	if status != "" {
 		sessionStatus := s.Info.Status
@@ -0,0 +1,244 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// patchHunkFuzz bounds how far (in lines) a hunk may drift from the line
+// numbers in its header before it is rejected.
+const patchHunkFuzz = 200
+
+var hunkHeaderRE = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ErrMalformedPatch is wrapped by errors for patches that cannot be parsed
+// as a single-file unified diff.
+var ErrMalformedPatch = errors.New("malformed patch")
+
+// PatchRejectError reports the hunks of a unified diff whose context did not
+// match the file in the sandbox. No changes are written when it is returned.
+type PatchRejectError struct {
+	Path    string
+	Rejects []string
+}
+
+func (e *PatchRejectError) Error() string {
+	return fmt.Sprintf("patch does not apply to %s: %d hunk(s) rejected", e.Path, len(e.Rejects))
+}
+
+// PatchFile applies a unified diff to a file in the session workspace. The
+// current content is read from the executor, patched in the gateway and
+// written back through the same path as UploadFile, so the result is
+// recorded as an upload_file step. Hunks are applied all-or-nothing.
+func (g *Gateway) PatchFile(ctx context.Context, sessionID string, req PatchFileRequest) (*UploadFileResponse, error) {
+	if req.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if strings.TrimSpace(req.Patch) == "" {
+		return nil, fmt.Errorf("patch is required")
+	}
+
+	s, podIP, releaseSession, err := g.acquireSessionPodIP(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseSession()
+
+	var original bytes.Buffer
+	if _, err := g.executorClient.ReadFile(ctx, podIP, req.Path, &original); err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	patched, err := applyUnifiedDiff(original.Bytes(), req.Patch)
+	if err != nil {
+		if rejectErr, ok := err.(*PatchRejectError); ok {
+			rejectErr.Path = req.Path
+		}
+		return nil, err
+	}
+
+	resp, err := g.writeUploadedFile(ctx, s, podIP, req.Path, bytes.NewReader(patched), "")
+	if err != nil {
+		return nil, err
+	}
+	g.store.SyncHistory(sessionID)
+
+	g.touchLastTaskTime(sessionID)
+	return resp, nil
+}
+
+type diffHunk struct {
+	header   string
+	oldStart int
+	oldCount int
+	newCount int
+	oldLines []string
+	newLines []string
+	// newNoEOL is set when the new side ends without a trailing newline.
+	newNoEOL bool
+}
+
+// parseUnifiedDiff extracts the hunks of a single-file unified diff. File
+// headers ("diff", "index", "---", "+++") are skipped.
+func parseUnifiedDiff(patch string) ([]diffHunk, error) {
+	lines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+	var hunks []diffHunk
+	fileHeaders := 0
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
+		if strings.HasPrefix(line, "--- ") {
+			fileHeaders++
+			if fileHeaders > 1 {
+				return nil, fmt.Errorf("%w: patch touches more than one file", ErrMalformedPatch)
+			}
+			continue
+		}
+		m := hunkHeaderRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		h := diffHunk{header: line, oldCount: 1, newCount: 1}
+		h.oldStart, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			h.oldCount, _ = strconv.Atoi(m[2])
+		}
+		if m[4] != "" {
+			h.newCount, _ = strconv.Atoi(m[4])
+		}
+
+		oldSeen, newSeen := 0, 0
+		for i+1 < len(lines) && (oldSeen < h.oldCount || newSeen < h.newCount) {
+			i++
+			body := strings.TrimSuffix(lines[i], "\r")
+			if body == "" {
+				// Some editors strip the leading space from empty context lines.
+				body = " "
+			}
+			switch body[0] {
+			case ' ':
+				h.oldLines = append(h.oldLines, body[1:])
+				h.newLines = append(h.newLines, body[1:])
+				oldSeen++
+				newSeen++
+			case '-':
+				h.oldLines = append(h.oldLines, body[1:])
+				oldSeen++
+			case '+':
+				h.newLines = append(h.newLines, body[1:])
+				newSeen++
+			case '\\':
+				continue
+			default:
+				return nil, fmt.Errorf("%w: hunk %q: unexpected line %q", ErrMalformedPatch, h.header, body)
+			}
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+				i++
+				if body[0] != '-' {
+					h.newNoEOL = true
+				}
+			}
+		}
+		if oldSeen != h.oldCount || newSeen != h.newCount {
+			return nil, fmt.Errorf("%w: hunk %q: truncated body", ErrMalformedPatch, h.header)
+		}
+		hunks = append(hunks, h)
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("%w: patch contains no hunks", ErrMalformedPatch)
+	}
+	return hunks, nil
+}
+
+// applyUnifiedDiff applies patch to original. Hunks may drift by up to
+// patchHunkFuzz lines from their headers; any hunk whose context cannot be
+// located is reported in a *PatchRejectError. Files with CRLF line endings
+// are matched without the carriage returns and keep CRLF endings.
+func applyUnifiedDiff(original []byte, patch string) ([]byte, error) {
+	hunks, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	content := string(original)
+	trailingNewline := len(content) == 0 || strings.HasSuffix(content, "\n")
+	eol := "\n"
+	if i := strings.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		eol = "\r\n"
+	}
+	var lines []string
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		for i := range lines {
+			lines[i] = strings.TrimSuffix(lines[i], "\r")
+		}
+	}
+
+	var out []string
+	var rejects []string
+	pos, offset := 0, 0
+	for _, h := range hunks {
+		want := h.oldStart - 1
+		if len(h.oldLines) == 0 {
+			want = h.oldStart
+		}
+		idx := findHunk(lines, h.oldLines, want+offset, pos)
+		if idx < 0 {
+			rejects = append(rejects, fmt.Sprintf("%s: context does not match", h.header))
+			continue
+		}
+		out = append(out, lines[pos:idx]...)
+		out = append(out, h.newLines...)
+		pos = idx + len(h.oldLines)
+		offset = idx - want
+		if pos == len(lines) {
+			trailingNewline = !h.newNoEOL
+		}
+	}
+	if len(rejects) > 0 {
+		return nil, &PatchRejectError{Rejects: rejects}
+	}
+	out = append(out, lines[pos:]...)
+
+	if len(out) == 0 {
+		return []byte{}, nil
+	}
+	result := strings.Join(out, eol)
+	if trailingNewline {
+		result += eol
+	}
+	return []byte(result), nil
+}
+
+// findHunk returns the line index at which old matches lines, searching
+// outward from want and never before floor. It returns -1 when no match
+// lies within patchHunkFuzz lines.
+func findHunk(lines, old []string, want, floor int) int {
+	for delta := 0; delta <= patchHunkFuzz; delta++ {
+		for _, idx := range []int{want + delta, want - delta} {
+			if idx < floor || idx+len(old) > len(lines) {
+				continue
+			}
+			if linesEqual(lines[idx:idx+len(old)], old) {
+				return idx
+			}
+			if delta == 0 {
+				break
+			}
+		}
+	}
+	return -1
+}
+
+func linesEqual(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	original := "one\ntwo\nthree\nfour\nfive\n"
+	tests := []struct {
+		name  string
+		input string
+		patch string
+		want  string
+	}{
+		{
+			name:  "replace line",
+			input: original,
+			patch: "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+			want:  "one\ntwo\nTHREE\nfour\nfive\n",
+		},
+		{
+			name:  "hunk drifted from header",
+			input: "zero\n" + original,
+			patch: "@@ -4,2 +4,3 @@\n four\n+four-and-a-half\n five\n",
+			want:  "zero\none\ntwo\nthree\nfour\nfour-and-a-half\nfive\n",
+		},
+		{
+			name:  "drop trailing newline",
+			input: "a\nb\n",
+			patch: "@@ -2 +2 @@\n-b\n+b\n\\ No newline at end of file\n",
+			want:  "a\nb",
+		},
+		{
+			name:  "create file",
+			input: "",
+			patch: "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+hello\n+world\n",
+			want:  "hello\nworld\n",
+		},
+		{
+			name:  "crlf file",
+			input: "one\r\ntwo\r\nthree\r\n",
+			patch: "@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n",
+			want:  "one\r\nTWO\r\nthree\r\n",
+		},
+		{
+			name:  "crlf patch",
+			input: "one\r\ntwo\r\n",
+			patch: "@@ -1,2 +1,2 @@\r\n one\r\n-two\r\n+2\r\n",
+			want:  "one\r\n2\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyUnifiedDiff([]byte(tt.input), tt.patch)
+			if err != nil {
+				t.Fatalf("applyUnifiedDiff returned error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("result = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUnifiedDiffRejectsMismatchedContext(t *testing.T) {
+	_, err := applyUnifiedDiff([]byte("one\ntwo\n"), "@@ -1,2 +1,2 @@\n one\n-deux\n+2\n")
+	var rejectErr *PatchRejectError
+	if !errors.As(err, &rejectErr) {
+		t.Fatalf("error = %v, want *PatchRejectError", err)
+	}
+	if len(rejectErr.Rejects) != 1 {
+		t.Fatalf("rejects = %#v, want 1", rejectErr.Rejects)
+	}
+}
+
+func TestApplyUnifiedDiffRejectsMalformedPatch(t *testing.T) {
+	for _, patch := range []string{"not a diff\n", "@@ -1,2 +1,2 @@\n one\n", "@@ -1 +1 @@\n*one\n"} {
+		if _, err := applyUnifiedDiff([]byte("one\ntwo\n"), patch); !errors.Is(err, ErrMalformedPatch) {
+			t.Fatalf("applyUnifiedDiff(%q) error = %v, want ErrMalformedPatch", patch, err)
+		}
+	}
+	if got := httpStatusForError(fmt.Errorf("%w: truncated body", ErrMalformedPatch)); got != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", got)
+	}
+}
+
+func TestPatchFileWritesPatchedContent(t *testing.T) {
+	store := newTestSessionStore("gw-patch")
+	var written string
+	gw := New(nil, &operationRuntimeAllocator{}, &client.MockExecutorClient{
+		ReadFileFunc: func(ctx context.Context, podIP string, path string, dst io.Writer) (*interfaces.FileReadResult, error) {
+			_, err := io.WriteString(dst, "x = 1\ny = 2\n")
+			return &interfaces.FileReadResult{}, err
+		},
+		WriteFileFunc: func(ctx context.Context, podIP string, path string, content io.Reader, expectedSHA256 string) (*interfaces.FileWriteResult, error) {
+			data, err := io.ReadAll(content)
+			written = string(data)
+			return &interfaces.FileWriteResult{Path: path, BytesWritten: int64(len(data))}, err
+		},
+	}, nil, nil, GatewayConfig{}, store)
+
+	_, err := gw.PatchFile(context.Background(), "gw-patch", PatchFileRequest{
+		Path:  "main.py",
+		Patch: "@@ -1,2 +1,2 @@\n x = 1\n-y = 2\n+y = 3\n",
+	})
+	if err != nil {
+		t.Fatalf("PatchFile returned error: %v", err)
+	}
+	if written != "x = 1\ny = 3\n" {
+		t.Fatalf("written = %q", written)
+	}
+}
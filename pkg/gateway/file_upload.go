@@ -28,13 +28,30 @@ func (g *Gateway) UploadFile(ctx context.Context, sessionID string, filePath str
 	}
 	defer releaseSession()
 
+	resp, err := g.writeUploadedFile(ctx, s, podIP, filePath, content, expectedSHA256)
+	if err != nil {
+		return nil, err
+	}
+	g.store.SyncHistory(sessionID)
+
+	g.touchLastTaskTime(sessionID)
+	return resp, nil
+}
+
+// writeUploadedFile writes content to the executor and records it as an
+// upload_file history step so restore and replay can re-apply it. The
+// caller is responsible for syncing history.
+func (g *Gateway) writeUploadedFile(ctx context.Context, s *session, podIP string, filePath string, content io.Reader, expectedSHA256 string) (*UploadFileResponse, error) {
 	var buf bytes.Buffer
 	tee := io.TeeReader(content, &buf)
 
+	start := time.Now()
 	result, err := g.executorClient.WriteFile(ctx, podIP, filePath, tee, expectedSHA256)
 	if err != nil {
+		g.recordStepMetrics(stepTypeFile, time.Since(start), 1)
 		return nil, err
 	}
+	g.recordStepMetrics(stepTypeFile, time.Since(start), 0)
 
 	g.storeUploadBlob(ctx, result.SHA256, buf.Bytes())
 
@@ -44,9 +61,7 @@ func (g *Gateway) UploadFile(ctx context.Context, sessionID string, filePath str
 		Input:     inputJSON,
 		Timestamp: time.Now(),
 	})
-	g.store.SyncHistory(sessionID)
 
-	g.touchLastTaskTime(sessionID)
 	return &UploadFileResponse{
 		Path:         result.Path,
 		BytesWritten: int(result.BytesWritten),
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -8,8 +9,10 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -25,18 +28,30 @@ type GatewayConfig struct {
 	DevboxIdleTimeout               time.Duration
 	DevboxStorageClassName          string
 	SweepInterval                   time.Duration
+	MaxActiveSessions               int
 	Namespace                       string
 	ExecutorAgentImage string
 	ExecutorPort       int
+	ExecutorMaxConcurrentCalls      int
 	IrohRelayURL                    string
 	IrohRelayExternalURL            string
 	ImagePullPolicy                 string
 	GRPCAuthToken                   string
 	GRPCAuthSecretName              string
+	ExecutorAuthEnabled             bool
+	ExecutorTLSEnabled              bool
+	ExecutorTLSSecretName           string
+	ExecutorStartupTimeout          time.Duration
+	ExecutorReadinessPeriod         time.Duration
+	ExecutorLivenessPeriod          time.Duration
+	ExecutorLivenessFailures        int32
+	RestoreBackend                  string
 	PodHTTPProxy                    string
 	PodNoProxy                      string
 	AdmissionQueueTimeout           time.Duration
 	AdmissionQueuePollInterval      time.Duration
+	SandboxFairBinding              bool
+	SessionReadyTimeout             time.Duration
 	PoolAutoscalerEnabled           bool
 	PoolAutoscalerInterval          time.Duration
 	PoolAutoscalerBuffer            int32
@@ -66,6 +81,14 @@ type GatewayConfig struct {
 	CheckpointGCInterval            time.Duration
 	FullObservationEnabled          bool
 	ObservationPreviewBytes         int
+	ExecEnvDenyList                 string
+	ExecEnvAllowList                string
+	ExecCommandDenyList             string
+	WorkspaceHostPathPrefixes       string
+	ExecMaxOutputBytes              int64
+	ExecKillOnOutputLimit           bool
+	ResourceSamplingEnabled         bool
+	TrajectoryQueueSize             int
 	BuildEnabled                    bool
 	BuildKanikoImage                string
 	BuildRegistrySecret             string
@@ -73,6 +96,11 @@ type GatewayConfig struct {
 	BuildCheckpointPVC              string
 	BuildRegistry                   string
 	K8sRESTConfig                   *rest.Config
+	// ExecutorAgentImagePullSecret and ExecutorAgentSecurityContext harden
+	// the injected executor-agent init container for private registries
+	// and restricted clusters. Both are optional.
+	ExecutorAgentImagePullSecret string
+	ExecutorAgentSecurityContext *corev1.SecurityContext
 }
 
 // session holds internal session state.
@@ -91,10 +119,42 @@ type session struct {
 	lastTaskTime        time.Time
 	lastAnnotationPatch time.Time
 	idleTimeout         time.Duration
+	maxLifetime         time.Duration
 	createdAt           time.Time
 	activeExecs         int32
 	operations          map[string]*operation
 	privateContainers   map[string]PrivateContainerSpec
+	resourceUsage       *SessionResourceUsage
+	// resourceUsagePod is the pod the last resource sample came from and
+	// resourcePodCPUSeconds its CPU counter; resourceCPUSecondsBase carries
+	// the CPU used in earlier pods or container restarts.
+	resourceUsagePod       string
+	resourcePodCPUSeconds  float64
+	resourceCPUSecondsBase float64
+	// execCtx is cancelled to abort the session's in-flight execs, for
+	// instance when it reaches its max lifetime. See execContext.
+	execCtx   context.Context
+	stopExecs context.CancelFunc
+}
+
+// execContext returns the context whose cancellation aborts the session's
+// in-flight execs, creating it on first use.
+func (s *session) execContext() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.execCtx == nil {
+		s.execCtx, s.stopExecs = context.WithCancel(context.Background())
+	}
+	return s.execCtx
+}
+
+// cancelExecs aborts every exec started through acquireSessionExec.
+func (s *session) cancelExecs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopExecs != nil {
+		s.stopExecs()
+	}
 }
 
 func (s *session) runtimeAllocation() RuntimeAllocation {
@@ -129,9 +189,10 @@ type Gateway struct {
 	admissionController   AdmissionController
 	executorClient        interfaces.ExecutorClient
 	metrics               interfaces.MetricsCollector
-	trajectoryWriter      *audit.TrajectoryWriter
+	trajectoryWriter      audit.TrajectoryStore
 	store                 SessionStore
 	gwConfig              GatewayConfig
+	configMu              sync.RWMutex // guards the ReloadableConfig fields of gwConfig
 	sweepStopCh           chan struct{}
 	sweepWg               sync.WaitGroup
 	autoscaleStopCh       chan struct{}
@@ -145,20 +206,32 @@ type Gateway struct {
 	checkpointGCWg        sync.WaitGroup
 	admissionQueueMu      sync.Mutex
 	admissionQueueDepth   map[types.NamespacedName]int32
+	admissionWaiters      map[types.NamespacedName][]uint64
+	admissionTicketSeq    uint64
+	poolDemandMu          sync.Mutex
+	poolDemand            map[types.NamespacedName]*poolDemandStats
 	poolStopMu            sync.Mutex
 	poolIndexMu           sync.Mutex
+	sessionNameMu         sync.Mutex
+	pendingSessionNames   map[string]struct{}
+	pendingSessions       atomic.Int64
+	draining              atomic.Bool
+	inflight              atomic.Int64
+	idempotencyMu         sync.Mutex
+	idempotentCreates     map[string]*idempotentCreate
 	poolIndex             *poolIndex
 	poolReadModel         PoolReadModel
 	trajMu                sync.RWMutex
 	trajCh                chan audit.TrajectoryEntry
 	trajWg                sync.WaitGroup
 	checkpointStore       *CheckpointStore
+	restoreBackend        RestoreBackend
 	k8sClientset          kubernetes.Interface
 }
 
 // New creates a new gateway. metrics and trajectoryWriter may be nil.
 // If store is nil, a default MemoryStore is used.
-func New(k8sClient client.Client, runtimeAllocator RuntimeAllocator, executorClient interfaces.ExecutorClient, metrics interfaces.MetricsCollector, trajectoryWriter *audit.TrajectoryWriter, gwConfig GatewayConfig, store SessionStore) *Gateway {
+func New(k8sClient client.Client, runtimeAllocator RuntimeAllocator, executorClient interfaces.ExecutorClient, metrics interfaces.MetricsCollector, trajectoryWriter audit.TrajectoryStore, gwConfig GatewayConfig, store SessionStore) *Gateway {
 	if store == nil {
 		store = NewMemoryStore()
 	}
@@ -174,6 +247,9 @@ func New(k8sClient client.Client, runtimeAllocator RuntimeAllocator, executorCli
 			clientset = cs
 		}
 	}
+	if executorClient != nil && gwConfig.ExecutorMaxConcurrentCalls > 0 {
+		executorClient = newLimitedExecutorClient(executorClient, gwConfig.ExecutorMaxConcurrentCalls, metrics)
+	}
 	gw := &Gateway{
 		k8sClient:           k8sClient,
 		k8sRESTConfig:       copyRESTConfig(gwConfig.K8sRESTConfig),
@@ -229,10 +305,11 @@ func (g *Gateway) resolveIdleTimeout(req CreateSessionRequest) time.Duration {
 	if req.IdleTimeoutSeconds > 0 {
 		return time.Duration(req.IdleTimeoutSeconds) * time.Second
 	}
+	rc := g.reloadable()
 	if req.Mode == SessionModeDevbox {
-		return g.gwConfig.DevboxIdleTimeout
+		return rc.DevboxIdleTimeout
 	}
-	return g.gwConfig.IdleTimeout
+	return rc.IdleTimeout
 }
 
 func randomSuffix(n int) string {
@@ -128,6 +128,7 @@ func (hc *HealthChecker) collect() {
 		}
 		hc.metrics.SetRuntimeIdleCapacity(idleCapacity)
 		hc.metrics.SetRuntimePendingWaiters(pendingWaiters)
+		hc.metrics.SetTrajectoryQueueDepth(hc.gw.trajectoryQueueDepth())
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		if err := hc.gw.publishCurrentPoolMetrics(ctx); err != nil {
@@ -71,7 +71,13 @@ func TestHealthCheckerCollectsImagePullDuration(t *testing.T) {
 }
 
 type recordingMetricsCollector struct {
-	imagePullDurations map[string]time.Duration
+	imagePullDurations   map[string]time.Duration
+	trajectoryDropped    int
+	restorePhases        []string
+	restoreReplayedSteps int
+	stepResults          []string
+	localityDecisions    []string
+	requeues             []string
 }
 
 func (m *recordingMetricsCollector) RecordHTTPRequestDuration(method, route, status string, duration time.Duration) {
@@ -93,15 +99,32 @@ func (m *recordingMetricsCollector) IncrementSessionDrop(reason, terminationReas
 func (m *recordingMetricsCollector) IncrementExecuteOperationResult(result string)         {}
 func (m *recordingMetricsCollector) RecordGatewayStepDuration(stepType string, duration time.Duration) {
 }
-func (m *recordingMetricsCollector) IncrementGatewayStepResult(stepType, result string) {}
+func (m *recordingMetricsCollector) IncrementGatewayStepResult(stepType, result string) {
+	m.stepResults = append(m.stepResults, stepType+"/"+result)
+}
 func (m *recordingMetricsCollector) RecordExecutorCallDuration(method string, duration time.Duration) {
 }
-func (m *recordingMetricsCollector) RecordRestoreDuration(duration time.Duration) {}
-func (m *recordingMetricsCollector) IncrementRestoreResult(result string)         {}
-func (m *recordingMetricsCollector) SetGatewayGoroutines(count int)               {}
-func (m *recordingMetricsCollector) SetGatewaySessionsTotal(count int)            {}
-func (m *recordingMetricsCollector) SetRuntimeIdleCapacity(count int)             {}
-func (m *recordingMetricsCollector) SetRuntimePendingWaiters(count int)           {}
-func (m *recordingMetricsCollector) ResetPoolAggregateMetrics()                   {}
+func (m *recordingMetricsCollector) RecordExecutorSemaphoreWait(duration time.Duration) {}
+func (m *recordingMetricsCollector) RecordRestoreDuration(duration time.Duration)       {}
+func (m *recordingMetricsCollector) IncrementRestoreResult(result string)               {}
+func (m *recordingMetricsCollector) RecordRestorePhaseDuration(phase string, duration time.Duration) {
+	m.restorePhases = append(m.restorePhases, phase)
+}
+func (m *recordingMetricsCollector) AddRestoreReplayedSteps(count int) {
+	m.restoreReplayedSteps += count
+}
+func (m *recordingMetricsCollector) SetGatewayGoroutines(count int)     {}
+func (m *recordingMetricsCollector) SetGatewaySessionsTotal(count int)  {}
+func (m *recordingMetricsCollector) SetRuntimeIdleCapacity(count int)   {}
+func (m *recordingMetricsCollector) SetRuntimePendingWaiters(count int) {}
+func (m *recordingMetricsCollector) SetTrajectoryQueueDepth(depth int)  {}
+func (m *recordingMetricsCollector) IncrementTrajectoryDropped()        { m.trajectoryDropped++ }
+func (m *recordingMetricsCollector) ResetPoolAggregateMetrics()         {}
 func (m *recordingMetricsCollector) SetPoolAggregateMetrics(profile, state string, desired, ready, allocated, queued int, saturation float64) {
 }
+func (m *recordingMetricsCollector) IncrementImageLocalityDecision(result, reason string) {
+	m.localityDecisions = append(m.localityDecisions, result+"/"+reason)
+}
+func (m *recordingMetricsCollector) IncrementReconcileRequeue(controller, reason string) {
+	m.requeues = append(m.requeues, controller+"/"+reason)
+}
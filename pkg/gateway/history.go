@@ -3,6 +3,8 @@ package gateway
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,6 +21,7 @@ type StepRecord struct {
 	SnapshotID      string          `json:"snapshot_id"`
 	DurationMs      int64           `json:"duration_ms"`
 	Timestamp       time.Time       `json:"timestamp"`
+	TraceID         string          `json:"trace_id,omitempty"`
 }
 
 // StepHistory is a thread-safe history of step executions.
@@ -75,6 +78,46 @@ func (h *StepHistory) GetUpTo(target int) []StepRecord {
 	return result
 }
 
+// Replace swaps the records for the given ones, e.g. after rebuilding the
+// history from the stored trajectory, and resets nextIndex past the last one.
+func (h *StepHistory) Replace(records []StepRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = make([]StepRecord, len(records))
+	copy(h.records, records)
+	h.nextIndex = 0
+	for _, r := range h.records {
+		if r.Index >= h.nextIndex {
+			h.nextIndex = r.Index + 1
+		}
+	}
+}
+
+// Merge adds the records whose index is not in the history yet, e.g. ones
+// rebuilt from the stored trajectory, which carry no output. Records already
+// present are kept as they are. The history stays ordered by index and
+// nextIndex moves past the last record.
+func (h *StepHistory) Merge(records []StepRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	have := make(map[int]bool, len(h.records))
+	for _, r := range h.records {
+		have[r.Index] = true
+	}
+	for _, r := range records {
+		if !have[r.Index] {
+			have[r.Index] = true
+			h.records = append(h.records, r)
+		}
+	}
+	sort.SliceStable(h.records, func(i, j int) bool { return h.records[i].Index < h.records[j].Index })
+	for _, r := range h.records {
+		if r.Index >= h.nextIndex {
+			h.nextIndex = r.Index + 1
+		}
+	}
+}
+
 // TruncateTo keeps only records with Index <= target and resets nextIndex.
 func (h *StepHistory) TruncateTo(target int) {
 	h.mu.Lock()
@@ -89,28 +132,83 @@ func (h *StepHistory) TruncateTo(target int) {
 	h.nextIndex = target + 1
 }
 
-// ExportTrajectory exports all steps as JSONL trajectory lines.
-func (h *StepHistory) ExportTrajectory(sessionID string) ([]byte, error) {
+// Page returns up to limit records after skipping the first offset. A
+// non-positive limit returns the rest.
+func (h *StepHistory) Page(offset, limit int) []StepRecord {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	if offset >= len(h.records) {
+		return nil
+	}
+	records := h.records[offset:]
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	result := make([]StepRecord, len(records))
+	copy(result, records)
+	return result
+}
 
-	var buf []byte
-	for _, r := range h.records {
-		obs, _ := json.Marshal(r.Output)
-		entry := TrajectoryEntry{
-			SessionID:   sessionID,
-			Step:        r.Index,
-			Action:      r.Input,
-			Observation: obs,
-			SnapshotID:  r.SnapshotID,
-			Timestamp:   r.Timestamp,
+// trajectoryEntry converts r into the JSONL trajectory line for sessionID.
+func (r StepRecord) trajectoryEntry(sessionID string) TrajectoryEntry {
+	obs, _ := json.Marshal(r.Output)
+	return TrajectoryEntry{
+		SessionID:   sessionID,
+		Step:        r.Index,
+		Action:      r.Input,
+		Observation: obs,
+		SnapshotID:  r.SnapshotID,
+		Timestamp:   r.Timestamp,
+		TraceID:     r.TraceID,
+	}
+}
+
+// StepRecordsToMessages converts step records into role/content chat
+// messages: each step becomes a user message carrying the action and a tool
+// message carrying its observation.
+func StepRecordsToMessages(records []StepRecord) []TrajectoryMessage {
+	messages := make([]TrajectoryMessage, 0, 2*len(records))
+	for _, r := range records {
+		messages = append(messages,
+			TrajectoryMessage{Role: "user", Name: r.Name, Content: stepActionText(r)},
+			TrajectoryMessage{Role: "tool", Name: r.Name, Content: stepObservationText(r.Output)},
+		)
+	}
+	return messages
+}
+
+func stepActionText(r StepRecord) string {
+	if r.Name == uploadFileStepName {
+		var upload uploadRecord
+		if err := json.Unmarshal(r.Input, &upload); err == nil {
+			return "upload_file " + upload.Path
 		}
-		line, err := json.Marshal(entry)
-		if err != nil {
-			return nil, err
+	}
+	var step StepRequest
+	if err := json.Unmarshal(r.Input, &step); err == nil && len(step.Command) > 0 {
+		args := make([]string, len(step.Command))
+		for i, arg := range step.Command {
+			args[i] = shellQuote(arg)
+		}
+		return strings.Join(args, " ")
+	}
+	return string(r.Input)
+}
+
+func stepObservationText(out StepOutput) string {
+	var b strings.Builder
+	b.WriteString(out.Stdout)
+	if out.Stderr != "" {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+		b.WriteString(out.Stderr)
+	}
+	if out.ExitCode != 0 {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
 		}
-		buf = append(buf, line...)
-		buf = append(buf, '\n')
+		fmt.Fprintf(&b, "exit code: %d", out.ExitCode)
 	}
-	return buf, nil
+	return b.String()
 }
@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Lincyaw/agent-env/pkg/audit"
+)
+
+func TestStepRecordsToMessages(t *testing.T) {
+	records := []StepRecord{
+		{Name: "build", Input: json.RawMessage(`{"name":"build","command":["sh","-c","echo 'hi there'"]}`), Output: StepOutput{Stdout: "ok\n", Stderr: "warn", ExitCode: 2}},
+		{Name: uploadFileStepName, Input: json.RawMessage(`{"path":"src/a.py","sha256":"x","size":3}`)},
+	}
+
+	got := StepRecordsToMessages(records)
+	want := []TrajectoryMessage{
+		{Role: "user", Name: "build", Content: `'sh' '-c' 'echo '\''hi there'\'''`},
+		{Role: "tool", Name: "build", Content: "ok\nwarn\nexit code: 2"},
+		{Role: "user", Name: uploadFileStepName, Content: "upload_file src/a.py"},
+		{Role: "tool", Name: uploadFileStepName, Content: ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("messages = %#v, want %d entries", got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("messages[%d] = %#v, want %#v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGetTrajectoryFormats(t *testing.T) {
+	store := newTestSessionStore("gw-traj")
+	sess, _ := store.Get("gw-traj")
+	sess.History.Add(StepRecord{Name: "ls", Input: json.RawMessage(`{"command":["ls"]}`), Output: StepOutput{Stdout: "a\n"}})
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+	router := SetupRoutes(gw, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/sessions/gw-traj/trajectory?format=messages", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var messages []TrajectoryMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &messages); err != nil {
+		t.Fatalf("decode messages: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Content != "'ls'" || messages[1].Content != "a\n" {
+		t.Fatalf("messages = %#v", messages)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/sessions/gw-traj/trajectory?format=csv", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestGetTrajectoryPaged(t *testing.T) {
+	store := newTestSessionStore("gw-traj")
+	sess, _ := store.Get("gw-traj")
+	for i := 0; i < 5; i++ {
+		sess.History.Add(StepRecord{Name: "step", Input: json.RawMessage(`{}`)})
+	}
+	fileStore, err := audit.NewFileTrajectoryWriter(audit.FileTrajectoryConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFileTrajectoryWriter returned error: %v", err)
+	}
+	defer fileStore.Close()
+	for i := 0; i < 5; i++ {
+		fileStore.WriteEntry(context.Background(), audit.TrajectoryEntry{SessionID: "gw-traj", Step: i, Action: json.RawMessage(`{}`), Observation: json.RawMessage(`{}`)})
+	}
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, fileStore, GatewayConfig{}, store)
+	router := SetupRoutes(gw, nil)
+
+	for _, source := range []string{"", "&source=storage"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/sessions/gw-traj/trajectory?offset=1&limit=3"+source, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("source %q: status = %d, want 200: %s", source, rec.Code, rec.Body.String())
+		}
+		var steps []int
+		dec := json.NewDecoder(rec.Body)
+		for dec.More() {
+			var entry TrajectoryEntry
+			if err := dec.Decode(&entry); err != nil {
+				t.Fatalf("source %q: decode entry: %v", source, err)
+			}
+			steps = append(steps, entry.Step)
+		}
+		if len(steps) != 3 || steps[0] != 1 || steps[2] != 3 {
+			t.Fatalf("source %q: steps = %v, want [1 2 3]", source, steps)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/sessions/gw-traj/trajectory?limit=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("negative limit status = %d, want 400", rec.Code)
+	}
+}
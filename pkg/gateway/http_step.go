@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHTTPStepTimeout bounds an HTTP step that sets no timeout of its own.
+const defaultHTTPStepTimeout = 30 * time.Second
+
+// maxHTTPStepBodyBytes caps the response body read when neither the gateway
+// nor the step sets an output limit.
+const maxHTTPStepBodyBytes = 16 << 20
+
+// httpStepClient never follows redirects: the sandbox chooses the Location,
+// and following it would let a tenant make the gateway fetch any URL its
+// network can reach. The 3xx response is returned to the caller as is.
+var httpStepClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var httpStepMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+func validateHTTPStep(h *HTTPStepRequest) error {
+	if h.Port < 1 || h.Port > tunnelMaxPort {
+		return fmt.Errorf("http.port must be between 1 and %d", tunnelMaxPort)
+	}
+	if h.Method != "" && !httpStepMethods[strings.ToUpper(h.Method)] {
+		return fmt.Errorf("http.method %q is not supported", h.Method)
+	}
+	if h.Path != "" && !strings.HasPrefix(h.Path, "/") {
+		return fmt.Errorf("http.path must start with /")
+	}
+	for name := range h.Headers {
+		if strings.EqualFold(name, "Host") {
+			return fmt.Errorf("http.headers cannot set Host")
+		}
+	}
+	return nil
+}
+
+// runHTTPStep sends the step's request to a service listening in the sandbox
+// at podIP and records the response in result. The body becomes stdout, and a
+// connection failure or a status of 400 or above exits 1, like curl --fail.
+func (g *Gateway) runHTTPStep(ctx context.Context, podIP string, step StepRequest, result *StepResult) {
+	h := step.HTTP
+	result.HTTP = &HTTPStepResult{}
+
+	timeout := defaultHTTPStepTimeout
+	if secs := resolveStepTimeoutSeconds(step); secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := strings.ToUpper(h.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	path := h.Path
+	if path == "" {
+		path = "/"
+	}
+	url := "http://" + net.JoinHostPort(podIP, strconv.Itoa(int(h.Port))) + path
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(h.Body))
+	if err != nil {
+		result.Output = StepOutput{Stderr: err.Error() + "\n", ExitCode: 1}
+		return
+	}
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpStepClient.Do(req)
+	if err != nil {
+		result.Output = StepOutput{Stderr: err.Error() + "\n", ExitCode: 1}
+		return
+	}
+	defer resp.Body.Close()
+
+	result.HTTP.StatusCode = resp.StatusCode
+	result.HTTP.Headers = make(map[string]string, len(resp.Header))
+	for name := range resp.Header {
+		result.HTTP.Headers[name] = resp.Header.Get(name)
+	}
+
+	limit := g.gwConfig.ExecMaxOutputBytes
+	if step.MaxOutputBytes > 0 && (limit <= 0 || step.MaxOutputBytes < limit) {
+		limit = step.MaxOutputBytes
+	}
+	readLimit := limit
+	if readLimit <= 0 {
+		readLimit = maxHTTPStepBodyBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, readLimit+1))
+	if limit <= 0 && int64(len(data)) > readLimit {
+		data = data[:readLimit]
+		result.OutputTruncated = true
+		result.Output.Stderr += fmt.Sprintf("response body truncated to %d bytes\n", readLimit)
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		data = data[:limit]
+		result.OutputTruncated = true
+		reason, msg := g.outputLimitFailure(limit)
+		result.Output.Stderr += msg
+		if reason != "" {
+			// The gateway stops reading at the cap, which fails the step
+			// the way a command killed at its output limit does.
+			result.FailureReason = reason
+			result.Output.ExitCode = 1
+		}
+	}
+	result.Output.Stdout = string(data)
+	if err != nil {
+		result.Output.Stderr += fmt.Sprintf("read response body: %v\n", err)
+		result.Output.ExitCode = 1
+		return
+	}
+	if resp.StatusCode >= 400 {
+		result.Output.ExitCode = 1
+	}
+}
@@ -0,0 +1,116 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+)
+
+func TestExecuteStepsRunsHTTPStep(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo-Method", r.Method)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(r.URL.RequestURI() + " " + r.Header.Get("X-Token") + " " + string(body)))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	store := newTestSessionStore("gw-http-step")
+	s, _ := store.Get("gw-http-step")
+	s.Info.PodIP = "127.0.0.1"
+	s.Runtime.PodIP = "127.0.0.1"
+	gw := New(nil, &operationRuntimeAllocator{}, &mockclient.MockExecutorClient{}, nil, nil, GatewayConfig{}, store)
+
+	resp, err := gw.ExecuteSteps(context.Background(), "gw-http-step", ExecuteRequest{Steps: []StepRequest{
+		{Name: "post", HTTP: &HTTPStepRequest{
+			Method:  "post",
+			Port:    int32(port),
+			Path:    "/api?x=1",
+			Headers: map[string]string{"X-Token": "t"},
+			Body:    "hello",
+		}},
+		{Name: "missing", HTTP: &HTTPStepRequest{Port: int32(port), Path: "/missing"}},
+	}})
+	if err != nil {
+		t.Fatalf("ExecuteSteps returned error: %v", err)
+	}
+
+	post := resp.Results[0]
+	if post.HTTP == nil || post.HTTP.StatusCode != http.StatusOK || post.Output.ExitCode != 0 {
+		t.Fatalf("post result = %+v", post)
+	}
+	if post.Output.Stdout != "/api?x=1 t hello" {
+		t.Fatalf("stdout = %q", post.Output.Stdout)
+	}
+	if post.HTTP.Headers["X-Echo-Method"] != http.MethodPost {
+		t.Fatalf("headers = %v", post.HTTP.Headers)
+	}
+	missing := resp.Results[1]
+	if missing.HTTP.StatusCode != http.StatusNotFound || missing.Output.ExitCode != 1 {
+		t.Fatalf("missing status = %d, exit = %d", missing.HTTP.StatusCode, missing.Output.ExitCode)
+	}
+}
+
+func TestRunHTTPStepDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/internal", http.StatusFound)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	gw := &Gateway{}
+	var result StepResult
+	gw.runHTTPStep(context.Background(), "127.0.0.1", StepRequest{HTTP: &HTTPStepRequest{Port: int32(port)}}, &result)
+	if followed {
+		t.Fatal("HTTP step followed the redirect")
+	}
+	if result.HTTP.StatusCode != http.StatusFound || result.HTTP.Headers["Location"] != target.URL+"/internal" {
+		t.Fatalf("result = %+v, want the 302 itself", result.HTTP)
+	}
+}
+
+func TestRunHTTPStepOutputLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "0123456789")
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	step := StepRequest{HTTP: &HTTPStepRequest{Port: int32(port)}, MaxOutputBytes: 4}
+
+	for _, tt := range []struct {
+		kill       bool
+		wantReason string
+		wantExit   int32
+	}{
+		{false, "", 0},
+		{true, StepFailureOutputLimit, 1},
+	} {
+		gw := &Gateway{gwConfig: GatewayConfig{ExecKillOnOutputLimit: tt.kill}}
+		var result StepResult
+		gw.runHTTPStep(context.Background(), "127.0.0.1", step, &result)
+		if result.Output.Stdout != "0123" || !result.OutputTruncated {
+			t.Fatalf("kill=%v: output = %+v, truncated = %v; want the body cut at 4 bytes", tt.kill, result.Output, result.OutputTruncated)
+		}
+		if result.FailureReason != tt.wantReason || result.Output.ExitCode != tt.wantExit {
+			t.Fatalf("kill=%v: failure reason = %q, exit = %d; want %q, %d", tt.kill, result.FailureReason, result.Output.ExitCode, tt.wantReason, tt.wantExit)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Lincyaw/agent-env/pkg/scheduler"
+)
+
+const defaultImageLocalityDebugK = 3
+
+// ImageLocalityDebugResponse is the body of GET /debug/image-locality.
+type ImageLocalityDebugResponse struct {
+	Image string `json:"image"`
+	K     int    `json:"k"`
+	// Weighted is set when selection weighted nodes by allocatable capacity.
+	Weighted bool `json:"weighted"`
+	// SelectedNodes are the nodes ImageScheduler.SelectNodes ranks first for
+	// the image: rendezvous-hashed over the nodes caching it, or over every
+	// schedulable node when none do.
+	SelectedNodes    []string `json:"selectedNodes"`
+	CachedNodes      []string `json:"cachedNodes"`
+	SchedulableNodes int      `json:"schedulableNodes"`
+}
+
+// imageLocalityDecision labels a pool creation for the image-locality
+// decision metric.
+func imageLocalityDecision(injected, spread, requested bool) (string, string) {
+	switch {
+	case injected && requested:
+		return "injected", "requested"
+	case injected:
+		return "injected", "default"
+	case spread:
+		return "skipped", "spread"
+	default:
+		return "skipped", "disabled"
+	}
+}
+
+// handleImageLocalityDebug reports which nodes image-locality selection picks
+// for ?image= right now, from a fresh Node list, so placement can be checked
+// without creating pods. ?weighted=true weights nodes by allocatable capacity.
+func handleImageLocalityDebug(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		image := r.URL.Query().Get("image")
+		if image == "" {
+			writeError(w, http.StatusBadRequest, "image query parameter is required")
+			return
+		}
+		k := defaultImageLocalityDebugK
+		if raw := r.URL.Query().Get("k"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, "k must be a positive integer")
+				return
+			}
+			k = n
+		}
+		weighted := false
+		if raw := r.URL.Query().Get("weighted"); raw != "" {
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "weighted must be a boolean")
+				return
+			}
+			weighted = b
+		}
+		if gw.k8sClient == nil {
+			writeError(w, http.StatusServiceUnavailable, "kubernetes client not configured")
+			return
+		}
+
+		var nodes corev1.NodeList
+		if err := gw.k8sClient.List(r.Context(), &nodes); err != nil {
+			writeError(w, http.StatusInternalServerError, "list nodes: "+err.Error())
+			return
+		}
+		sched := scheduler.NewImageSchedulerFromNodes(nodes.Items)
+		sched.SetCapacityWeighted(weighted)
+		writeJSON(w, http.StatusOK, ImageLocalityDebugResponse{
+			Image:            image,
+			K:                k,
+			Weighted:         weighted,
+			SelectedNodes:    sched.SelectNodes(image, k),
+			CachedNodes:      sched.CachedNodesForImage(image),
+			SchedulableNodes: sched.SchedulableNodeCount(),
+		})
+	}
+}
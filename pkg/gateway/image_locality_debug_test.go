@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func readyNode(name string, images ...string) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	for _, image := range images {
+		node.Status.Images = append(node.Status.Images, corev1.ContainerImage{Names: []string{image}})
+	}
+	return node
+}
+
+func TestImageLocalityDebugReportsSelectedNodes(t *testing.T) {
+	k8sClient := fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).WithObjects(
+		readyNode("node-a", "python:3.12"),
+		readyNode("node-b"),
+		readyNode("node-c", "python:3.12"),
+	).Build()
+	handler := handleImageLocalityDebug(&Gateway{k8sClient: k8sClient})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/debug/image-locality?image=python:3.12&k=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var resp ImageLocalityDebugResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if want := []string{"node-a", "node-c"}; !reflect.DeepEqual(resp.CachedNodes, want) {
+		t.Fatalf("cachedNodes = %v, want %v", resp.CachedNodes, want)
+	}
+	if len(resp.SelectedNodes) != 2 || resp.SchedulableNodes != 3 || resp.K != 5 {
+		t.Fatalf("response = %+v, want the two cached nodes out of 3 schedulable", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/debug/image-locality?image=python:3.12&k=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("k=0 status = %d, want 400", rec.Code)
+	}
+}
+
+func TestCreatePoolRecordsImageLocalityDecision(t *testing.T) {
+	metrics := &recordingMetricsCollector{}
+	gw := &Gateway{
+		k8sClient: fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).Build(),
+		metrics:   metrics,
+		gwConfig:  GatewayConfig{ImageLocalityEnabled: true, GRPCAuthToken: "test-token"},
+	}
+	for _, req := range []CreatePoolRequest{
+		{Name: "near", Namespace: "default", Image: "python:3.12", Replicas: 1},
+		{Name: "wide", Namespace: "default", Image: "python:3.12", Replicas: 1, ImageLocality: json.RawMessage(`{"mode":"spread"}`)},
+	} {
+		if err := gw.CreatePool(context.Background(), req); err != nil {
+			t.Fatalf("CreatePool %s returned error: %v", req.Name, err)
+		}
+	}
+	if want := []string{"injected/default", "skipped/spread"}; !reflect.DeepEqual(metrics.localityDecisions, want) {
+		t.Fatalf("locality decisions = %v, want %v", metrics.localityDecisions, want)
+	}
+}
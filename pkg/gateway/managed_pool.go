@@ -26,6 +26,9 @@ func (e *doomedPoolError) Error() string {
 
 func (e *doomedPoolError) Unwrap() error { return e.err }
 
+// Is reports doomed pools as unhealthy so API clients get POOL_UNHEALTHY.
+func (e *doomedPoolError) Is(target error) bool { return target == ErrPoolUnhealthy }
+
 // provisioningWaitFailure reports whether a session create failed only
 // because the caller stopped waiting for warm capacity (admission timeout,
 // client-set allocation deadline, or client disconnect). Demand is still
@@ -49,6 +49,7 @@ func (g *Gateway) managedPoolGCLoop() {
 
 	if deleted, err := g.reconcileManagedPoolGC(context.Background()); err != nil {
 		log.Printf("managed pool GC reconcile failed: %v", err)
+		g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonError)
 	} else if deleted > 0 {
 		log.Printf("managed pool GC deleted %d stopped managed pool(s)", deleted)
 	}
@@ -67,6 +68,7 @@ func (g *Gateway) managedPoolGCLoop() {
 		case <-ticker.C:
 			if deleted, err := g.reconcileManagedPoolGC(context.Background()); err != nil {
 				log.Printf("managed pool GC reconcile failed: %v", err)
+				g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonError)
 			} else if deleted > 0 {
 				log.Printf("managed pool GC deleted %d stopped managed pool(s)", deleted)
 			}
@@ -103,6 +105,7 @@ func (g *Gateway) reconcileManagedPoolGC(ctx context.Context) (int, error) {
 		pool := &pools.Items[i]
 		key := types.NamespacedName{Name: pool.Name, Namespace: pool.Namespace}
 		if _, inUse := references[key]; inUse {
+			g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonInUse)
 			continue
 		}
 		if !isManagedPool(pool) || !poolLifecycleStopped(pool) {
@@ -116,6 +119,9 @@ func (g *Gateway) reconcileManagedPoolGC(ctx context.Context) (int, error) {
 
 	maxStopped := g.gwConfig.ManagedPoolGCMaxStopped
 	if len(candidates) <= maxStopped {
+		for range candidates {
+			g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonUnderStoppedLimit)
+		}
 		return 0, nil
 	}
 	sort.SliceStable(candidates, func(i, j int) bool {
@@ -136,6 +142,7 @@ func (g *Gateway) reconcileManagedPoolGC(ctx context.Context) (int, error) {
 			break
 		}
 		if minIdleAge > 0 && now.Sub(candidate.lastUsed) < minIdleAge {
+			g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonIdleTimeoutPending)
 			continue
 		}
 		removed, err := g.deleteManagedPoolGCCandidate(ctx, candidate.pool.Namespace, candidate.pool.Name)
@@ -175,12 +182,15 @@ func (g *Gateway) stopIdleRunningManagedPools(ctx context.Context, namespace str
 		}
 		key := types.NamespacedName{Name: pool.Name, Namespace: pool.Namespace}
 		if _, inUse := references[key]; inUse {
+			g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonInUse)
 			continue
 		}
 		if queued[key] > 0 {
+			g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonQueuedRequests)
 			continue
 		}
 		if now.Sub(managedPoolLastUsedAt(pool, now)) < minIdle {
+			g.recordReconcileRequeue(reconcilerManagedPoolGC, requeueReasonIdleTimeoutPending)
 			continue
 		}
 		if stopped, err := g.stopManagedPoolIfUnused(ctx, pool.Name, pool.Namespace); err != nil {
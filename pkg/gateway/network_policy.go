@@ -40,7 +40,7 @@ func (g *Gateway) UpdateSessionNetworkPolicy(ctx context.Context, sessionID stri
 
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return fmt.Errorf("session %s not found", sessionID)
+		return &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	allocation := s.runtimeAllocation()
@@ -5,16 +5,32 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	executeOperationRunning = "running"
-	executeOperationDone    = "done"
-	executeOperationError   = "error"
+	executeOperationRunning   = "running"
+	executeOperationDone      = "done"
+	executeOperationError     = "error"
+	executeOperationCancelled = "cancelled"
 )
 
+// Lifecycle states of an operation. A running operation moves to exactly
+// one of the other two, so a cancel racing completion either wins before
+// the result is recorded or leaves the finished result alone.
+const (
+	operationStateRunning int32 = iota
+	operationStateFinished
+	operationStateCancelled
+)
+
+// errOperationCancelled is the result of an operation stopped through
+// CancelOperation.
+var errOperationCancelled = errors.New("operation cancelled")
+
 // OperationPending is returned when an async operation has been accepted
 // but the HTTP client context expired before it finished. The handler
 // should respond with 202 Accepted + the operationID.
@@ -38,6 +54,8 @@ type operation struct {
 	result      any
 	resultJSON  json.RawMessage // cached marshal of result, set once on completion
 	err         error
+	cancel      context.CancelFunc
+	state       atomic.Int32
 }
 
 func operationRequestHash(req any) string {
@@ -54,7 +72,7 @@ func operationRequestHash(req any) string {
 func (g *Gateway) OperationStatus(sessionID, operationID string) (*ExecuteOperationInfo, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, fmt.Errorf("session %s not found", sessionID)
+		return nil, &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	op := s.operations[operationID]
@@ -65,6 +83,36 @@ func (g *Gateway) OperationStatus(sessionID, operationID string) (*ExecuteOperat
 	return op.info(), nil
 }
 
+// CancelOperation stops a running operation and waits, until ctx ends, for
+// it to wind down. Cancelling closes the executor connection of the step in
+// flight, which makes the executor kill the command; steps not yet started
+// are skipped. Cancelling a finished operation returns its final status.
+func (g *Gateway) CancelOperation(ctx context.Context, sessionID, operationID string) (*ExecuteOperationInfo, error) {
+	s, ok := g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+	s.mu.RLock()
+	op := s.operations[operationID]
+	s.mu.RUnlock()
+	if op == nil {
+		return nil, fmt.Errorf("operation %s not found", operationID)
+	}
+
+	if !op.state.CompareAndSwap(operationStateRunning, operationStateCancelled) {
+		// Already finished: wait for the result to be published, which
+		// follows the state change immediately.
+		<-op.done
+		return op.info(), nil
+	}
+	op.cancel()
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+	}
+	return op.info(), nil
+}
+
 func (op *operation) info() *ExecuteOperationInfo {
 	select {
 	case <-op.done:
@@ -84,6 +132,9 @@ func (op *operation) info() *ExecuteOperationInfo {
 		status = executeOperationError
 		errText = op.err.Error()
 	}
+	if errors.Is(op.err, errOperationCancelled) {
+		status = executeOperationCancelled
+	}
 	return &ExecuteOperationInfo{
 		OperationID: op.id,
 		SessionID:   op.sessionID,
@@ -135,7 +186,7 @@ func (g *Gateway) executeStepsWithOperation(ctx context.Context, sessionID strin
 func (g *Gateway) getOrStartOperation(sessionID, operationID, requestHash string, workFn func(context.Context) (any, error)) (*operation, bool, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, false, fmt.Errorf("session %s not found", sessionID)
+		return nil, false, &SessionNotFoundError{SessionID: sessionID}
 	}
 	now := time.Now()
 
@@ -150,6 +201,7 @@ func (g *Gateway) getOrStartOperation(sessionID, operationID, requestHash string
 		}
 		return op, false, nil
 	}
+	bgCtx, cancel := context.WithCancel(context.Background())
 	op := &operation{
 		id:          operationID,
 		sessionID:   sessionID,
@@ -157,14 +209,18 @@ func (g *Gateway) getOrStartOperation(sessionID, operationID, requestHash string
 		createdAt:   now,
 		startedAt:   now,
 		done:        make(chan struct{}),
+		cancel:      cancel,
 	}
 	s.operations[operationID] = op
 	s.mu.Unlock()
 
 	go func() {
 		defer close(op.done)
-		bgCtx := context.Background()
+		defer cancel()
 		result, err := workFn(bgCtx)
+		if !op.state.CompareAndSwap(operationStateRunning, operationStateFinished) {
+			err = errOperationCancelled
+		}
 		finished := time.Now()
 		op.result = result
 		if result != nil {
@@ -174,7 +230,10 @@ func (g *Gateway) getOrStartOperation(sessionID, operationID, requestHash string
 		op.finishedAt = &finished
 		if g.metrics != nil {
 			mResult := "success"
-			if err != nil {
+			switch {
+			case errors.Is(err, errOperationCancelled):
+				mResult = "cancelled"
+			case err != nil:
 				mResult = "error"
 			}
 			g.metrics.IncrementExecuteOperationResult(mResult)
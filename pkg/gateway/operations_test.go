@@ -241,3 +241,74 @@ func (a *operationRuntimeAllocator) Touch(ctx context.Context, allocation Runtim
 func (a *operationRuntimeAllocator) DiagnosticStats() map[string]AllocatorPoolStats {
 	return nil
 }
+
+func TestCancelOperationStopsRunningSteps(t *testing.T) {
+	store := newTestSessionStore("gw-op-cancel")
+	sessionID := "gw-op-cancel"
+
+	started := make(chan struct{}, 1)
+	var executeCalls atomic.Int32
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			executeCalls.Add(1)
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, GatewayConfig{}, store)
+
+	_, err := gw.ExecuteSteps(context.Background(), sessionID, ExecuteRequest{
+		OperationID: "op-cancel",
+		Steps: []StepRequest{
+			{Name: "sleep", Command: []string{"sleep", "600"}},
+			{Name: "after", Command: []string{"true"}},
+		},
+	})
+	var pending *OperationPending
+	if !isOperationPending(err, &pending) {
+		t.Fatalf("expected OperationPending, got: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info, err := gw.CancelOperation(ctx, sessionID, "op-cancel")
+	if err != nil {
+		t.Fatalf("CancelOperation returned error: %v", err)
+	}
+	if info.Status != executeOperationCancelled {
+		t.Fatalf("status = %q, want %q", info.Status, executeOperationCancelled)
+	}
+	if got := executeCalls.Load(); got != 1 {
+		t.Fatalf("executor execute calls = %d, want 1", got)
+	}
+
+	if _, err := gw.CancelOperation(ctx, sessionID, "op-missing"); err == nil {
+		t.Fatal("CancelOperation of an unknown operation succeeded")
+	}
+}
+
+func TestCancelOperationLeavesFinishedResult(t *testing.T) {
+	store := newTestSessionStore("gw-op-finished")
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	op, _, err := gw.getOrStartOperation("gw-op-finished", "op-done", "hash", func(context.Context) (any, error) {
+		return &ExecuteResponse{}, nil
+	})
+	if err != nil {
+		t.Fatalf("getOrStartOperation returned error: %v", err)
+	}
+	<-op.done
+
+	info, err := gw.CancelOperation(context.Background(), "gw-op-finished", "op-done")
+	if err != nil {
+		t.Fatalf("CancelOperation returned error: %v", err)
+	}
+	if info.Status != executeOperationDone {
+		t.Fatalf("status = %q, want %q", info.Status, executeOperationDone)
+	}
+	if got := op.state.Load(); got != operationStateFinished {
+		t.Fatalf("state = %d, want finished", got)
+	}
+}
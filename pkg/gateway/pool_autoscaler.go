@@ -42,6 +42,7 @@ func (g *Gateway) poolAutoscaleLoop() {
 
 	if err := g.reconcilePoolAutoscaling(context.Background()); err != nil {
 		log.Printf("pool autoscaler reconcile failed: %v", err)
+		g.recordReconcileRequeue(reconcilerPoolAutoscaler, requeueReasonError)
 	}
 
 	interval := g.gwConfig.PoolAutoscalerInterval
@@ -58,6 +59,7 @@ func (g *Gateway) poolAutoscaleLoop() {
 		case <-ticker.C:
 			if err := g.reconcilePoolAutoscaling(context.Background()); err != nil {
 				log.Printf("pool autoscaler reconcile failed: %v", err)
+				g.recordReconcileRequeue(reconcilerPoolAutoscaler, requeueReasonError)
 			}
 		}
 	}
@@ -79,6 +81,7 @@ func (g *Gateway) reconcilePoolAutoscaling(ctx context.Context) error {
 	for i := range pools.Items {
 		pool := &pools.Items[i]
 		if poolAutoscalingDisabled(pool) {
+			g.recordReconcileRequeue(reconcilerPoolAutoscaler, requeueReasonAutoscaleDisabled)
 			continue
 		}
 
@@ -87,6 +90,7 @@ func (g *Gateway) reconcilePoolAutoscaling(ctx context.Context) error {
 		target := g.poolAutoscaleTarget(queuedRequests)
 		current := desiredSandboxWarmPoolReplicas(pool)
 		if target == current {
+			g.recordReconcileRequeue(reconcilerPoolAutoscaler, requeueReasonAtTarget)
 			continue
 		}
 
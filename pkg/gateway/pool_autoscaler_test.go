@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -118,3 +119,28 @@ func TestReconcilePoolAutoscalingSkipsDisabledPool(t *testing.T) {
 		t.Fatalf("Replicas = %v, want unchanged 2", got.Spec.Replicas)
 	}
 }
+
+func TestReconcilePoolAutoscalingRecordsRequeueReasons(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	atTarget := testSandboxWarmPool("code", "default", "code-template", 1, 1, "code")
+	disabled := testSandboxWarmPool("frozen", "default", "frozen-template", 2, 2, "frozen")
+	disabled.Annotations[scheduling.PoolAutoscaleAnnotation] = "disabled"
+	metrics := &recordingMetricsCollector{}
+	gw := &Gateway{
+		k8sClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(atTarget, disabled).Build(),
+		metrics:   metrics,
+		gwConfig: GatewayConfig{
+			PoolAutoscalerBuffer:      1,
+			PoolAutoscalerMaxReplicas: 5,
+		},
+	}
+
+	if err := gw.reconcilePoolAutoscaling(context.Background()); err != nil {
+		t.Fatalf("reconcilePoolAutoscaling returned error: %v", err)
+	}
+
+	want := []string{"pool_autoscaler/at_target", "pool_autoscaler/autoscale_disabled"}
+	if !reflect.DeepEqual(metrics.requeues, want) {
+		t.Fatalf("requeues = %v, want %v", metrics.requeues, want)
+	}
+}
@@ -47,6 +47,7 @@ type indexedPool struct {
 	State         string
 	Replicas      int32
 	ReadyReplicas int32
+	MaxAllocated  int32
 	CreatedAt     time.Time
 }
 
@@ -225,7 +226,7 @@ func (idx *poolIndex) ListPools(opts PoolListOptions) []PoolInfo {
 			continue
 		}
 		template := idx.templates[types.NamespacedName{Name: pool.TemplateName, Namespace: pool.Namespace}]
-		pools = append(pools, PoolInfo{
+		info := PoolInfo{
 			Name:              pool.Name,
 			Namespace:         pool.Namespace,
 			Profile:           firstNonEmpty(pool.Profile, template.Profile, defaultPoolProfile),
@@ -233,9 +234,12 @@ func (idx *poolIndex) ListPools(opts PoolListOptions) []PoolInfo {
 			Replicas:          pool.Replicas,
 			ReadyReplicas:     pool.ReadyReplicas,
 			AllocatedReplicas: allocated,
+			MaxAllocated:      pool.MaxAllocated,
 			State:             firstNonEmpty(pool.State, labels.PoolStateRunning),
 			CreatedAt:         pool.CreatedAt,
-		})
+		}
+		applyAllocationCappedCondition(&info)
+		pools = append(pools, info)
 	}
 	sort.SliceStable(pools, func(i, j int) bool {
 		if pools[i].CreatedAt.Equal(pools[j].CreatedAt) {
@@ -349,6 +353,7 @@ func (idx *poolIndex) snapshotLocked(key types.NamespacedName, pool indexedPool)
 		DesiredReplicas:   pool.Replicas,
 		ReadyReplicas:     pool.ReadyReplicas,
 		AllocatedReplicas: idx.claimCounts[key],
+		MaxAllocated:      pool.MaxAllocated,
 	}
 }
 
@@ -529,6 +534,7 @@ func indexedPoolFromObject(pool *extensionsv1beta1.SandboxWarmPool) indexedPool
 		State:         firstNonEmpty(pool.Annotations[labels.PoolStateAnnotation], pool.Labels[labels.PoolStateLabelKey], labels.PoolStateRunning),
 		Replicas:      desiredSandboxWarmPoolReplicas(pool),
 		ReadyReplicas: pool.Status.ReadyReplicas,
+		MaxAllocated:  poolMaxAllocated(pool.ObjectMeta),
 		CreatedAt:     pool.CreationTimestamp.Time,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -58,6 +59,19 @@ func (g *Gateway) CreatePool(ctx context.Context, req CreatePoolRequest) error {
 	if err := validatePrivateContainers(req.PrivateContainers); err != nil {
 		return err
 	}
+	if err := validateWorkspaceVolume(req.WorkspaceVolume, g.gwConfig.WorkspaceHostPathPrefixes); err != nil {
+		return err
+	}
+	if err := validateExecutorProbes(req.ExecutorProbes); err != nil {
+		return err
+	}
+	if req.MaxAllocated != nil && *req.MaxAllocated < 0 {
+		return fmt.Errorf("maxAllocated must not be negative")
+	}
+	locality, err := decodeImageLocality(req.ImageLocality)
+	if err != nil {
+		return err
+	}
 
 	templateName := sandboxTemplateName(req.Name)
 	existingPool := &extensionsv1beta1.SandboxWarmPool{}
@@ -87,6 +101,9 @@ func (g *Gateway) CreatePool(ctx context.Context, req CreatePoolRequest) error {
 		applyManagedPoolMetadata(&templateMeta, true)
 		applyManagedPoolMetadata(&poolMeta, true)
 	}
+	if req.MaxAllocated != nil {
+		applyPoolMaxAllocatedMetadata(&poolMeta, *req.MaxAllocated)
+	}
 	if replicas > 0 {
 		applyPoolStateMetadata(&poolMeta, labels.PoolStateRunning)
 	} else {
@@ -94,7 +111,11 @@ func (g *Gateway) CreatePool(ctx context.Context, req CreatePoolRequest) error {
 		applyPoolLastUsedMetadata(&poolMeta, time.Now())
 		ensureObjectAnnotations(&poolMeta)[scheduling.PoolAutoscaleAnnotation] = "false"
 	}
-	imageLocalityEnabled := g.gwConfig.ImageLocalityEnabled || hasJSONPayload(req.ImageLocality)
+	spread := locality.Mode == ImageLocalityModeSpread
+	imageLocalityEnabled := (g.gwConfig.ImageLocalityEnabled || hasJSONPayload(req.ImageLocality)) && !spread
+	if g.metrics != nil {
+		g.metrics.IncrementImageLocalityDecision(imageLocalityDecision(imageLocalityEnabled, spread, hasJSONPayload(req.ImageLocality)))
+	}
 	if imageLocalityEnabled {
 		ensureObjectAnnotations(&templateMeta)[scheduling.ImageLocalityAnnotation] = scheduling.ImageLocalityEnabledValue
 		ensureObjectAnnotations(&poolMeta)[scheduling.ImageLocalityAnnotation] = scheduling.ImageLocalityEnabledValue
@@ -117,10 +138,15 @@ func (g *Gateway) CreatePool(ctx context.Context, req CreatePoolRequest) error {
 			Service:                    boolPtr(false),
 			PodTemplate: sandboxv1beta1.PodTemplate{
 				ObjectMeta: podMetadata,
-				Spec:       g.sandboxPodSpec(req.Image, *resources, req.PrivateContainers),
+				Spec:       g.sandboxPodSpec(req.Image, *resources, req.PrivateContainers, req.ExecutorProbes, workspaceMountPath(req.WorkspaceVolume)),
 			},
 		},
 	}
+	if spread {
+		template.Spec.PodTemplate.Spec.Affinity = poolSpreadAffinity(req.Name)
+	}
+	applyWorkspaceVolume(&template.Spec.PodTemplate.Spec, req.WorkspaceVolume)
+	template.Spec.VolumeClaimTemplates = workspaceVolumeClaimTemplates(req.WorkspaceVolume)
 	if req.AllowInternet != nil && !*req.AllowInternet {
 		template.Spec.NetworkPolicyManagement = extensionsv1beta1.NetworkPolicyManagementManaged
 		template.Spec.NetworkPolicy = denyInternetEgressPolicy(g.egressAllowCIDRs())
@@ -245,9 +271,129 @@ func (g *Gateway) GetPool(ctx context.Context, name, namespace string) (*PoolInf
 	}
 
 	info := g.poolInfoFromSandboxWarmPool(ctx, pool)
+	pods := listPoolPods(ctx, g.k8sClient, pool.Name, pool.Namespace)
+	info.NodeDistribution = poolNodeDistribution(pods)
+	info.PodFailures = poolPodFailures(pods)
 	return &info, nil
 }
 
+// poolSpreadAffinity prefers scheduling a pool's pods away from each other,
+// first across nodes and then across zones. It is a preference only, so a
+// pool larger than the cluster still fills up.
+func poolSpreadAffinity(poolName string) *corev1.Affinity {
+	selector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{sandboxv1beta1.SandboxWarmPoolLabel: sandboxcontrollers.NameHash(poolName)},
+	}
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight:          100,
+					PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: corev1.LabelHostname},
+				},
+				{
+					Weight:          50,
+					PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: corev1.LabelTopologyZone},
+				},
+			},
+		},
+	}
+}
+
+// listPoolPods returns the pool's pods that are not being deleted. Lookup
+// failures yield nil; callers only derive diagnostics from the result.
+func listPoolPods(ctx context.Context, c client.Client, poolRef, namespace string) []corev1.Pod {
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods,
+		client.InNamespace(namespace),
+		client.MatchingLabels{sandboxv1beta1.SandboxWarmPoolLabel: sandboxcontrollers.NameHash(poolRef)},
+	); err != nil {
+		return nil
+	}
+	live := pods.Items[:0]
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			live = append(live, pod)
+		}
+	}
+	return live
+}
+
+// poolNodeDistribution counts the pool's scheduled pods per node, which
+// shows whether image locality is concentrating the pool as intended.
+func poolNodeDistribution(pods []corev1.Pod) map[string]int32 {
+	var distribution map[string]int32
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		if distribution == nil {
+			distribution = make(map[string]int32)
+		}
+		distribution[pod.Spec.NodeName]++
+	}
+	return distribution
+}
+
+// maxPoolPodFailures bounds PoolInfo.PodFailures so a pool of crash-looping
+// pods does not turn the pool response into a pod dump.
+const maxPoolPodFailures = 10
+
+// poolPodFailures collects the unrecoverable pod and container states among
+// pods, newest first, so operators see each distinct failure (an image pull
+// on one node, a crash loop on another) rather than a single summary.
+func poolPodFailures(pods []corev1.Pod) []PodFailure {
+	var failures []PodFailure
+	for i := range pods {
+		pod := &pods[i]
+		at := pod.CreationTimestamp.Time
+		if pod.Status.Phase == corev1.PodFailed {
+			failures = append(failures, PodFailure{
+				Pod:     pod.Name,
+				Node:    pod.Spec.NodeName,
+				Reason:  pod.Status.Reason,
+				Message: truncateDiagnostic(pod.Status.Message),
+				Time:    at,
+			})
+			continue
+		}
+		statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			failure := PodFailure{Pod: pod.Name, Node: pod.Spec.NodeName, Container: cs.Name, Time: at}
+			last := cs.LastTerminationState.Terminated
+			if last != nil && !last.FinishedAt.IsZero() {
+				failure.Time = last.FinishedAt.Time
+			}
+			switch {
+			case cs.State.Waiting != nil && unrecoverableWaitingReason(cs.State.Waiting.Reason):
+				failure.Reason = cs.State.Waiting.Reason
+				failure.Message = truncateDiagnostic(cs.State.Waiting.Message)
+			case last != nil && unrecoverableTerminatedReason(last.Reason):
+				failure.Reason = last.Reason
+				failure.Message = truncateDiagnostic(last.Message)
+			default:
+				continue
+			}
+			failures = append(failures, failure)
+		}
+	}
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].Time.After(failures[j].Time) })
+	if len(failures) > maxPoolPodFailures {
+		failures = failures[:maxPoolPodFailures]
+	}
+	return failures
+}
+
+func truncateDiagnostic(msg string) string {
+	if len(msg) > maxDiagnosticMessageSize {
+		return msg[:maxDiagnosticMessageSize] + "..."
+	}
+	return msg
+}
+
 // ScalePool updates the replica count of a SandboxWarmPool.
 func (g *Gateway) ScalePool(ctx context.Context, name string, req ScalePoolRequest) (*PoolInfo, error) {
 	ns, err := g.resolveNamespace(req.Namespace)
@@ -263,6 +409,12 @@ func (g *Gateway) ScalePool(ctx context.Context, name string, req ScalePoolReque
 	if req.Resources != nil {
 		return nil, fmt.Errorf("updating resources requires updating the SandboxTemplate and is not supported by ScalePool")
 	}
+	if req.MaxAllocated != nil {
+		if *req.MaxAllocated < 0 {
+			return nil, fmt.Errorf("maxAllocated must not be negative")
+		}
+		applyPoolMaxAllocatedMetadata(&pool.ObjectMeta, *req.MaxAllocated)
+	}
 	pool.Spec.Replicas = int32Ptr(req.Replicas)
 	if req.Replicas > 0 {
 		applyPoolStateMetadata(&pool.ObjectMeta, labels.PoolStateRunning)
@@ -443,19 +595,12 @@ func (g *Gateway) deleteSessionsForPool(ctx context.Context, poolName, namespace
 	return nil
 }
 
-func isSessionNotFoundError(err error, sessionID string) bool {
-	if err == nil {
-		return false
-	}
-	return strings.Contains(err.Error(), "session "+sessionID+" not found")
-}
-
 // checkPoolHealth returns an error if the SandboxWarmPool cannot be found.
 func (g *Gateway) checkPoolHealth(ctx context.Context, poolRef, namespace string) error {
 	pool := &extensionsv1beta1.SandboxWarmPool{}
 	if err := g.k8sClient.Get(ctx, types.NamespacedName{Name: poolRef, Namespace: namespace}, pool); err != nil {
 		if errors.IsNotFound(err) {
-			return fmt.Errorf("pool %q not found in namespace %q", poolRef, namespace)
+			return &PoolNotFoundError{Name: poolRef, Namespace: namespace}
 		}
 		return fmt.Errorf("get pool: %w", err)
 	}
@@ -581,10 +726,7 @@ func describePodContainerIssues(pod *corev1.Pod) string {
 		case cs.State.Waiting != nil && cs.State.Waiting.Reason != "":
 			detail := cs.Name + " " + cs.State.Waiting.Reason
 			if msg := cs.State.Waiting.Message; msg != "" {
-				if len(msg) > maxDiagnosticMessageSize {
-					msg = msg[:maxDiagnosticMessageSize] + "..."
-				}
-				detail += ": " + msg
+				detail += ": " + truncateDiagnostic(msg)
 			}
 			parts = append(parts, detail)
 		case cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0:
@@ -700,6 +842,25 @@ func poolListItemStopped(pool *extensionsv1beta1.SandboxWarmPool, allocated int3
 	return desiredSandboxWarmPoolReplicas(pool) == 0 && pool.Status.ReadyReplicas == 0
 }
 
+// applyAllocationCappedCondition reports an AllocationCapped condition on
+// pools that carry a MaxAllocated cap.
+func applyAllocationCappedCondition(info *PoolInfo) {
+	if info.MaxAllocated <= 0 {
+		return
+	}
+	condition := PoolCondition{
+		Type:    "AllocationCapped",
+		Status:  string(metav1.ConditionFalse),
+		Reason:  "BelowCap",
+		Message: fmt.Sprintf("%d of %d allocations in use", info.AllocatedReplicas, info.MaxAllocated),
+	}
+	if info.AllocatedReplicas >= info.MaxAllocated {
+		condition.Status = string(metav1.ConditionTrue)
+		condition.Reason = "CapReached"
+	}
+	info.Conditions = append(info.Conditions, condition)
+}
+
 func (g *Gateway) poolInfoFromSandboxWarmPool(ctx context.Context, pool *extensionsv1beta1.SandboxWarmPool) PoolInfo {
 	info := PoolInfo{
 		Name:          pool.Name,
@@ -707,6 +868,7 @@ func (g *Gateway) poolInfoFromSandboxWarmPool(ctx context.Context, pool *extensi
 		Profile:       firstNonEmpty(profileFromObjectMeta(pool.ObjectMeta), defaultPoolProfile),
 		Replicas:      desiredSandboxWarmPoolReplicas(pool),
 		ReadyReplicas: pool.Status.ReadyReplicas,
+		MaxAllocated:  poolMaxAllocated(pool.ObjectMeta),
 		State:         firstNonEmpty(pool.Annotations[labels.PoolStateAnnotation], labels.PoolStateRunning),
 		CreatedAt:     pool.CreationTimestamp.Time,
 	}
@@ -718,11 +880,13 @@ func (g *Gateway) poolInfoFromSandboxWarmPool(ctx context.Context, pool *extensi
 	if readModel, ok := g.syncedPoolReadModel(); ok {
 		if snapshot, found := readModel.SnapshotPool(pool.Namespace, pool.Name); found {
 			info.AllocatedReplicas = snapshot.AllocatedReplicas
+			applyAllocationCappedCondition(&info)
 			return info
 		}
 	}
 	if allocated, err := g.claimCountForPool(ctx, pool.Namespace, pool.Name); err == nil {
 		info.AllocatedReplicas = allocated
 	}
+	applyAllocationCappedCondition(&info)
 	return info
 }
@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	sandboxv1beta1 "sigs.k8s.io/agent-sandbox/api/v1beta1"
+	sandboxcontrollers "sigs.k8s.io/agent-sandbox/controllers"
+	extensionsv1beta1 "sigs.k8s.io/agent-sandbox/extensions/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Lincyaw/agent-env/pkg/labels"
+)
+
+func TestGetPoolReportsNodeDistribution(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "arl", "code-template", 3, 3, "code")
+	template := testSandboxTemplate("code-template", "arl", "python:3.12", "code")
+	now := metav1.NewTime(time.Now())
+	poolPod := func(name, node string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "arl",
+				Labels:    map[string]string{sandboxv1beta1.SandboxWarmPoolLabel: sandboxcontrollers.NameHash("code")},
+			},
+			Spec: corev1.PodSpec{NodeName: node},
+		}
+	}
+	terminating := poolPod("pod-terminating", "node-b")
+	terminating.DeletionTimestamp = &now
+	terminating.Finalizers = []string{"test/hold"}
+	other := poolPod("pod-other", "node-c")
+	other.Labels[sandboxv1beta1.SandboxWarmPoolLabel] = sandboxcontrollers.NameHash("other")
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pool, template,
+		poolPod("pod-1", "node-a"),
+		poolPod("pod-2", "node-a"),
+		poolPod("pod-3", "node-b"),
+		poolPod("pod-pending", ""),
+		terminating,
+		other,
+	).Build()
+	gw := New(k8sClient, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{Namespace: "arl"}, NewMemoryStore())
+
+	info, err := gw.GetPool(context.Background(), "code", "arl")
+	if err != nil {
+		t.Fatalf("GetPool returned error: %v", err)
+	}
+	want := map[string]int32{"node-a": 2, "node-b": 1}
+	if !reflect.DeepEqual(info.NodeDistribution, want) {
+		t.Fatalf("NodeDistribution = %#v, want %#v", info.NodeDistribution, want)
+	}
+}
+
+func TestPoolPodFailuresListsDistinctFailuresNewestFirst(t *testing.T) {
+	base := time.Now()
+	failing := func(name, node string, age time.Duration, cs corev1.ContainerStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(base.Add(-age))},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{cs}},
+		}
+	}
+	pods := []corev1.Pod{
+		failing("pod-pull", "node-a", 2*time.Minute, corev1.ContainerStatus{
+			Name:  "executor",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "pull access denied"}},
+		}),
+		failing("pod-crash", "node-b", 5*time.Minute, corev1.ContainerStatus{
+			Name:                 "executor",
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", FinishedAt: metav1.NewTime(base)}},
+		}),
+		failing("pod-ok", "node-a", time.Minute, corev1.ContainerStatus{
+			Name:  "executor",
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}),
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-evicted", CreationTimestamp: metav1.NewTime(base.Add(-time.Hour))},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+		},
+	}
+
+	got := poolPodFailures(pods)
+	want := []string{"pod-crash/CrashLoopBackOff", "pod-pull/ImagePullBackOff", "pod-evicted/Evicted"}
+	if len(got) != len(want) {
+		t.Fatalf("failures = %#v, want %v", got, want)
+	}
+	for i, f := range got {
+		if f.Pod+"/"+f.Reason != want[i] {
+			t.Fatalf("failures[%d] = %s/%s, want %s", i, f.Pod, f.Reason, want[i])
+		}
+	}
+	if got[1].Node != "node-a" || got[1].Message != "pull access denied" {
+		t.Fatalf("image pull failure = %#v", got[1])
+	}
+
+	for i := 0; i < 2*maxPoolPodFailures; i++ {
+		pods = append(pods, pods[0])
+	}
+	if got := poolPodFailures(pods); len(got) != maxPoolPodFailures {
+		t.Fatalf("failures = %d, want capped at %d", len(got), maxPoolPodFailures)
+	}
+}
+
+func TestGetPoolReportsAllocationCappedCondition(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "arl", "code-template", 3, 3, "code")
+	applyPoolMaxAllocatedMetadata(&pool.ObjectMeta, 1)
+	template := testSandboxTemplate("code-template", "arl", "python:3.12", "code")
+	claim := &extensionsv1beta1.SandboxClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "claim-1",
+			Namespace: "arl",
+			Labels:    map[string]string{labels.PoolLabelKey: "code"},
+		},
+		Spec: extensionsv1beta1.SandboxClaimSpec{
+			WarmPoolRef: extensionsv1beta1.SandboxWarmPoolRef{Name: "code"},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool, template, claim).Build()
+	gw := New(k8sClient, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{Namespace: "arl"}, NewMemoryStore())
+
+	info, err := gw.GetPool(context.Background(), "code", "arl")
+	if err != nil {
+		t.Fatalf("GetPool returned error: %v", err)
+	}
+	if info.MaxAllocated != 1 || info.AllocatedReplicas != 1 {
+		t.Fatalf("pool = %#v, want 1 of 1 allocated", info)
+	}
+	if len(info.Conditions) != 1 || info.Conditions[0].Type != "AllocationCapped" || info.Conditions[0].Status != string(metav1.ConditionTrue) {
+		t.Fatalf("conditions = %#v, want AllocationCapped=True", info.Conditions)
+	}
+}
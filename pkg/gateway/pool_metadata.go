@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -54,6 +55,26 @@ func applyPoolLastUsedMetadata(meta *metav1.ObjectMeta, at time.Time) {
 	meta.Annotations[labels.PoolLastUsedAnnotation] = at.UTC().Format(time.RFC3339)
 }
 
+// applyPoolMaxAllocatedMetadata records the pool's allocation cap; a
+// non-positive cap removes it.
+func applyPoolMaxAllocatedMetadata(meta *metav1.ObjectMeta, maxAllocated int32) {
+	if maxAllocated <= 0 {
+		delete(meta.Annotations, labels.PoolMaxAllocatedAnnotation)
+		return
+	}
+	ensureObjectAnnotations(meta)[labels.PoolMaxAllocatedAnnotation] = strconv.FormatInt(int64(maxAllocated), 10)
+}
+
+// poolMaxAllocated returns the pool's allocation cap, or 0 when the pool is
+// uncapped or the annotation is malformed.
+func poolMaxAllocated(meta metav1.ObjectMeta) int32 {
+	v, err := strconv.ParseInt(strings.TrimSpace(meta.Annotations[labels.PoolMaxAllocatedAnnotation]), 10, 32)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return int32(v)
+}
+
 func setLabelIfValid(meta *metav1.ObjectMeta, key, value string) {
 	if !validLabelValue.MatchString(value) {
 		if meta.Labels != nil {
@@ -65,6 +65,13 @@ type PoolSnapshot struct {
 	DesiredReplicas   int32
 	ReadyReplicas     int32
 	AllocatedReplicas int32
+	MaxAllocated      int32
+}
+
+// AllocationCapped reports whether the pool already has as many allocated
+// sessions as its MaxAllocated cap allows.
+func (p PoolSnapshot) AllocationCapped() bool {
+	return p.MaxAllocated > 0 && p.AllocatedReplicas >= p.MaxAllocated
 }
 
 func (p PoolSnapshot) WarmAvailable() int32 {
@@ -114,7 +121,7 @@ func (DefaultPoolSelector) SelectPool(_ context.Context, intent ResourceIntent,
 				}, nil
 			}
 		}
-		return PoolSelection{}, fmt.Errorf("pool %q not found in namespace %q", intent.PinnedPoolName, scope.Namespace)
+		return PoolSelection{}, &PoolNotFoundError{Name: intent.PinnedPoolName, Namespace: scope.Namespace}
 	}
 
 	var candidates []PoolSnapshot
@@ -161,6 +168,10 @@ func (DefaultPoolSelector) SelectPool(_ context.Context, intent ResourceIntent,
 	}, nil
 }
 
+// admissionReasonAllocationCapped is the admission reason for a pool whose
+// MaxAllocated cap is reached.
+const admissionReasonAllocationCapped = "allocation_capped"
+
 // AdmissionDecision captures the bounded decision made before creating a Claim.
 type AdmissionDecision struct {
 	Admitted      bool
@@ -184,6 +195,13 @@ func NewDefaultAdmissionController() DefaultAdmissionController {
 
 func (a DefaultAdmissionController) Admit(_ context.Context, intent ResourceIntent, selection PoolSelection) (AdmissionDecision, error) {
 	warmAvailable := selection.Pool.WarmAvailable()
+	if selection.Pool.AllocationCapped() {
+		return AdmissionDecision{
+			Admitted:      false,
+			Reason:        admissionReasonAllocationCapped,
+			WarmAvailable: warmAvailable,
+		}, ErrPoolAtCapacity
+	}
 	if intent.ClaimEnv {
 		return AdmissionDecision{
 			Admitted:      true,
@@ -269,6 +287,13 @@ func (g *Gateway) planSessionAllocation(ctx context.Context, intent ResourceInte
 	}
 
 	selection, decision, err := g.tryPlanSessionAllocation(ctx, intent)
+	if err == nil && g.gwConfig.SandboxFairBinding && g.hasAdmissionWaiters(selection) {
+		// Capacity is available, but earlier requests are already queued
+		// for this pool; join the back of the queue instead of jumping it.
+		decision.Admitted = false
+		decision.Reason = "queued_behind_earlier_requests"
+		err = fmt.Errorf("%w: %s", ErrPoolAtCapacity, decision.Reason)
+	}
 	if err == nil || ctx.Err() != nil {
 		return selection, decision, err
 	}
@@ -286,9 +311,18 @@ func (g *Gateway) waitForWarmCapacity(ctx context.Context, intent ResourceIntent
 	queueKey := types.NamespacedName{Name: selection.PoolName, Namespace: selection.Namespace}
 	g.incrementAdmissionQueue(queueKey)
 	defer g.decrementAdmissionQueue(queueKey)
+	fair := g.gwConfig.SandboxFairBinding
+	var ticket uint64
+	if fair {
+		ticket = g.enqueueAdmissionWaiter(queueKey)
+		defer g.dequeueAdmissionWaiter(queueKey, ticket)
+	}
+	g.recordPoolNoIdle(queueKey, time.Now())
 
-	if err := g.scalePoolForQueuedDemand(ctx, queueKey); err != nil {
-		return selection, decision, err
+	if decision.Reason != admissionReasonAllocationCapped {
+		if err := g.scalePoolForQueuedDemand(ctx, queueKey); err != nil {
+			return selection, decision, err
+		}
 	}
 
 	poll := g.gwConfig.AdmissionQueuePollInterval
@@ -312,6 +346,9 @@ func (g *Gateway) waitForWarmCapacity(ctx context.Context, intent ResourceIntent
 			if err := ctx.Err(); err != nil {
 				return selection, decision, waitErr(err)
 			}
+			if fair && !g.isAdmissionHead(queueKey, ticket) {
+				continue
+			}
 			nextSelection, nextDecision, nextErr := g.tryPlanSessionAllocation(ctx, intent)
 			if nextErr == nil {
 				return nextSelection, nextDecision, nil
@@ -320,6 +357,11 @@ func (g *Gateway) waitForWarmCapacity(ctx context.Context, intent ResourceIntent
 			if !errors.Is(nextErr, ErrPoolAtCapacity) {
 				return nextSelection, nextDecision, nextErr
 			}
+			// A capped pool frees capacity only when sessions end; growing
+			// the warm pool would not let the waiter in.
+			if nextDecision.Reason == admissionReasonAllocationCapped {
+				continue
+			}
 			if err := g.scalePoolForQueuedDemand(ctx, queueKey); err != nil {
 				return nextSelection, nextDecision, err
 			}
@@ -395,6 +437,53 @@ func (g *Gateway) decrementAdmissionQueue(key types.NamespacedName) {
 	g.admissionQueueDepth[key] = next
 }
 
+// enqueueAdmissionWaiter appends a waiter to the FIFO queue of key and
+// returns its ticket. Used only when SandboxFairBinding is enabled.
+func (g *Gateway) enqueueAdmissionWaiter(key types.NamespacedName) uint64 {
+	g.admissionQueueMu.Lock()
+	defer g.admissionQueueMu.Unlock()
+	if g.admissionWaiters == nil {
+		g.admissionWaiters = make(map[types.NamespacedName][]uint64)
+	}
+	g.admissionTicketSeq++
+	ticket := g.admissionTicketSeq
+	g.admissionWaiters[key] = append(g.admissionWaiters[key], ticket)
+	return ticket
+}
+
+func (g *Gateway) dequeueAdmissionWaiter(key types.NamespacedName, ticket uint64) {
+	g.admissionQueueMu.Lock()
+	defer g.admissionQueueMu.Unlock()
+	waiters := g.admissionWaiters[key]
+	for i, t := range waiters {
+		if t == ticket {
+			waiters = append(waiters[:i:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(g.admissionWaiters, key)
+		return
+	}
+	g.admissionWaiters[key] = waiters
+}
+
+// isAdmissionHead reports whether ticket is the oldest waiter for key; only
+// the head of a fair queue may try to take warm capacity.
+func (g *Gateway) isAdmissionHead(key types.NamespacedName, ticket uint64) bool {
+	g.admissionQueueMu.Lock()
+	defer g.admissionQueueMu.Unlock()
+	waiters := g.admissionWaiters[key]
+	return len(waiters) > 0 && waiters[0] == ticket
+}
+
+func (g *Gateway) hasAdmissionWaiters(selection PoolSelection) bool {
+	key := types.NamespacedName{Name: selection.PoolName, Namespace: selection.Namespace}
+	g.admissionQueueMu.Lock()
+	defer g.admissionQueueMu.Unlock()
+	return len(g.admissionWaiters[key]) > 0
+}
+
 func (g *Gateway) admissionQueueSnapshot() map[types.NamespacedName]int32 {
 	g.admissionQueueMu.Lock()
 	defer g.admissionQueueMu.Unlock()
@@ -564,6 +653,7 @@ func poolSnapshotFromObjects(pool *extensionsv1beta1.SandboxWarmPool, template *
 		DesiredReplicas:   desiredSandboxWarmPoolReplicas(pool),
 		ReadyReplicas:     pool.Status.ReadyReplicas,
 		AllocatedReplicas: allocated,
+		MaxAllocated:      poolMaxAllocated(pool.ObjectMeta),
 	}
 }
 
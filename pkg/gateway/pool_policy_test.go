@@ -10,6 +10,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	sandboxv1beta1 "sigs.k8s.io/agent-sandbox/api/v1beta1"
 	extensionsv1beta1 "sigs.k8s.io/agent-sandbox/extensions/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -85,6 +86,26 @@ func TestDefaultAdmissionControllerRequiresWarmCapacityByDefault(t *testing.T) {
 	}
 }
 
+func TestDefaultAdmissionControllerQueuesAllocationCappedPool(t *testing.T) {
+	admission := NewDefaultAdmissionController()
+
+	decision, err := admission.Admit(context.Background(), ResourceIntent{ClaimEnv: true}, PoolSelection{
+		Pool: PoolSnapshot{ReadyReplicas: 3, AllocatedReplicas: 2, MaxAllocated: 2},
+	})
+	if !errors.Is(err, ErrPoolAtCapacity) {
+		t.Fatalf("capped Admit error = %v, want ErrPoolAtCapacity", err)
+	}
+	if decision.Admitted || decision.Reason != admissionReasonAllocationCapped {
+		t.Fatalf("capped decision = %#v, want allocation_capped rejection", decision)
+	}
+
+	if _, err := admission.Admit(context.Background(), ResourceIntent{}, PoolSelection{
+		Pool: PoolSnapshot{ReadyReplicas: 3, AllocatedReplicas: 1, MaxAllocated: 2},
+	}); err != nil {
+		t.Fatalf("below-cap Admit returned error: %v", err)
+	}
+}
+
 func TestSnapshotPoolsIncludesProfileImageAndClaims(t *testing.T) {
 	scheme := newGatewayTestScheme(t)
 	pool := testSandboxWarmPool("code", "default", "code-template", 3, 2, "code")
@@ -491,3 +512,38 @@ func (a *recordingRuntimeAllocator) Touch(ctx context.Context, allocation Runtim
 func (a *recordingRuntimeAllocator) DiagnosticStats() map[string]AllocatorPoolStats {
 	return nil
 }
+
+func TestPlanSessionAllocationFairBindingQueuesBehindEarlierWaiters(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "default", "code-template", 1, 1, "code")
+	template := testSandboxTemplate("code-template", "default", "python:3.12", "code")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool, template).Build()
+	gw := New(k8sClient, &recordingRuntimeAllocator{}, nil, nil, nil, GatewayConfig{
+		AdmissionQueuePollInterval: time.Millisecond,
+		SandboxFairBinding:         true,
+	}, NewMemoryStore())
+	intent := ResourceIntent{
+		Scope:             RequestScope{Namespace: "default"},
+		Profile:           "code",
+		AllocationTimeout: 20 * time.Millisecond,
+	}
+
+	key := types.NamespacedName{Name: "code", Namespace: "default"}
+	earlier := gw.enqueueAdmissionWaiter(key)
+	_, _, err := gw.planSessionAllocation(context.Background(), intent)
+	if !errors.Is(err, ErrPoolAtCapacity) {
+		t.Fatalf("planSessionAllocation error = %v, want ErrPoolAtCapacity while an earlier waiter is queued", err)
+	}
+	if !gw.isAdmissionHead(key, earlier) {
+		t.Fatal("earlier waiter lost the head of the queue")
+	}
+
+	gw.dequeueAdmissionWaiter(key, earlier)
+	selection, _, err := gw.planSessionAllocation(context.Background(), intent)
+	if err != nil {
+		t.Fatalf("planSessionAllocation returned error after queue drained: %v", err)
+	}
+	if selection.PoolName != "code" {
+		t.Fatalf("selected pool = %q, want code", selection.PoolName)
+	}
+}
@@ -0,0 +1,181 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// poolDemandWindow bounds how much allocation history the gateway keeps per
+// pool for capacity recommendations.
+const poolDemandWindow = time.Hour
+
+type poolLifetimeSample struct {
+	endedAt  time.Time
+	lifetime time.Duration
+}
+
+type poolDemandStats struct {
+	allocations []time.Time
+	noIdle      []time.Time
+	lifetimes   []poolLifetimeSample
+}
+
+// poolDemandSample is the observed demand for one pool over the window.
+type poolDemandSample struct {
+	Window       time.Duration
+	Allocations  int
+	NoIdleEvents int
+	Lifetimes    int
+	AvgLifetime  time.Duration
+	Replicas     int32
+	Allocated    int32
+}
+
+func (g *Gateway) recordPoolAllocation(key types.NamespacedName, at time.Time) {
+	g.updatePoolDemand(key, at, func(stats *poolDemandStats) {
+		stats.allocations = append(stats.allocations, at)
+	})
+}
+
+func (g *Gateway) recordPoolNoIdle(key types.NamespacedName, at time.Time) {
+	g.updatePoolDemand(key, at, func(stats *poolDemandStats) {
+		stats.noIdle = append(stats.noIdle, at)
+	})
+}
+
+func (g *Gateway) recordPoolSessionLifetime(key types.NamespacedName, endedAt time.Time, lifetime time.Duration) {
+	if lifetime <= 0 {
+		return
+	}
+	g.updatePoolDemand(key, endedAt, func(stats *poolDemandStats) {
+		stats.lifetimes = append(stats.lifetimes, poolLifetimeSample{endedAt: endedAt, lifetime: lifetime})
+	})
+}
+
+func (g *Gateway) updatePoolDemand(key types.NamespacedName, now time.Time, update func(*poolDemandStats)) {
+	if key.Name == "" {
+		return
+	}
+	g.poolDemandMu.Lock()
+	defer g.poolDemandMu.Unlock()
+	if g.poolDemand == nil {
+		g.poolDemand = make(map[types.NamespacedName]*poolDemandStats)
+	}
+	stats := g.poolDemand[key]
+	if stats == nil {
+		stats = &poolDemandStats{}
+		g.poolDemand[key] = stats
+	}
+	update(stats)
+	stats.prune(now.Add(-poolDemandWindow))
+}
+
+func (s *poolDemandStats) prune(cutoff time.Time) {
+	s.allocations = pruneTimes(s.allocations, cutoff)
+	s.noIdle = pruneTimes(s.noIdle, cutoff)
+	i := 0
+	for i < len(s.lifetimes) && s.lifetimes[i].endedAt.Before(cutoff) {
+		i++
+	}
+	s.lifetimes = s.lifetimes[i:]
+}
+
+func pruneTimes(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+func (g *Gateway) poolDemandSnapshot(key types.NamespacedName, now time.Time) poolDemandSample {
+	sample := poolDemandSample{Window: poolDemandWindow}
+	g.poolDemandMu.Lock()
+	defer g.poolDemandMu.Unlock()
+	stats := g.poolDemand[key]
+	if stats == nil {
+		return sample
+	}
+	stats.prune(now.Add(-poolDemandWindow))
+	sample.Allocations = len(stats.allocations)
+	sample.NoIdleEvents = len(stats.noIdle)
+	sample.Lifetimes = len(stats.lifetimes)
+	if len(stats.lifetimes) > 0 {
+		var total time.Duration
+		for _, l := range stats.lifetimes {
+			total += l.lifetime
+		}
+		sample.AvgLifetime = total / time.Duration(len(stats.lifetimes))
+	}
+	return sample
+}
+
+// RecommendPoolReplicas suggests a replica count for a pool from the demand
+// the gateway observed over the last poolDemandWindow.
+func (g *Gateway) RecommendPoolReplicas(ctx context.Context, name, namespace string) (*PoolRecommendation, error) {
+	info, err := g.GetPool(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	sample := g.poolDemandSnapshot(types.NamespacedName{Name: info.Name, Namespace: info.Namespace}, time.Now())
+	sample.Replicas = info.Replicas
+	sample.Allocated = info.AllocatedReplicas
+	rec := computePoolRecommendation(sample)
+	rec.Name = info.Name
+	rec.Namespace = info.Namespace
+	return &rec, nil
+}
+
+// computePoolRecommendation estimates steady-state concurrency with Little's
+// law (allocation rate x average lifetime) and adds square-root headroom,
+// widened by the share of allocations that found no idle sandbox.
+func computePoolRecommendation(sample poolDemandSample) PoolRecommendation {
+	window := sample.Window
+	if window <= 0 {
+		window = poolDemandWindow
+	}
+	rec := PoolRecommendation{
+		CurrentReplicas:           sample.Replicas,
+		WindowSeconds:             int64(window.Seconds()),
+		AllocationsPerMinute:      float64(sample.Allocations) / window.Minutes(),
+		AvgSessionLifetimeSeconds: sample.AvgLifetime.Seconds(),
+		NoIdleEvents:              sample.NoIdleEvents,
+	}
+
+	var concurrency float64
+	switch {
+	case sample.Allocations == 0 && sample.Allocated == 0:
+		rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("no allocations observed in the last %s", window))
+	case sample.Lifetimes == 0:
+		concurrency = float64(sample.Allocated)
+		rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("no completed sessions in the last %s; using %d allocated sandboxes as the concurrency estimate", window, sample.Allocated))
+	default:
+		concurrency = rec.AllocationsPerMinute / 60 * sample.AvgLifetime.Seconds()
+		rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("%.2f allocations/min x %.0fs average lifetime = %.2f concurrent sandboxes", rec.AllocationsPerMinute, rec.AvgSessionLifetimeSeconds, concurrency))
+	}
+
+	base := int32(math.Ceil(concurrency))
+	if base < sample.Allocated {
+		base = sample.Allocated
+		rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("raised base to %d currently allocated sandboxes", base))
+	}
+
+	headroom := int32(math.Ceil(math.Sqrt(float64(base))))
+	if base > 0 {
+		rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("headroom of %d (square root of base demand) absorbs bursts", headroom))
+	}
+	if sample.NoIdleEvents > 0 {
+		ratio := float64(sample.NoIdleEvents) / float64(max(sample.Allocations, 1))
+		extra := int32(math.Ceil(ratio * float64(max(base, 1))))
+		headroom += extra
+		rec.Reasoning = append(rec.Reasoning, fmt.Sprintf("%d allocations found no idle sandbox; added %d replicas of headroom", sample.NoIdleEvents, extra))
+	}
+
+	rec.Headroom = headroom
+	rec.RecommendedReplicas = base + headroom
+	return rec
+}
@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestComputePoolRecommendationFromSteadyDemand(t *testing.T) {
+	gw := &Gateway{}
+	key := types.NamespacedName{Name: "code", Namespace: "default"}
+	now := time.Now()
+	// One allocation per minute, each session living ten minutes: ~10 concurrent.
+	for i := range 60 {
+		at := now.Add(-time.Duration(59-i) * time.Minute)
+		gw.recordPoolAllocation(key, at)
+		gw.recordPoolSessionLifetime(key, at, 10*time.Minute)
+	}
+
+	sample := gw.poolDemandSnapshot(key, now)
+	sample.Replicas = 4
+	sample.Allocated = 8
+	rec := computePoolRecommendation(sample)
+
+	if rec.RecommendedReplicas < 12 || rec.RecommendedReplicas > 16 {
+		t.Fatalf("RecommendedReplicas = %d, want within [12, 16]: %#v", rec.RecommendedReplicas, rec)
+	}
+	if rec.Headroom <= 0 {
+		t.Fatalf("Headroom = %d, want positive", rec.Headroom)
+	}
+	if rec.CurrentReplicas != 4 {
+		t.Fatalf("CurrentReplicas = %d, want 4", rec.CurrentReplicas)
+	}
+	if len(rec.Reasoning) == 0 {
+		t.Fatal("Reasoning is empty")
+	}
+}
+
+func TestComputePoolRecommendationAddsHeadroomForNoIdleEvents(t *testing.T) {
+	base := poolDemandSample{
+		Window:      time.Hour,
+		Allocations: 60,
+		Lifetimes:   60,
+		AvgLifetime: 10 * time.Minute,
+	}
+	starved := base
+	starved.NoIdleEvents = 30
+
+	calm := computePoolRecommendation(base)
+	busy := computePoolRecommendation(starved)
+	if busy.RecommendedReplicas <= calm.RecommendedReplicas {
+		t.Fatalf("starved recommendation = %d, want more than %d", busy.RecommendedReplicas, calm.RecommendedReplicas)
+	}
+	if busy.RecommendedReplicas > 2*calm.RecommendedReplicas {
+		t.Fatalf("starved recommendation = %d, want at most %d", busy.RecommendedReplicas, 2*calm.RecommendedReplicas)
+	}
+}
+
+func TestComputePoolRecommendationWithoutDemand(t *testing.T) {
+	rec := computePoolRecommendation(poolDemandSample{Window: time.Hour, Replicas: 3})
+	if rec.RecommendedReplicas != 0 {
+		t.Fatalf("RecommendedReplicas = %d, want 0", rec.RecommendedReplicas)
+	}
+}
+
+func TestPoolDemandSnapshotDropsSamplesOutsideWindow(t *testing.T) {
+	gw := &Gateway{}
+	key := types.NamespacedName{Name: "code", Namespace: "default"}
+	now := time.Now()
+	gw.recordPoolAllocation(key, now.Add(-2*poolDemandWindow))
+	gw.recordPoolAllocation(key, now.Add(-time.Minute))
+
+	sample := gw.poolDemandSnapshot(key, now)
+	if sample.Allocations != 1 {
+		t.Fatalf("Allocations = %d, want 1", sample.Allocations)
+	}
+}
@@ -37,7 +37,7 @@ func (g *Gateway) ExecuteContainerSteps(
 		return nil, fmt.Errorf("container %q is not a private container", container)
 	}
 
-	s, _, releaseSession, err := g.acquireSessionPodIP(ctx, sessionID)
+	ctx, s, _, releaseSession, err := g.acquireSessionExec(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +99,13 @@ func (g *Gateway) executeContainerStep(
 		Input:     inputJSON,
 		Timestamp: start,
 	}
+	step, envWarning, denial := g.applyStepPolicy(step)
+	if denial != "" {
+		result.Output.Stderr = envWarning + denial
+		result.Output.ExitCode = commandDeniedExitCode
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result
+	}
 	command := buildContainerExecCommand(step)
 	if len(command) == 0 {
 		result.Output.Stderr = "no command specified"
@@ -123,6 +130,7 @@ func (g *Gateway) executeContainerStep(
 		VersionedParams(&corev1.PodExecOptions{
 			Container: container,
 			Command:   command,
+			Stdin:     step.Stdin != "",
 			Stdout:    true,
 			Stderr:    true,
 			TTY:       false,
@@ -136,11 +144,15 @@ func (g *Gateway) executeContainerStep(
 		return result
 	}
 
-	err = executor.StreamWithContext(stepCtx, remotecommand.StreamOptions{
+	streamOptions := remotecommand.StreamOptions{
 		Stdout: &stdout,
 		Stderr: &stderr,
 		Tty:    false,
-	})
+	}
+	if step.Stdin != "" {
+		streamOptions.Stdin = strings.NewReader(step.Stdin)
+	}
+	err = executor.StreamWithContext(stepCtx, streamOptions)
 	result.Output.Stdout = stdout.String()
 	result.Output.Stderr = stderr.String()
 	if err != nil {
@@ -151,6 +163,7 @@ func (g *Gateway) executeContainerStep(
 			result.Output.Stderr = err.Error()
 		}
 	}
+	result.Output.Stderr = envWarning + result.Output.Stderr
 	result.DurationMs = time.Since(start).Milliseconds()
 	return result
 }
@@ -0,0 +1,36 @@
+package gateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecuteContainerStepAppliesStepPolicy(t *testing.T) {
+	gw := &Gateway{gwConfig: GatewayConfig{ExecCommandDenyList: "kubectl", ExecEnvDenyList: "TOKEN"}}
+
+	// A denied command is refused before any exec stream is opened.
+	result := gw.executeContainerStep(context.Background(), nil, "default", "pod-1", "grader", 0, StepRequest{
+		Name:    "escape",
+		Command: []string{"kubectl", "get", "secrets"},
+		Env:     map[string]string{"TOKEN": "x"},
+	})
+	if result.Output.ExitCode != commandDeniedExitCode || !strings.Contains(result.Output.Stderr, commandDeniedText) {
+		t.Fatalf("denied step output = %#v", result.Output)
+	}
+	if !strings.Contains(result.Output.Stderr, "TOKEN") {
+		t.Fatalf("stderr = %q, want the dropped env var reported", result.Output.Stderr)
+	}
+
+	step, warning, denial := gw.applyStepPolicy(StepRequest{
+		Command: []string{"pytest"},
+		Env:     map[string]string{"TOKEN": "x", "CI": "1"},
+	})
+	if denial != "" || warning == "" {
+		t.Fatalf("denial = %q, warning = %q; want the step allowed with a warning", denial, warning)
+	}
+	command := buildContainerExecCommand(step)
+	if got := command[len(command)-1]; strings.Contains(got, "TOKEN") || !strings.Contains(got, "'CI=1'") {
+		t.Fatalf("container command = %q, want TOKEN dropped and CI kept", got)
+	}
+}
@@ -8,7 +8,8 @@ import (
 )
 
 const (
-	executorContainerName = "executor"
+	executorContainerName          = "executor"
+	executorAgentInitContainerName = "copy-executor-agent"
 )
 
 func validatePrivateContainers(containers []PrivateContainerSpec) error {
@@ -36,6 +36,18 @@ func NewRateLimiter(rps float64, burst int) *RateLimiter {
 	return rl
 }
 
+// SetLimit changes the rate and burst for new and existing visitors.
+func (rl *RateLimiter) SetLimit(rps float64, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limit = rate.Limit(rps)
+	rl.burst = burst
+	for _, v := range rl.visitors {
+		v.limiter.SetLimit(rl.limit)
+		v.limiter.SetBurst(burst)
+	}
+}
+
 func (rl *RateLimiter) getVisitor(ip string) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	sandboxv1beta1 "sigs.k8s.io/agent-sandbox/api/v1beta1"
+	sandboxcontrollers "sigs.k8s.io/agent-sandbox/controllers"
+	extensionsv1beta1 "sigs.k8s.io/agent-sandbox/extensions/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// readinessTimeout bounds a whole /readyz check, including sidecar pings.
+	readinessTimeout = 5 * time.Second
+	// readinessNoRunningPod is reported for pools with nothing to ping.
+	readinessNoRunningPod = "no running pod"
+)
+
+// handleReadyz reports whether the gateway can reach the cluster. It always
+// lists pools; with ?sidecars=true it also pings one running sidecar per pool,
+// which costs a dial per pool and is therefore opt-in. The detailed mode
+// names pods and their errors and lets a caller trigger dials, so it is only
+// served when allowSidecars is set, which the internal router does.
+func handleReadyz(gw *Gateway, allowSidecars bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		checkSidecars, _ := strconv.ParseBool(r.URL.Query().Get("sidecars"))
+		if checkSidecars && !allowSidecars {
+			writeError(w, http.StatusForbidden, "sidecar readiness checks are only served on the internal port")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		resp := gw.checkReadiness(ctx, checkSidecars)
+		status := http.StatusOK
+		if resp.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, resp)
+	}
+}
+
+// checkReadiness lists pools in the gateway namespace and, when
+// checkSidecars is set, pings one sidecar per pool. Pools without a running
+// pod are reported but do not fail the check: a pool scaled to zero says
+// nothing about the gateway's connectivity. A draining gateway is never
+// ready, so it is taken out of its Service while in-flight work finishes.
+func (g *Gateway) checkReadiness(ctx context.Context, checkSidecars bool) ReadinessResponse {
+	if g.Draining() {
+		return ReadinessResponse{Status: "unavailable", Error: ErrGatewayDraining.Error()}
+	}
+	var pools extensionsv1beta1.SandboxWarmPoolList
+	if err := g.k8sClient.List(ctx, &pools, client.InNamespace(g.runtimeNamespace())); err != nil {
+		return ReadinessResponse{Status: "unavailable", Error: "list pools: " + err.Error()}
+	}
+	resp := ReadinessResponse{Status: "ok"}
+	if !checkSidecars || g.executorClient == nil {
+		return resp
+	}
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		result := g.checkPoolSidecar(ctx, pool.Name, pool.Namespace)
+		if !result.Reachable && result.Error != readinessNoRunningPod {
+			resp.Status = "unavailable"
+		}
+		resp.Pools = append(resp.Pools, result)
+	}
+	return resp
+}
+
+// checkPoolSidecar pings the sidecar of the first running, addressable pod
+// in the pool.
+func (g *Gateway) checkPoolSidecar(ctx context.Context, poolName, namespace string) PoolReadiness {
+	result := PoolReadiness{Name: poolName, Namespace: namespace}
+	var pods corev1.PodList
+	if err := g.k8sClient.List(ctx, &pods,
+		client.InNamespace(namespace),
+		client.MatchingLabels{sandboxv1beta1.SandboxWarmPoolLabel: sandboxcontrollers.NameHash(poolName)},
+	); err != nil {
+		result.Error = "list pods: " + err.Error()
+		return result
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		result.PodName = pod.Name
+		if err := g.executorClient.HealthCheck(ctx, pod.Status.PodIP); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Reachable = true
+		return result
+	}
+	result.Error = readinessNoRunningPod
+	return result
+}
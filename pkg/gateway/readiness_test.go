@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	sandboxv1beta1 "sigs.k8s.io/agent-sandbox/api/v1beta1"
+	sandboxcontrollers "sigs.k8s.io/agent-sandbox/controllers"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+// pingExecutorClient answers HealthCheck from a per-IP error table.
+type pingExecutorClient struct {
+	interfaces.ExecutorClient
+	errs map[string]error
+}
+
+func (c *pingExecutorClient) HealthCheck(_ context.Context, podIP string) error {
+	return c.errs[podIP]
+}
+
+func readinessTestPod(name, pool, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{sandboxv1beta1.SandboxWarmPoolLabel: sandboxcontrollers.NameHash(pool)},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+	}
+}
+
+func serveReadyz(t *testing.T, router http.Handler, target string) (int, ReadinessResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	var resp ReadinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode readyz response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func TestReadyzSidecarPingIsOptIn(t *testing.T) {
+	k8sClient := fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).WithObjects(
+		testSandboxWarmPool("code", "default", "code-tpl", 1, 1, "code"),
+		testSandboxWarmPool("idle", "default", "idle-tpl", 0, 0, "idle"),
+		readinessTestPod("code-abc", "code", "10.0.0.1"),
+	).Build()
+	exec := &pingExecutorClient{errs: map[string]error{"10.0.0.1": errors.New("connection refused")}}
+	gw := New(k8sClient, &operationRuntimeAllocator{}, exec, nil, nil, GatewayConfig{Namespace: "default"}, newTestSessionStore("unused"))
+
+	public := SetupRoutes(gw, nil)
+	internal := SetupInternalRoutes(NewHealthChecker(gw, nil, ""))
+
+	status, resp := serveReadyz(t, public, "/readyz")
+	if status != http.StatusOK || resp.Status != "ok" || len(resp.Pools) != 0 {
+		t.Fatalf("plain readyz = %d %#v, want 200 ok without pool results", status, resp)
+	}
+	if status, resp = serveReadyz(t, public, "/readyz?sidecars=true"); status != http.StatusForbidden {
+		t.Fatalf("public readyz with sidecars = %d %#v, want 403", status, resp)
+	}
+
+	status, resp = serveReadyz(t, internal, "/readyz?sidecars=true")
+	if status != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+		t.Fatalf("readyz with unreachable sidecar = %d %#v, want 503", status, resp)
+	}
+	if len(resp.Pools) != 2 {
+		t.Fatalf("pool results = %#v, want 2", resp.Pools)
+	}
+
+	exec.errs = nil
+	status, resp = serveReadyz(t, internal, "/readyz?sidecars=true")
+	if status != http.StatusOK {
+		t.Fatalf("readyz with reachable sidecar = %d %#v, want 200", status, resp)
+	}
+	for _, pool := range resp.Pools {
+		switch pool.Name {
+		case "code":
+			if !pool.Reachable || pool.PodName != "code-abc" {
+				t.Fatalf("code pool = %#v, want reachable via code-abc", pool)
+			}
+		case "idle":
+			if pool.Reachable || pool.Error != readinessNoRunningPod {
+				t.Fatalf("idle pool = %#v, want %q", pool, readinessNoRunningPod)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package gateway
+
+// Reconcile loops labelled in the requeue metric.
+const (
+	reconcilerPoolAutoscaler = "pool_autoscaler"
+	reconcilerManagedPoolGC  = "managed_pool_gc"
+)
+
+// Reasons a reconcile loop left a pool for its next pass.
+const (
+	requeueReasonError              = "error"
+	requeueReasonAutoscaleDisabled  = "autoscale_disabled"
+	requeueReasonAtTarget           = "at_target"
+	requeueReasonInUse              = "in_use"
+	requeueReasonQueuedRequests     = "queued_requests"
+	requeueReasonIdleTimeoutPending = "idle_timeout_pending"
+	requeueReasonUnderStoppedLimit  = "under_stopped_limit"
+)
+
+// recordReconcileRequeue counts one pool that controller left unchanged
+// until its next pass, or one failed pass when reason is requeueReasonError.
+func (g *Gateway) recordReconcileRequeue(controller, reason string) {
+	if g.metrics == nil {
+		return
+	}
+	g.metrics.IncrementReconcileRequeue(controller, reason)
+}
@@ -38,6 +38,7 @@ type redisSessionData struct {
 	LastTaskTime        time.Time              `json:"lastTaskTime"`
 	LastAnnotationPatch time.Time              `json:"lastAnnotationPatch"`
 	IdleTimeout         time.Duration          `json:"idleTimeout"`
+	MaxLifetime         time.Duration          `json:"maxLifetime,omitempty"`
 	CreatedAt           time.Time              `json:"createdAt"`
 	PrivateContainers   []PrivateContainerSpec `json:"privateContainers,omitempty"`
 
@@ -79,6 +80,7 @@ func sessionToRedisData(s *session) redisSessionData {
 		LastTaskTime:        s.lastTaskTime,
 		LastAnnotationPatch: s.lastAnnotationPatch,
 		IdleTimeout:         s.idleTimeout,
+		MaxLifetime:         s.maxLifetime,
 		CreatedAt:           s.createdAt,
 	}
 	if len(s.privateContainers) > 0 {
@@ -133,6 +135,7 @@ func redisDataToSession(data redisSessionData, actions redisSessionActionsData)
 		lastTaskTime:        data.LastTaskTime,
 		lastAnnotationPatch: data.LastAnnotationPatch,
 		idleTimeout:         data.IdleTimeout,
+		maxLifetime:         data.MaxLifetime,
 		createdAt:           data.CreatedAt,
 		operations:          make(map[string]*operation),
 		privateContainers:   privateContainerMap(data.PrivateContainers),
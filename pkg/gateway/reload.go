@@ -0,0 +1,46 @@
+package gateway
+
+import "time"
+
+// ReloadableConfig is the part of GatewayConfig that can change while the
+// gateway runs. Idle timeouts apply to sessions created or recovered after
+// the change; sessions already held keep the timeout they started with.
+// Every other GatewayConfig field is read at startup and needs a restart.
+type ReloadableConfig struct {
+	IdleTimeout       time.Duration
+	DevboxIdleTimeout time.Duration
+	MaxActiveSessions int
+	ExecEnvDenyList   string
+	ExecEnvAllowList  string
+	// ExecCommandDenyList is a comma-separated list of glob patterns
+	// matched against the basename of each step's program.
+	ExecCommandDenyList string
+}
+
+// ApplyReloadableConfig swaps the reloadable settings into the running
+// gateway.
+func (g *Gateway) ApplyReloadableConfig(rc ReloadableConfig) {
+	g.configMu.Lock()
+	defer g.configMu.Unlock()
+	g.gwConfig.IdleTimeout = rc.IdleTimeout
+	g.gwConfig.DevboxIdleTimeout = rc.DevboxIdleTimeout
+	g.gwConfig.MaxActiveSessions = rc.MaxActiveSessions
+	g.gwConfig.ExecEnvDenyList = rc.ExecEnvDenyList
+	g.gwConfig.ExecEnvAllowList = rc.ExecEnvAllowList
+	g.gwConfig.ExecCommandDenyList = rc.ExecCommandDenyList
+}
+
+// reloadable returns the current reloadable settings. Code reading any of
+// these fields must go through it rather than gwConfig directly.
+func (g *Gateway) reloadable() ReloadableConfig {
+	g.configMu.RLock()
+	defer g.configMu.RUnlock()
+	return ReloadableConfig{
+		IdleTimeout:         g.gwConfig.IdleTimeout,
+		DevboxIdleTimeout:   g.gwConfig.DevboxIdleTimeout,
+		MaxActiveSessions:   g.gwConfig.MaxActiveSessions,
+		ExecEnvDenyList:     g.gwConfig.ExecEnvDenyList,
+		ExecEnvAllowList:    g.gwConfig.ExecEnvAllowList,
+		ExecCommandDenyList: g.gwConfig.ExecCommandDenyList,
+	}
+}
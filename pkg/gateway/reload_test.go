@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApplyReloadableConfigAffectsNewSessions(t *testing.T) {
+	gw := &Gateway{gwConfig: GatewayConfig{IdleTimeout: time.Minute, ExecEnvDenyList: "LD_PRELOAD"}}
+
+	gw.ApplyReloadableConfig(ReloadableConfig{
+		IdleTimeout:       5 * time.Minute,
+		DevboxIdleTimeout: time.Hour,
+		ExecEnvDenyList:   "HOME",
+	})
+
+	if got := gw.resolveIdleTimeout(CreateSessionRequest{}); got != 5*time.Minute {
+		t.Fatalf("idle timeout = %v, want 5m", got)
+	}
+	if got := gw.resolveIdleTimeout(CreateSessionRequest{Mode: SessionModeDevbox}); got != time.Hour {
+		t.Fatalf("devbox idle timeout = %v, want 1h", got)
+	}
+	env, _ := gw.filterStepEnv(map[string]string{"LD_PRELOAD": "x", "HOME": "/root"})
+	if _, ok := env["HOME"]; ok || env["LD_PRELOAD"] != "x" {
+		t.Fatalf("filtered env = %v, want only LD_PRELOAD", env)
+	}
+}
+
+func TestRateLimiterSetLimitUpdatesExistingVisitors(t *testing.T) {
+	rl := NewRateLimiter(1, 1)
+	defer rl.Close()
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.9:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", code)
+	}
+	if code := request(); code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", code)
+	}
+
+	rl.SetLimit(1000, 10)
+	time.Sleep(5 * time.Millisecond)
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("request after raising the limit = %d, want 200", code)
+	}
+}
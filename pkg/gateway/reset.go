@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Reset gives a session a clean workspace without tearing it down. The
+// executor has no in-place reset, so a fresh runtime is allocated from the
+// session's pool and swapped in, exactly as Restore does. With
+// PreserveFiles the files uploaded through the gateway are written to the
+// new runtime; changes made by executed commands are not carried over.
+// TruncateHistory drops the recorded steps (keeping the preserved uploads),
+// so later restores start from the reset workspace.
+func (g *Gateway) Reset(ctx context.Context, sessionID string, req ResetRequest) (*ResetResponse, error) {
+	s, ok := g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+
+	atomic.AddInt32(&s.activeExecs, 1)
+	defer atomic.AddInt32(&s.activeExecs, -1)
+
+	var uploads []StepRecord
+	if req.PreserveFiles {
+		for _, record := range s.History.GetAll() {
+			if record.Name == uploadFileStepName {
+				uploads = append(uploads, record)
+			}
+		}
+	}
+
+	if len(uploads) > 0 && g.trajectoryWriter == nil {
+		return nil, fmt.Errorf("preserveFiles requires a trajectory writer to retrieve uploaded content")
+	}
+
+	newSandboxName := fmt.Sprintf("%s-x%d", sessionID, time.Now().UnixMilli())
+	newAllocation, err := g.allocateReplacementRuntime(ctx, sessionID, s, newSandboxName)
+	if err != nil {
+		return nil, fmt.Errorf("allocate new runtime for reset: %w", err)
+	}
+
+	for _, record := range uploads {
+		if err := g.replayUpload(ctx, newAllocation.PodIP, record); err != nil {
+			if err := g.releaseRestoreAllocation(*newAllocation); err != nil {
+				log.Printf("Warning: failed to release runtime %s after reset failure: %v", newAllocation.PodName, err)
+			}
+			return nil, fmt.Errorf("restore uploaded file for step %d: %w", record.Index, err)
+		}
+	}
+
+	g.swapSessionRuntime(s, newSandboxName, *newAllocation)
+	log.Printf("Reset %s: new pod %s (%s), %d uploaded files preserved", sessionID, newAllocation.PodName, newAllocation.PodIP, len(uploads))
+
+	if req.TruncateHistory {
+		s.History.Replace(uploads)
+	}
+	g.touchLastTaskTime(sessionID)
+	g.store.SyncHistory(sessionID)
+
+	return &ResetResponse{
+		SandboxName:    newSandboxName,
+		FilesPreserved: len(uploads),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type resetRuntimeAllocator struct {
+	operationRuntimeAllocator
+	allocated []RuntimeAllocateRequest
+}
+
+func (a *resetRuntimeAllocator) Allocate(ctx context.Context, req RuntimeAllocateRequest) (*RuntimeAllocation, error) {
+	a.allocated = append(a.allocated, req)
+	return &RuntimeAllocation{
+		Backend:     runtimeBackendSandboxClaim,
+		PoolRef:     req.PoolRef,
+		Namespace:   req.Namespace,
+		SandboxName: req.SandboxName,
+		PodIP:       "10.0.0.2",
+		PodName:     "pod-2",
+	}, nil
+}
+
+func TestResetSwapsRuntimeAndTruncatesHistory(t *testing.T) {
+	store := newTestSessionStore("gw-reset")
+	alloc := &resetRuntimeAllocator{}
+	gw := New(nil, alloc, nil, nil, nil, GatewayConfig{}, store)
+
+	sess, _ := store.Get("gw-reset")
+	sess.History.Add(StepRecord{Name: "rm", Input: json.RawMessage(`{"command":["rm","-rf","src"]}`)})
+
+	resp, err := gw.Reset(context.Background(), "gw-reset", ResetRequest{TruncateHistory: true})
+	if err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if len(alloc.allocated) != 1 || alloc.allocated[0].PoolRef != "code" {
+		t.Fatalf("allocations = %#v, want one from pool code", alloc.allocated)
+	}
+	if resp.SandboxName != alloc.allocated[0].SandboxName || resp.FilesPreserved != 0 {
+		t.Fatalf("response = %#v", resp)
+	}
+	if sess.Info.PodIP != "10.0.0.2" || sess.Runtime.PodName != "pod-2" {
+		t.Fatalf("session runtime = %#v / %#v, want pod-2", sess.Info, sess.Runtime)
+	}
+	if got := sess.History.Len(); got != 0 {
+		t.Fatalf("history length = %d, want 0", got)
+	}
+}
+
+func TestResetPreserveFilesRequiresTrajectoryWriter(t *testing.T) {
+	store := newTestSessionStore("gw-reset")
+	alloc := &resetRuntimeAllocator{}
+	gw := New(nil, alloc, nil, nil, nil, GatewayConfig{}, store)
+
+	sess, _ := store.Get("gw-reset")
+	sess.History.Add(StepRecord{Name: uploadFileStepName, Input: json.RawMessage(`{"path":"a.txt","sha256":"abc","size":1}`)})
+
+	_, err := gw.Reset(context.Background(), "gw-reset", ResetRequest{PreserveFiles: true})
+	if err == nil || !strings.Contains(err.Error(), "trajectory writer") {
+		t.Fatalf("Reset error = %v, want missing trajectory writer", err)
+	}
+	if len(alloc.allocated) != 0 {
+		t.Fatalf("allocations = %d, want none before validation passes", len(alloc.allocated))
+	}
+}
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const resourceSampleTimeout = 10 * time.Second
+
+// kubeletSummary is the part of the kubelet's /stats/summary response that
+// resource sampling reads.
+type kubeletSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Containers []struct {
+			Name string `json:"name"`
+			CPU  *struct {
+				UsageCoreNanoSeconds *uint64 `json:"usageCoreNanoSeconds"`
+			} `json:"cpu"`
+			Memory *struct {
+				WorkingSetBytes *uint64 `json:"workingSetBytes"`
+			} `json:"memory"`
+		} `json:"containers"`
+	} `json:"pods"`
+}
+
+// containerUsage is one reading of a container's cumulative CPU time and
+// working set.
+type containerUsage struct {
+	cpuSeconds  float64
+	memoryBytes int64
+}
+
+// sampleResourceUsage reads the executor container's counters from the
+// kubelet stats summary, through the API server's node proxy, and stores
+// them on the session. Nothing is read from inside the sandbox, so tenants
+// cannot report their own figures. It runs after each execute and failures
+// are only logged: accounting never fails a step.
+func (g *Gateway) sampleResourceUsage(s *session, sessionID string) {
+	if g.k8sClientset == nil {
+		log.Printf("Resource sample %s: no Kubernetes client configured", sessionID)
+		return
+	}
+	s.mu.RLock()
+	allocation := s.runtimeAllocation()
+	s.mu.RUnlock()
+	if allocation.PodName == "" || allocation.Namespace == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resourceSampleTimeout)
+	defer cancel()
+	sample, err := g.executorContainerUsage(ctx, allocation.Namespace, allocation.PodName)
+	if err != nil {
+		log.Printf("Resource sample %s: %v", sessionID, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordResourceSample(allocation.PodName, sample, time.Now())
+}
+
+func (g *Gateway) executorContainerUsage(ctx context.Context, namespace, podName string) (containerUsage, error) {
+	pod, err := g.k8sClientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return containerUsage{}, fmt.Errorf("get pod: %w", err)
+	}
+	if pod.Spec.NodeName == "" {
+		return containerUsage{}, fmt.Errorf("pod %s/%s is not scheduled", namespace, podName)
+	}
+	raw, err := g.k8sClientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", pod.Spec.NodeName, "proxy", "stats", "summary").
+		DoRaw(ctx)
+	if err != nil {
+		return containerUsage{}, fmt.Errorf("get kubelet stats for node %s: %w", pod.Spec.NodeName, err)
+	}
+	var summary kubeletSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return containerUsage{}, fmt.Errorf("decode kubelet stats: %w", err)
+	}
+	return findContainerUsage(summary, namespace, podName, executorContainerName)
+}
+
+// findContainerUsage picks one container's counters out of a kubelet stats
+// summary. It fails when the kubelet has no CPU counter for it yet.
+func findContainerUsage(summary kubeletSummary, namespace, podName, container string) (containerUsage, error) {
+	for _, pod := range summary.Pods {
+		if pod.PodRef.Namespace != namespace || pod.PodRef.Name != podName {
+			continue
+		}
+		for _, c := range pod.Containers {
+			if c.Name != container || c.CPU == nil || c.CPU.UsageCoreNanoSeconds == nil {
+				continue
+			}
+			usage := containerUsage{cpuSeconds: float64(*c.CPU.UsageCoreNanoSeconds) / 1e9}
+			if c.Memory != nil && c.Memory.WorkingSetBytes != nil {
+				usage.memoryBytes = int64(*c.Memory.WorkingSetBytes)
+			}
+			return usage, nil
+		}
+	}
+	return containerUsage{}, fmt.Errorf("no stats for container %s in pod %s/%s", container, namespace, podName)
+}
+
+// recordResourceSample folds a reading from podName into the session's
+// usage. The kubelet's CPU counter is per container, so when the session
+// moves to another pod, or the container restarts and the counter drops,
+// the usage seen so far is carried into the running total. Peak memory is
+// the highest working set seen in any sample. Callers hold s.mu.
+func (s *session) recordResourceSample(podName string, sample containerUsage, at time.Time) {
+	if podName != s.resourceUsagePod || sample.cpuSeconds < s.resourcePodCPUSeconds {
+		s.resourceCPUSecondsBase += s.resourcePodCPUSeconds
+		s.resourcePodCPUSeconds = 0
+		s.resourceUsagePod = podName
+	}
+	s.resourcePodCPUSeconds = sample.cpuSeconds
+
+	usage := SessionResourceUsage{
+		CPUSeconds:      s.resourceCPUSecondsBase + sample.cpuSeconds,
+		PeakMemoryBytes: sample.memoryBytes,
+		SampledAt:       at,
+	}
+	if prev := s.resourceUsage; prev != nil && prev.PeakMemoryBytes > usage.PeakMemoryBytes {
+		usage.PeakMemoryBytes = prev.PeakMemoryBytes
+	}
+	s.resourceUsage = &usage
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestFindContainerUsage(t *testing.T) {
+	var summary kubeletSummary
+	if err := json.Unmarshal([]byte(`{"pods":[{"podRef":{"name":"pod-1","namespace":"default"},"containers":[
+		{"name":"executor","cpu":{"usageCoreNanoSeconds":2500000000},"memory":{"workingSetBytes":104857600}}]}]}`), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+
+	usage, err := findContainerUsage(summary, "default", "pod-1", executorContainerName)
+	if err != nil || usage.cpuSeconds != 2.5 || usage.memoryBytes != 104857600 {
+		t.Fatalf("usage = %+v, err = %v", usage, err)
+	}
+	if _, err := findContainerUsage(summary, "default", "pod-2", executorContainerName); err == nil {
+		t.Fatal("findContainerUsage returned stats for a pod missing from the summary")
+	}
+}
+
+func TestRecordResourceSampleTotalsCPUAcrossPods(t *testing.T) {
+	s := &session{}
+	now := time.Now()
+	s.recordResourceSample("pod-1", containerUsage{cpuSeconds: 1, memoryBytes: 8192}, now)
+	s.recordResourceSample("pod-1", containerUsage{cpuSeconds: 3, memoryBytes: 4096}, now)
+	// The session moved to a fresh pod whose counter starts over.
+	s.recordResourceSample("pod-2", containerUsage{cpuSeconds: 0.5, memoryBytes: 2048}, now)
+	// The container restarted and its counter dropped.
+	s.recordResourceSample("pod-2", containerUsage{cpuSeconds: 0.25, memoryBytes: 2048}, now)
+
+	if got := s.resourceUsage.CPUSeconds; got != 3.75 {
+		t.Fatalf("CPUSeconds = %v, want 3.75 (3 + 0.5 + 0.25)", got)
+	}
+	if got := s.resourceUsage.PeakMemoryBytes; got != 8192 {
+		t.Fatalf("PeakMemoryBytes = %d, want 8192", got)
+	}
+}
+
+func TestSampleResourceUsageReadsKubeletStats(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/pods/pod-1":
+			fmt.Fprint(w, `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod-1","namespace":"default"},"spec":{"nodeName":"node-a"}}`)
+		case "/api/v1/nodes/node-a/proxy/stats/summary":
+			fmt.Fprint(w, `{"pods":[{"podRef":{"name":"pod-1","namespace":"default"},"containers":[
+				{"name":"sidecar","cpu":{"usageCoreNanoSeconds":9000000000}},
+				{"name":"executor","cpu":{"usageCoreNanoSeconds":2000000000},"memory":{"workingSetBytes":4096}}]}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer apiServer.Close()
+
+	store := newTestSessionStore("gw-usage")
+	s, _ := store.Get("gw-usage")
+	s.Runtime.Namespace = "default"
+	s.Runtime.PodName = "pod-1"
+	cfg := GatewayConfig{ResourceSamplingEnabled: true, K8sRESTConfig: &rest.Config{Host: apiServer.URL}}
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, cfg, store)
+
+	gw.sampleResourceUsage(s, "gw-usage")
+
+	info, err := gw.GetSession("gw-usage")
+	if err != nil {
+		t.Fatalf("GetSession returned error: %v", err)
+	}
+	usage := info.ResourceUsage
+	if usage == nil || usage.CPUSeconds != 2 || usage.PeakMemoryBytes != 4096 || usage.SampledAt.IsZero() {
+		t.Fatalf("ResourceUsage = %+v, want the executor container's 2 CPU seconds and 4096 bytes", usage)
+	}
+}
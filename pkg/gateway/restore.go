@@ -6,12 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/Lincyaw/agent-env/pkg/audit"
-	"github.com/Lincyaw/agent-env/pkg/interfaces"
 )
 
 // ReplayFrom replays steps from a source session, optionally as an async operation.
@@ -68,11 +67,15 @@ func (g *Gateway) replayNow(ctx context.Context, targetSessionID string, req Rep
 			errors++
 			continue
 		}
-		execReq := &interfaces.ExecRequest{
-			Command:        step.Command,
-			Env:            step.Env,
-			WorkingDir:     step.WorkDir,
-			TimeoutSeconds: resolveStepTimeoutSeconds(step),
+		if step.HTTP != nil || deniedStepRecord(record) {
+			// HTTP steps observe services and denied steps never ran;
+			// neither leaves anything to rebuild.
+			continue
+		}
+		execReq, _, denial := g.buildStepExecRequest(ctx, step)
+		if denial != "" {
+			log.Printf("Warning: replay exec step %d skipped: %s", record.Index, strings.TrimSpace(denial))
+			continue
 		}
 		if _, err := g.executorClient.Execute(ctx, podIP, execReq); err != nil {
 			log.Printf("Warning: replay exec step %d failed on %s: %v", record.Index, podIP, err)
@@ -128,6 +131,7 @@ func (g *Gateway) replayRecordsFromTrajectory(ctx context.Context, sessionID str
 			Name:      e.Name,
 			Input:     e.Action,
 			Timestamp: e.Timestamp,
+			TraceID:   e.TraceID,
 		}
 	}
 	return records, nil
@@ -158,7 +162,7 @@ func (g *Gateway) replayUpload(ctx context.Context, podIP string, record StepRec
 // Restore restores a session to a previous snapshot, optionally as an async operation.
 func (g *Gateway) Restore(ctx context.Context, sessionID string, req RestoreRequest) (*RestoreResponse, error) {
 	if req.OperationID == "" {
-		return g.restoreNow(ctx, sessionID, req.SnapshotID)
+		return g.restoreNow(ctx, sessionID, req)
 	}
 	return g.restoreWithOperation(ctx, sessionID, req)
 }
@@ -166,7 +170,7 @@ func (g *Gateway) Restore(ctx context.Context, sessionID string, req RestoreRequ
 func (g *Gateway) restoreWithOperation(ctx context.Context, sessionID string, req RestoreRequest) (*RestoreResponse, error) {
 	hash := operationRequestHash(req)
 	op, _, err := g.getOrStartOperation(sessionID, req.OperationID, hash, func(bgCtx context.Context) (any, error) {
-		return g.restoreNow(bgCtx, sessionID, req.SnapshotID)
+		return g.restoreNow(bgCtx, sessionID, req)
 	})
 	if err != nil {
 		return nil, err
@@ -175,7 +179,7 @@ func (g *Gateway) restoreWithOperation(ctx context.Context, sessionID string, re
 }
 
 // restoreNow restores a session synchronously, returning a RestoreResponse.
-func (g *Gateway) restoreNow(ctx context.Context, sessionID string, snapshotID string) (resp *RestoreResponse, retErr error) {
+func (g *Gateway) restoreNow(ctx context.Context, sessionID string, req RestoreRequest) (resp *RestoreResponse, retErr error) {
 	restoreStart := time.Now()
 	defer func() {
 		if g.metrics != nil {
@@ -188,61 +192,91 @@ func (g *Gateway) restoreNow(ctx context.Context, sessionID string, snapshotID s
 		}
 	}()
 
-	targetIdx, err := strconv.Atoi(snapshotID)
+	snapshotID := req.SnapshotID
+	targetIdx, persisted, err := parseSnapshotID(snapshotID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid snapshot_id %q: must be a step index", snapshotID)
+		return nil, err
 	}
 
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, fmt.Errorf("session %s not found", sessionID)
+		return nil, &SessionNotFoundError{SessionID: sessionID}
 	}
 
 	atomic.AddInt32(&s.activeExecs, 1)
 	defer atomic.AddInt32(&s.activeExecs, -1)
 
-	records := s.History.GetUpTo(targetIdx)
-	if len(records) == 0 && targetIdx >= 0 {
-		if targetIdx > 0 {
-			return nil, fmt.Errorf("no history records up to index %d", targetIdx)
-		}
+	records, fromTrajectory, err := g.restoreRecords(ctx, sessionID, s, targetIdx, req.FromTrajectory)
+	if err != nil {
+		return nil, err
 	}
 
-	s.mu.RLock()
-	oldAllocation := s.runtimeAllocation()
-	lifecycle := g.sessionRuntimeLifecycleLocked(s, time.Now())
-	s.mu.RUnlock()
-
-	log.Printf("Restore %s to snapshot %s: %d steps to replay", sessionID, snapshotID, len(records))
+	log.Printf("Restore %s to snapshot %s: %d steps recorded", sessionID, snapshotID, len(records))
 
 	newSandboxName := fmt.Sprintf("%s-r%d", sessionID, time.Now().UnixMilli())
+	provisionStart := time.Now()
+	newAllocation, err := g.allocateReplacementRuntime(ctx, sessionID, s, newSandboxName)
+	if g.metrics != nil {
+		g.metrics.RecordRestorePhaseDuration("provision", time.Since(provisionStart))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("allocate new runtime for restore: %w", err)
+	}
 
-	allocCtx, allocCancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer allocCancel()
+	log.Printf("Restore %s: new pod %s (%s) allocated", sessionID, newAllocation.PodName, newAllocation.PodIP)
 
-	newAllocation, err := g.runtimeAllocator.Allocate(allocCtx, RuntimeAllocateRequest{
-		PoolRef:     oldAllocation.PoolRef,
-		Namespace:   oldAllocation.Namespace,
-		SessionID:   sessionID,
-		SandboxName: newSandboxName,
-		Lifecycle:   lifecycle,
+	var result RestoreResult
+	phaseStart := time.Now()
+	defer func() {
+		if g.metrics != nil {
+			phase := result.Phase
+			if phase == "" {
+				phase = "restore"
+			}
+			g.metrics.RecordRestorePhaseDuration(phase, time.Since(phaseStart))
+			g.metrics.AddRestoreReplayedSteps(result.StepsReplayed)
+		}
+	}()
+	result, err = g.activeRestoreBackend().RestoreTo(ctx, RestoreTarget{
+		SessionID: sessionID,
+		StepIndex: targetIdx,
+		Persisted: persisted,
+		PodIP:     newAllocation.PodIP,
+		Records:   records,
 	})
 	if err != nil {
-		diag := g.diagnosePoolHealth(ctx, oldAllocation.PoolRef, oldAllocation.Namespace)
-		return nil, fmt.Errorf("allocate new runtime for restore: %w (%s)", err, diag)
+		if err := g.releaseRestoreAllocation(*newAllocation); err != nil {
+			log.Printf("Warning: failed to release runtime %s after restore failure: %v", newAllocation.PodName, err)
+		}
+		return nil, err
 	}
 
-	log.Printf("Restore %s: new pod %s (%s) allocated", sessionID, newAllocation.PodName, newAllocation.PodIP)
+	log.Printf("Restore %s complete: %d steps replayed on %s", sessionID, result.StepsReplayed, newAllocation.PodName)
 
+	g.swapSessionRuntime(s, newSandboxName, *newAllocation)
+
+	if fromTrajectory {
+		s.History.Merge(records)
+	}
+	s.History.TruncateTo(targetIdx)
+	g.touchLastTaskTime(sessionID)
+	g.store.SyncHistory(sessionID)
+
+	return &RestoreResponse{
+		SnapshotID:    snapshotID,
+		StepsReplayed: result.StepsReplayed,
+	}, nil
+}
+
+// replayRestoreRecords re-runs records on the replacement runtime at podIP
+// and returns how many were replayed.
+func (g *Gateway) replayRestoreRecords(ctx context.Context, sessionID, podIP string, records []StepRecord) (int, error) {
 	stepsReplayed := 0
 	for _, record := range records {
 		if record.Name == uploadFileStepName {
-			if err := g.replayUpload(ctx, newAllocation.PodIP, record); err != nil {
+			if err := g.replayUpload(ctx, podIP, record); err != nil {
 				log.Printf("Warning: restore upload step %d failed: %v", record.Index, err)
-				if err := g.releaseRestoreAllocation(*newAllocation); err != nil {
-					log.Printf("Warning: failed to release runtime %s after restore failure: %v", newAllocation.PodName, err)
-				}
-				return nil, fmt.Errorf("replay upload step %d failed: %w", record.Index, err)
+				return stepsReplayed, fmt.Errorf("replay upload step %d failed: %w", record.Index, err)
 			}
 			stepsReplayed++
 			continue
@@ -253,42 +287,100 @@ func (g *Gateway) restoreNow(ctx context.Context, sessionID string, snapshotID s
 			log.Printf("Warning: failed to unmarshal step %d for replay: %v", record.Index, err)
 			continue
 		}
+		if step.HTTP != nil || deniedStepRecord(record) {
+			continue
+		}
 
-		restoreTimeout := resolveStepTimeoutSeconds(step)
-		if restoreTimeout < 600 {
-			restoreTimeout = 600
+		execReq, _, denial := g.buildStepExecRequest(ctx, step)
+		if denial != "" {
+			log.Printf("Restore %s: step %d skipped: %s", sessionID, record.Index, strings.TrimSpace(denial))
+			continue
 		}
-		execReq := &interfaces.ExecRequest{
-			Command:        step.Command,
-			Env:            step.Env,
-			WorkingDir:     step.WorkDir,
-			TimeoutSeconds: restoreTimeout,
+		if execReq.TimeoutSeconds < 600 {
+			execReq.TimeoutSeconds = 600
 		}
-		if _, err := g.executorClient.Execute(ctx, newAllocation.PodIP, execReq); err != nil {
-			if err := g.releaseRestoreAllocation(*newAllocation); err != nil {
-				log.Printf("Warning: failed to release runtime %s after restore failure: %v", newAllocation.PodName, err)
-			}
-			return nil, fmt.Errorf("replay step %d failed: %w", record.Index, err)
+		if _, err := g.executorClient.Execute(ctx, podIP, execReq); err != nil {
+			return stepsReplayed, fmt.Errorf("replay step %d failed: %w", record.Index, err)
 		}
 		stepsReplayed++
 		if stepsReplayed%10 == 0 {
 			log.Printf("Restore %s: %d/%d steps done", sessionID, stepsReplayed, len(records))
 		}
 	}
+	return stepsReplayed, nil
+}
+
+// restoreRecords returns the steps to replay up to targetIdx. The in-memory
+// history is used unless the caller forces the durable path or the history
+// was lost (e.g. after a gateway restart) and a trajectory writer is set.
+func (g *Gateway) restoreRecords(ctx context.Context, sessionID string, s *session, targetIdx int, forceTrajectory bool) ([]StepRecord, bool, error) {
+	if !forceTrajectory {
+		records := s.History.GetUpTo(targetIdx)
+		if len(records) > 0 || targetIdx <= 0 {
+			return records, false, nil
+		}
+		if g.trajectoryWriter == nil {
+			return nil, false, fmt.Errorf("no history records up to index %d", targetIdx)
+		}
+		log.Printf("Restore %s: no in-memory history up to %d, falling back to stored trajectory", sessionID, targetIdx)
+	} else if g.trajectoryWriter == nil {
+		return nil, false, fmt.Errorf("fromTrajectory requested but no trajectory writer is configured")
+	}
+
+	records, err := g.replayRecordsFromTrajectory(ctx, sessionID, &targetIdx)
+	if err != nil {
+		return nil, false, err
+	}
+	return records, true, nil
+}
+
+// defaultSessionReadyTimeout bounds the wait for a replacement runtime when
+// GatewayConfig.SessionReadyTimeout is unset.
+const defaultSessionReadyTimeout = 5 * time.Minute
+
+// allocateReplacementRuntime allocates a fresh runtime from the session's
+// pool, used when the workspace has to be rebuilt from a clean sandbox.
+func (g *Gateway) allocateReplacementRuntime(ctx context.Context, sessionID string, s *session, sandboxName string) (*RuntimeAllocation, error) {
+	s.mu.RLock()
+	oldAllocation := s.runtimeAllocation()
+	lifecycle := g.sessionRuntimeLifecycleLocked(s, time.Now())
+	sessionLabels, sessionAnnotations := s.Info.Labels, s.Info.Annotations
+	s.mu.RUnlock()
+
+	readyTimeout := g.gwConfig.SessionReadyTimeout
+	if readyTimeout <= 0 {
+		readyTimeout = defaultSessionReadyTimeout
+	}
+	allocCtx, allocCancel := context.WithTimeout(ctx, readyTimeout)
+	defer allocCancel()
 
-	log.Printf("Restore %s complete: %d steps replayed on %s", sessionID, stepsReplayed, newAllocation.PodName)
+	newAllocation, err := g.runtimeAllocator.Allocate(allocCtx, RuntimeAllocateRequest{
+		PoolRef:     oldAllocation.PoolRef,
+		Namespace:   oldAllocation.Namespace,
+		SessionID:   sessionID,
+		SandboxName: sandboxName,
+		Labels:      sessionLabels,
+		Annotations: sessionAnnotations,
+		Lifecycle:   lifecycle,
+	})
+	if err != nil {
+		diag := g.diagnosePoolHealth(ctx, oldAllocation.PoolRef, oldAllocation.Namespace)
+		return nil, fmt.Errorf("%w (%s)", err, diag)
+	}
+	return newAllocation, nil
+}
 
+// swapSessionRuntime points the session at newAllocation and releases the
+// runtime it replaces in the background.
+func (g *Gateway) swapSessionRuntime(s *session, sandboxName string, newAllocation RuntimeAllocation) {
 	s.mu.Lock()
+	oldAllocation := s.runtimeAllocation()
 	s.Info.PodIP = newAllocation.PodIP
 	s.Info.PodName = newAllocation.PodName
-	s.Info.SandboxName = newSandboxName
-	s.Runtime = *newAllocation
+	s.Info.SandboxName = sandboxName
+	s.Runtime = newAllocation
 	s.mu.Unlock()
 
-	s.History.TruncateTo(targetIdx)
-	g.touchLastTaskTime(sessionID)
-	g.store.SyncHistory(sessionID)
-
 	go func() {
 		bgCtx, bgCancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer bgCancel()
@@ -301,11 +393,6 @@ func (g *Gateway) restoreNow(ctx context.Context, sessionID string, snapshotID s
 			}
 		}
 	}()
-
-	return &RestoreResponse{
-		SnapshotID:    snapshotID,
-		StepsReplayed: stepsReplayed,
-	}, nil
 }
 
 func (g *Gateway) releaseRestoreAllocation(allocation RuntimeAllocation) error {
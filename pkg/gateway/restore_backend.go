@@ -0,0 +1,101 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Restore backends selectable through GatewayConfig.RestoreBackend. Empty
+// picks tarball when checkpointing and a checkpoint store are configured,
+// replay otherwise.
+const (
+	RestoreBackendReplay  = "replay"
+	RestoreBackendTarball = "tarball"
+)
+
+// RestoreBackend captures session workspaces and rebuilds them on a fresh
+// runtime. Restore owns runtime allocation, the swap and history
+// bookkeeping; a backend only decides how a workspace is captured and
+// materialized.
+type RestoreBackend interface {
+	// Snapshot captures the session's workspace as of its latest step and
+	// returns an ID that Restore accepts.
+	Snapshot(ctx context.Context, sessionID string) (*SnapshotResponse, error)
+	// RestoreTo rebuilds the workspace described by target on the
+	// replacement runtime at target.PodIP.
+	RestoreTo(ctx context.Context, target RestoreTarget) (RestoreResult, error)
+}
+
+// RestoreTarget is the workspace state a RestoreBackend rebuilds.
+type RestoreTarget struct {
+	SessionID string
+	// StepIndex is the last step whose effects the workspace should hold.
+	StepIndex int
+	// Persisted is set when the snapshot ID named a persisted snapshot
+	// ("snap-N") rather than a bare step index.
+	Persisted bool
+	// PodIP is the replacement runtime.
+	PodIP string
+	// Records are the session's steps up to StepIndex.
+	Records []StepRecord
+}
+
+// RestoreResult reports how a RestoreBackend rebuilt a workspace.
+type RestoreResult struct {
+	// Phase labels the restore phase metric, e.g. "replay" or "extract".
+	Phase         string
+	StepsReplayed int
+}
+
+// SetRestoreBackend replaces the configured restore backend.
+func (g *Gateway) SetRestoreBackend(backend RestoreBackend) {
+	g.restoreBackend = backend
+}
+
+func (g *Gateway) activeRestoreBackend() RestoreBackend {
+	if g.restoreBackend != nil {
+		return g.restoreBackend
+	}
+	switch g.gwConfig.RestoreBackend {
+	case RestoreBackendReplay:
+		return replayRestoreBackend{g: g}
+	case RestoreBackendTarball:
+		return tarballRestoreBackend{g: g}
+	}
+	if g.gwConfig.SandboxCheckpointEnabled && g.checkpointStore != nil {
+		return tarballRestoreBackend{g: g}
+	}
+	return replayRestoreBackend{g: g}
+}
+
+// Snapshot captures the session's workspace with the configured restore
+// backend and returns an ID to pass to Restore.
+func (g *Gateway) Snapshot(ctx context.Context, sessionID string) (*SnapshotResponse, error) {
+	return g.activeRestoreBackend().Snapshot(ctx, sessionID)
+}
+
+// replayRestoreBackend rebuilds workspaces by re-running recorded steps.
+// Its snapshots are bare step indices, so it needs no storage.
+type replayRestoreBackend struct {
+	g *Gateway
+}
+
+func (b replayRestoreBackend) Snapshot(_ context.Context, sessionID string) (*SnapshotResponse, error) {
+	s, ok := b.g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+	last := s.History.Len() - 1
+	if last < 0 {
+		return nil, fmt.Errorf("session %s has no steps to snapshot", sessionID)
+	}
+	return &SnapshotResponse{SnapshotID: strconv.Itoa(last), Step: last}, nil
+}
+
+// RestoreTo replays the target's records. A persisted snapshot ID is
+// replayed too, since "snap-N" names the same state as step N.
+func (b replayRestoreBackend) RestoreTo(ctx context.Context, target RestoreTarget) (RestoreResult, error) {
+	replayed, err := b.g.replayRestoreRecords(ctx, target.SessionID, target.PodIP, target.Records)
+	return RestoreResult{Phase: "replay", StepsReplayed: replayed}, err
+}
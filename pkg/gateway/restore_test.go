@@ -0,0 +1,417 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+// replayExecutorClient succeeds every Execute and counts the calls.
+type replayExecutorClient struct {
+	interfaces.ExecutorClient
+	executed int
+}
+
+func (c *replayExecutorClient) Execute(_ context.Context, _ string, _ *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+	c.executed++
+	return &interfaces.ExecResponse{Done: true}, nil
+}
+
+func (c *replayExecutorClient) CloseConnection(string) error { return nil }
+
+func TestRestoreFromTrajectoryRequiresTrajectoryWriter(t *testing.T) {
+	store := newTestSessionStore("gw-restore")
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	_, err := gw.Restore(context.Background(), "gw-restore", RestoreRequest{SnapshotID: "0", FromTrajectory: true})
+	if err == nil || !strings.Contains(err.Error(), "no trajectory writer") {
+		t.Fatalf("Restore error = %v, want missing trajectory writer", err)
+	}
+}
+
+func TestRestoreWithoutHistoryOrTrajectoryFails(t *testing.T) {
+	store := newTestSessionStore("gw-restore")
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	_, err := gw.Restore(context.Background(), "gw-restore", RestoreRequest{SnapshotID: "3"})
+	if err == nil || !strings.Contains(err.Error(), "no history records up to index 3") {
+		t.Fatalf("Restore error = %v, want missing history", err)
+	}
+}
+
+func TestRestoreRecordsPhaseMetricsAndReplayedSteps(t *testing.T) {
+	store := newTestSessionStore("gw-restore")
+	s, _ := store.Get("gw-restore")
+	for _, cmd := range []string{"echo one", "echo two"} {
+		input, _ := json.Marshal(StepRequest{Command: []string{"sh", "-c", cmd}})
+		s.History.Add(StepRecord{Name: "exec", Input: input})
+	}
+	exec := &replayExecutorClient{}
+	metrics := &recordingMetricsCollector{}
+	alloc := &recordingRuntimeAllocator{allocation: RuntimeAllocation{PodIP: "10.0.0.2", PodName: "pod-2"}}
+	gw := New(nil, alloc, exec, metrics, nil, GatewayConfig{}, store)
+
+	resp, err := gw.Restore(context.Background(), "gw-restore", RestoreRequest{SnapshotID: "1"})
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if resp.StepsReplayed != 2 || exec.executed != 2 {
+		t.Fatalf("steps replayed = %d (executed %d), want 2", resp.StepsReplayed, exec.executed)
+	}
+	if strings.Join(metrics.restorePhases, ",") != "provision,replay" {
+		t.Fatalf("restore phases = %v, want provision then replay", metrics.restorePhases)
+	}
+	if metrics.restoreReplayedSteps != 2 {
+		t.Fatalf("replayed steps metric = %d, want 2", metrics.restoreReplayedSteps)
+	}
+}
+
+// snapshotExecutorClient serves a fixed checkpoint tar and records where
+// files were written and which commands ran.
+type snapshotExecutorClient struct {
+	replayExecutorClient
+	commands        []string
+	written         map[string]string
+	checkpointSteps []int
+	downloaded      []int
+}
+
+func (c *snapshotExecutorClient) ListCheckpointSteps(context.Context, string) ([]int, error) {
+	return c.checkpointSteps, nil
+}
+
+func (c *snapshotExecutorClient) Execute(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+	c.commands = append(c.commands, podIP+": "+strings.Join(req.Command, " "))
+	return c.replayExecutorClient.Execute(ctx, podIP, req)
+}
+
+func (c *snapshotExecutorClient) DownloadCheckpoint(_ context.Context, _ string, step int, dst io.Writer) error {
+	c.downloaded = append(c.downloaded, step)
+	_, err := dst.Write(bytes.Repeat([]byte{0}, 2048))
+	return err
+}
+
+func (c *snapshotExecutorClient) WriteFile(_ context.Context, podIP string, path string, content io.Reader, _ string) (*interfaces.FileWriteResult, error) {
+	io.Copy(io.Discard, content)
+	c.written[path] = podIP
+	return &interfaces.FileWriteResult{}, nil
+}
+
+func TestRestoreFromSnapshotExtractsInsteadOfReplaying(t *testing.T) {
+	store := newTestSessionStore("gw-snap")
+	s, _ := store.Get("gw-snap")
+	for _, cmd := range []string{"echo one", "echo two"} {
+		input, _ := json.Marshal(StepRequest{Command: []string{"sh", "-c", cmd}})
+		s.History.Add(StepRecord{Name: "exec", Input: input})
+	}
+	// The agent checkpointed only one of the two steps.
+	exec := &snapshotExecutorClient{written: map[string]string{}, checkpointSteps: []int{1}}
+	metrics := &recordingMetricsCollector{}
+	alloc := &recordingRuntimeAllocator{allocation: RuntimeAllocation{PodIP: "10.0.0.2", PodName: "pod-2"}}
+	cfg := GatewayConfig{SandboxCheckpointEnabled: true, CheckpointStorePath: t.TempDir()}
+	gw := New(nil, alloc, exec, metrics, nil, cfg, store)
+
+	snap, err := gw.Snapshot(context.Background(), "gw-snap")
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	if snap.SnapshotID != "snap-1" || snap.Step != 1 {
+		t.Fatalf("snapshot = %+v, want snap-1 at step 1", snap)
+	}
+	if len(exec.downloaded) != 1 || exec.downloaded[0] != 1 {
+		t.Fatalf("downloaded checkpoint steps = %v, want the agent's latest (1)", exec.downloaded)
+	}
+
+	resp, err := gw.Restore(context.Background(), "gw-snap", RestoreRequest{SnapshotID: snap.SnapshotID})
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if resp.StepsReplayed != 0 || exec.executed != 2 {
+		t.Fatalf("steps replayed = %d, commands = %v, want only extract and cleanup", resp.StepsReplayed, exec.commands)
+	}
+	if exec.written["/tmp/arl-restore.tar"] != "10.0.0.2" {
+		t.Fatalf("snapshot tar written to %v, want the replacement pod", exec.written)
+	}
+	if !strings.HasPrefix(exec.commands[0], "10.0.0.2: tar xf") {
+		t.Fatalf("first command = %q, want tar extraction on the replacement pod", exec.commands[0])
+	}
+	if strings.Join(metrics.restorePhases, ",") != "provision,extract" {
+		t.Fatalf("restore phases = %v, want provision then extract", metrics.restorePhases)
+	}
+}
+
+func TestRestoreUnknownSnapshotFails(t *testing.T) {
+	store := newTestSessionStore("gw-snap")
+	alloc := &recordingRuntimeAllocator{allocation: RuntimeAllocation{PodIP: "10.0.0.2", PodName: "pod-2"}}
+	cfg := GatewayConfig{SandboxCheckpointEnabled: true, CheckpointStorePath: t.TempDir()}
+	gw := New(nil, alloc, &replayExecutorClient{}, nil, nil, cfg, store)
+
+	_, err := gw.Restore(context.Background(), "gw-snap", RestoreRequest{SnapshotID: "snap-0"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Restore error = %v, want snapshot not found", err)
+	}
+}
+
+// fakeRestoreBackend records the target it is asked to restore.
+type fakeRestoreBackend struct {
+	target RestoreTarget
+}
+
+func (b *fakeRestoreBackend) Snapshot(context.Context, string) (*SnapshotResponse, error) {
+	return &SnapshotResponse{SnapshotID: "snap-1", Step: 1}, nil
+}
+
+func (b *fakeRestoreBackend) RestoreTo(_ context.Context, target RestoreTarget) (RestoreResult, error) {
+	b.target = target
+	return RestoreResult{Phase: "fake", StepsReplayed: 7}, nil
+}
+
+func TestRestoreDelegatesToRestoreBackend(t *testing.T) {
+	store := newTestSessionStore("gw-backend")
+	s, _ := store.Get("gw-backend")
+	for _, cmd := range []string{"echo one", "echo two", "echo three"} {
+		input, _ := json.Marshal(StepRequest{Command: []string{"sh", "-c", cmd}})
+		s.History.Add(StepRecord{Name: "exec", Input: input})
+	}
+	metrics := &recordingMetricsCollector{}
+	alloc := &recordingRuntimeAllocator{allocation: RuntimeAllocation{PodIP: "10.0.0.2", PodName: "pod-2"}}
+	gw := New(nil, alloc, &replayExecutorClient{}, metrics, nil, GatewayConfig{}, store)
+	backend := &fakeRestoreBackend{}
+	gw.SetRestoreBackend(backend)
+
+	snap, err := gw.Snapshot(context.Background(), "gw-backend")
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	resp, err := gw.Restore(context.Background(), "gw-backend", RestoreRequest{SnapshotID: snap.SnapshotID})
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	got := backend.target
+	if !got.Persisted || got.StepIndex != 1 || got.PodIP != "10.0.0.2" || len(got.Records) != 2 {
+		t.Fatalf("restore target = %+v, want persisted step 1 with 2 records on the replacement pod", got)
+	}
+	if resp.StepsReplayed != 7 || strings.Join(metrics.restorePhases, ",") != "provision,fake" {
+		t.Fatalf("steps replayed = %d, phases = %v, want the backend's result", resp.StepsReplayed, metrics.restorePhases)
+	}
+	if s.History.Len() != 2 {
+		t.Fatalf("history length = %d, want truncated to step 1", s.History.Len())
+	}
+}
+
+func TestReplayBackendSnapshotNeedsNoCheckpointStore(t *testing.T) {
+	store := newTestSessionStore("gw-replay")
+	s, _ := store.Get("gw-replay")
+	input, _ := json.Marshal(StepRequest{Command: []string{"true"}})
+	s.History.Add(StepRecord{Name: "exec", Input: input})
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	snap, err := gw.Snapshot(context.Background(), "gw-replay")
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+	if snap.SnapshotID != "0" || snap.Step != 0 {
+		t.Fatalf("snapshot = %+v, want replayable step index 0", snap)
+	}
+}
+
+// countingExecutorClient succeeds every Execute and is safe for concurrent use.
+type countingExecutorClient struct {
+	interfaces.ExecutorClient
+	executed atomic.Int32
+}
+
+func (c *countingExecutorClient) Execute(context.Context, string, *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+	c.executed.Add(1)
+	return &interfaces.ExecResponse{Done: true}, nil
+}
+
+func (c *countingExecutorClient) CloseConnection(string) error { return nil }
+
+// freshRuntimeAllocator hands out a new pod for every Allocate.
+type freshRuntimeAllocator struct {
+	operationRuntimeAllocator
+	allocated atomic.Int32
+}
+
+func (a *freshRuntimeAllocator) Allocate(_ context.Context, req RuntimeAllocateRequest) (*RuntimeAllocation, error) {
+	n := a.allocated.Add(1)
+	return &RuntimeAllocation{
+		PoolRef:     req.PoolRef,
+		Namespace:   req.Namespace,
+		SandboxName: req.SandboxName,
+		PodIP:       fmt.Sprintf("10.0.1.%d", n),
+		PodName:     fmt.Sprintf("pod-r%d", n),
+	}, nil
+}
+
+// TestRestoreConcurrentWithExecute is meant for go test -race: restores swap
+// the session's pod while executes read it.
+func TestRestoreConcurrentWithExecute(t *testing.T) {
+	store := newTestSessionStore("gw-race")
+	s, _ := store.Get("gw-race")
+	input, _ := json.Marshal(StepRequest{Command: []string{"true"}})
+	s.History.Add(StepRecord{Name: "exec", Input: input})
+	exec := &countingExecutorClient{}
+	gw := New(nil, &freshRuntimeAllocator{}, exec, nil, nil, GatewayConfig{}, store)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				req := ExecuteRequest{Steps: []StepRequest{{Name: "step", Command: []string{"true"}}}}
+				if _, err := gw.ExecuteSteps(context.Background(), "gw-race", req); err != nil {
+					t.Errorf("ExecuteSteps returned error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 5; j++ {
+			if _, err := gw.Restore(context.Background(), "gw-race", RestoreRequest{SnapshotID: "0"}); err != nil {
+				t.Errorf("Restore returned error: %v", err)
+				return
+			}
+			if _, err := gw.GetSession("gw-race"); err != nil {
+				t.Errorf("GetSession returned error: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	info, err := gw.GetSession("gw-race")
+	if err != nil {
+		t.Fatalf("GetSession returned error: %v", err)
+	}
+	if info.PodName != "pod-r5" {
+		t.Fatalf("PodName = %q, want the last restored pod", info.PodName)
+	}
+}
+
+func TestStepHistoryReplaceResetsNextIndex(t *testing.T) {
+	h := NewStepHistory()
+	h.Add(StepRecord{Name: "stale"})
+	h.Replace([]StepRecord{{Index: 0, Name: "a"}, {Index: 1, Name: "b"}, {Index: 2, Name: "c"}})
+
+	if h.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", h.Len())
+	}
+	if idx := h.Add(StepRecord{Name: "d"}); idx != 3 {
+		t.Fatalf("next index = %d, want 3", idx)
+	}
+}
+
+func TestStepHistoryMergeKeepsExistingOutput(t *testing.T) {
+	h := NewStepHistory()
+	h.Add(StepRecord{Name: "a", Output: StepOutput{Stdout: "kept\n"}})
+	h.Merge([]StepRecord{{Index: 2, Name: "c"}, {Index: 0, Name: "a"}, {Index: 1, Name: "b"}})
+
+	records := h.GetAll()
+	if len(records) != 3 || records[0].Index != 0 || records[1].Index != 1 || records[2].Index != 2 {
+		t.Fatalf("records = %+v, want indexes 0, 1, 2", records)
+	}
+	if records[0].Output.Stdout != "kept\n" {
+		t.Fatalf("record 0 output = %+v, want the recorded output kept", records[0].Output)
+	}
+	if idx := h.Add(StepRecord{Name: "d"}); idx != 3 {
+		t.Fatalf("next index = %d, want 3", idx)
+	}
+}
+
+func TestReplayAppliesCommandAndEnvPolicy(t *testing.T) {
+	store := newTestSessionStore("gw-replay")
+	s, _ := store.Get("gw-replay")
+	addStep := func(step StepRequest, out StepOutput) {
+		input, _ := json.Marshal(step)
+		s.History.Add(StepRecord{Name: "exec", Input: input, Output: out})
+	}
+	// Denied when recorded, under a policy that has since changed.
+	addStep(StepRequest{Command: []string{"wget", "http://169.254.169.254/"}}, StepOutput{
+		ExitCode: commandDeniedExitCode,
+		Stderr:   `command "wget" ` + commandDeniedText + ` (matches "wget")` + "\n",
+	})
+	// Recorded before the current policy denied it.
+	addStep(StepRequest{Command: []string{"curl", "http://169.254.169.254/"}}, StepOutput{})
+	addStep(StepRequest{Command: []string{"ls"}, Env: map[string]string{"SECRET": "x", "OK": "1"}}, StepOutput{})
+
+	var ran []*interfaces.ExecRequest
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			ran = append(ran, req)
+			return &interfaces.ExecResponse{Done: true}, nil
+		},
+	}
+	cfg := GatewayConfig{ExecCommandDenyList: "curl", ExecEnvDenyList: "SECRET"}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, cfg, store)
+
+	resp, err := gw.ReplayFrom(context.Background(), "gw-replay", ReplayRequest{SourceSessionID: "gw-replay"})
+	if err != nil {
+		t.Fatalf("ReplayFrom returned error: %v", err)
+	}
+	if resp.StepsReplayed != 1 || len(ran) != 1 || ran[0].Command[0] != "ls" {
+		t.Fatalf("replayed %d steps (%d executed), want only ls", resp.StepsReplayed, len(ran))
+	}
+	if _, ok := ran[0].Env["SECRET"]; ok || ran[0].Env["OK"] != "1" {
+		t.Fatalf("replayed env = %v, want SECRET dropped and OK kept", ran[0].Env)
+	}
+}
+
+func TestRestoreSkipsDeniedCommands(t *testing.T) {
+	store := newTestSessionStore("gw-restore")
+	s, _ := store.Get("gw-restore")
+	for _, cmd := range [][]string{{"curl", "http://169.254.169.254/"}, {"make"}} {
+		input, _ := json.Marshal(StepRequest{Command: cmd})
+		s.History.Add(StepRecord{Name: "exec", Input: input})
+	}
+	exec := &snapshotExecutorClient{written: map[string]string{}}
+	alloc := &recordingRuntimeAllocator{allocation: RuntimeAllocation{PodIP: "10.0.0.2", PodName: "pod-2"}}
+	gw := New(nil, alloc, exec, nil, nil, GatewayConfig{ExecCommandDenyList: "curl"}, store)
+
+	resp, err := gw.Restore(context.Background(), "gw-restore", RestoreRequest{SnapshotID: "1"})
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if resp.StepsReplayed != 1 || len(exec.commands) != 1 || exec.commands[0] != "10.0.0.2: make" {
+		t.Fatalf("restore ran %v (%d replayed), want only make", exec.commands, resp.StepsReplayed)
+	}
+}
+
+func TestReplayAndRestoreKeepStepLimits(t *testing.T) {
+	store := newTestSessionStore("gw-limits")
+	s, _ := store.Get("gw-limits")
+	input, _ := json.Marshal(StepRequest{Command: []string{"make"}, MemoryBytes: 1 << 20, CPUSeconds: 10})
+	s.History.Add(StepRecord{Name: "exec", Input: input})
+	exec := &snapshotExecutorClient{written: map[string]string{}}
+	alloc := &recordingRuntimeAllocator{allocation: RuntimeAllocation{PodIP: "10.0.0.2", PodName: "pod-2"}}
+	gw := New(nil, alloc, exec, nil, nil, GatewayConfig{}, store)
+
+	if _, err := gw.ReplayFrom(context.Background(), "gw-limits", ReplayRequest{SourceSessionID: "gw-limits"}); err != nil {
+		t.Fatalf("ReplayFrom returned error: %v", err)
+	}
+	if _, err := gw.Restore(context.Background(), "gw-limits", RestoreRequest{SnapshotID: "0"}); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if len(exec.commands) != 2 {
+		t.Fatalf("commands = %v, want one replay and one restore", exec.commands)
+	}
+	for _, cmd := range exec.commands {
+		if !strings.Contains(cmd, "ulimit -v 1024") || !strings.Contains(cmd, "ulimit -S -t 10") {
+			t.Fatalf("command %q ran without the step's limits", cmd)
+		}
+	}
+}
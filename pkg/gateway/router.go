@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/pprof"
 	"path"
@@ -37,10 +39,13 @@ func SetupRoutes(gw *Gateway, authCfg *AuthConfig) chi.Router {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	r.Get("/readyz", handleReadyz(gw, false))
 
 	r.Route("/v1", func(r chi.Router) {
 		// Session creation (user role, no ownership)
 		r.With(authUser, maxBodySize(10*1024*1024)).Post("/sessions", handleCreateSession(gw))
+		r.With(authUser, maxBodySize(10*1024*1024)).Post("/sessions:batch", handleCreateSessionsBatch(gw))
+		r.With(authUser, maxBodySize(10*1024*1024)).Post("/run", handleRun(gw))
 
 		// Session-scoped endpoints
 		r.Route("/sessions/{id}", func(r chi.Router) {
@@ -53,21 +58,29 @@ func SetupRoutes(gw *Gateway, authCfg *AuthConfig) chi.Router {
 			r.Group(func(r chi.Router) {
 				r.Use(sessionOwnership(gw))
 				r.Delete("/", handleDeleteSession(gw))
+				r.Patch("/", handleUpdateSession(gw))
 				r.With(maxBodySize(10 * 1024 * 1024)).Patch("/network-policy", handleUpdateNetworkPolicy(gw))
 				r.Post("/suspend", handleSuspendSession(gw))
 				r.Post("/resume", handleResumeSession(gw))
 				r.Get("/iroh-addr", handleGetIrohAddr(gw))
 				r.With(maxBodySize(10 * 1024 * 1024)).Post("/execute", handleExecute(gw))
+				r.With(maxBodySize(10 * 1024 * 1024)).Post("/execute/stream", handleExecuteStream(gw))
 				r.With(maxBodySize(10 * 1024 * 1024)).Post("/containers/{container}/execute", handleExecuteContainer(gw))
 				r.Get("/operations/{operationID}", handleGetExecuteOperation(gw))
+				r.Post("/operations/{operationID}/cancel", handleCancelExecuteOperation(gw))
 				r.Post("/upload-file", handleUploadFile(gw))
+				r.Post("/upload-archive", handleUploadArchive(gw))
+				r.With(maxBodySize(10 * 1024 * 1024)).Post("/patch-file", handlePatchFile(gw))
 				r.With(maxBodySize(10 * 1024 * 1024)).Post("/download-file", handleDownloadFile(gw))
+				r.Post("/snapshot", handleSnapshot(gw))
 				r.Post("/restore", handleRestore(gw))
+				r.Post("/reset", handleReset(gw))
 				r.Post("/replay", handleReplay(gw))
 				r.Get("/shell", handleShell(gw, authCfg))
 				r.Get("/tunnel/{port}", handleTunnel(gw, authCfg))
 				r.Get("/history", handleGetHistory(gw))
 				r.Get("/trajectory", handleGetTrajectory(gw))
+				r.Get("/stats", handleGetSessionStats(gw))
 				r.Get("/logs", handleSessionLogs(gw))
 			})
 		})
@@ -88,6 +101,7 @@ func SetupRoutes(gw *Gateway, authCfg *AuthConfig) chi.Router {
 				r.Post("/destroy", handleDestroyPool(gw))
 				r.Post("/prefetch", handlePrefetchPool(gw))
 				r.Get("/logs", handlePoolLogs(gw))
+				r.Get("/recommendation", handleGetPoolRecommendation(gw))
 			})
 			r.Post("/managed/sessions", handleCreateManagedSession(gw))
 			r.Delete("/managed/experiments/{id}", handleDeleteExperiment(gw))
@@ -203,12 +217,15 @@ func (w *metricResponseWriter) Unwrap() http.ResponseWriter {
 }
 
 // SetupInternalRoutes builds a chi.Router for the internal-only port
-// (metrics, debug, alertmanager webhook). No authentication.
+// (metrics, debug, detailed readiness, alertmanager webhook). No
+// authentication.
 func SetupInternalRoutes(hc *HealthChecker) chi.Router {
 	r := chi.NewRouter()
 
 	if hc != nil {
 		r.Get("/debug/health", hc.HandleDebugHealth())
+		r.Get("/readyz", handleReadyz(hc.gw, true))
+		r.Get("/debug/image-locality", handleImageLocalityDebug(hc.gw))
 		r.Post("/internal/alertmanager-webhook", hc.HandleAlertManagerWebhook())
 	}
 
@@ -218,7 +235,7 @@ func SetupInternalRoutes(hc *HealthChecker) chi.Router {
 	r.Get("/debug/pprof/symbol", pprof.Symbol)
 	r.Get("/debug/pprof/trace", pprof.Trace)
 
-	r.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	r.Handle("/metrics", metricsHandler())
 
 	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -228,6 +245,18 @@ func SetupInternalRoutes(hc *HealthChecker) chi.Router {
 	return r
 }
 
+// SetupMetricsRoutes serves /metrics alone, for a listener that is reachable
+// from outside the pod while the internal router stays on loopback.
+func SetupMetricsRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Handle("/metrics", metricsHandler())
+	return r
+}
+
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{})
+}
+
 func handleCreateSession(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateSessionRequest
@@ -255,6 +284,88 @@ func handleCreateSession(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+func handleCreateSessionsBatch(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BatchCreateSessionsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.Template.Image == "" && req.Template.Profile == "" {
+			writeError(w, http.StatusBadRequest, "image or profile is required")
+			return
+		}
+		if req.Template.Mode != "" && !validSessionMode(req.Template.Mode) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid session mode: %q", req.Template.Mode))
+			return
+		}
+
+		resp, err := gw.CreateSessionsBatch(r.Context(), req)
+		if errors.Is(err, ErrTooManySessions) || errors.Is(err, ErrGatewayDraining) {
+			writeGatewayError(w, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		status := http.StatusCreated
+		if len(resp.Errors) > 0 {
+			status = http.StatusMultiStatus
+		}
+		writeJSON(w, status, resp)
+	}
+}
+
+func handleRun(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.Session.Image == "" && req.Session.Profile == "" {
+			writeError(w, http.StatusBadRequest, "image or profile is required")
+			return
+		}
+		if req.Session.Mode != "" && !validSessionMode(req.Session.Mode) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid session mode: %q", req.Session.Mode))
+			return
+		}
+		if len(req.Execute.Steps) == 0 {
+			writeError(w, http.StatusBadRequest, "steps is required")
+			return
+		}
+		if req.Execute.ParallelismLimit < 0 {
+			writeError(w, http.StatusBadRequest, "parallelismLimit cannot be negative")
+			return
+		}
+		if _, _, err := stepGraph(req.Execute.Steps); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := validateSteps(req.Execute.Steps); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := gw.validateStepConcurrency(req.Execute); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		resp, err := gw.Run(r.Context(), req)
+		if err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 func handleGetSession(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -332,6 +443,27 @@ func handleDeleteSession(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+func handleUpdateSession(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		var req UpdateSessionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.IdleTimeoutSeconds < 0 {
+			writeError(w, http.StatusBadRequest, "idleTimeoutSeconds cannot be negative")
+			return
+		}
+		info, err := gw.UpdateSession(id, req)
+		if err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	}
+}
+
 func handleSuspendSession(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -424,8 +556,28 @@ func handleExecute(gw *Gateway) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, "steps is required")
 			return
 		}
+		if req.ParallelismLimit < 0 {
+			writeError(w, http.StatusBadRequest, "parallelismLimit cannot be negative")
+			return
+		}
+		if _, _, err := stepGraph(req.Steps); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := validateSteps(req.Steps); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := gw.validateStepConcurrency(req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		if r.Header.Get("Accept") == "text/event-stream" && req.OperationID == "" {
+			if hasStepDependencies(req.Steps) {
+				writeError(w, http.StatusBadRequest, "dependsOn is not supported for streaming execution")
+				return
+			}
 			gw.ExecuteStepsSSE(w, r.Context(), id, req)
 			return
 		}
@@ -440,6 +592,40 @@ func handleExecute(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+// handleExecuteStream always streams step output as SSE, regardless of the
+// Accept header. Client disconnects cancel the request context, which stops
+// the running command.
+func handleExecuteStream(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var req ExecuteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(req.Steps) == 0 {
+			writeError(w, http.StatusBadRequest, "steps is required")
+			return
+		}
+		if req.OperationID != "" {
+			writeError(w, http.StatusBadRequest, "operationID is not supported for streaming execution")
+			return
+		}
+		if hasStepDependencies(req.Steps) {
+			writeError(w, http.StatusBadRequest, "dependsOn is not supported for streaming execution")
+			return
+		}
+		if err := validateSteps(req.Steps); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		gw.ExecuteStepsSSE(w, r.Context(), id, req)
+	}
+}
+
 func handleGetExecuteOperation(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -457,6 +643,23 @@ func handleGetExecuteOperation(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+func handleCancelExecuteOperation(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		operationID := chi.URLParam(r, "operationID")
+		if operationID == "" {
+			writeError(w, http.StatusBadRequest, "operationID is required")
+			return
+		}
+		info, err := gw.CancelOperation(r.Context(), id, operationID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	}
+}
+
 func handleExecuteContainer(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -475,6 +678,10 @@ func handleExecuteContainer(gw *Gateway) http.HandlerFunc {
 			writeError(w, http.StatusBadRequest, "steps is required")
 			return
 		}
+		if err := validateSteps(req.Steps); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		resp, err := gw.ExecuteContainerSteps(r.Context(), id, container, req)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
@@ -504,6 +711,91 @@ func handleUploadFile(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+// handleUploadArchive accepts a raw tar or tar.gz body, or a
+// multipart/form-data upload whose "archive" part holds the archive.
+func handleUploadArchive(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var archive io.Reader = r.Body
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			mr, err := r.MultipartReader()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			part, err := nextArchivePart(mr)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			defer part.Close()
+			archive = part
+		}
+
+		resp, err := gw.UploadArchive(r.Context(), id, archive)
+		if err != nil && resp != nil {
+			resp.Error = err.Error()
+			writeJSON(w, httpStatusForError(err), resp)
+			return
+		}
+		if err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func nextArchivePart(mr *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("multipart body has no archive part")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "archive" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+func handlePatchFile(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var req PatchFileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Path == "" || req.Patch == "" {
+			writeError(w, http.StatusBadRequest, "path and patch are required")
+			return
+		}
+
+		resp, err := gw.PatchFile(r.Context(), id, req)
+		if err != nil {
+			var rejectErr *PatchRejectError
+			if errors.As(err, &rejectErr) {
+				writeJSON(w, http.StatusConflict, ErrorResponse{
+					Error:  rejectErr.Error(),
+					Detail: strings.Join(rejectErr.Rejects, "\n"),
+				})
+				return
+			}
+			writeGatewayError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 func handleDownloadFile(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -614,6 +906,39 @@ func handleRestore(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+func handleSnapshot(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := gw.Snapshot(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func handleReset(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var req ResetRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		resp, err := gw.Reset(r.Context(), id, req)
+		if err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 func handleGetHistory(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -626,17 +951,72 @@ func handleGetHistory(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+func handleGetSessionStats(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := gw.GetSessionStats(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeGatewayError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, stats)
+	}
+}
+
 func handleGetTrajectory(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
-		data, err := gw.ExportTrajectory(id)
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "jsonl":
+		case "messages":
+			messages, err := gw.ExportTrajectoryMessages(id)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, messages)
+			return
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported trajectory format %q (use jsonl or messages)", format))
+			return
+		}
+		q := r.URL.Query()
+		page := TrajectoryPage{FromStore: q.Get("source") == "storage"}
+		for _, p := range []struct {
+			name string
+			dst  *int
+		}{{"offset", &page.Offset}, {"limit", &page.Limit}} {
+			raw := strings.TrimSpace(q.Get(p.name))
+			if raw == "" {
+				continue
+			}
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, p.name+" must be a non-negative integer")
+				return
+			}
+			*p.dst = n
+		}
+		entries, err := gw.GetTrajectoryPage(r.Context(), id, page)
 		if err != nil {
-			writeError(w, http.StatusNotFound, err.Error())
+			switch {
+			case errors.Is(err, errTrajectoryStoreNotConfigured):
+				writeError(w, http.StatusServiceUnavailable, err.Error())
+			case errors.Is(err, ErrSessionNotFound):
+				writeError(w, http.StatusNotFound, err.Error())
+			default:
+				writeGatewayError(w, err)
+			}
 			return
 		}
+		// Encode line by line so a long page is never held as one buffer.
 		w.Header().Set("Content-Type", "application/x-ndjson")
 		w.WriteHeader(http.StatusOK)
-		w.Write(data)
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return
+			}
+		}
 	}
 }
 
@@ -679,6 +1059,23 @@ func handleGetPool(gw *Gateway) http.HandlerFunc {
 	}
 }
 
+func handleGetPoolRecommendation(gw *Gateway) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		ns := r.URL.Query().Get("namespace")
+		rec, err := gw.RecommendPoolReplicas(r.Context(), name, ns)
+		if err != nil {
+			if errors.Is(err, ErrNamespaceNotAllowed) {
+				writeGatewayError(w, err)
+				return
+			}
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, rec)
+	}
+}
+
 func handleScalePool(gw *Gateway) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := chi.URLParam(r, "name")
@@ -772,7 +1169,13 @@ func writeGatewayError(w http.ResponseWriter, err error) {
 		})
 		return
 	}
-	writeError(w, httpStatusForError(err), err.Error())
+	if errors.Is(err, ErrTooManySessions) {
+		w.Header().Set("Retry-After", sessionLimitRetryAfter)
+	}
+	writeJSON(w, httpStatusForError(err), ErrorResponse{
+		Error: err.Error(),
+		Code:  errorCodeForError(err),
+	})
 }
 
 func parseLogParams(r *http.Request) (bool, int32) {
@@ -879,6 +1282,8 @@ func handleListSessions(gw *Gateway) http.HandlerFunc {
 			Profile:      q.Get("profile"),
 			ExperimentID: q.Get("experiment"),
 			Status:       q.Get("status"),
+			PoolRef:      q.Get("poolRef"),
+			Namespace:    q.Get("namespace"),
 			Limit:        limit,
 			Cursor:       q.Get("cursor"),
 		})
@@ -951,14 +1356,10 @@ func handleCreateManagedSession(gw *Gateway) http.HandlerFunc {
 
 		info, err := gw.CreateManagedSession(r.Context(), req)
 		if err != nil {
-			if errors.Is(err, ErrNamespaceNotAllowed) {
+			if errors.Is(err, ErrNamespaceNotAllowed) || errors.Is(err, ErrPoolAtCapacity) {
 				writeGatewayError(w, err)
 				return
 			}
-			if errors.Is(err, ErrPoolAtCapacity) {
-				writeError(w, http.StatusTooManyRequests, err.Error())
-				return
-			}
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -28,6 +28,8 @@ type RuntimeAllocateRequest struct {
 	Managed              bool
 	ExperimentID         string
 	Mode                 string
+	Labels               map[string]string
+	Annotations          map[string]string
 	Lifecycle            RuntimeLifecycle
 	Env                  []RuntimeEnvVar
 	VolumeClaimTemplates []RuntimeVolumeClaimTemplate
@@ -55,6 +57,7 @@ type RuntimeLifecycle struct {
 	CreatedAt      time.Time
 	LastActivityAt time.Time
 	IdleTimeout    time.Duration
+	MaxLifetime    time.Duration
 	FinishedTTL    time.Duration
 }
 
@@ -65,15 +65,33 @@ func (g *Gateway) sessionSweepLoop() {
 func (g *Gateway) sweepSessions() {
 	now := time.Now()
 	g.store.Range(func(sessionID string, s *session) bool {
-		if atomic.LoadInt32(&s.activeExecs) > 0 {
-			return true
-		}
+		active := atomic.LoadInt32(&s.activeExecs)
 
 		s.mu.RLock()
 		lastTask := s.lastTaskTime
 		idleTimeout := s.idleTimeout
+		maxLifetime := s.maxLifetime
+		createdAt := s.createdAt
 		s.mu.RUnlock()
 
+		// Max lifetime is a hard limit: work still running on the session
+		// is aborted rather than allowed to extend it.
+		if maxLifetime > 0 && !createdAt.IsZero() && now.Sub(createdAt) > maxLifetime {
+			log.Printf("Session %s reached max lifetime %v, deleting", sessionID, maxLifetime)
+			if active > 0 {
+				log.Printf("Session %s: cancelling %d in-flight exec(s) past max lifetime", sessionID, active)
+				s.cancelExecs()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := g.deleteSession(ctx, sessionID, "max_lifetime"); err != nil {
+				log.Printf("Warning: failed to delete expired session %s: %v", sessionID, err)
+			}
+			cancel()
+			return true
+		}
+		if active > 0 {
+			return true
+		}
 		if idleTimeout > 0 && now.Sub(lastTask) > idleTimeout {
 			log.Printf("Session %s idle for %v (timeout=%v), deleting", sessionID, now.Sub(lastTask), idleTimeout)
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -85,6 +103,7 @@ func (g *Gateway) sweepSessions() {
 
 		return true
 	})
+	g.pruneIdempotencyKeys()
 }
 
 func (g *Gateway) sweepRuntimeClaims() {
@@ -154,7 +173,7 @@ func (g *Gateway) reapRuntimeClaim(ctx context.Context, claim *extensionsv1beta1
 	terminalExpired := claimTerminalExpired(claim, now)
 	idleExpired := false
 	if !hasSession {
-		idleExpired = claimIdleExpired(claim, now, g.gwConfig.IdleTimeout)
+		idleExpired = claimIdleExpired(claim, now, g.reloadable().IdleTimeout)
 	}
 	unhealthy, err := g.claimRuntimeUnhealthy(ctx, claim, now)
 	if err != nil {
@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -219,3 +220,60 @@ func TestRuntimeReaperDeletesStaleClaimForActiveSession(t *testing.T) {
 		t.Fatal("active session was deleted while reaping stale claim")
 	}
 }
+
+func TestSweepSessionsDeletesSessionPastMaxLifetime(t *testing.T) {
+	store := newTestSessionStore("gw-lifetime")
+	sess, _ := store.Get("gw-lifetime")
+	sess.createdAt = time.Now().Add(-2 * time.Hour)
+	sess.maxLifetime = time.Hour
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	gw.sweepSessions()
+
+	if info, err := gw.GetSession("gw-lifetime"); err == nil && info.DeletionReason != "max_lifetime" {
+		t.Fatalf("session = %#v, want deleted for max_lifetime", info)
+	}
+}
+
+func TestSweepSessionsCancelsExecsPastMaxLifetime(t *testing.T) {
+	store := newTestSessionStore("gw-lifetime")
+	sess, _ := store.Get("gw-lifetime")
+	sess.createdAt = time.Now().Add(-2 * time.Hour)
+	sess.maxLifetime = time.Hour
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	execCtx, _, _, release, err := gw.acquireSessionExec(context.Background(), "gw-lifetime")
+	if err != nil {
+		t.Fatalf("acquireSessionExec: %v", err)
+	}
+	defer release()
+	if n := atomic.LoadInt32(&sess.activeExecs); n == 0 {
+		t.Fatal("acquireSessionExec did not mark an active exec")
+	}
+
+	gw.sweepSessions()
+
+	select {
+	case <-execCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight exec was not cancelled at max lifetime")
+	}
+	if _, ok := store.Get("gw-lifetime"); ok {
+		t.Fatal("session with an in-flight exec survived its max lifetime")
+	}
+}
+
+func TestSweepSessionsKeepsIdleSessionWithActiveExec(t *testing.T) {
+	store := newTestSessionStore("gw-idle")
+	sess, _ := store.Get("gw-idle")
+	sess.lastTaskTime = time.Now().Add(-2 * time.Hour)
+	sess.idleTimeout = time.Hour
+	atomic.AddInt32(&sess.activeExecs, 1)
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	gw.sweepSessions()
+
+	if _, ok := store.Get("gw-idle"); !ok {
+		t.Fatal("session with an active exec was reaped as idle")
+	}
+}
@@ -45,6 +45,14 @@ func NewSandboxClaimRuntimeAllocator(k8sClient client.Client, namespace ...strin
 	}
 }
 
+// SetPollInterval sets how often a pending claim is checked for readiness.
+// Non-positive values keep the current interval.
+func (a *SandboxClaimRuntimeAllocator) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		a.pollInterval = d
+	}
+}
+
 func (a *SandboxClaimRuntimeAllocator) Start(ctx context.Context) error {
 	return nil
 }
@@ -73,15 +81,16 @@ func (a *SandboxClaimRuntimeAllocator) Allocate(ctx context.Context, req Runtime
 	}
 	claimName := runtimeDNSLabel(claimBase)
 	now := time.Now().UTC()
-	annotations := map[string]string{
-		labels.SessionAnnotation:      req.SessionID,
-		labels.SandboxLabelKey:        req.SandboxName,
-		labels.LastActivityAnnotation: now.Format(time.RFC3339),
-	}
-	podAnnotations := map[string]string{
-		labels.SessionAnnotation:      req.SessionID,
-		labels.LastActivityAnnotation: now.Format(time.RFC3339),
-	}
+	// Caller metadata is copied first so the gateway's own keys always win.
+	annotations := copyStringMap(req.Annotations)
+	annotations[labels.SessionAnnotation] = req.SessionID
+	annotations[labels.SandboxLabelKey] = req.SandboxName
+	annotations[labels.LastActivityAnnotation] = now.Format(time.RFC3339)
+	podAnnotations := copyStringMap(req.Annotations)
+	podAnnotations[labels.SessionAnnotation] = req.SessionID
+	podAnnotations[labels.LastActivityAnnotation] = now.Format(time.RFC3339)
+	claimLabels := copyStringMap(req.Labels)
+	claimLabels[labels.PoolLabelKey] = req.PoolRef
 	if req.OwnerKeyHash != "" {
 		annotations[labels.OwnerKeyHashAnnotation] = req.OwnerKeyHash
 		podAnnotations[labels.OwnerKeyHashAnnotation] = req.OwnerKeyHash
@@ -101,17 +110,16 @@ func (a *SandboxClaimRuntimeAllocator) Allocate(ctx context.Context, req Runtime
 	annotateLifecycle(annotations, req.Lifecycle)
 	claim := &extensionsv1beta1.SandboxClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      claimName,
-			Namespace: req.Namespace,
-			Labels: map[string]string{
-				labels.PoolLabelKey: req.PoolRef,
-			},
+			Name:        claimName,
+			Namespace:   req.Namespace,
+			Labels:      claimLabels,
 			Annotations: annotations,
 		},
 		Spec: extensionsv1beta1.SandboxClaimSpec{
 			WarmPoolRef: extensionsv1beta1.SandboxWarmPoolRef{Name: req.PoolRef},
 			Lifecycle:   sandboxClaimLifecycle(now, req.Lifecycle),
 			AdditionalPodMetadata: sandboxv1beta1.PodMetadata{
+				Labels:      req.Labels,
 				Annotations: podAnnotations,
 			},
 			Env:                  sandboxClaimEnv(req.Env),
@@ -139,8 +147,9 @@ func (a *SandboxClaimRuntimeAllocator) Allocate(ctx context.Context, req Runtime
 		return nil, cause
 	}
 
-	ticker := time.NewTicker(a.pollInterval)
-	defer ticker.Stop()
+	delay := a.pollInterval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 	for {
 		latest := &extensionsv1beta1.SandboxClaim{}
 		if err := a.k8sClient.Get(ctx, types.NamespacedName{Name: claimName, Namespace: req.Namespace}, latest); err != nil {
@@ -166,11 +175,35 @@ func (a *SandboxClaimRuntimeAllocator) Allocate(ctx context.Context, req Runtime
 				err = fmt.Errorf("%w (%s)", err, reason)
 			}
 			return cleanupCreatedClaim(err)
-		case <-ticker.C:
+		case <-timer.C:
 		}
+		delay = nextClaimPollDelay(delay, a.pollInterval, latest.Status.SandboxStatus.Name != "")
+		timer.Reset(delay)
 	}
 }
 
+// maxUnboundClaimPollInterval caps the backoff while a claim waits for an
+// idle sandbox.
+const maxUnboundClaimPollInterval = 5 * time.Second
+
+// nextClaimPollDelay backs off polling of a claim that is still waiting for
+// an idle sandbox, so an exhausted pool is not hammered by every pending
+// create. Once the claim is bound the base interval is used again, keeping
+// readiness detection for a starting sandbox fast.
+func nextClaimPollDelay(current, base time.Duration, bound bool) time.Duration {
+	if bound || current <= 0 {
+		return base
+	}
+	next := current * 2
+	if next > maxUnboundClaimPollInterval {
+		next = maxUnboundClaimPollInterval
+	}
+	if next < base {
+		next = base
+	}
+	return next
+}
+
 // describePendingClaim explains why a claim has not become ready: container
 // states of the bound sandbox pod when the claim is bound, otherwise the
 // pool's warm sandbox pods. The parent wait context is already done here, so
@@ -464,6 +497,9 @@ func annotateLifecycle(annotations map[string]string, lifecycle RuntimeLifecycle
 	if lifecycle.IdleTimeout > 0 {
 		annotations[labels.IdleTimeoutAnnotation] = durationSecondsString(lifecycle.IdleTimeout)
 	}
+	if lifecycle.MaxLifetime > 0 {
+		annotations[labels.MaxLifetimeAnnotation] = durationSecondsString(lifecycle.MaxLifetime)
+	}
 	if lifecycle.FinishedTTL > 0 {
 		annotations[labels.FinishedTTLAnnotation] = durationSecondsString(lifecycle.FinishedTTL)
 	}
@@ -492,16 +528,29 @@ func sandboxClaimLifecycle(now time.Time, lifecycle RuntimeLifecycle) *extension
 	return lc
 }
 
+// runtimeShutdownTime is the earlier of the idle deadline and the
+// max-lifetime ceiling, or nil when neither applies.
 func runtimeShutdownTime(now time.Time, lifecycle RuntimeLifecycle) *time.Time {
-	lastActivityAt := lifecycle.LastActivityAt
-	if lastActivityAt.IsZero() {
-		lastActivityAt = now
-	}
+	var shutdownAt *time.Time
 	if lifecycle.IdleTimeout > 0 {
+		lastActivityAt := lifecycle.LastActivityAt
+		if lastActivityAt.IsZero() {
+			lastActivityAt = now
+		}
 		t := lastActivityAt.Add(lifecycle.IdleTimeout)
-		return &t
+		shutdownAt = &t
 	}
-	return nil
+	if lifecycle.MaxLifetime > 0 {
+		createdAt := lifecycle.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		t := createdAt.Add(lifecycle.MaxLifetime)
+		if shutdownAt == nil || t.Before(*shutdownAt) {
+			shutdownAt = &t
+		}
+	}
+	return shutdownAt
 }
 
 func durationSecondsString(d time.Duration) string {
@@ -113,6 +113,7 @@ func TestSandboxClaimRuntimeAllocatorAllocate(t *testing.T) {
 		Namespace:   namespace,
 		SessionID:   sessionID,
 		SandboxName: sandboxName,
+		Annotations: map[string]string{"example.com/run": "run-7"},
 		Env: []RuntimeEnvVar{
 			{Name: "AGENT_CONFIG_PATH", Value: "/workspace/agent.yaml"},
 			{Name: "PRIVATE_MODE", Value: "enabled", ContainerName: "private"},
@@ -155,6 +156,12 @@ func TestSandboxClaimRuntimeAllocatorAllocate(t *testing.T) {
 	if got := claim.Spec.AdditionalPodMetadata.Annotations[labels.SessionAnnotation]; got != sessionID {
 		t.Fatalf("pod metadata session annotation = %q, want %q", got, sessionID)
 	}
+	if got := claim.Annotations["example.com/run"]; got != "run-7" {
+		t.Fatalf("claim caller annotation = %q, want run-7", got)
+	}
+	if got := claim.Spec.AdditionalPodMetadata.Annotations["example.com/run"]; got != "run-7" {
+		t.Fatalf("pod metadata caller annotation = %q, want run-7", got)
+	}
 	if len(claim.Spec.AdditionalPodMetadata.Labels) != 0 {
 		t.Fatalf("pod metadata labels = %#v, want none", claim.Spec.AdditionalPodMetadata.Labels)
 	}
@@ -286,3 +293,48 @@ func TestSandboxClaimRuntimeAllocatorTouchReturnsNotFound(t *testing.T) {
 		t.Fatalf("Touch error = %v, want NotFound", err)
 	}
 }
+
+func TestNextClaimPollDelayBacksOffUntilBound(t *testing.T) {
+	base := 500 * time.Millisecond
+	delay := base
+	var got []time.Duration
+	for range 5 {
+		delay = nextClaimPollDelay(delay, base, false)
+		got = append(got, delay)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, maxUnboundClaimPollInterval, maxUnboundClaimPollInterval}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unbound delays = %v, want %v", got, want)
+		}
+	}
+	if delay := nextClaimPollDelay(maxUnboundClaimPollInterval, base, true); delay != base {
+		t.Fatalf("bound delay = %v, want base %v", delay, base)
+	}
+	if delay := nextClaimPollDelay(10*time.Second, 10*time.Second, false); delay != 10*time.Second {
+		t.Fatalf("delay with base above cap = %v, want 10s", delay)
+	}
+}
+
+func TestRuntimeShutdownTimeCapsIdleDeadlineAtMaxLifetime(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	lifecycle := RuntimeLifecycle{
+		CreatedAt:      created,
+		LastActivityAt: created.Add(50 * time.Minute),
+		IdleTimeout:    30 * time.Minute,
+		MaxLifetime:    time.Hour,
+	}
+	if got := runtimeShutdownTime(created, lifecycle); got == nil || !got.Equal(created.Add(time.Hour)) {
+		t.Fatalf("shutdown = %v, want max-lifetime ceiling %v", got, created.Add(time.Hour))
+	}
+
+	lifecycle.LastActivityAt = created.Add(10 * time.Minute)
+	if got := runtimeShutdownTime(created, lifecycle); got == nil || !got.Equal(created.Add(40*time.Minute)) {
+		t.Fatalf("shutdown = %v, want idle deadline %v", got, created.Add(40*time.Minute))
+	}
+
+	lifecycle.IdleTimeout = 0
+	if got := runtimeShutdownTime(created, lifecycle); got == nil || !got.Equal(created.Add(time.Hour)) {
+		t.Fatalf("shutdown = %v, want max-lifetime ceiling without idle timeout", got)
+	}
+}
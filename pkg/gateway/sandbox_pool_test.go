@@ -22,6 +22,7 @@ import (
 	"github.com/Lincyaw/agent-env/pkg/scheduling"
 
 	sandboxv1beta1 "sigs.k8s.io/agent-sandbox/api/v1beta1"
+	sandboxcontrollers "sigs.k8s.io/agent-sandbox/controllers"
 	extensionsv1beta1 "sigs.k8s.io/agent-sandbox/extensions/api/v1beta1"
 )
 
@@ -123,6 +124,9 @@ func TestCreatePoolCreatesSandboxWarmPoolAndExecutableTemplate(t *testing.T) {
 		t.Fatal("template missing executor container")
 	}
 	executor := findContainer(podSpec.Containers, "executor")
+	if len(executor.Command) != 3 || !strings.Contains(executor.Command[2], " --workspace='/workspace' ") {
+		t.Fatalf("executor command = %q, want the agent rooted at /workspace", executor.Command)
+	}
 	assertResourceQuantity(t, executor.Resources.Requests[corev1.ResourceCPU], "500m")
 	assertResourceQuantity(t, executor.Resources.Requests[corev1.ResourceMemory], "512Mi")
 	assertResourceQuantity(t, executor.Resources.Limits[corev1.ResourceCPU], "8")
@@ -229,6 +233,170 @@ func TestCreatePoolRejectsConfigEnv(t *testing.T) {
 	}
 }
 
+func TestCreatePoolAppliesWorkspaceVolume(t *testing.T) {
+	cases := []struct {
+		name      string
+		spec      *WorkspaceVolumeSpec
+		wantMount string
+		check     func(t *testing.T, template *extensionsv1beta1.SandboxTemplate)
+	}{
+		{
+			name:      "memory emptyDir",
+			spec:      &WorkspaceVolumeSpec{EmptyDir: &WorkspaceEmptyDir{Medium: "Memory", SizeLimit: "2Gi"}},
+			wantMount: "/workspace",
+			check: func(t *testing.T, template *extensionsv1beta1.SandboxTemplate) {
+				vol := findVolume(template.Spec.PodTemplate.Spec.Volumes, "workspace")
+				if vol == nil || vol.EmptyDir == nil || vol.EmptyDir.Medium != corev1.StorageMediumMemory {
+					t.Fatalf("workspace volume = %#v, want memory EmptyDir", vol)
+				}
+				assertResourceQuantity(t, *vol.EmptyDir.SizeLimit, "2Gi")
+			},
+		},
+		{
+			name:      "claim template",
+			spec:      &WorkspaceVolumeSpec{MountPath: "/data", ClaimTemplate: &WorkspaceClaimTemplate{StorageSize: "20Gi", StorageClassName: "fast"}},
+			wantMount: "/data",
+			check: func(t *testing.T, template *extensionsv1beta1.SandboxTemplate) {
+				if vol := findVolume(template.Spec.PodTemplate.Spec.Volumes, "workspace"); vol != nil {
+					t.Fatalf("claim-backed workspace has pod volume %#v; the sandbox controller adds it", vol)
+				}
+				vcts := template.Spec.VolumeClaimTemplates
+				if len(vcts) != 1 || vcts[0].Name != "workspace" || *vcts[0].Spec.StorageClassName != "fast" {
+					t.Fatalf("volumeClaimTemplates = %#v, want one fast workspace claim", vcts)
+				}
+			},
+		},
+		{
+			name:      "hostPath",
+			spec:      &WorkspaceVolumeSpec{HostPath: "/mnt/scratch"},
+			wantMount: "/workspace",
+			check: func(t *testing.T, template *extensionsv1beta1.SandboxTemplate) {
+				vol := findVolume(template.Spec.PodTemplate.Spec.Volumes, "workspace")
+				if vol == nil || vol.HostPath == nil || vol.HostPath.Path != "/mnt/scratch" {
+					t.Fatalf("workspace volume = %#v, want hostPath /mnt/scratch", vol)
+				}
+				executor := findContainer(template.Spec.PodTemplate.Spec.Containers, "executor")
+				for _, m := range executor.VolumeMounts {
+					if m.Name == "workspace" && m.SubPathExpr != "$(ARL_POD_NAME)" {
+						t.Fatalf("workspace mount = %#v, want a per-pod subPathExpr", m)
+					}
+				}
+				var podName *corev1.EnvVar
+				for i := range executor.Env {
+					if executor.Env[i].Name == "ARL_POD_NAME" {
+						podName = &executor.Env[i]
+					}
+				}
+				if podName == nil || podName.ValueFrom == nil || podName.ValueFrom.FieldRef.FieldPath != "metadata.name" {
+					t.Fatalf("ARL_POD_NAME env = %#v, want the pod name from the downward API", podName)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			k8sClient := fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).Build()
+			gw := &Gateway{k8sClient: k8sClient, gwConfig: GatewayConfig{GRPCAuthToken: "test-token", WorkspaceHostPathPrefixes: "/mnt"}}
+			if err := gw.CreatePool(context.Background(), CreatePoolRequest{
+				Name:            "pool",
+				Namespace:       "default",
+				Image:           "busybox:1.36.1",
+				Replicas:        1,
+				WorkspaceVolume: tc.spec,
+			}); err != nil {
+				t.Fatalf("CreatePool returned error: %v", err)
+			}
+			template := &extensionsv1beta1.SandboxTemplate{}
+			if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "pool-template", Namespace: "default"}, template); err != nil {
+				t.Fatalf("get sandbox template: %v", err)
+			}
+			executor := findContainer(template.Spec.PodTemplate.Spec.Containers, "executor")
+			mounted := false
+			for _, m := range executor.VolumeMounts {
+				if m.Name == "workspace" && m.MountPath == tc.wantMount {
+					mounted = true
+				}
+			}
+			if !mounted {
+				t.Fatalf("executor mounts = %#v, want workspace at %s", executor.VolumeMounts, tc.wantMount)
+			}
+			if !strings.Contains(executor.Command[2], " --workspace='"+tc.wantMount+"' ") {
+				t.Fatalf("executor command = %q, want the agent rooted at %s", executor.Command[2], tc.wantMount)
+			}
+			tc.check(t, template)
+		})
+	}
+}
+
+func TestCreatePoolRejectsConflictingWorkspaceVolumeSources(t *testing.T) {
+	gw := &Gateway{
+		k8sClient: fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).Build(),
+		gwConfig:  GatewayConfig{GRPCAuthToken: "test-token"},
+	}
+	err := gw.CreatePool(context.Background(), CreatePoolRequest{
+		Name:      "pool",
+		Namespace: "default",
+		Image:     "busybox:1.36.1",
+		WorkspaceVolume: &WorkspaceVolumeSpec{
+			EmptyDir: &WorkspaceEmptyDir{},
+			HostPath: "/mnt/scratch",
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("CreatePool error = %v, want mutually exclusive workspace sources", err)
+	}
+}
+
+func TestCreatePoolRejectsHostPathOutsideAllowedPrefixes(t *testing.T) {
+	gw := &Gateway{
+		k8sClient: fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).Build(),
+		gwConfig:  GatewayConfig{GRPCAuthToken: "test-token", WorkspaceHostPathPrefixes: "/mnt/scratch"},
+	}
+	for _, hostPath := range []string{"/", "/etc", "/mnt/scratch-other", "/mnt/scratch/../../etc"} {
+		err := gw.CreatePool(context.Background(), CreatePoolRequest{
+			Name:            "pool",
+			Namespace:       "default",
+			Image:           "busybox:1.36.1",
+			WorkspaceVolume: &WorkspaceVolumeSpec{HostPath: hostPath},
+		})
+		if err == nil || !strings.Contains(err.Error(), "workspaceVolume.hostPath") {
+			t.Fatalf("CreatePool with hostPath %q error = %v, want hostPath rejection", hostPath, err)
+		}
+	}
+}
+
+func TestCreatePoolAppliesExecutorProbes(t *testing.T) {
+	k8sClient := fake.NewClientBuilder().WithScheme(newGatewayTestScheme(t)).Build()
+	gw := &Gateway{k8sClient: k8sClient, gwConfig: GatewayConfig{
+		GRPCAuthToken:          "test-token",
+		ExecutorStartupTimeout: 90 * time.Second,
+		ExecutorLivenessPeriod: 20 * time.Second,
+	}}
+	if err := gw.CreatePool(context.Background(), CreatePoolRequest{
+		Name:           "pool",
+		Namespace:      "default",
+		Image:          "busybox:1.36.1",
+		Replicas:       1,
+		ExecutorProbes: &ExecutorProbeSpec{LivenessFailureThreshold: 5},
+	}); err != nil {
+		t.Fatalf("CreatePool returned error: %v", err)
+	}
+	template := &extensionsv1beta1.SandboxTemplate{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "pool-template", Namespace: "default"}, template); err != nil {
+		t.Fatalf("get sandbox template: %v", err)
+	}
+	executor := findContainer(template.Spec.PodTemplate.Spec.Containers, "executor")
+	if p := executor.StartupProbe; p == nil || p.PeriodSeconds*p.FailureThreshold != 90 {
+		t.Fatalf("startup probe = %#v, want a 90s budget", p)
+	}
+	if p := executor.ReadinessProbe; p == nil || p.PeriodSeconds != 5 {
+		t.Fatalf("readiness probe = %#v, want the default 5s period", p)
+	}
+	if p := executor.LivenessProbe; p == nil || p.PeriodSeconds != 20 || p.FailureThreshold != 5 || p.TCPSocket == nil {
+		t.Fatalf("liveness probe = %#v, want TCP every 20s with 5 failures", p)
+	}
+}
+
 func TestCreatePoolAppliesSchedulerNameAndImageLocalityHints(t *testing.T) {
 	scheme := newGatewayTestScheme(t)
 	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
@@ -266,6 +434,139 @@ func TestCreatePoolAppliesSchedulerNameAndImageLocalityHints(t *testing.T) {
 	}
 }
 
+func TestCreatePoolSpreadModeInjectsAntiAffinity(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	gw := &Gateway{
+		k8sClient: k8sClient,
+		gwConfig: GatewayConfig{
+			ImageLocalityEnabled: true,
+			GRPCAuthToken:        "test-token",
+		},
+	}
+
+	if err := gw.CreatePool(context.Background(), CreatePoolRequest{
+		Name:          "pool",
+		Namespace:     "default",
+		Image:         "python:3.12",
+		Replicas:      3,
+		ImageLocality: json.RawMessage(`{"mode":"spread"}`),
+	}); err != nil {
+		t.Fatalf("CreatePool returned error: %v", err)
+	}
+
+	template := &extensionsv1beta1.SandboxTemplate{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "pool-template", Namespace: "default"}, template); err != nil {
+		t.Fatalf("get sandbox template: %v", err)
+	}
+	if _, ok := template.Annotations[scheduling.ImageLocalityAnnotation]; ok {
+		t.Fatal("spread pool still carries the image locality annotation")
+	}
+	affinity := template.Spec.PodTemplate.Spec.Affinity
+	if affinity == nil || affinity.PodAntiAffinity == nil || len(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 2 {
+		t.Fatalf("affinity = %#v, want preferred pod anti-affinity", affinity)
+	}
+	term := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	if term.TopologyKey != corev1.LabelHostname || term.LabelSelector.MatchLabels[sandboxv1beta1.SandboxWarmPoolLabel] != sandboxcontrollers.NameHash("pool") {
+		t.Fatalf("anti-affinity term = %#v", term)
+	}
+
+	err := gw.CreatePool(context.Background(), CreatePoolRequest{
+		Name:          "other",
+		Namespace:     "default",
+		Image:         "python:3.12",
+		ImageLocality: json.RawMessage(`{"mode":"scatter"}`),
+	})
+	if err == nil || !strings.Contains(err.Error(), "imageLocality.mode") {
+		t.Fatalf("CreatePool with unknown mode = %v, want imageLocality.mode error", err)
+	}
+}
+
+func TestCreatePoolHardensExecutorAgentInitContainer(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	runAsUser := int64(65532)
+	gw := &Gateway{
+		k8sClient: k8sClient,
+		gwConfig: GatewayConfig{
+			GRPCAuthToken:                "test-token",
+			ExecutorAgentImagePullSecret: "registry-creds",
+			ExecutorAgentSecurityContext: &corev1.SecurityContext{
+				RunAsUser:              &runAsUser,
+				RunAsNonRoot:           boolPtr(true),
+				ReadOnlyRootFilesystem: boolPtr(true),
+			},
+		},
+	}
+
+	if err := gw.CreatePool(context.Background(), CreatePoolRequest{
+		Name:      "pool",
+		Namespace: "default",
+		Image:     "python:3.12",
+		Replicas:  1,
+	}); err != nil {
+		t.Fatalf("CreatePool returned error: %v", err)
+	}
+
+	template := &extensionsv1beta1.SandboxTemplate{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "pool-template", Namespace: "default"}, template); err != nil {
+		t.Fatalf("get sandbox template: %v", err)
+	}
+	podSpec := template.Spec.PodTemplate.Spec
+	if len(podSpec.ImagePullSecrets) != 1 || podSpec.ImagePullSecrets[0].Name != "registry-creds" {
+		t.Fatalf("ImagePullSecrets = %#v, want registry-creds", podSpec.ImagePullSecrets)
+	}
+	sc := findContainer(podSpec.InitContainers, executorAgentInitContainerName).SecurityContext
+	if sc == nil || sc.RunAsUser == nil || *sc.RunAsUser != 65532 || sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+		t.Fatalf("init container securityContext = %#v", sc)
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		t.Fatalf("allowPrivilegeEscalation = %v, want the gateway policy (false)", sc.AllowPrivilegeEscalation)
+	}
+	if executor := findContainer(podSpec.Containers, executorContainerName); executor.SecurityContext.RunAsUser != nil {
+		t.Fatalf("executor container inherited runAsUser %d", *executor.SecurityContext.RunAsUser)
+	}
+}
+
+func TestCreatePoolGivesExecutorAPerPodToken(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	gw := &Gateway{
+		k8sClient: k8sClient,
+		gwConfig:  GatewayConfig{GRPCAuthToken: "test-token", ExecutorAuthEnabled: true},
+	}
+	if err := gw.CreatePool(context.Background(), CreatePoolRequest{
+		Name:      "pool",
+		Namespace: "default",
+		Image:     "python:3.12",
+		Replicas:  1,
+	}); err != nil {
+		t.Fatalf("CreatePool returned error: %v", err)
+	}
+
+	template := &extensionsv1beta1.SandboxTemplate{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "pool-template", Namespace: "default"}, template); err != nil {
+		t.Fatalf("get sandbox template: %v", err)
+	}
+	podSpec := template.Spec.PodTemplate.Spec
+	derive := findContainer(podSpec.InitContainers, executorTokenInitContainerName)
+	if !hasVolumeMountName(derive.VolumeMounts, "executor-master-token") || !hasVolumeMountName(derive.VolumeMounts, "executor-token") {
+		t.Fatalf("token init container mounts = %#v, want master and derived token", derive.VolumeMounts)
+	}
+	executor := findContainer(podSpec.Containers, executorContainerName)
+	if hasVolumeMountName(executor.VolumeMounts, "executor-master-token") {
+		t.Fatal("executor container mounts the master token")
+	}
+	if !hasVolumeMountName(executor.VolumeMounts, "executor-token") {
+		t.Fatalf("executor container mounts = %#v, want the derived token", executor.VolumeMounts)
+	}
+	for _, env := range executor.Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			t.Fatalf("executor env %s reads secret %s", env.Name, env.ValueFrom.SecretKeyRef.Name)
+		}
+	}
+}
+
 func TestCreatePoolUsesConfiguredDefaultSandboxResources(t *testing.T) {
 	scheme := newGatewayTestScheme(t)
 	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
@@ -1022,6 +1323,15 @@ func hasContainer(containers []corev1.Container, name string) bool {
 	return findContainer(containers, name).Name != ""
 }
 
+func findVolume(volumes []corev1.Volume, name string) *corev1.Volume {
+	for i := range volumes {
+		if volumes[i].Name == name {
+			return &volumes[i]
+		}
+	}
+	return nil
+}
+
 func findContainer(containers []corev1.Container, name string) corev1.Container {
 	for _, container := range containers {
 		if container.Name == name {
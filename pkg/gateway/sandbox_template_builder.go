@@ -21,7 +21,16 @@ import (
 )
 
 const (
-	defaultGRPCAuthSecretName = "agent-env-grpc-token"
+	defaultGRPCAuthSecretName    = "agent-env-grpc-token"
+	defaultExecutorTLSSecretName = "agent-env-executor-tls"
+	// executorTLSMountPath holds the agent's tls.crt, tls.key and ca.crt.
+	executorTLSMountPath = "/etc/arl/executor-tls"
+
+	// executorTokenInitContainerName derives the pod's executor auth token
+	// from the master token, which is mounted into that init container only.
+	executorTokenInitContainerName = "derive-executor-token"
+	executorTokenMountPath         = "/var/run/arl-auth"
+	executorMasterTokenMountPath   = "/var/run/arl-master"
 )
 
 func sandboxTemplateName(poolName string) string {
@@ -130,6 +139,8 @@ func (g *Gateway) sandboxPodSpec(
 	image string,
 	resources corev1.ResourceRequirements,
 	privateContainers []PrivateContainerSpec,
+	probes *ExecutorProbeSpec,
+	workspaceDir string,
 ) corev1.PodSpec {
 	executorAgentImage := g.gwConfig.ExecutorAgentImage
 	if executorAgentImage == "" {
@@ -142,12 +153,15 @@ func (g *Gateway) sandboxPodSpec(
 	}
 
 	automount := false
-	executorCommand := fmt.Sprintf("exec /arl-bin/executor-agent --socket=/var/run/arl/exec.sock --workspace=/ --tcp-port=%d", executorPort)
+	// Relative step workdirs resolve against --workspace, and the agent
+	// keeps them inside it.
+	executorCommand := fmt.Sprintf("exec /arl-bin/executor-agent --socket=/var/run/arl/exec.sock --workspace=%s --tcp-port=%d",
+		shellQuote(workspaceDir), executorPort)
 	pod := corev1.PodSpec{
 		AutomountServiceAccountToken: &automount,
 		InitContainers: []corev1.Container{
 			{
-				Name:            "copy-executor-agent",
+				Name:            executorAgentInitContainerName,
 				Image:           executorAgentImage,
 				ImagePullPolicy: g.injectedPullPolicy(),
 				Command:         []string{"cp", "/executor-agent", "/arl-bin/executor-agent"},
@@ -171,27 +185,6 @@ func (g *Gateway) sandboxPodSpec(
 					{Name: "arl-bin", MountPath: "/arl-bin"},
 					{Name: "arl-socket", MountPath: "/var/run/arl"},
 				},
-				StartupProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(int32(executorPort))},
-					},
-					PeriodSeconds:    2,
-					FailureThreshold: 30,
-				},
-				ReadinessProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(int32(executorPort))},
-					},
-					PeriodSeconds:    5,
-					FailureThreshold: 3,
-				},
-				LivenessProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(int32(executorPort))},
-					},
-					PeriodSeconds:    10,
-					FailureThreshold: 3,
-				},
 			},
 		},
 		Volumes: []corev1.Volume{
@@ -199,6 +192,7 @@ func (g *Gateway) sandboxPodSpec(
 			{Name: "arl-socket", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
 		},
 	}
+	g.applyExecutorProbes(&pod, int32(executorPort), probes)
 	if g.gwConfig.SandboxCheckpointEnabled {
 		pod.Volumes = append(pod.Volumes, corev1.Volume{
 			Name:         "checkpoint-scratch",
@@ -217,6 +211,24 @@ func (g *Gateway) sandboxPodSpec(
 			}
 		}
 	}
+	if g.gwConfig.ExecutorTLSEnabled {
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name:         "executor-tls",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: g.executorTLSSecretName()}},
+		})
+		for i := range pod.Containers {
+			if pod.Containers[i].Name == "executor" {
+				pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, corev1.VolumeMount{
+					Name:      "executor-tls",
+					MountPath: executorTLSMountPath,
+					ReadOnly:  true,
+				})
+			}
+		}
+	}
+	if g.gwConfig.ExecutorAuthEnabled {
+		g.applyExecutorTokenDerivation(&pod, executorAgentImage)
+	}
 	if schedulerName := strings.TrimSpace(g.gwConfig.SchedulerName); schedulerName != "" {
 		pod.SchedulerName = schedulerName
 	}
@@ -232,11 +244,88 @@ func (g *Gateway) sandboxPodSpec(
 	if seccomp := g.sandboxSeccompProfile(); seccomp != nil {
 		pod.SecurityContext = &corev1.PodSecurityContext{SeccompProfile: seccomp}
 	}
+	g.applyExecutorAgentHardening(&pod)
 	g.applyContainerSecurityPolicy(&pod)
 	g.injectProxyEnv(&pod)
 	return pod
 }
 
+// applyExecutorTokenDerivation gives the executor a token of its own instead
+// of the shared master: an init container holding the master secret writes
+// hex(HMAC-SHA256(master, pod UID)) to an emptyDir, and only that emptyDir is
+// mounted into the executor. Sandbox commands run alongside the agent and can
+// read whatever it can, so a leaked token then opens this pod alone.
+func (g *Gateway) applyExecutorTokenDerivation(pod *corev1.PodSpec, executorAgentImage string) {
+	podUID := corev1.EnvVar{
+		Name:      "ARL_POD_UID",
+		ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.uid"}},
+	}
+	pod.Volumes = append(pod.Volumes,
+		corev1.Volume{
+			Name:         "executor-master-token",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: g.grpcAuthSecretName()}},
+		},
+		corev1.Volume{
+			Name:         "executor-token",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}},
+		},
+	)
+	pod.InitContainers = append(pod.InitContainers, corev1.Container{
+		Name:            executorTokenInitContainerName,
+		Image:           executorAgentImage,
+		ImagePullPolicy: g.injectedPullPolicy(),
+		Command: []string{
+			"/executor-agent",
+			"--derive-token-from=" + executorMasterTokenMountPath + "/token",
+			"--derive-token-to=" + executorTokenMountPath + "/token",
+		},
+		Env: []corev1.EnvVar{podUID},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "executor-master-token", MountPath: executorMasterTokenMountPath, ReadOnly: true},
+			{Name: "executor-token", MountPath: executorTokenMountPath},
+		},
+	})
+	for i := range pod.Containers {
+		if pod.Containers[i].Name == executorContainerName {
+			pod.Containers[i].Env = append(pod.Containers[i].Env,
+				podUID,
+				corev1.EnvVar{Name: "ARL_EXECUTOR_TOKEN_FILE", Value: executorTokenMountPath + "/token"},
+			)
+			pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      "executor-token",
+				MountPath: executorTokenMountPath,
+				ReadOnly:  true,
+			})
+		}
+	}
+}
+
+// applyExecutorAgentHardening adds the configured pull secret and security
+// context for the injected executor-agent init containers. The pull secret is
+// appended so secrets already on the pod keep working.
+func (g *Gateway) applyExecutorAgentHardening(pod *corev1.PodSpec) {
+	if secret := strings.TrimSpace(g.gwConfig.ExecutorAgentImagePullSecret); secret != "" {
+		present := false
+		for _, ref := range pod.ImagePullSecrets {
+			if ref.Name == secret {
+				present = true
+				break
+			}
+		}
+		if !present {
+			pod.ImagePullSecrets = append(pod.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+		}
+	}
+	if sc := g.gwConfig.ExecutorAgentSecurityContext; sc != nil {
+		for i := range pod.InitContainers {
+			switch pod.InitContainers[i].Name {
+			case executorAgentInitContainerName, executorTokenInitContainerName:
+				pod.InitContainers[i].SecurityContext = sc.DeepCopy()
+			}
+		}
+	}
+}
+
 func (g *Gateway) sandboxPrivateContainer(spec PrivateContainerSpec) corev1.Container {
 	container := corev1.Container{
 		Name:            spec.Name,
@@ -350,11 +439,25 @@ func (g *Gateway) grpcAuthSecretName() string {
 	return defaultGRPCAuthSecretName
 }
 
+func (g *Gateway) executorTLSSecretName() string {
+	if name := strings.TrimSpace(g.gwConfig.ExecutorTLSSecretName); name != "" {
+		return name
+	}
+	return defaultExecutorTLSSecretName
+}
+
 func (g *Gateway) executorEnv() []corev1.EnvVar {
 	var envs []corev1.EnvVar
 	if g.gwConfig.IrohRelayURL != "" {
 		envs = append(envs, corev1.EnvVar{Name: "IROH_RELAY_URL", Value: g.gwConfig.IrohRelayURL})
 	}
+	if g.gwConfig.ExecutorTLSEnabled {
+		envs = append(envs,
+			corev1.EnvVar{Name: "ARL_EXECUTOR_TLS_CERT", Value: executorTLSMountPath + "/tls.crt"},
+			corev1.EnvVar{Name: "ARL_EXECUTOR_TLS_KEY", Value: executorTLSMountPath + "/tls.key"},
+			corev1.EnvVar{Name: "ARL_EXECUTOR_TLS_CA", Value: executorTLSMountPath + "/ca.crt"},
+		)
+	}
 	return envs
 }
 
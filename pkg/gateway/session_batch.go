@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	maxBatchSessionCount           = 256
+	defaultBatchSessionConcurrency = 16
+)
+
+// CreateSessionsBatch creates req.Count sessions from the same template
+// concurrently. Individual failures are reported per item so callers can
+// proceed with whatever sessions became ready.
+func (g *Gateway) CreateSessionsBatch(ctx context.Context, req BatchCreateSessionsRequest) (*BatchCreateSessionsResponse, error) {
+	ctx, span := otel.Tracer("gateway").Start(ctx, "Gateway.CreateSessionsBatch",
+		traceStartAttrs("image", req.Template.Image, "profile", req.Template.Profile),
+	)
+	defer span.End()
+	span.SetAttributes(attribute.Int("batch.count", req.Count))
+
+	if err := g.checkNotDraining(); err != nil {
+		recordSpanErr(span, err)
+		return nil, err
+	}
+
+	if req.Count <= 0 {
+		err := fmt.Errorf("count is required and must be positive")
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	if req.Template.Name != "" || req.Template.IdempotencyKey != "" {
+		err := fmt.Errorf("name and idempotencyKey cannot be set on a batch template")
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	if req.Count > maxBatchSessionCount {
+		err := fmt.Errorf("count must be at most %d", maxBatchSessionCount)
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	if limit := int64(g.reloadable().MaxActiveSessions); limit > 0 && g.store.Count() >= limit {
+		err := fmt.Errorf("%w: limit is %d", ErrTooManySessions, limit)
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchSessionConcurrency
+	}
+
+	infos := make([]*SessionInfo, req.Count)
+	errs := make([]error, req.Count)
+	var eg errgroup.Group
+	eg.SetLimit(concurrency)
+	for i := range req.Count {
+		eg.Go(func() error {
+			infos[i], errs[i] = g.CreateSession(ctx, req.Template)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	resp := &BatchCreateSessionsResponse{Sessions: make([]SessionInfo, 0, req.Count)}
+	for i := range req.Count {
+		if errs[i] != nil {
+			resp.Errors = append(resp.Errors, BatchSessionError{Index: i, Error: errs[i].Error(), Code: errorCodeForError(errs[i])})
+			continue
+		}
+		resp.Sessions = append(resp.Sessions, *infos[i])
+	}
+	span.SetAttributes(
+		attribute.Int("batch.created", len(resp.Sessions)),
+		attribute.Int("batch.failed", len(resp.Errors)),
+	)
+	return resp, nil
+}
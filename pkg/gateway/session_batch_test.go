@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCreateSessionsBatchReturnsPartialSuccess(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "default", "code-template", 8, 8, "code")
+	template := testSandboxTemplate("code-template", "default", "python:3.12", "code")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool, template).Build()
+	allocator := &flakyRuntimeAllocator{failEvery: 3}
+	gw := New(k8sClient, allocator, nil, nil, nil, GatewayConfig{}, NewMemoryStore())
+
+	resp, err := gw.CreateSessionsBatch(context.Background(), BatchCreateSessionsRequest{
+		Count:       6,
+		Concurrency: 2,
+		Template:    CreateSessionRequest{Profile: "code"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSessionsBatch returned error: %v", err)
+	}
+	if len(resp.Sessions) != 4 {
+		t.Fatalf("created sessions = %d, want 4", len(resp.Sessions))
+	}
+	if len(resp.Errors) != 2 {
+		t.Fatalf("errors = %d, want 2: %#v", len(resp.Errors), resp.Errors)
+	}
+	if got := gw.store.Count(); got != 4 {
+		t.Fatalf("store count = %d, want 4", got)
+	}
+}
+
+func TestCreateSessionsBatchRejectsInvalidCount(t *testing.T) {
+	gw := New(nil, &flakyRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, NewMemoryStore())
+
+	for _, count := range []int{0, maxBatchSessionCount + 1} {
+		if _, err := gw.CreateSessionsBatch(context.Background(), BatchCreateSessionsRequest{
+			Count:    count,
+			Template: CreateSessionRequest{Profile: "code"},
+		}); err == nil {
+			t.Fatalf("count %d: expected error", count)
+		}
+	}
+}
+
+func TestCreateSessionsBatchStopsAtMaxActiveSessions(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "default", "code-template", 8, 8, "code")
+	template := testSandboxTemplate("code-template", "default", "python:3.12", "code")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool, template).Build()
+	gw := New(k8sClient, &flakyRuntimeAllocator{}, nil, nil, nil, GatewayConfig{MaxActiveSessions: 3}, NewMemoryStore())
+
+	resp, err := gw.CreateSessionsBatch(context.Background(), BatchCreateSessionsRequest{
+		Count:    5,
+		Template: CreateSessionRequest{Profile: "code"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSessionsBatch returned error: %v", err)
+	}
+	if len(resp.Sessions) != 3 || len(resp.Errors) != 2 {
+		t.Fatalf("created %d, failed %d; want 3 and 2", len(resp.Sessions), len(resp.Errors))
+	}
+	for _, e := range resp.Errors {
+		if e.Code != ErrorCodeTooManySessions {
+			t.Fatalf("error code = %q, want %q", e.Code, ErrorCodeTooManySessions)
+		}
+	}
+
+	if _, err := gw.CreateSessionsBatch(context.Background(), BatchCreateSessionsRequest{
+		Count:    1,
+		Template: CreateSessionRequest{Profile: "code"},
+	}); !errors.Is(err, ErrTooManySessions) {
+		t.Fatalf("batch at the cap = %v, want ErrTooManySessions", err)
+	}
+
+	gw.store.IncrCount(-1)
+	if _, err := gw.CreateSession(context.Background(), CreateSessionRequest{Profile: "code"}); err != nil {
+		t.Fatalf("CreateSession after a slot freed: %v", err)
+	}
+}
+
+type flakyRuntimeAllocator struct {
+	calls     atomic.Int32
+	failEvery int32
+}
+
+func (a *flakyRuntimeAllocator) Start(ctx context.Context) error { return nil }
+func (a *flakyRuntimeAllocator) Stop()                           {}
+
+func (a *flakyRuntimeAllocator) Allocate(ctx context.Context, req RuntimeAllocateRequest) (*RuntimeAllocation, error) {
+	n := a.calls.Add(1)
+	if a.failEvery > 0 && n%a.failEvery == 0 {
+		return nil, fmt.Errorf("pod died before ready")
+	}
+	return &RuntimeAllocation{
+		Backend:     runtimeBackendSandboxClaim,
+		PoolRef:     req.PoolRef,
+		Namespace:   req.Namespace,
+		SandboxName: req.SandboxName,
+		ClaimName:   req.SandboxName,
+		PodName:     fmt.Sprintf("pod-%d", n),
+		PodIP:       fmt.Sprintf("10.0.0.%d", n),
+	}, nil
+}
+
+func (a *flakyRuntimeAllocator) Release(ctx context.Context, allocation RuntimeAllocation) error {
+	return nil
+}
+
+func (a *flakyRuntimeAllocator) Resolve(ctx context.Context, allocation RuntimeAllocation, sessionID string) (*RuntimeAllocation, error) {
+	return &allocation, nil
+}
+
+func (a *flakyRuntimeAllocator) Touch(ctx context.Context, allocation RuntimeAllocation, sessionID string, at time.Time, lifecycle RuntimeLifecycle) error {
+	return nil
+}
+
+func (a *flakyRuntimeAllocator) DiagnosticStats() map[string]AllocatorPoolStats {
+	return nil
+}
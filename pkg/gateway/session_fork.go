@@ -165,7 +165,7 @@ func (g *Gateway) completeFork(ctx context.Context, sourceID string, req ForkSes
 func (g *Gateway) applyCheckpointToSession(ctx context.Context, sessionID, tarPath string) error {
 	sess, ok := g.store.Get(sessionID)
 	if !ok {
-		return fmt.Errorf("session %s not found", sessionID)
+		return &SessionNotFoundError{SessionID: sessionID}
 	}
 
 	sess.mu.RLock()
@@ -175,6 +175,12 @@ func (g *Gateway) applyCheckpointToSession(ctx context.Context, sessionID, tarPa
 	if podIP == "" {
 		return fmt.Errorf("session %s has no pod IP", sessionID)
 	}
+	return g.applyCheckpointTar(ctx, podIP, tarPath)
+}
+
+// applyCheckpointTar uploads a checkpoint tar to the runtime at podIP and
+// extracts it over the root filesystem.
+func (g *Gateway) applyCheckpointTar(ctx context.Context, podIP, tarPath string) error {
 	if g.executorClient == nil {
 		return fmt.Errorf("executor client not configured")
 	}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
 )
 
 const (
@@ -15,11 +16,12 @@ const (
 	runtimePatchMinInterval   = 5 * time.Second
 )
 
-func (g *Gateway) runtimeLifecycle(createdAt, lastActivityAt time.Time, idleTimeout time.Duration) RuntimeLifecycle {
+func (g *Gateway) runtimeLifecycle(createdAt, lastActivityAt time.Time, idleTimeout, maxLifetime time.Duration) RuntimeLifecycle {
 	return RuntimeLifecycle{
 		CreatedAt:      createdAt,
 		LastActivityAt: lastActivityAt,
 		IdleTimeout:    idleTimeout,
+		MaxLifetime:    maxLifetime,
 		FinishedTTL:    defaultRuntimeFinishedTTL,
 	}
 }
@@ -32,7 +34,7 @@ func (g *Gateway) sessionRuntimeLifecycleLocked(s *session, at time.Time) Runtim
 	if createdAt.IsZero() {
 		createdAt = at
 	}
-	return g.runtimeLifecycle(createdAt, at, s.idleTimeout)
+	return g.runtimeLifecycle(createdAt, at, s.idleTimeout, s.maxLifetime)
 }
 
 func runtimePatchInterval(idleTimeout time.Duration) time.Duration {
@@ -77,7 +79,9 @@ func (g *Gateway) startSessionHeartbeat(sessionID string, s *session) func() {
 }
 
 // touchLastTaskTime updates the in-memory lastTaskTime for session idle tracking
-// and asynchronously patches runtime last-activity annotations (throttled to at most once per 30s).
+// and asynchronously patches runtime last-activity annotations, throttled by
+// runtimePatchInterval. Failed patches are retried and, if they still fail,
+// the throttle is reset so the next touch patches again.
 func (g *Gateway) touchLastTaskTime(sessionID string) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
@@ -102,13 +106,28 @@ func (g *Gateway) touchLastTaskTime(sessionID string) {
 			bgCtx, bgCancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer bgCancel()
 
-			if err := g.runtimeAllocator.Touch(bgCtx, allocation, sessionID, now, lifecycle); err != nil {
+			// Retry transient API failures: a lost patch leaves the claim's
+			// shutdown time stale and the sandbox can expire while in use.
+			err := retry.OnError(retry.DefaultBackoff, func(err error) bool {
+				return !errors.IsNotFound(err) && bgCtx.Err() == nil
+			}, func() error {
+				return g.runtimeAllocator.Touch(bgCtx, allocation, sessionID, now, lifecycle)
+			})
+			if err != nil {
 				log.Printf("Warning: failed to patch last-activity for runtime %s: %v", allocation.PodName, err)
 				if errors.IsNotFound(err) {
 					if current, ok := g.store.Get(sessionID); ok {
 						g.dropSession(sessionID, current)
 					}
+					return
 				}
+				// Let the next touch patch again instead of waiting out the
+				// full patch interval.
+				s.mu.Lock()
+				if s.lastAnnotationPatch.Equal(now) {
+					s.lastAnnotationPatch = time.Time{}
+				}
+				s.mu.Unlock()
 			}
 		}()
 	}
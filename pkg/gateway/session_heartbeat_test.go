@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTouchLastTaskTimeRetriesTransientPatchFailures(t *testing.T) {
+	store := newTestSessionStore("gw-touch")
+	allocator := &flakyTouchRuntimeAllocator{failures: 2, done: make(chan struct{})}
+	gw := New(nil, allocator, nil, nil, nil, GatewayConfig{}, store)
+
+	gw.touchLastTaskTime("gw-touch")
+
+	select {
+	case <-allocator.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("touch not retried to success; calls = %d", allocator.calls.Load())
+	}
+	if got := allocator.calls.Load(); got != 3 {
+		t.Fatalf("Touch calls = %d, want 3", got)
+	}
+}
+
+type flakyTouchRuntimeAllocator struct {
+	operationRuntimeAllocator
+	calls    atomic.Int32
+	failures int32
+	done     chan struct{}
+}
+
+func (a *flakyTouchRuntimeAllocator) Touch(ctx context.Context, allocation RuntimeAllocation, sessionID string, at time.Time, lifecycle RuntimeLifecycle) error {
+	if n := a.calls.Add(1); n <= a.failures {
+		return fmt.Errorf("apiserver unavailable")
+	}
+	close(a.done)
+	return nil
+}
+
+func TestUpdateSessionPatchesNewIdleTimeout(t *testing.T) {
+	store := newTestSessionStore("gw-update")
+	s, _ := store.Get("gw-update")
+	s.idleTimeout = time.Minute
+	s.lastAnnotationPatch = time.Now()
+	allocator := &lifecycleTouchRuntimeAllocator{lifecycles: make(chan RuntimeLifecycle, 1)}
+	gw := New(nil, allocator, nil, nil, nil, GatewayConfig{}, store)
+
+	info, err := gw.UpdateSession("gw-update", UpdateSessionRequest{IdleTimeoutSeconds: 3600})
+	if err != nil {
+		t.Fatalf("UpdateSession returned error: %v", err)
+	}
+	if info.IdleTimeoutSeconds != 3600 {
+		t.Fatalf("IdleTimeoutSeconds = %d, want 3600", info.IdleTimeoutSeconds)
+	}
+	select {
+	case lifecycle := <-allocator.lifecycles:
+		if lifecycle.IdleTimeout != time.Hour {
+			t.Fatalf("patched idle timeout = %v, want 1h", lifecycle.IdleTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("UpdateSession did not patch the runtime lifecycle")
+	}
+
+	if _, err := gw.UpdateSession("gw-update", UpdateSessionRequest{}); err == nil {
+		t.Fatal("UpdateSession without fields succeeded")
+	}
+}
+
+type lifecycleTouchRuntimeAllocator struct {
+	operationRuntimeAllocator
+	lifecycles chan RuntimeLifecycle
+}
+
+func (a *lifecycleTouchRuntimeAllocator) Touch(ctx context.Context, allocation RuntimeAllocation, sessionID string, at time.Time, lifecycle RuntimeLifecycle) error {
+	a.lifecycles <- lifecycle
+	return nil
+}
@@ -0,0 +1,85 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxIdempotencyKeyLength bounds caller-supplied idempotency keys.
+const maxIdempotencyKeyLength = 256
+
+// idempotentCreate tracks one CreateSession call made with an idempotency
+// key. done is closed once the call finishes; sessionID is set on success.
+type idempotentCreate struct {
+	sessionID string
+	done      chan struct{}
+}
+
+// createSessionIdempotent runs CreateSession at most once per idempotency
+// key. A repeat of a key whose session is still live returns that session;
+// a repeat that arrives while the first call is still allocating waits for
+// it. Keys are scoped to the caller's API key and expire with the session.
+func (g *Gateway) createSessionIdempotent(ctx context.Context, req CreateSessionRequest) (*SessionInfo, error) {
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("idempotencyKey must be at most %d characters", maxIdempotencyKeyLength)
+	}
+	ownerHash, _ := KeyHashFromContext(ctx)
+	key := ownerHash + "/" + req.IdempotencyKey
+	req.IdempotencyKey = ""
+
+	for {
+		g.idempotencyMu.Lock()
+		entry, ok := g.idempotentCreates[key]
+		if !ok {
+			break
+		}
+		if entry.sessionID == "" {
+			g.idempotencyMu.Unlock()
+			select {
+			case <-entry.done:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if info, err := g.GetSession(entry.sessionID); err == nil {
+			g.idempotencyMu.Unlock()
+			return info, nil
+		}
+		delete(g.idempotentCreates, key)
+		g.idempotencyMu.Unlock()
+	}
+
+	entry := &idempotentCreate{done: make(chan struct{})}
+	if g.idempotentCreates == nil {
+		g.idempotentCreates = make(map[string]*idempotentCreate)
+	}
+	g.idempotentCreates[key] = entry
+	g.idempotencyMu.Unlock()
+
+	info, err := g.CreateSession(ctx, req)
+
+	g.idempotencyMu.Lock()
+	if err != nil {
+		delete(g.idempotentCreates, key)
+	} else {
+		entry.sessionID = info.ID
+	}
+	close(entry.done)
+	g.idempotencyMu.Unlock()
+	return info, err
+}
+
+// pruneIdempotencyKeys forgets keys whose sessions are gone.
+func (g *Gateway) pruneIdempotencyKeys() {
+	g.idempotencyMu.Lock()
+	defer g.idempotencyMu.Unlock()
+	for key, entry := range g.idempotentCreates {
+		if entry.sessionID == "" {
+			continue
+		}
+		if _, ok := g.store.Get(entry.sessionID); !ok {
+			delete(g.idempotentCreates, key)
+		}
+	}
+}
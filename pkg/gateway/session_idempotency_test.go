@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCreateSessionIdempotencyKeyReturnsExistingSession(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "arl1", "code-template", 1, 1, "code")
+	template := testSandboxTemplate("code-template", "arl1", "python:3.12", "code")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool, template).Build()
+	allocator := &recordingRuntimeAllocator{
+		allocation: RuntimeAllocation{
+			Backend:   runtimeBackendSandboxClaim,
+			PodName:   "pod-1",
+			PodIP:     "10.0.0.1",
+			ClaimName: "claim-1",
+		},
+	}
+	store := NewMemoryStore()
+	gw := New(k8sClient, allocator, nil, nil, nil, GatewayConfig{Namespace: "arl1"}, store)
+	req := CreateSessionRequest{Profile: "code", IdempotencyKey: "run-42"}
+
+	first, err := gw.CreateSession(context.Background(), req)
+	if err != nil {
+		t.Fatalf("first CreateSession returned error: %v", err)
+	}
+	second, err := gw.CreateSession(context.Background(), req)
+	if err != nil {
+		t.Fatalf("retried CreateSession returned error: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("retried session ID = %q, want %q", second.ID, first.ID)
+	}
+	if got := store.Count(); got != 1 {
+		t.Fatalf("session count = %d, want 1", got)
+	}
+
+	store.Delete(first.ID)
+	gw.pruneIdempotencyKeys()
+	third, err := gw.CreateSession(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateSession after expiry returned error: %v", err)
+	}
+	if third.ID == first.ID {
+		t.Fatalf("CreateSession after expiry reused session %q", first.ID)
+	}
+}
@@ -18,6 +18,12 @@ import (
 
 // CreateSession allocates a sandbox runtime from the pool and registers a session.
 func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (*SessionInfo, error) {
+	if err := g.checkNotDraining(); err != nil {
+		return nil, err
+	}
+	if req.IdempotencyKey != "" {
+		return g.createSessionIdempotent(ctx, req)
+	}
 	ctx, span := otel.Tracer("gateway").Start(ctx, "Gateway.CreateSession",
 		traceStartAttrs("image", req.Image, "profile", req.Profile, "namespace", req.Namespace),
 	)
@@ -59,6 +65,29 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 		recordSpanErr(span, err)
 		return nil, err
 	}
+	if req.MaxLifetimeSeconds < 0 {
+		err := fmt.Errorf("maxLifetimeSeconds cannot be negative")
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	if err := validateSessionMetadata(req); err != nil {
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	if req.Name != "" {
+		releaseName, err := g.reserveSessionName(allocationCtx, req.Name)
+		if err != nil {
+			recordSpanErr(span, err)
+			return nil, err
+		}
+		defer releaseName()
+	}
+	releaseSlot, err := g.reserveSessionSlot()
+	if err != nil {
+		recordSpanErr(span, err)
+		return nil, err
+	}
+	defer releaseSlot()
 	claimEnv, err := parseConfigEnvVars(req.ConfigEnv)
 	if err != nil {
 		recordSpanErr(span, err)
@@ -122,12 +151,16 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 		}
 	}
 
-	sessionID := sessionName(req.Image, randomSuffix(8))
+	sessionID := req.Name
+	if sessionID == "" {
+		sessionID = sessionName(req.Image, randomSuffix(8))
+	}
 	sandboxName := sessionID
 	ownerHash, _ := KeyHashFromContext(ctx)
 	createdAt := time.Now()
 	idleTimeout := g.resolveIdleTimeout(req)
-	lifecycle := g.runtimeLifecycle(createdAt, createdAt, idleTimeout)
+	maxLifetime := time.Duration(req.MaxLifetimeSeconds) * time.Second
+	lifecycle := g.runtimeLifecycle(createdAt, createdAt, idleTimeout, maxLifetime)
 	span.SetAttributes(
 		attribute.String("session.id", sessionID),
 		attribute.String("pool.selected", poolRef),
@@ -146,6 +179,8 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 		Managed:              req.Managed,
 		ExperimentID:         req.ExperimentID,
 		Mode:                 req.Mode,
+		Labels:               req.Labels,
+		Annotations:          req.Annotations,
 		Lifecycle:            lifecycle,
 		Env:                  claimEnv,
 		VolumeClaimTemplates: g.devboxVolumeClaimTemplates(req),
@@ -159,6 +194,9 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 			}
 			g.metrics.IncrementPodAllocationResult(poolRef, result)
 		}
+		if allocationCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("%w: %w", ErrSandboxTimeout, err)
+		}
 		diag := g.diagnosePoolHealth(ctx, poolRef, ns)
 		log.Printf("Runtime allocation failed for session %s (experiment=%s): %v (%s)", sessionID, req.ExperimentID, err, diag)
 		if doomReason := handlePoolAfterCreateFailure(err, poolRef); doomReason != "" {
@@ -184,7 +222,10 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 		PodName:     allocation.PodName,
 		CreatedAt:   createdAt,
 		Status:      "active",
+		Labels:      req.Labels,
+		Annotations: req.Annotations,
 	}
+	info.IdleTimeoutSeconds = int(idleTimeout / time.Second)
 	if req.Mode == SessionModeDevbox {
 		info.ConnectionInfo = buildConnectionInfo(sessionID, allocation.PodIP, req.Devbox)
 	}
@@ -206,11 +247,13 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 		lastAnnotationPatch: createdAt,
 		createdAt:           createdAt,
 		idleTimeout:         idleTimeout,
+		maxLifetime:         maxLifetime,
 		operations:          make(map[string]*operation),
 		privateContainers:   privateContainerMap(req.PrivateContainers),
 	})
 
 	activeSessions := g.store.IncrCount(1)
+	g.recordPoolAllocation(types.NamespacedName{Name: poolRef, Namespace: ns}, createdAt)
 	if g.metrics != nil {
 		g.metrics.SetActiveSessions(activeSessions)
 		allocationDuration := time.Since(allocStart)
@@ -222,13 +265,34 @@ func (g *Gateway) CreateSession(ctx context.Context, req CreateSessionRequest) (
 	return &info, nil
 }
 
+// sessionLimitRetryAfter is the Retry-After hint, in seconds, sent with
+// ErrTooManySessions.
+const sessionLimitRetryAfter = "5"
+
+// reserveSessionSlot holds a MaxActiveSessions slot for a create in flight,
+// so concurrent creates cannot overshoot the cap between the count check and
+// IncrCount. The returned func drops the reservation; call it once the
+// session is counted or the create has failed.
+func (g *Gateway) reserveSessionSlot() (func(), error) {
+	limit := int64(g.reloadable().MaxActiveSessions)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	pending := g.pendingSessions.Add(1)
+	if g.store.Count()+pending > limit {
+		g.pendingSessions.Add(-1)
+		return nil, fmt.Errorf("%w: limit is %d", ErrTooManySessions, limit)
+	}
+	return func() { g.pendingSessions.Add(-1) }, nil
+}
+
 // GetIrohAddr retrieves the iroh endpoint address from the executor for the
 // given session. Returns empty string if the executor does not provide iroh
 // or the address is not yet available.
 func (g *Gateway) GetIrohAddr(ctx context.Context, sessionID string) (string, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return "", fmt.Errorf("session %s not found", sessionID)
+		return "", &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	podIP := s.Info.PodIP
@@ -290,17 +354,50 @@ func (g *Gateway) resolvedAllocationTimeout(req CreateSessionRequest) time.Durat
 func (g *Gateway) GetSession(sessionID string) (*SessionInfo, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, fmt.Errorf("session %s not found", sessionID)
+		return nil, &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	info := s.Info
 	if info.Status == "" {
 		info.Status = "active"
 	}
+	info.IdleTimeoutSeconds = int(s.idleTimeout / time.Second)
+	if s.resourceUsage != nil {
+		usage := *s.resourceUsage
+		info.ResourceUsage = &usage
+	}
 	s.mu.RUnlock()
 	return &info, nil
 }
 
+// UpdateSession extends a live session: it can replace the idle timeout and
+// records activity, so the new deadline counts from now. The sandbox claim's
+// shutdown time is re-patched right away rather than on the next throttled
+// touch.
+func (g *Gateway) UpdateSession(sessionID string, req UpdateSessionRequest) (*SessionInfo, error) {
+	if req.IdleTimeoutSeconds < 0 {
+		return nil, fmt.Errorf("idleTimeoutSeconds cannot be negative")
+	}
+	if !req.KeepAlive && req.IdleTimeoutSeconds == 0 {
+		return nil, fmt.Errorf("keepAlive or idleTimeoutSeconds is required")
+	}
+	s, ok := g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+
+	s.mu.Lock()
+	if req.IdleTimeoutSeconds > 0 {
+		s.idleTimeout = time.Duration(req.IdleTimeoutSeconds) * time.Second
+		s.Info.IdleTimeoutSeconds = req.IdleTimeoutSeconds
+	}
+	s.lastAnnotationPatch = time.Time{}
+	s.mu.Unlock()
+
+	g.touchLastTaskTime(sessionID)
+	return g.GetSession(sessionID)
+}
+
 func (g *Gateway) GetHistoricalSession(sessionID string) (*session, bool) {
 	return g.store.GetHistorical(sessionID)
 }
@@ -309,7 +406,7 @@ func (g *Gateway) GetHistoricalSession(sessionID string) (*session, bool) {
 func (g *Gateway) SuspendSession(ctx context.Context, sessionID string) error {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return fmt.Errorf("session %s not found", sessionID)
+		return &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	mode := s.mode
@@ -348,7 +445,7 @@ func (g *Gateway) SuspendSession(ctx context.Context, sessionID string) error {
 func (g *Gateway) ResumeSession(ctx context.Context, sessionID string) error {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return fmt.Errorf("session %s not found", sessionID)
+		return &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.mu.RLock()
 	mode := s.mode
@@ -391,13 +488,13 @@ func (g *Gateway) DeleteSession(ctx context.Context, sessionID string) error {
 func (g *Gateway) deleteSession(ctx context.Context, sessionID string, reason string) error {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return fmt.Errorf("session %s not found", sessionID)
+		return &SessionNotFoundError{SessionID: sessionID}
 	}
 
 	s.mu.Lock()
 	if s.closed {
 		s.mu.Unlock()
-		return fmt.Errorf("session %s not found", sessionID)
+		return &SessionNotFoundError{SessionID: sessionID}
 	}
 	s.closed = true
 	if reason == "" {
@@ -420,7 +517,8 @@ func (g *Gateway) deleteSession(ctx context.Context, sessionID string, reason st
 	if s.History != nil {
 		stepCount = s.History.Len()
 	}
-	duration := int64(time.Since(s.createdAt).Seconds())
+	lifetime := now.Sub(s.createdAt)
+	duration := int64(lifetime.Seconds())
 	s.mu.RUnlock()
 
 	log.Printf("Deleting session %s (reason=%s, experiment=%s, pool=%s, pod=%s, steps=%d, duration=%ds)",
@@ -446,6 +544,7 @@ func (g *Gateway) deleteSession(ctx context.Context, sessionID string, reason st
 
 	g.store.Delete(sessionID)
 	activeSessions := g.store.IncrCount(-1)
+	g.recordPoolSessionLifetime(types.NamespacedName{Name: allocation.PoolRef, Namespace: allocation.Namespace}, now, lifetime)
 
 	if g.metrics != nil {
 		g.metrics.SetActiveSessions(activeSessions)
@@ -487,6 +586,7 @@ func (g *Gateway) dropSession(sessionID string, s *session) {
 	s.Info.DeletedAt = &now
 	info := s.Info
 	allocation := s.runtimeAllocation()
+	lifetime := now.Sub(s.createdAt)
 	s.mu.Unlock()
 
 	diagCtx, diagCancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -517,6 +617,7 @@ func (g *Gateway) dropSession(sessionID string, s *session) {
 	}
 	g.store.Delete(sessionID)
 	activeSessions := g.store.IncrCount(-1)
+	g.recordPoolSessionLifetime(types.NamespacedName{Name: allocation.PoolRef, Namespace: allocation.Namespace}, now, lifetime)
 	if g.metrics != nil {
 		g.metrics.SetActiveSessions(activeSessions)
 		g.metrics.IncrementSessionDeletion("runtime_lost")
@@ -128,3 +128,29 @@ func benchmarkGatewayWithSessions(b *testing.B, count int) *Gateway {
 	store.SetCount(int64(count))
 	return New(nil, nil, nil, nil, nil, GatewayConfig{}, store)
 }
+
+func TestListSessionsFiltersByPoolRefAndNamespace(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.Set("s1", &session{
+		Info:    SessionInfo{ID: "s1", PoolRef: "pool-a", Namespace: "team-a", CreatedAt: now},
+		History: NewStepHistory(),
+	})
+	store.Set("s2", &session{
+		Info:    SessionInfo{ID: "s2", PoolRef: "pool-a", Namespace: "team-b", CreatedAt: now},
+		History: NewStepHistory(),
+	})
+	store.Set("s3", &session{
+		Info:    SessionInfo{ID: "s3", PoolRef: "pool-b", Namespace: "team-a", CreatedAt: now},
+		History: NewStepHistory(),
+	})
+	gw := New(nil, nil, nil, nil, nil, GatewayConfig{}, store)
+
+	if got := gw.ListSessions(SessionListOptions{PoolRef: "pool-a"}); len(got) != 2 {
+		t.Fatalf("poolRef filter length = %d, want 2: %#v", len(got), got)
+	}
+	got := gw.ListSessions(SessionListOptions{PoolRef: "pool-a", Namespace: "team-a"})
+	if len(got) != 1 || got[0].ID != "s1" {
+		t.Fatalf("poolRef+namespace filter = %#v, want [s1]", got)
+	}
+}
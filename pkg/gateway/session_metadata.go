@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reservedMetadataPrefix is the key prefix the gateway uses for its own
+// labels and annotations; callers may not set keys under it.
+const reservedMetadataPrefix = "arl.infra.io/"
+
+// validateSessionMetadata checks the caller-provided session name, labels
+// and annotations before anything is allocated.
+func validateSessionMetadata(req CreateSessionRequest) error {
+	if req.Name != "" {
+		if errs := validation.IsDNS1123Label(req.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid session name %q: %s", req.Name, strings.Join(errs, "; "))
+		}
+	}
+	for _, key := range sortedKeys(req.Labels) {
+		if err := validateSessionMetadataKey("label", key); err != nil {
+			return err
+		}
+		if errs := validation.IsValidLabelValue(req.Labels[key]); len(errs) > 0 {
+			return fmt.Errorf("invalid session label %q value: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	for _, key := range sortedKeys(req.Annotations) {
+		if err := validateSessionMetadataKey("annotation", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateSessionMetadataKey(kind, key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid session %s key %q: %s", kind, key, strings.Join(errs, "; "))
+	}
+	if strings.HasPrefix(key, reservedMetadataPrefix) {
+		return fmt.Errorf("invalid session %s key %q: prefix %s is reserved", kind, key, reservedMetadataPrefix)
+	}
+	return nil
+}
+
+// reserveSessionName claims a caller-chosen session name until the returned
+// release func is called, so two concurrent creates cannot both bind it. A
+// name is also refused while a deleted session's record or trajectory is
+// still stored under it, so its steps are never mixed with a new session's.
+func (g *Gateway) reserveSessionName(ctx context.Context, name string) (func(), error) {
+	if _, ok := g.store.GetHistorical(name); ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNameInUse, name)
+	}
+	if g.trajectoryWriter != nil {
+		entries, err := g.trajectoryWriter.GetTrajectoryPaged(ctx, name, 0, 1)
+		if err != nil {
+			return nil, fmt.Errorf("check trajectory for session name %s: %w", name, err)
+		}
+		if len(entries) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNameInUse, name)
+		}
+	}
+
+	g.sessionNameMu.Lock()
+	defer g.sessionNameMu.Unlock()
+	if _, ok := g.store.Get(name); ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNameInUse, name)
+	}
+	if _, pending := g.pendingSessionNames[name]; pending {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNameInUse, name)
+	}
+	if g.pendingSessionNames == nil {
+		g.pendingSessionNames = make(map[string]struct{})
+	}
+	g.pendingSessionNames[name] = struct{}{}
+	return func() {
+		g.sessionNameMu.Lock()
+		delete(g.pendingSessionNames, name)
+		g.sessionNameMu.Unlock()
+	}, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// copyStringMap returns a non-nil copy of m.
+func copyStringMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Lincyaw/agent-env/pkg/audit"
+)
+
+func TestValidateSessionMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateSessionRequest
+		wantErr bool
+	}{
+		{name: "empty", req: CreateSessionRequest{}},
+		{
+			name: "valid name and metadata",
+			req: CreateSessionRequest{
+				Name:        "exp-42-trial-3",
+				Labels:      map[string]string{"example.com/experiment": "exp-42"},
+				Annotations: map[string]string{"example.com/notes": "free form: anything goes"},
+			},
+		},
+		{name: "name not a DNS label", req: CreateSessionRequest{Name: "Exp_42"}, wantErr: true},
+		{name: "invalid label value", req: CreateSessionRequest{Labels: map[string]string{"run": "a b"}}, wantErr: true},
+		{name: "reserved label key", req: CreateSessionRequest{Labels: map[string]string{"arl.infra.io/pool": "x"}}, wantErr: true},
+		{name: "reserved annotation key", req: CreateSessionRequest{Annotations: map[string]string{"arl.infra.io/session": "x"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSessionMetadata(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateSessionMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReserveSessionNameRejectsDuplicates(t *testing.T) {
+	store := newTestSessionStore("taken")
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	if _, err := gw.reserveSessionName(context.Background(), "taken"); !errors.Is(err, ErrSessionNameInUse) {
+		t.Fatalf("reserve live session name error = %v, want ErrSessionNameInUse", err)
+	}
+
+	release, err := gw.reserveSessionName(context.Background(), "fresh")
+	if err != nil {
+		t.Fatalf("reserve fresh name: %v", err)
+	}
+	if _, err := gw.reserveSessionName(context.Background(), "fresh"); !errors.Is(err, ErrSessionNameInUse) {
+		t.Fatalf("reserve in-flight name error = %v, want ErrSessionNameInUse", err)
+	}
+	release()
+	if _, err := gw.reserveSessionName(context.Background(), "fresh"); err != nil {
+		t.Fatalf("reserve released name: %v", err)
+	}
+}
+
+// namedTrajectoryStore holds a stored trajectory for one session ID.
+type namedTrajectoryStore struct {
+	audit.TrajectoryStore
+	sessionID string
+}
+
+func (s namedTrajectoryStore) GetTrajectoryPaged(_ context.Context, sessionID string, _, _ int) ([]audit.TrajectoryEntry, error) {
+	if sessionID != s.sessionID {
+		return nil, nil
+	}
+	return []audit.TrajectoryEntry{{SessionID: sessionID}}, nil
+}
+
+func TestReserveSessionNameRejectsNamesWithStoredTrajectory(t *testing.T) {
+	store := NewMemoryStore()
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, namedTrajectoryStore{sessionID: "deleted"}, GatewayConfig{}, store)
+
+	if _, err := gw.reserveSessionName(context.Background(), "deleted"); !errors.Is(err, ErrSessionNameInUse) {
+		t.Fatalf("reserve name of a deleted session error = %v, want ErrSessionNameInUse", err)
+	}
+	if _, err := gw.reserveSessionName(context.Background(), "unused"); err != nil {
+		t.Fatalf("reserve unused name: %v", err)
+	}
+}
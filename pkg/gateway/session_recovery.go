@@ -210,10 +210,12 @@ func (g *Gateway) newRecoveredSessionFromClaim(ctx context.Context, sessionID st
 	lastTask := recoveredLastActivity(claim, info.CreatedAt)
 	managed := strings.EqualFold(claim.Annotations[labels.ManagedAnnotation], "true")
 	recoveredMode := claim.Annotations[labels.ModeAnnotation]
-	idleTimeout := g.gwConfig.IdleTimeout
+	rc := g.reloadable()
+	idleTimeout := rc.IdleTimeout
 	if recoveredMode == SessionModeDevbox {
-		idleTimeout = g.gwConfig.DevboxIdleTimeout
+		idleTimeout = rc.DevboxIdleTimeout
 	}
+	maxLifetime, _ := durationAnnotation(claim.Annotations, labels.MaxLifetimeAnnotation)
 	info.Mode = recoveredMode
 	return &session{
 		Info:         info,
@@ -226,6 +228,7 @@ func (g *Gateway) newRecoveredSessionFromClaim(ctx context.Context, sessionID st
 		lastTaskTime: lastTask,
 		createdAt:    info.CreatedAt,
 		idleTimeout:  idleTimeout,
+		maxLifetime:  maxLifetime,
 		operations:   make(map[string]*operation),
 	}
 }
@@ -266,7 +269,10 @@ func (g *Gateway) applyRecoveredRuntime(ctx context.Context, s *session, session
 		s.lastTaskTime = lastTask
 	}
 	if s.idleTimeout == 0 {
-		s.idleTimeout = g.gwConfig.IdleTimeout
+		s.idleTimeout = g.reloadable().IdleTimeout
+	}
+	if s.maxLifetime == 0 {
+		s.maxLifetime, _ = durationAnnotation(claim.Annotations, labels.MaxLifetimeAnnotation)
 	}
 }
 
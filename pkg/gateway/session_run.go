@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// runSessionDeleteTimeout bounds the cleanup of a one-shot run's session,
+// which runs even after the caller has gone away.
+const runSessionDeleteTimeout = 30 * time.Second
+
+// Run creates a session, executes req.Execute in it and deletes the session
+// again, so a stateless caller cannot leak a sandbox. The session is deleted
+// whether or not the steps succeed.
+func (g *Gateway) Run(ctx context.Context, req RunRequest) (*ExecuteResponse, error) {
+	info, err := g.CreateSession(ctx, req.Session)
+	if err != nil {
+		return nil, fmt.Errorf("create run session: %w", err)
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), runSessionDeleteTimeout)
+		defer cancel()
+		if err := g.deleteSession(cleanupCtx, info.ID, "run complete"); err != nil {
+			log.Printf("Warning: failed to delete run session %s: %v", info.ID, err)
+		}
+	}()
+
+	execReq := req.Execute
+	// Nothing can poll an operation on a session that is deleted on return.
+	execReq.OperationID = ""
+	return g.ExecuteSteps(ctx, info.ID, execReq)
+}
@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunExecutesAndDeletesSession(t *testing.T) {
+	scheme := newGatewayTestScheme(t)
+	pool := testSandboxWarmPool("code", "arl1", "code-template", 1, 1, "code")
+	template := testSandboxTemplate("code-template", "arl1", "python:3.12", "code")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pool, template).Build()
+	allocator := &recordingRuntimeAllocator{
+		allocation: RuntimeAllocation{
+			Backend:   runtimeBackendSandboxClaim,
+			PodName:   "pod-1",
+			PodIP:     "10.0.0.1",
+			ClaimName: "claim-1",
+		},
+	}
+	executed := 0
+	exec := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(context.Context, string, *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			executed++
+			return &interfaces.ExecResponse{Done: true}, nil
+		},
+	}
+	store := NewMemoryStore()
+	gw := New(k8sClient, allocator, exec, nil, nil, GatewayConfig{Namespace: "arl1"}, store)
+
+	resp, err := gw.Run(context.Background(), RunRequest{
+		Session: CreateSessionRequest{Profile: "code"},
+		Execute: ExecuteRequest{
+			OperationID: "ignored",
+			Steps:       []StepRequest{{Name: "score", Command: []string{"pytest"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(resp.Results) != 1 || executed != 1 {
+		t.Fatalf("results = %d (executed %d), want 1", len(resp.Results), executed)
+	}
+	if _, ok := store.Get(resp.SessionID); ok {
+		t.Fatalf("run session %s still in the store", resp.SessionID)
+	}
+}
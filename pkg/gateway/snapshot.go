@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// snapshotIDPrefix marks a snapshot ID that names a persisted workspace tar,
+// as opposed to a bare step index restored by replay.
+const snapshotIDPrefix = "snap-"
+
+// tarballRestoreBackend persists workspace tars to the checkpoint store and
+// extracts them on restore. Bare step indices are still replayed.
+type tarballRestoreBackend struct {
+	g *Gateway
+}
+
+// Snapshot persists the session's workspace as of its latest step to the
+// checkpoint store and returns an ID that Restore extracts directly instead
+// of replaying steps.
+func (b tarballRestoreBackend) Snapshot(ctx context.Context, sessionID string) (*SnapshotResponse, error) {
+	g := b.g
+	if !g.gwConfig.SandboxCheckpointEnabled || g.checkpointStore == nil {
+		return nil, fmt.Errorf("snapshots require checkpointing and a checkpoint store")
+	}
+
+	s, ok := g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+	s.mu.RLock()
+	podIP := s.Info.PodIP
+	s.mu.RUnlock()
+
+	last := s.History.Len() - 1
+	if last < 0 {
+		return nil, fmt.Errorf("session %s has no steps to snapshot", sessionID)
+	}
+	if podIP == "" {
+		return nil, fmt.Errorf("session %s has no pod IP", sessionID)
+	}
+
+	// History indices and agent checkpoint steps diverge whenever a step
+	// runs without a checkpoint (HTTP steps, denied commands), so snapshot
+	// the agent's latest checkpoint and record which one it was.
+	steps, err := g.executorClient.ListCheckpointSteps(ctx, podIP)
+	if err != nil {
+		return nil, fmt.Errorf("list checkpoint steps: %w", err)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("session %s has no checkpoints to snapshot", sessionID)
+	}
+	checkpointStep := slices.Max(steps)
+	if !g.checkpointStore.HasStep(sessionID, checkpointStep) {
+		if err := g.persistSingleCheckpointStep(sessionID, podIP, checkpointStep); err != nil {
+			return nil, fmt.Errorf("persist snapshot at step %d: %w", last, err)
+		}
+	}
+	if err := g.checkpointStore.SaveSnapshot(sessionID, last, checkpointStep); err != nil {
+		return nil, fmt.Errorf("record snapshot at step %d: %w", last, err)
+	}
+
+	return &SnapshotResponse{
+		SnapshotID: snapshotIDPrefix + strconv.Itoa(last),
+		Step:       last,
+	}, nil
+}
+
+// parseSnapshotID returns the step index a snapshot ID refers to and whether
+// it names a persisted snapshot rather than a step to replay up to.
+func parseSnapshotID(snapshotID string) (int, bool, error) {
+	raw, persisted := strings.CutPrefix(snapshotID, snapshotIDPrefix)
+	idx, err := strconv.Atoi(raw)
+	if err != nil || (persisted && idx < 0) {
+		return 0, false, fmt.Errorf("invalid snapshot_id %q: must be a step index or a snapshot ID", snapshotID)
+	}
+	return idx, persisted, nil
+}
+
+func (b tarballRestoreBackend) RestoreTo(ctx context.Context, target RestoreTarget) (RestoreResult, error) {
+	if !target.Persisted {
+		return replayRestoreBackend{g: b.g}.RestoreTo(ctx, target)
+	}
+	err := b.g.restoreSnapshot(ctx, target.SessionID, target.StepIndex, target.PodIP)
+	return RestoreResult{Phase: "extract"}, err
+}
+
+// restoreSnapshot extracts the persisted workspace for step targetIdx into the
+// runtime at podIP.
+func (g *Gateway) restoreSnapshot(ctx context.Context, sessionID string, targetIdx int, podIP string) error {
+	if g.checkpointStore == nil {
+		return fmt.Errorf("snapshot restore requires a checkpoint store")
+	}
+	checkpointStep, err := g.checkpointStore.SnapshotCheckpointStep(sessionID, targetIdx)
+	if err != nil || !g.checkpointStore.HasStep(sessionID, checkpointStep) {
+		return fmt.Errorf("snapshot %s%d not found for session %s", snapshotIDPrefix, targetIdx, sessionID)
+	}
+	tarPath, err := g.checkpointStore.LoadCombined(sessionID, checkpointStep)
+	if err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+	defer os.Remove(tarPath)
+
+	return g.applyCheckpointTar(ctx, podIP, tarPath)
+}
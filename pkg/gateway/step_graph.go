@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StepFailureDependency is the FailureReason of a step that was not run
+// because a step it depends on failed.
+const StepFailureDependency = "dependency_failed"
+
+func hasStepDependencies(steps []StepRequest) bool {
+	for _, step := range steps {
+		if len(step.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stepGraph resolves each step's DependsOn names to step indices and returns
+// them with a topological order of the steps. Ties keep request order, so
+// steps without dependencies run in the order they were sent.
+func stepGraph(steps []StepRequest) (deps [][]int, order []int, err error) {
+	byName := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if step.Name == "" {
+			continue
+		}
+		if _, dup := byName[step.Name]; dup {
+			byName[step.Name] = -1
+			continue
+		}
+		byName[step.Name] = i
+	}
+
+	deps = make([][]int, len(steps))
+	dependents := make([][]int, len(steps))
+	pending := make([]int, len(steps))
+	for i, step := range steps {
+		for _, name := range step.DependsOn {
+			j, ok := byName[name]
+			switch {
+			case !ok:
+				return nil, nil, fmt.Errorf("step %d depends on unknown step %q", i, name)
+			case j < 0:
+				return nil, nil, fmt.Errorf("step %d depends on %q, which names more than one step", i, name)
+			case j == i:
+				return nil, nil, fmt.Errorf("step %q depends on itself", name)
+			}
+			deps[i] = append(deps[i], j)
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+		}
+	}
+
+	order = make([]int, 0, len(steps))
+	done := make([]bool, len(steps))
+	for len(order) < len(steps) {
+		next := -1
+		for i := range steps {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, nil, fmt.Errorf("step dependency cycle: %s", describeStepCycle(steps, deps, done))
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+	}
+	return deps, order, nil
+}
+
+// describeStepCycle walks dependencies from an unfinished step until a step
+// repeats and renders that loop, e.g. "test -> build -> test".
+func describeStepCycle(steps []StepRequest, deps [][]int, done []bool) string {
+	start := 0
+	for i := range steps {
+		if !done[i] {
+			start = i
+			break
+		}
+	}
+	seen := map[int]int{}
+	var path []int
+	for i := start; ; {
+		if at, ok := seen[i]; ok {
+			names := make([]string, 0, len(path)-at+1)
+			for _, j := range path[at:] {
+				names = append(names, steps[j].Name)
+			}
+			return strings.Join(append(names, steps[i].Name), " -> ")
+		}
+		seen[i] = len(path)
+		path = append(path, i)
+		for _, j := range deps[i] {
+			if !done[j] {
+				i = j
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStepGraphOrdersByDependency(t *testing.T) {
+	steps := []StepRequest{
+		{Name: "test", DependsOn: []string{"build"}},
+		{Name: "lint"},
+		{Name: "build"},
+	}
+	_, order, err := stepGraph(steps)
+	if err != nil {
+		t.Fatalf("stepGraph returned error: %v", err)
+	}
+	if want := []int{1, 2, 0}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+}
+
+func TestStepGraphRejectsInvalidDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []StepRequest
+		wantErr string
+	}{
+		{
+			name:    "cycle",
+			steps:   []StepRequest{{Name: "build", DependsOn: []string{"test"}}, {Name: "test", DependsOn: []string{"build"}}},
+			wantErr: "step dependency cycle: build -> test -> build",
+		},
+		{
+			name:    "unknown step",
+			steps:   []StepRequest{{Name: "test", DependsOn: []string{"build"}}},
+			wantErr: `depends on unknown step "build"`,
+		},
+		{
+			name:    "ambiguous name",
+			steps:   []StepRequest{{Name: "build"}, {Name: "build"}, {Name: "test", DependsOn: []string{"build"}}},
+			wantErr: "names more than one step",
+		},
+		{
+			name:    "self",
+			steps:   []StepRequest{{Name: "build", DependsOn: []string{"build"}}},
+			wantErr: "depends on itself",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := stepGraph(tt.steps)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("stepGraph error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+// Exit codes the limit wrapper reports when the step is killed by a signal.
+// The shell does not exec the command, so a signal death surfaces as 128+N.
+const (
+	exitSIGABRT = 128 + 6
+	exitSIGKILL = 128 + 9
+	exitSIGSEGV = 128 + 11
+	exitSIGXCPU = 128 + 24
+)
+
+// cpuLimitGraceSeconds is how far the hard CPU limit sits above the soft
+// limit a step asked for.
+const cpuLimitGraceSeconds = 5
+
+// Failure reasons reported in StepResult.FailureReason.
+const (
+	StepFailureMemoryLimit  = "memory_limit_exceeded"
+	StepFailureCPUTimeLimit = "cpu_time_limit_exceeded"
+	StepFailureOutputLimit  = "output_limit_exceeded"
+)
+
+// stepLimitScript applies the limits via ulimit and runs "$@" as a child so
+// the exit status of a signalled command is visible to the caller.
+const stepLimitScript = `%s "$@"; exit $?`
+
+// applyStepLimits wraps command in a /bin/sh ulimit prelude when the step
+// requests a memory or CPU time cap. Limits apply to the step's process
+// tree only, so a runaway step fails on its own instead of pushing the whole
+// sandbox pod over its cgroup limit.
+func applyStepLimits(step StepRequest) []string {
+	if len(step.Command) == 0 || (step.MemoryBytes <= 0 && step.CPUSeconds <= 0) {
+		return step.Command
+	}
+	var prelude []string
+	if step.MemoryBytes > 0 {
+		// ulimit -v takes KiB; round up so tiny limits never become "0".
+		prelude = append(prelude, fmt.Sprintf("ulimit -v %d || exit 126;", (step.MemoryBytes+1023)/1024))
+	}
+	if step.CPUSeconds > 0 {
+		// The kernel sends SIGXCPU at the soft limit, which only RLIMIT_CPU
+		// does, so a step killed by it is unambiguous. The hard limit
+		// backs it up for commands that ignore SIGXCPU; it is best effort
+		// since an unprivileged shell cannot raise an existing hard limit.
+		prelude = append(prelude, fmt.Sprintf("ulimit -S -t %d || exit 126; ulimit -H -t %d 2>/dev/null;",
+			step.CPUSeconds, step.CPUSeconds+cpuLimitGraceSeconds))
+	}
+	script := fmt.Sprintf(stepLimitScript, strings.Join(prelude, " "))
+	return append([]string{"/bin/sh", "-c", script, "sh"}, step.Command...)
+}
+
+// stepLimitFailure maps the exit code the agent reports for a limited step
+// to a failure reason and a message for stderr. A reason is only given for a
+// limit the step actually set, and it returns empty strings when the exit
+// does not look like a violation of it. SIGKILL is never attributed: it is
+// also what the OOM killer, timeouts and cancellation use. Memory exhaustion
+// is inferred from the abort and segfault signals that failed allocations
+// under ulimit -v typically end in.
+func stepLimitFailure(step StepRequest, exitCode int32) (string, string) {
+	switch {
+	case step.CPUSeconds > 0 && exitCode == exitSIGXCPU:
+		return StepFailureCPUTimeLimit, fmt.Sprintf("step exceeded cpu time limit of %ds\n", step.CPUSeconds)
+	case step.MemoryBytes > 0 && (exitCode == exitSIGABRT || exitCode == exitSIGSEGV):
+		return StepFailureMemoryLimit, fmt.Sprintf("step exceeded memory limit of %d bytes\n", step.MemoryBytes)
+	}
+	return "", ""
+}
+
+// applyOutputLimit sets req's output cap for step: the step's own
+// MaxOutputBytes when set, never above the gateway's ExecMaxOutputBytes.
+func (g *Gateway) applyOutputLimit(req *interfaces.ExecRequest, step StepRequest) {
+	limit := g.gwConfig.ExecMaxOutputBytes
+	if step.MaxOutputBytes > 0 && (limit <= 0 || step.MaxOutputBytes < limit) {
+		limit = step.MaxOutputBytes
+	}
+	req.MaxOutputBytes = limit
+	req.KillOnOutputLimit = g.gwConfig.ExecKillOnOutputLimit && limit > 0
+}
+
+// clipOutput returns the part of data that fits within limit once used bytes
+// have already been forwarded (0 means no limit), and whether any of data was
+// dropped. Streamed steps count output with it themselves, so the cap holds
+// even against agents that do not enforce it.
+func clipOutput(data string, used, limit int64) (string, bool) {
+	if limit > 0 && used+int64(len(data)) > limit {
+		return data[:max(limit-used, 0)], true
+	}
+	return data, false
+}
+
+// outputLimitFailure returns the failure reason and stderr marker for a step
+// whose output was truncated. The reason is only set when the gateway kills
+// steps at the cap; otherwise the step ran to completion.
+func (g *Gateway) outputLimitFailure(limit int64) (string, string) {
+	msg := fmt.Sprintf("[output truncated: step exceeded output limit of %d bytes]\n", limit)
+	if g.gwConfig.ExecKillOnOutputLimit {
+		return StepFailureOutputLimit, msg
+	}
+	return "", msg
+}
@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+func TestApplyStepLimitsLeavesUnlimitedStepsAlone(t *testing.T) {
+	cmd := []string{"python", "-c", "print(1)"}
+	if got := applyStepLimits(StepRequest{Command: cmd}); !reflect.DeepEqual(got, cmd) {
+		t.Fatalf("command = %#v, want %#v", got, cmd)
+	}
+}
+
+func TestApplyStepLimitsReportsCPUTimeLimit(t *testing.T) {
+	if _, err := exec.LookPath("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+	step := StepRequest{
+		Command:    []string{"sh", "-c", "while :; do :; done"},
+		CPUSeconds: 1,
+	}
+	argv := applyStepLimits(step)
+	err := exec.Command(argv[0], argv[1:]...).Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("run error = %v, want exit error", err)
+	}
+	code := int32(exitErr.ExitCode())
+	reason, msg := stepLimitFailure(step, code)
+	if reason != StepFailureCPUTimeLimit || msg == "" {
+		t.Fatalf("stepLimitFailure(%d) = %q, %q; want cpu time limit", code, reason, msg)
+	}
+}
+
+func TestStepLimitFailureIgnoresOrdinaryExits(t *testing.T) {
+	step := StepRequest{MemoryBytes: 1 << 20, CPUSeconds: 5}
+	for _, code := range []int32{0, 1, 2, 127} {
+		if reason, _ := stepLimitFailure(step, code); reason != "" {
+			t.Fatalf("stepLimitFailure(%d) = %q, want empty", code, reason)
+		}
+	}
+	if reason, _ := stepLimitFailure(StepRequest{}, exitSIGXCPU); reason != "" {
+		t.Fatalf("unlimited step reported %q", reason)
+	}
+	// A memory-only step that hits SIGXCPU, and any SIGKILL, name no limit.
+	if reason, _ := stepLimitFailure(StepRequest{MemoryBytes: 1 << 20}, exitSIGXCPU); reason != "" {
+		t.Fatalf("memory-limited step attributed SIGXCPU to %q", reason)
+	}
+	if reason, _ := stepLimitFailure(step, exitSIGKILL); reason != "" {
+		t.Fatalf("SIGKILL attributed to %q", reason)
+	}
+}
+
+func TestApplyOutputLimitNeverRaisesGatewayCap(t *testing.T) {
+	gw := &Gateway{gwConfig: GatewayConfig{ExecMaxOutputBytes: 1000, ExecKillOnOutputLimit: true}}
+	for _, tc := range []struct {
+		step, want int64
+	}{{0, 1000}, {10, 10}, {5000, 1000}} {
+		req := &interfaces.ExecRequest{}
+		gw.applyOutputLimit(req, StepRequest{MaxOutputBytes: tc.step})
+		if req.MaxOutputBytes != tc.want || !req.KillOnOutputLimit {
+			t.Errorf("step cap %d: MaxOutputBytes = %d, KillOnOutputLimit = %v, want %d, true",
+				tc.step, req.MaxOutputBytes, req.KillOnOutputLimit, tc.want)
+		}
+	}
+
+	gw.gwConfig.ExecMaxOutputBytes = 0
+	req := &interfaces.ExecRequest{}
+	gw.applyOutputLimit(req, StepRequest{})
+	if req.MaxOutputBytes != 0 || req.KillOnOutputLimit {
+		t.Fatalf("uncapped: MaxOutputBytes = %d, KillOnOutputLimit = %v", req.MaxOutputBytes, req.KillOnOutputLimit)
+	}
+}
+
+func TestExecuteStepsMarksTruncatedOutput(t *testing.T) {
+	store := newTestSessionStore("gw-output-limit")
+	executorClient := &mockclient.MockExecutorClient{
+		ExecuteFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (*interfaces.ExecResponse, error) {
+			return &interfaces.ExecResponse{Stdout: "yyyy", ExitCode: 1, OutputTruncated: true}, nil
+		},
+	}
+	cfg := GatewayConfig{ExecMaxOutputBytes: 4, ExecKillOnOutputLimit: true}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, cfg, store)
+
+	resp, err := gw.ExecuteSteps(context.Background(), "gw-output-limit", ExecuteRequest{Steps: []StepRequest{{
+		Name:    "spam",
+		Command: []string{"yes"},
+	}}})
+	if err != nil {
+		t.Fatalf("ExecuteSteps returned error: %v", err)
+	}
+	result := resp.Results[0]
+	if !result.OutputTruncated || result.FailureReason != StepFailureOutputLimit {
+		t.Fatalf("OutputTruncated = %v, FailureReason = %q", result.OutputTruncated, result.FailureReason)
+	}
+	if !strings.Contains(result.Output.Stderr, "output limit of 4 bytes") {
+		t.Fatalf("stderr = %q, want truncation marker", result.Output.Stderr)
+	}
+}
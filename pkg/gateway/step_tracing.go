@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContextEnv maps W3C trace-context headers to the environment
+// variables used to carry them into child processes.
+var traceContextEnv = map[string]string{
+	"traceparent": "TRACEPARENT",
+	"tracestate":  "TRACESTATE",
+}
+
+// startStepSpan starts the span covering one step's executor call.
+func startStepSpan(ctx context.Context, index int, step StepRequest) (context.Context, trace.Span) {
+	return otel.Tracer("gateway").Start(ctx, "Gateway.ExecuteStep",
+		traceStartAttrs("step.name", step.Name),
+		trace.WithAttributes(attribute.Int("step.index", index)),
+	)
+}
+
+// endStepSpan records the step outcome on span and ends it.
+func endStepSpan(span trace.Span, result *StepResult, err error) {
+	span.SetAttributes(attribute.Int("step.exit_code", int(result.Output.ExitCode)))
+	if result.FailureReason != "" {
+		span.SetAttributes(attribute.String("step.failure_reason", result.FailureReason))
+	}
+	recordSpanErr(span, err)
+	span.End()
+}
+
+// spanTraceID returns the hex trace ID of span, or "" when tracing is off.
+func spanTraceID(span trace.Span) string {
+	sc := span.SpanContext()
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// withTraceContextEnv returns env with the trace context of ctx added as
+// TRACEPARENT/TRACESTATE, so the executor and the commands it runs can
+// continue the trace. Values the caller set explicitly are kept.
+func withTraceContextEnv(ctx context.Context, env map[string]string) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return env
+	}
+	out := make(map[string]string, len(env)+len(carrier))
+	for k, v := range env {
+		out[k] = v
+	}
+	for header, name := range traceContextEnv {
+		value := carrier.Get(header)
+		if value == "" {
+			continue
+		}
+		if _, set := out[name]; !set {
+			out[name] = value
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTraceContextEnvInjectsTraceparent(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	env := withTraceContextEnv(ctx, map[string]string{"FOO": "bar"})
+	if got, want := env["TRACEPARENT"], "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; got != want {
+		t.Fatalf("TRACEPARENT = %q, want %q", got, want)
+	}
+	if env["FOO"] != "bar" {
+		t.Fatalf("env = %#v, want caller values kept", env)
+	}
+
+	env = withTraceContextEnv(ctx, map[string]string{"TRACEPARENT": "caller"})
+	if env["TRACEPARENT"] != "caller" {
+		t.Fatalf("TRACEPARENT = %q, want caller value preserved", env["TRACEPARENT"])
+	}
+
+	if env := withTraceContextEnv(context.Background(), nil); env != nil {
+		t.Fatalf("env without trace context = %#v, want unchanged nil", env)
+	}
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// validateSteps rejects steps whose fields cannot run, before any step of the
+// request starts.
+func validateSteps(steps []StepRequest) error {
+	for i, step := range steps {
+		if err := validateStepWorkDir(step.WorkDir); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+		if step.HTTP == nil {
+			continue
+		}
+		if len(step.Command) > 0 {
+			return fmt.Errorf("step %d: command and http are mutually exclusive", i)
+		}
+		if err := validateHTTPStep(step.HTTP); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateStepConcurrency rejects concurrent steps while sandbox
+// checkpointing is on. The agent numbers checkpoints in the order commands
+// start, so with steps overlapping the checkpoint persisted for a result
+// would hold another step's diff and a restore would replay the wrong one.
+func (g *Gateway) validateStepConcurrency(req ExecuteRequest) error {
+	if !g.gwConfig.SandboxCheckpointEnabled {
+		return nil
+	}
+	if req.Parallel {
+		return fmt.Errorf("parallel is not supported while sandbox checkpointing is enabled")
+	}
+	if hasStepDependencies(req.Steps) {
+		return fmt.Errorf("dependsOn is not supported while sandbox checkpointing is enabled")
+	}
+	return nil
+}
+
+// validateStepWorkDir accepts absolute directories as given. Relative ones
+// are resolved by the executor agent against its workspace, so they may not
+// climb above it.
+func validateStepWorkDir(workDir string) error {
+	if workDir == "" || strings.HasPrefix(workDir, "/") {
+		return nil
+	}
+	if clean := path.Clean(workDir); clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("workDir %q escapes the workspace", workDir)
+	}
+	return nil
+}
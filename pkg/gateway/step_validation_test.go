@@ -0,0 +1,56 @@
+package gateway
+
+import "testing"
+
+func TestValidateSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    StepRequest
+		wantErr bool
+	}{
+		{"command", StepRequest{Command: []string{"true"}}, false},
+		{"absolute workDir", StepRequest{WorkDir: "/tmp"}, false},
+		{"relative workDir", StepRequest{WorkDir: "src/../lib"}, false},
+		{"workDir above workspace", StepRequest{WorkDir: "src/../../etc"}, true},
+		{"workDir parent", StepRequest{WorkDir: ".."}, true},
+		{"http", StepRequest{HTTP: &HTTPStepRequest{Port: 8080}}, false},
+		{"missing port", StepRequest{HTTP: &HTTPStepRequest{}}, true},
+		{"port out of range", StepRequest{HTTP: &HTTPStepRequest{Port: 70000}}, true},
+		{"relative path", StepRequest{HTTP: &HTTPStepRequest{Port: 80, Path: "health"}}, true},
+		{"unknown method", StepRequest{HTTP: &HTTPStepRequest{Port: 80, Method: "CONNECT"}}, true},
+		{"host header", StepRequest{HTTP: &HTTPStepRequest{Port: 80, Headers: map[string]string{"host": "x"}}}, true},
+		{"command and http", StepRequest{Command: []string{"true"}, HTTP: &HTTPStepRequest{Port: 80}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSteps([]StepRequest{tt.step})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateStepConcurrencyWithCheckpointing(t *testing.T) {
+	steps := []StepRequest{{Name: "a", Command: []string{"true"}}, {Name: "b", Command: []string{"true"}, DependsOn: []string{"a"}}}
+	tests := []struct {
+		name       string
+		checkpoint bool
+		req        ExecuteRequest
+		wantErr    bool
+	}{
+		{"parallel without checkpointing", false, ExecuteRequest{Steps: steps[:1], Parallel: true}, false},
+		{"sequential with checkpointing", true, ExecuteRequest{Steps: steps[:1]}, false},
+		{"parallel with checkpointing", true, ExecuteRequest{Steps: steps[:1], Parallel: true}, true},
+		{"dependsOn with checkpointing", true, ExecuteRequest{Steps: steps}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := &Gateway{gwConfig: GatewayConfig{SandboxCheckpointEnabled: tt.checkpoint}}
+			err := gw.validateStepConcurrency(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateStepConcurrency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,17 +2,19 @@ package gateway
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/Lincyaw/agent-env/pkg/audit"
 )
 
-// SetTrajectoryWriter installs a ClickHouse writer after gateway startup and
+const defaultTrajectoryQueueSize = 4096
+
+// SetTrajectoryWriter installs a trajectory store after gateway startup and
 // starts the trajectory worker. If the worker is already running, the new
 // writer is closed and ignored.
-func (g *Gateway) SetTrajectoryWriter(writer *audit.TrajectoryWriter) {
+func (g *Gateway) SetTrajectoryWriter(writer audit.TrajectoryStore) {
 	if writer == nil {
 		return
 	}
@@ -40,7 +42,11 @@ func (g *Gateway) StartTrajectoryWorker() {
 		return
 	}
 	writer := g.trajectoryWriter
-	ch := make(chan audit.TrajectoryEntry, 4096)
+	queueSize := g.gwConfig.TrajectoryQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultTrajectoryQueueSize
+	}
+	ch := make(chan audit.TrajectoryEntry, queueSize)
 	g.trajCh = ch
 	g.trajWg.Add(1)
 	g.trajMu.Unlock()
@@ -75,33 +81,162 @@ func (g *Gateway) StopTrajectoryWorker() {
 	}
 }
 
+// enqueueTrajectory queues entry for the trajectory worker. When the queue
+// is full the oldest queued entry is dropped to make room, so a stalled
+// store loses the stale end of the backlog rather than the newest steps.
 func (g *Gateway) enqueueTrajectory(entry audit.TrajectoryEntry, sessionID string, step int) {
 	g.trajMu.RLock()
 	defer g.trajMu.RUnlock()
 	if g.trajCh == nil {
 		return
 	}
-	select {
-	case g.trajCh <- entry:
-	default:
-		log.Printf("Warning: trajectory channel full, dropping entry for session %s step %d", sessionID, step)
+	for {
+		select {
+		case g.trajCh <- entry:
+			return
+		default:
+		}
+		select {
+		case dropped := <-g.trajCh:
+			log.Printf("Warning: trajectory channel full, dropping oldest entry for session %s step %d to queue session %s step %d",
+				dropped.SessionID, dropped.Step, sessionID, step)
+			if g.metrics != nil {
+				g.metrics.IncrementTrajectoryDropped()
+			}
+		default:
+			// The worker drained the queue in the meantime; retry the send.
+		}
 	}
 }
 
+// trajectoryQueueDepth returns the number of entries waiting to be written.
+func (g *Gateway) trajectoryQueueDepth() int {
+	g.trajMu.RLock()
+	defer g.trajMu.RUnlock()
+	return len(g.trajCh)
+}
+
 // GetHistory returns the execution history for a session.
 func (g *Gateway) GetHistory(sessionID string) ([]StepRecord, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, fmt.Errorf("session %s not found", sessionID)
+		return nil, &SessionNotFoundError{SessionID: sessionID}
 	}
 	return s.History.GetAll(), nil
 }
 
-// ExportTrajectory exports the trajectory as JSONL.
-func (g *Gateway) ExportTrajectory(sessionID string) ([]byte, error) {
+// errTrajectoryStoreNotConfigured is returned when a trajectory is requested
+// from storage but the gateway runs without a trajectory store.
+var errTrajectoryStoreNotConfigured = errors.New("trajectory storage is not configured")
+
+// TrajectoryPage selects a window of a session's trajectory. Offset skips
+// that many steps in order and a zero Limit returns the rest. FromStore reads
+// the persisted trajectory instead of the in-memory history, which keeps
+// working after the session's history was lost to a gateway restart.
+type TrajectoryPage struct {
+	Offset    int
+	Limit     int
+	FromStore bool
+}
+
+// GetTrajectoryPage returns one page of a session's trajectory as JSONL
+// entries, oldest step first.
+func (g *Gateway) GetTrajectoryPage(ctx context.Context, sessionID string, page TrajectoryPage) ([]TrajectoryEntry, error) {
+	if page.FromStore {
+		g.trajMu.RLock()
+		writer := g.trajectoryWriter
+		g.trajMu.RUnlock()
+		if writer == nil {
+			return nil, errTrajectoryStoreNotConfigured
+		}
+		stored, err := writer.GetTrajectoryPaged(ctx, sessionID, page.Offset, page.Limit)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]TrajectoryEntry, len(stored))
+		for i, e := range stored {
+			entries[i] = TrajectoryEntry{
+				SessionID:   e.SessionID,
+				Step:        e.Step,
+				Action:      e.Action,
+				Observation: e.Observation,
+				SnapshotID:  e.SnapshotID,
+				Timestamp:   e.Timestamp,
+				TraceID:     e.TraceID,
+			}
+		}
+		return entries, nil
+	}
+
+	s, ok := g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+	records := s.History.Page(page.Offset, page.Limit)
+	entries := make([]TrajectoryEntry, len(records))
+	for i, r := range records {
+		entries[i] = r.trajectoryEntry(sessionID)
+	}
+	return entries, nil
+}
+
+// trajectoryStatsReader is implemented by trajectory stores that aggregate a
+// session's persisted steps server-side.
+type trajectoryStatsReader interface {
+	GetStats(ctx context.Context, sessionID string) (map[string]interface{}, error)
+}
+
+// GetSessionStats summarizes a session's steps. Totals come from the
+// trajectory store when it can aggregate them, and from the in-memory
+// history otherwise.
+func (g *Gateway) GetSessionStats(ctx context.Context, sessionID string) (*SessionStats, error) {
+	s, ok := g.store.Get(sessionID)
+	if !ok {
+		return nil, &SessionNotFoundError{SessionID: sessionID}
+	}
+	s.mu.RLock()
+	createdAt := s.Info.CreatedAt
+	s.mu.RUnlock()
+
+	records := s.History.GetAll()
+	stats := &SessionStats{
+		SessionID:    sessionID,
+		Source:       "history",
+		HistorySteps: len(records),
+		AgeSeconds:   time.Since(createdAt).Seconds(),
+	}
+	for _, r := range records {
+		stats.TotalDurationMs += r.DurationMs
+	}
+	stats.TotalSteps = int64(len(records))
+	if len(records) > 0 {
+		stats.AvgDurationMs = float64(stats.TotalDurationMs) / float64(len(records))
+	}
+
+	g.trajMu.RLock()
+	reader, ok := g.trajectoryWriter.(trajectoryStatsReader)
+	g.trajMu.RUnlock()
+	if !ok {
+		return stats, nil
+	}
+	stored, err := reader.GetStats(ctx, sessionID)
+	if err != nil {
+		log.Printf("Warning: trajectory stats for session %s: %v", sessionID, err)
+		return stats, nil
+	}
+	total, _ := stored["total_steps"].(int64)
+	avg, _ := stored["avg_duration_ms"].(float64)
+	sum, _ := stored["total_duration_ms"].(int64)
+	stats.Source = "storage"
+	stats.TotalSteps, stats.AvgDurationMs, stats.TotalDurationMs = total, avg, sum
+	return stats, nil
+}
+
+// ExportTrajectoryMessages exports the trajectory as role/content messages.
+func (g *Gateway) ExportTrajectoryMessages(sessionID string) ([]TrajectoryMessage, error) {
 	s, ok := g.store.Get(sessionID)
 	if !ok {
-		return nil, fmt.Errorf("session %s not found", sessionID)
+		return nil, &SessionNotFoundError{SessionID: sessionID}
 	}
-	return s.History.ExportTrajectory(sessionID)
+	return StepRecordsToMessages(s.History.GetAll()), nil
 }
@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Lincyaw/agent-env/pkg/audit"
+)
+
+func TestEnqueueTrajectoryDropsOldestEntry(t *testing.T) {
+	metrics := &recordingMetricsCollector{}
+	gw := &Gateway{metrics: metrics, trajCh: make(chan audit.TrajectoryEntry, 1)}
+
+	gw.enqueueTrajectory(audit.TrajectoryEntry{SessionID: "gw-1", Step: 0}, "gw-1", 0)
+	gw.enqueueTrajectory(audit.TrajectoryEntry{SessionID: "gw-1", Step: 1}, "gw-1", 1)
+
+	if got := gw.trajectoryQueueDepth(); got != 1 {
+		t.Fatalf("queue depth = %d, want 1", got)
+	}
+	if metrics.trajectoryDropped != 1 {
+		t.Fatalf("dropped = %d, want 1", metrics.trajectoryDropped)
+	}
+	if queued := <-gw.trajCh; queued.Step != 1 {
+		t.Fatalf("queued step = %d, want the newest step 1", queued.Step)
+	}
+}
+
+// statsTrajectoryStore reports fixed aggregates like the ClickHouse writer.
+type statsTrajectoryStore struct {
+	audit.TrajectoryStore
+}
+
+func (statsTrajectoryStore) GetStats(context.Context, string) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"total_steps":       int64(40),
+		"avg_duration_ms":   float64(25),
+		"total_duration_ms": int64(1000),
+	}, nil
+}
+
+func TestGetSessionStatsFromHistory(t *testing.T) {
+	store := newTestSessionStore("gw-stats")
+	s, _ := store.Get("gw-stats")
+	s.History.Add(StepRecord{Name: "a", DurationMs: 100})
+	s.History.Add(StepRecord{Name: "b", DurationMs: 300})
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, nil, GatewayConfig{}, store)
+
+	stats, err := gw.GetSessionStats(context.Background(), "gw-stats")
+	if err != nil {
+		t.Fatalf("GetSessionStats returned error: %v", err)
+	}
+	if stats.Source != "history" || stats.TotalSteps != 2 || stats.HistorySteps != 2 {
+		t.Fatalf("stats = %+v, want 2 steps from history", stats)
+	}
+	if stats.TotalDurationMs != 400 || stats.AvgDurationMs != 200 {
+		t.Fatalf("durations = %d total, %v avg, want 400 and 200", stats.TotalDurationMs, stats.AvgDurationMs)
+	}
+}
+
+func TestGetSessionStatsPrefersTrajectoryStore(t *testing.T) {
+	store := newTestSessionStore("gw-stats")
+	s, _ := store.Get("gw-stats")
+	s.History.Add(StepRecord{Name: "a", DurationMs: 100})
+	gw := New(nil, &operationRuntimeAllocator{}, nil, nil, statsTrajectoryStore{}, GatewayConfig{}, store)
+
+	stats, err := gw.GetSessionStats(context.Background(), "gw-stats")
+	if err != nil {
+		t.Fatalf("GetSessionStats returned error: %v", err)
+	}
+	if stats.Source != "storage" || stats.TotalSteps != 40 || stats.TotalDurationMs != 1000 {
+		t.Fatalf("stats = %+v, want the stored aggregates", stats)
+	}
+	if stats.HistorySteps != 1 {
+		t.Fatalf("HistorySteps = %d, want 1", stats.HistorySteps)
+	}
+}
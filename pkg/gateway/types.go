@@ -74,6 +74,18 @@ type PortInfo struct {
 
 // CreateSessionRequest is the body for POST /v1/sessions
 type CreateSessionRequest struct {
+	// Name, when set, is used as the session ID and sandbox name. It must be
+	// a DNS-1123 label, and it cannot be reused while a session record or
+	// trajectory is still stored under it.
+	Name string `json:"name,omitempty"`
+	// Labels and Annotations are copied onto the sandbox claim and pod so
+	// sandboxes can be correlated with external systems.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// IdempotencyKey makes creation safe to retry: a repeat with the same
+	// key returns the session created by the first request while it lives.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+
 	Image                    string                 `json:"image,omitempty"`
 	Profile                  string                 `json:"profile,omitempty"`
 	Namespace                string                 `json:"namespace,omitempty"`
@@ -81,6 +93,7 @@ type CreateSessionRequest struct {
 	Devbox                   *DevboxConfig          `json:"devbox,omitempty"`
 	ConfigEnv                json.RawMessage        `json:"configEnv,omitempty"`
 	IdleTimeoutSeconds       int                    `json:"idleTimeoutSeconds,omitempty"`
+	MaxLifetimeSeconds       int                    `json:"maxLifetimeSeconds,omitempty"` // hard ceiling regardless of activity; 0 = none
 	AllocationTimeoutSeconds *int                   `json:"allocationTimeoutSeconds,omitempty"`
 	PrivateContainers        []PrivateContainerSpec `json:"privateContainers,omitempty"`
 	AllowInternet            *bool                  `json:"allowInternet,omitempty"`
@@ -90,11 +103,65 @@ type CreateSessionRequest struct {
 	ExperimentID             string                 `json:"-"`
 }
 
+// BatchCreateSessionsRequest is the body for POST /v1/sessions:batch
+type BatchCreateSessionsRequest struct {
+	Count       int                  `json:"count"`
+	Concurrency int                  `json:"concurrency,omitempty"`
+	Template    CreateSessionRequest `json:"template"`
+}
+
+// BatchCreateSessionsResponse is the response for POST /v1/sessions:batch.
+// Sessions lists the ready sessions; Errors lists the items that failed.
+type BatchCreateSessionsResponse struct {
+	Sessions []SessionInfo       `json:"sessions"`
+	Errors   []BatchSessionError `json:"errors,omitempty"`
+}
+
+// BatchSessionError describes one failed item of a batch create
+type BatchSessionError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
 func hasJSONPayload(raw json.RawMessage) bool {
 	trimmed := bytes.TrimSpace(raw)
 	return len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null"))
 }
 
+// Image locality modes for CreatePoolRequest.ImageLocality.
+const (
+	// ImageLocalityModeConcentrate packs pool pods onto nodes that already
+	// hold the image, trading availability for startup latency.
+	ImageLocalityModeConcentrate = "concentrate"
+	// ImageLocalityModeSpread prefers placing pool pods on distinct nodes
+	// and zones, so a single node failure does not take out the pool.
+	ImageLocalityModeSpread = "spread"
+)
+
+// ImageLocalitySpec is the object form of CreatePoolRequest.ImageLocality.
+// Any other non-null payload enables the concentrate mode.
+type ImageLocalitySpec struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+func decodeImageLocality(raw json.RawMessage) (ImageLocalitySpec, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if !hasJSONPayload(trimmed) || trimmed[0] != '{' {
+		return ImageLocalitySpec{}, nil
+	}
+	var spec ImageLocalitySpec
+	if err := json.Unmarshal(trimmed, &spec); err != nil {
+		return ImageLocalitySpec{}, fmt.Errorf("imageLocality must be an object: %w", err)
+	}
+	switch spec.Mode {
+	case "", ImageLocalityModeConcentrate, ImageLocalityModeSpread:
+	default:
+		return ImageLocalitySpec{}, fmt.Errorf("imageLocality.mode must be %s or %s: %q", ImageLocalityModeConcentrate, ImageLocalityModeSpread, spec.Mode)
+	}
+	return spec, nil
+}
+
 func decodeConfigEnv(configEnv json.RawMessage) (json.RawMessage, error) {
 	trimmed := bytes.TrimSpace(configEnv)
 	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
@@ -150,16 +217,57 @@ type ExecuteRequest struct {
 	Steps       []StepRequest `json:"steps"`
 	TraceID     string        `json:"traceID,omitempty"`
 	OperationID string        `json:"operationID,omitempty"`
+	// Parallel runs independent steps concurrently in the same sandbox.
+	// Results and history keep request order, or dependency order when
+	// steps set DependsOn. Streaming execute ignores it, and it is
+	// rejected while sandbox checkpointing is enabled.
+	Parallel bool `json:"parallel,omitempty"`
+	// ParallelismLimit bounds concurrent steps when Parallel is set; zero
+	// runs all steps at once.
+	ParallelismLimit int `json:"parallelismLimit,omitempty"`
 }
 
 // StepRequest describes a single execution step
 type StepRequest struct {
-	Name           string            `json:"name"`
-	Command        []string          `json:"command,omitempty"`
-	Env            map[string]string `json:"env,omitempty"`
-	WorkDir        string            `json:"workDir,omitempty"`
-	TimeoutSeconds int32             `json:"timeoutSeconds,omitempty"`
-	Timeout        int32             `json:"timeout,omitempty"`
+	Name    string            `json:"name"`
+	Command []string          `json:"command,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// WorkDir is used as given when absolute. A relative WorkDir is joined
+	// onto the sandbox's workspace and may not climb above it.
+	WorkDir        string `json:"workDir,omitempty"`
+	TimeoutSeconds int32  `json:"timeoutSeconds,omitempty"`
+	Timeout        int32  `json:"timeout,omitempty"`
+	// MemoryBytes caps the virtual memory of the step's processes.
+	MemoryBytes int64 `json:"memoryBytes,omitempty"`
+	// CPUSeconds caps the CPU time the step's processes may consume.
+	CPUSeconds int32 `json:"cpuSeconds,omitempty"`
+	// DependsOn names steps of the same request that must finish first.
+	// Steps with no path between them run concurrently, and a step whose
+	// dependency failed is reported as skipped. Not supported when streaming
+	// or while sandbox checkpointing is enabled.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Stdin is piped to the command, whose standard input is closed after it
+	// so commands such as "python -" see EOF.
+	Stdin string `json:"stdin,omitempty"`
+	// MaxOutputBytes lowers the gateway's cap on the step's combined stdout
+	// and stderr. It cannot raise it.
+	MaxOutputBytes int64 `json:"maxOutputBytes,omitempty"`
+	// HTTP sends a request to a service listening in the sandbox instead of
+	// running a command. Command must be empty when it is set.
+	HTTP *HTTPStepRequest `json:"http,omitempty"`
+}
+
+// HTTPStepRequest describes an HTTP step's request. It is sent from the
+// gateway to the sandbox's pod IP, so the service must listen on a
+// non-loopback address.
+type HTTPStepRequest struct {
+	// Method defaults to GET.
+	Method string `json:"method,omitempty"`
+	Port   int32  `json:"port"`
+	// Path defaults to "/" and may include a query string.
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
 }
 
 // PrivateContainerSpec describes a gateway-managed container that is not part
@@ -189,10 +297,29 @@ type UploadFileResponse struct {
 	SHA256       string `json:"sha256,omitempty"`
 }
 
+// UploadArchiveResponse is the response for POST /v1/sessions/{id}/upload-archive.
+// When extraction fails partway, it is returned with the error status and
+// Error set, listing the files written before the failure.
+type UploadArchiveResponse struct {
+	Files        []UploadFileResponse `json:"files"`
+	BytesWritten int64                `json:"bytesWritten"`
+	Skipped      []string             `json:"skipped,omitempty"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// PatchFileRequest is the body for POST /v1/sessions/{id}/patch-file
+type PatchFileRequest struct {
+	Path  string `json:"path"`
+	Patch string `json:"patch"`
+}
+
 // RestoreRequest is the body for POST /v1/sessions/{id}/restore
 type RestoreRequest struct {
 	SnapshotID  string `json:"snapshotID"`
 	OperationID string `json:"operationID,omitempty"`
+	// FromTrajectory replays from the stored trajectory instead of the
+	// in-memory history.
+	FromTrajectory bool `json:"fromTrajectory,omitempty"`
 }
 
 // RestoreResponse is the response for POST /v1/sessions/{id}/restore
@@ -201,6 +328,51 @@ type RestoreResponse struct {
 	StepsReplayed int    `json:"stepsReplayed"`
 }
 
+// SessionStats is the response for GET /v1/sessions/{id}/stats
+type SessionStats struct {
+	SessionID string `json:"sessionID"`
+	// Source is "storage" when the step totals come from the trajectory
+	// store and "history" when they are computed from in-memory history.
+	Source          string  `json:"source"`
+	TotalSteps      int64   `json:"totalSteps"`
+	AvgDurationMs   float64 `json:"avgDurationMs"`
+	TotalDurationMs int64   `json:"totalDurationMs"`
+	HistorySteps    int     `json:"historySteps"`
+	AgeSeconds      float64 `json:"ageSeconds"`
+}
+
+// SnapshotResponse is the response for POST /v1/sessions/{id}/snapshot
+type SnapshotResponse struct {
+	// SnapshotID restores the persisted workspace when passed to restore.
+	SnapshotID string `json:"snapshotID"`
+	// Step is the index of the last step captured by the snapshot.
+	Step int `json:"step"`
+}
+
+// UpdateSessionRequest is the body for PATCH /v1/sessions/{id}
+type UpdateSessionRequest struct {
+	// KeepAlive records activity now, restarting the idle clock.
+	KeepAlive bool `json:"keepAlive,omitempty"`
+	// IdleTimeoutSeconds replaces the session's idle timeout. The idle clock
+	// restarts from the update.
+	IdleTimeoutSeconds int `json:"idleTimeoutSeconds,omitempty"`
+}
+
+// ResetRequest is the body for POST /v1/sessions/{id}/reset
+type ResetRequest struct {
+	// PreserveFiles re-uploads files written through the gateway onto the
+	// clean workspace.
+	PreserveFiles bool `json:"preserveFiles,omitempty"`
+	// TruncateHistory drops recorded steps other than preserved uploads.
+	TruncateHistory bool `json:"truncateHistory,omitempty"`
+}
+
+// ResetResponse is the response for POST /v1/sessions/{id}/reset
+type ResetResponse struct {
+	SandboxName    string `json:"sandboxName"`
+	FilesPreserved int    `json:"filesPreserved"`
+}
+
 // ReplayRequest is the body for POST /v1/sessions/{id}/replay
 type ReplayRequest struct {
 	SourceSessionID string `json:"sourceSessionID"`
@@ -227,7 +399,60 @@ type CreatePoolRequest struct {
 	ImageLocality     json.RawMessage              `json:"imageLocality,omitempty"`
 	PrivateContainers []PrivateContainerSpec       `json:"privateContainers,omitempty"`
 	AllowInternet     *bool                        `json:"allowInternet,omitempty"`
-	Managed           bool                         `json:"-"`
+	// MaxAllocated caps the number of sessions allocated from the pool at
+	// once; requests beyond it queue. Zero or unset means no cap.
+	MaxAllocated *int32 `json:"maxAllocated,omitempty"`
+	// WorkspaceVolume backs the executor's workspace with a dedicated
+	// volume. Unset keeps the workspace on the container filesystem.
+	WorkspaceVolume *WorkspaceVolumeSpec `json:"workspaceVolume,omitempty"`
+	// ExecutorProbes overrides the gateway's probe timings for the executor
+	// container. Unset fields keep the gateway defaults.
+	ExecutorProbes *ExecutorProbeSpec `json:"executorProbes,omitempty"`
+	Managed        bool               `json:"-"`
+}
+
+// ExecutorProbeSpec tunes the executor container's health probes.
+type ExecutorProbeSpec struct {
+	// StartupTimeoutSeconds is how long the agent may take to start
+	// listening before the container is restarted.
+	StartupTimeoutSeconds int32 `json:"startupTimeoutSeconds,omitempty"`
+	// ReadinessPeriodSeconds is the interval between readiness checks.
+	ReadinessPeriodSeconds int32 `json:"readinessPeriodSeconds,omitempty"`
+	// LivenessPeriodSeconds is the interval between liveness checks.
+	LivenessPeriodSeconds int32 `json:"livenessPeriodSeconds,omitempty"`
+	// LivenessFailureThreshold is the number of consecutive failed
+	// liveness checks that restart the container.
+	LivenessFailureThreshold int32 `json:"livenessFailureThreshold,omitempty"`
+}
+
+// WorkspaceVolumeSpec selects the volume mounted as the sandbox workspace.
+// At most one of EmptyDir, ClaimTemplate and HostPath may be set; with none
+// set an unbounded disk-backed EmptyDir is used.
+type WorkspaceVolumeSpec struct {
+	// MountPath is where the executor sees the workspace and the root that
+	// relative step workdirs resolve against. Defaults to /workspace.
+	MountPath     string                  `json:"mountPath,omitempty"`
+	EmptyDir      *WorkspaceEmptyDir      `json:"emptyDir,omitempty"`
+	ClaimTemplate *WorkspaceClaimTemplate `json:"claimTemplate,omitempty"`
+	// HostPath mounts a directory from the node. It must lie under one of
+	// the gateway's WORKSPACE_HOST_PATH_PREFIXES; with none configured it is
+	// rejected. Each sandbox gets its own subdirectory, named after its pod,
+	// so sandboxes on the same node never share files.
+	HostPath string `json:"hostPath,omitempty"`
+}
+
+// WorkspaceEmptyDir configures an EmptyDir workspace.
+type WorkspaceEmptyDir struct {
+	SizeLimit string `json:"sizeLimit,omitempty"`
+	// Medium is "" for node disk or "Memory" for tmpfs.
+	Medium string `json:"medium,omitempty"`
+}
+
+// WorkspaceClaimTemplate requests a per-sandbox PVC for the workspace.
+type WorkspaceClaimTemplate struct {
+	StorageSize      string `json:"storageSize"`
+	StorageClassName string `json:"storageClassName,omitempty"`
+	AccessMode       string `json:"accessMode,omitempty"`
 }
 
 // PrefetchPoolRequest is the body for POST /v1/pools/{name}/prefetch
@@ -240,6 +465,8 @@ type ScalePoolRequest struct {
 	Replicas  int32                        `json:"replicas"`
 	Namespace string                       `json:"namespace,omitempty"`
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// MaxAllocated replaces the pool's allocation cap when set; 0 removes it.
+	MaxAllocated *int32 `json:"maxAllocated,omitempty"`
 }
 
 // --- Response types ---
@@ -263,6 +490,32 @@ type SessionInfo struct {
 	IrohAddr        string          `json:"irohAddr,omitempty"`
 	ParentSessionID string          `json:"parentSessionId,omitempty"`
 	ForkStep        int             `json:"forkStep,omitempty"`
+	// IdleTimeoutSeconds is the idle timeout currently applied to the
+	// session; 0 means it is never reaped for idleness.
+	IdleTimeoutSeconds int `json:"idleTimeoutSeconds,omitempty"`
+	// Labels and Annotations echo the caller metadata from session creation.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// ResourceUsage is the last resource sample of the executor container.
+	// It is only set when resource sampling is enabled.
+	ResourceUsage *SessionResourceUsage `json:"resourceUsage,omitempty"`
+}
+
+// SessionResourceUsage is the executor container's consumption as reported
+// by the kubelet after the session's latest execute. CPUSeconds is a running
+// total across every pod the session has used; PeakMemoryBytes is the
+// highest working set seen in any sample.
+type SessionResourceUsage struct {
+	CPUSeconds      float64   `json:"cpuSeconds"`
+	PeakMemoryBytes int64     `json:"peakMemoryBytes"`
+	SampledAt       time.Time `json:"sampledAt"`
+}
+
+// RunRequest is the body for POST /v1/run. The session is created, the
+// steps are executed in it, and the session is deleted again.
+type RunRequest struct {
+	Session CreateSessionRequest `json:"session"`
+	Execute ExecuteRequest       `json:"execute"`
 }
 
 // ExecuteResponse is the response for POST /v1/sessions/{id}/execute
@@ -309,6 +562,28 @@ type StepResult struct {
 	DurationMs int64           `json:"duration_ms"`
 	Timestamp  time.Time       `json:"timestamp"`
 	Input      json.RawMessage `json:"input"`
+	// FailureReason is set when the step was stopped by one of its resource
+	// limits (see StepFailureMemoryLimit and StepFailureCPUTimeLimit).
+	FailureReason string `json:"failure_reason,omitempty"`
+	// TraceID identifies the step's trace when tracing is enabled.
+	TraceID string `json:"trace_id,omitempty"`
+	// OverheadUs is the time, in microseconds, the executor agent spent
+	// between receiving the step and starting its command. Spawning usually
+	// takes well under a millisecond, hence the finer unit. It is omitted
+	// when the agent does not report it.
+	OverheadUs int64 `json:"overhead_us,omitempty"`
+	// OutputTruncated is set when output past the step's output cap was
+	// discarded.
+	OutputTruncated bool `json:"output_truncated,omitempty"`
+	// HTTP is set for HTTP steps. It is empty when no response was received.
+	HTTP *HTTPStepResult `json:"http,omitempty"`
+}
+
+// HTTPStepResult is the response to an HTTP step. The body is reported as
+// the step's stdout.
+type HTTPStepResult struct {
+	StatusCode int               `json:"status_code,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
 }
 
 // PoolInfo describes a warm pool
@@ -320,9 +595,40 @@ type PoolInfo struct {
 	Replicas          int32           `json:"replicas"`
 	ReadyReplicas     int32           `json:"readyReplicas"`
 	AllocatedReplicas int32           `json:"allocatedReplicas"`
+	MaxAllocated      int32           `json:"maxAllocated,omitempty"`
 	State             string          `json:"state,omitempty"`
 	CreatedAt         time.Time       `json:"createdAt,omitempty"`
 	Conditions        []PoolCondition `json:"conditions,omitempty"`
+	// NodeDistribution counts the pool's scheduled pods per node. It is only
+	// populated by GET /v1/pools/{name}.
+	NodeDistribution map[string]int32 `json:"nodeDistribution,omitempty"`
+	// PodFailures lists the most recent unrecoverable pod and container
+	// states in the pool. It is only populated by GET /v1/pools/{name}.
+	PodFailures []PodFailure `json:"podFailures,omitempty"`
+}
+
+// PodFailure describes one failing pool pod or container.
+type PodFailure struct {
+	Pod       string    `json:"pod"`
+	Node      string    `json:"node,omitempty"`
+	Container string    `json:"container,omitempty"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// PoolRecommendation is the response for GET /v1/pools/{name}/recommendation
+type PoolRecommendation struct {
+	Name                      string   `json:"name"`
+	Namespace                 string   `json:"namespace"`
+	CurrentReplicas           int32    `json:"currentReplicas"`
+	RecommendedReplicas       int32    `json:"recommendedReplicas"`
+	Headroom                  int32    `json:"headroom"`
+	WindowSeconds             int64    `json:"windowSeconds"`
+	AllocationsPerMinute      float64  `json:"allocationsPerMinute"`
+	AvgSessionLifetimeSeconds float64  `json:"avgSessionLifetimeSeconds"`
+	NoIdleEvents              int      `json:"noIdleEvents"`
+	Reasoning                 []string `json:"reasoning"`
 }
 
 // PoolListOptions controls the cost and shape of pool list responses.
@@ -351,6 +657,8 @@ type SessionListOptions struct {
 	Profile      string
 	ExperimentID string
 	Status       string
+	PoolRef      string
+	Namespace    string
 	Limit        int
 	Cursor       string
 }
@@ -410,9 +718,34 @@ type BuildResponse struct {
 // ErrorResponse is a generic error response
 type ErrorResponse struct {
 	Error  string `json:"error"`
+	Code   string `json:"code,omitempty"` // machine-readable, e.g. POOL_NOT_FOUND
 	Detail string `json:"detail,omitempty"`
 }
 
+// ReadinessResponse is the body of GET /readyz.
+type ReadinessResponse struct {
+	Status string          `json:"status"` // "ok" or "unavailable"
+	Error  string          `json:"error,omitempty"`
+	Pools  []PoolReadiness `json:"pools,omitempty"`
+}
+
+// PoolReadiness reports whether one pool's sidecar answered a ping.
+type PoolReadiness struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	PodName   string `json:"podName,omitempty"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TrajectoryMessage is one role/content message in the "messages"
+// trajectory export format.
+type TrajectoryMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Name    string `json:"name,omitempty"`
+}
+
 // TrajectoryEntry is a single entry in JSONL trajectory export
 type TrajectoryEntry struct {
 	SessionID   string          `json:"session_id"`
@@ -421,4 +754,5 @@ type TrajectoryEntry struct {
 	Observation json.RawMessage `json:"observation"`
 	SnapshotID  string          `json:"snapshot_id"`
 	Timestamp   time.Time       `json:"timestamp"`
+	TraceID     string          `json:"trace_id,omitempty"`
 }
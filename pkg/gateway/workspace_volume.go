@@ -0,0 +1,183 @@
+package gateway
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	sandboxv1beta1 "sigs.k8s.io/agent-sandbox/api/v1beta1"
+)
+
+const (
+	workspaceVolumeName       = "workspace"
+	defaultWorkspaceMountPath = "/workspace"
+	// workspacePodNameEnv carries the pod name into containers that mount
+	// a hostPath workspace, for the per-sandbox subPathExpr.
+	workspacePodNameEnv = "ARL_POD_NAME"
+)
+
+// validateWorkspaceVolume checks spec. A hostPath workspace must lie under
+// one of the operator's comma-separated allowedHostPaths prefixes, since any
+// API caller can create pools and the directory is mounted read-write.
+func validateWorkspaceVolume(spec *WorkspaceVolumeSpec, allowedHostPaths string) error {
+	if spec == nil {
+		return nil
+	}
+	if mountPath := strings.TrimSpace(spec.MountPath); mountPath != "" && !strings.HasPrefix(mountPath, "/") {
+		return fmt.Errorf("workspaceVolume.mountPath must be absolute")
+	}
+	sources := 0
+	if spec.EmptyDir != nil {
+		sources++
+	}
+	if spec.ClaimTemplate != nil {
+		sources++
+	}
+	if spec.HostPath != "" {
+		sources++
+	}
+	if sources > 1 {
+		return fmt.Errorf("workspaceVolume.emptyDir, claimTemplate and hostPath are mutually exclusive")
+	}
+	if ed := spec.EmptyDir; ed != nil {
+		if ed.Medium != "" && !strings.EqualFold(ed.Medium, string(corev1.StorageMediumMemory)) {
+			return fmt.Errorf("workspaceVolume.emptyDir.medium must be empty or Memory")
+		}
+		if ed.SizeLimit != "" {
+			if _, err := resource.ParseQuantity(ed.SizeLimit); err != nil {
+				return fmt.Errorf("workspaceVolume.emptyDir.sizeLimit %q is invalid: %w", ed.SizeLimit, err)
+			}
+		}
+	}
+	if ct := spec.ClaimTemplate; ct != nil {
+		if ct.StorageSize == "" {
+			return fmt.Errorf("workspaceVolume.claimTemplate.storageSize is required")
+		}
+		if _, err := resource.ParseQuantity(ct.StorageSize); err != nil {
+			return fmt.Errorf("workspaceVolume.claimTemplate.storageSize %q is invalid: %w", ct.StorageSize, err)
+		}
+		switch corev1.PersistentVolumeAccessMode(ct.AccessMode) {
+		case "", corev1.ReadWriteOnce, corev1.ReadWriteOncePod, corev1.ReadWriteMany:
+		default:
+			return fmt.Errorf("workspaceVolume.claimTemplate.accessMode %q is not supported", ct.AccessMode)
+		}
+	}
+	if spec.HostPath != "" {
+		return validateWorkspaceHostPath(spec.HostPath, allowedHostPaths)
+	}
+	return nil
+}
+
+func validateWorkspaceHostPath(hostPath, allowedHostPaths string) error {
+	if !strings.HasPrefix(hostPath, "/") || path.Clean(hostPath) != hostPath {
+		return fmt.Errorf("workspaceVolume.hostPath must be a clean absolute path")
+	}
+	for _, part := range strings.Split(allowedHostPaths, ",") {
+		prefix := strings.TrimSuffix(strings.TrimSpace(part), "/")
+		if prefix != "" && (hostPath == prefix || strings.HasPrefix(hostPath, prefix+"/")) {
+			return nil
+		}
+	}
+	return fmt.Errorf("workspaceVolume.hostPath %q is not under an allowed prefix (WORKSPACE_HOST_PATH_PREFIXES)", hostPath)
+}
+
+// workspaceMountPath returns where the executor sees the workspace, which is
+// also the root the agent resolves relative workdirs against.
+func workspaceMountPath(spec *WorkspaceVolumeSpec) string {
+	if spec != nil {
+		if mountPath := strings.TrimSpace(spec.MountPath); mountPath != "" {
+			return mountPath
+		}
+	}
+	return defaultWorkspaceMountPath
+}
+
+// applyWorkspaceVolume adds the workspace volume to pod and mounts it in the
+// executor container. A claim-backed workspace gets no pod volume here: the
+// sandbox controller adds one per volume claim template, named after it. A
+// hostPath directory is shared by every sandbox of the pool on a node, so
+// each pod mounts its own subdirectory of it, named after the pod.
+func applyWorkspaceVolume(pod *corev1.PodSpec, spec *WorkspaceVolumeSpec) {
+	if spec == nil {
+		return
+	}
+	switch {
+	case spec.ClaimTemplate != nil:
+	case spec.HostPath != "":
+		hostPathType := corev1.HostPathDirectoryOrCreate
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: workspaceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: spec.HostPath, Type: &hostPathType},
+			},
+		})
+	default:
+		emptyDir := &corev1.EmptyDirVolumeSource{}
+		if ed := spec.EmptyDir; ed != nil {
+			if ed.Medium != "" {
+				emptyDir.Medium = corev1.StorageMediumMemory
+			}
+			if ed.SizeLimit != "" {
+				limit := resource.MustParse(ed.SizeLimit)
+				emptyDir.SizeLimit = &limit
+			}
+		}
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name:         workspaceVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+		})
+	}
+
+	for i := range pod.Containers {
+		if pod.Containers[i].Name == executorContainerName {
+			pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      workspaceVolumeName,
+				MountPath: workspaceMountPath(spec),
+			})
+		}
+	}
+	if spec.HostPath != "" {
+		isolateHostPathWorkspace(pod)
+	}
+}
+
+// isolateHostPathWorkspace points every workspace mount in pod, including
+// private containers that share it, at a subdirectory named after the pod.
+// The kubelet creates the subdirectory; it is left on the node when the pod
+// goes away.
+func isolateHostPathWorkspace(pod *corev1.PodSpec) {
+	for i := range pod.Containers {
+		container := &pod.Containers[i]
+		mounted := false
+		for j := range container.VolumeMounts {
+			if container.VolumeMounts[j].Name == workspaceVolumeName {
+				container.VolumeMounts[j].SubPathExpr = "$(" + workspacePodNameEnv + ")"
+				mounted = true
+			}
+		}
+		if mounted {
+			container.Env = append(container.Env, corev1.EnvVar{
+				Name: workspacePodNameEnv,
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+				},
+			})
+		}
+	}
+}
+
+// workspaceVolumeClaimTemplates returns the template-level PVC request for a
+// claim-backed workspace, or nil for any other source.
+func workspaceVolumeClaimTemplates(spec *WorkspaceVolumeSpec) []sandboxv1beta1.PersistentVolumeClaimTemplate {
+	if spec == nil || spec.ClaimTemplate == nil {
+		return nil
+	}
+	return sandboxClaimVCTs([]RuntimeVolumeClaimTemplate{{
+		Name:             workspaceVolumeName,
+		StorageSize:      spec.ClaimTemplate.StorageSize,
+		AccessMode:       spec.ClaimTemplate.AccessMode,
+		StorageClassName: spec.ClaimTemplate.StorageClassName,
+	}})
+}
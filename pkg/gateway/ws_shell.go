@@ -3,11 +3,15 @@ package gateway
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
@@ -51,22 +55,57 @@ func newUpgrader(authCfg *AuthConfig) websocket.Upgrader {
 
 // wsMessage is the JSON envelope for WebSocket messages.
 type wsMessage struct {
-	Type     string `json:"type"`                // "input", "output", "signal", "resize", "exit"
+	Type     string `json:"type"`                // "input", "output", "signal", "resize", "exec", "exit"
 	Data     string `json:"data,omitempty"`      // stdin/stdout data
 	Signal   string `json:"signal,omitempty"`    // signal name (e.g., "SIGINT")
 	Rows     int32  `json:"rows,omitempty"`      // terminal rows
 	Cols     int32  `json:"cols,omitempty"`      // terminal columns
 	ExitCode int32  `json:"exit_code,omitempty"` // exit code
+
+	// Exec fields. ID is chosen by the client and echoed on every message
+	// the command produces; Stream tags exec output as "stdout" or "stderr".
+	ID             string            `json:"id,omitempty"`
+	Command        []string          `json:"command,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	WorkDir        string            `json:"workdir,omitempty"`
+	TimeoutSeconds int32             `json:"timeout_seconds,omitempty"`
+	Stream         string            `json:"stream,omitempty"`
+}
+
+// maxWSExecsPerConn caps the exec commands one shell connection may have
+// running at once.
+const maxWSExecsPerConn = 16
+
+// wsWriter serializes writes to a WebSocket shared by the shell and any
+// exec commands, since gorilla connections allow only one concurrent writer.
+type wsWriter struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (w *wsWriter) write(msg wsMessage) error {
+	data, _ := json.Marshal(msg)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+// wsExecs bounds and tracks the exec commands of one shell connection, so
+// the connection can wait for them before it releases the session.
+type wsExecs struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
 }
 
 // handleShell upgrades to WebSocket and proxies to executor InteractiveShell stream.
+// "exec" messages on the same socket run single commands via ExecuteStream.
 func handleShell(gw *Gateway, authCfg *AuthConfig) http.HandlerFunc {
 	upgrader := newUpgrader(authCfg)
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
 
-		_, podIP, releaseSession, err := gw.acquireSessionPodIP(r.Context(), id)
+		sessionCtx, s, podIP, releaseSession, err := gw.acquireSessionExec(r.Context(), id)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
@@ -81,44 +120,47 @@ func handleShell(gw *Gateway, authCfg *AuthConfig) http.HandlerFunc {
 		}
 		defer ws.Close()
 
+		out := &wsWriter{ws: ws}
+
 		// Open bidi stream to executor with cancellable context
-		ctx, cancel := context.WithCancel(r.Context())
+		ctx, cancel := context.WithCancel(sessionCtx)
 		defer cancel()
 
 		shellStream, err := gw.executorClient.InteractiveShell(ctx, podIP)
 		if err != nil {
-			writeWSError(ws, "failed to open shell: "+err.Error())
+			out.write(wsMessage{Type: "error", Data: "failed to open shell: " + err.Error()})
 			return
 		}
 		defer shellStream.Close()
 
 		done := make(chan struct{})
+		readerDone := make(chan struct{})
+		execs := &wsExecs{slots: make(chan struct{}, maxWSExecsPerConn)}
 
 		// Executor -> WebSocket: read from executor, send to client
 		go func() {
 			defer close(done)
 			for {
-				out, recvErr := shellStream.Recv()
+				chunk, recvErr := shellStream.Recv()
 				if recvErr != nil {
 					if recvErr != io.EOF {
-						writeWSError(ws, "shell stream error: "+recvErr.Error())
+						out.write(wsMessage{Type: "error", Data: "shell stream error: " + recvErr.Error()})
 					}
 					return
 				}
 
 				var msg wsMessage
-				if out.Closed {
-					msg = wsMessage{Type: "exit", ExitCode: out.ExitCode}
+				if chunk.Closed {
+					msg = wsMessage{Type: "exit", ExitCode: chunk.ExitCode}
 				} else {
-					msg = wsMessage{Type: "output", Data: out.Data}
+					msg = wsMessage{Type: "output", Data: chunk.Data}
 				}
 
-				data, _ := json.Marshal(msg)
-				if writeErr := ws.WriteMessage(websocket.TextMessage, data); writeErr != nil {
+				if writeErr := out.write(msg); writeErr != nil {
 					return
 				}
 
-				if out.Closed {
+				if chunk.Closed {
 					return
 				}
 			}
@@ -126,6 +168,7 @@ func handleShell(gw *Gateway, authCfg *AuthConfig) http.HandlerFunc {
 
 		// WebSocket -> Executor: read from client, send to executor
 		go func() {
+			defer close(readerDone)
 			for {
 				_, rawMsg, readErr := ws.ReadMessage()
 				if readErr != nil {
@@ -148,6 +191,9 @@ func handleShell(gw *Gateway, authCfg *AuthConfig) http.HandlerFunc {
 					input.Resize = true
 					input.Rows = msg.Rows
 					input.Cols = msg.Cols
+				case "exec":
+					gw.startWSExec(ctx, s, id, podIP, msg, out, execs)
+					continue
 				default:
 					continue
 				}
@@ -158,12 +204,87 @@ func handleShell(gw *Gateway, authCfg *AuthConfig) http.HandlerFunc {
 			}
 		}()
 
-		// Wait for shell to close
+		// Wait for shell to close, then stop the reader and any execs it
+		// started so none outlive the session hold.
 		<-done
+		cancel()
+		ws.Close()
+		<-readerDone
+		execs.wg.Wait()
+	}
+}
+
+// startWSExec runs an exec message in the background. It counts as an
+// active exec on the session and as in-flight work for Drain, and is
+// refused with an "error" message once the connection has
+// maxWSExecsPerConn execs running.
+func (g *Gateway) startWSExec(ctx context.Context, s *session, sessionID, podIP string, msg wsMessage, out *wsWriter, execs *wsExecs) {
+	select {
+	case execs.slots <- struct{}{}:
+	default:
+		out.write(wsMessage{Type: "error", ID: msg.ID, Data: fmt.Sprintf("too many concurrent execs on this connection (max %d)", maxWSExecsPerConn)})
+		return
 	}
+	atomic.AddInt32(&s.activeExecs, 1)
+	g.inflight.Add(1)
+	execs.wg.Add(1)
+	go func() {
+		defer func() {
+			<-execs.slots
+			atomic.AddInt32(&s.activeExecs, -1)
+			g.inflight.Add(-1)
+			execs.wg.Done()
+		}()
+		g.streamWSExec(ctx, sessionID, podIP, msg, out)
+	}()
 }
 
-func writeWSError(ws *websocket.Conn, errMsg string) {
-	msg, _ := json.Marshal(wsMessage{Type: "error", Data: errMsg})
-	ws.WriteMessage(websocket.TextMessage, msg)
+// streamWSExec runs one command outside the shell and streams its output
+// as "output" messages tagged with the stream, ending with an "exit" (or an
+// "error" if the executor could not start it).
+func (g *Gateway) streamWSExec(ctx context.Context, sessionID, podIP string, msg wsMessage, out *wsWriter) {
+	if len(msg.Command) == 0 {
+		out.write(wsMessage{Type: "error", ID: msg.ID, Data: "exec requires a command"})
+		return
+	}
+	if err := validateStepWorkDir(msg.WorkDir); err != nil {
+		out.write(wsMessage{Type: "error", ID: msg.ID, Data: err.Error()})
+		return
+	}
+	// Build the request the way runStep does so the operator's command and
+	// env policy and output cap apply to shell-side execs too.
+	step := StepRequest{Command: msg.Command, Env: msg.Env, WorkDir: msg.WorkDir, TimeoutSeconds: msg.TimeoutSeconds}
+	execReq, envWarning, denial := g.buildStepExecRequest(ctx, step)
+	if envWarning != "" {
+		out.write(wsMessage{Type: "output", ID: msg.ID, Stream: "stderr", Data: envWarning})
+	}
+	if denial != "" {
+		out.write(wsMessage{Type: "output", ID: msg.ID, Stream: "stderr", Data: denial})
+		out.write(wsMessage{Type: "exit", ID: msg.ID, ExitCode: commandDeniedExitCode})
+		return
+	}
+	g.touchLastTaskTime(sessionID)
+	execStart := time.Now()
+	stream, err := g.executorClient.ExecuteStream(ctx, podIP, execReq)
+	if g.metrics != nil {
+		g.metrics.RecordExecutorCallDuration("ExecuteStream", time.Since(execStart))
+	}
+	if err != nil {
+		out.write(wsMessage{Type: "error", ID: msg.ID, Data: "exec failed: " + err.Error()})
+		return
+	}
+	var exitCode int32
+	for chunk := range stream {
+		if chunk.Stdout != "" {
+			out.write(wsMessage{Type: "output", ID: msg.ID, Stream: "stdout", Data: chunk.Stdout})
+		}
+		if chunk.Stderr != "" {
+			out.write(wsMessage{Type: "output", ID: msg.ID, Stream: "stderr", Data: chunk.Stderr})
+		}
+		if chunk.Done {
+			exitCode = chunk.ExitCode
+		}
+	}
+	g.touchLastTaskTime(sessionID)
+	out.write(wsMessage{Type: "exit", ID: msg.ID, ExitCode: exitCode})
 }
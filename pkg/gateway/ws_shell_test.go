@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	mockclient "github.com/Lincyaw/agent-env/pkg/client"
+	"github.com/Lincyaw/agent-env/pkg/interfaces"
+)
+
+// streamExecutorClient replays a fixed chunk sequence from ExecuteStream.
+type streamExecutorClient struct {
+	interfaces.ExecutorClient
+	chunks []interfaces.ExecResponse
+	req    *interfaces.ExecRequest
+}
+
+func (c *streamExecutorClient) ExecuteStream(_ context.Context, _ string, req *interfaces.ExecRequest) (<-chan interfaces.ExecResponse, error) {
+	c.req = req
+	ch := make(chan interfaces.ExecResponse, len(c.chunks))
+	for _, chunk := range c.chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestStreamWSExecTagsStreamsAndEndsWithExit(t *testing.T) {
+	exec := &streamExecutorClient{chunks: []interfaces.ExecResponse{
+		{Stdout: "hello\n"},
+		{Stderr: "warn\n"},
+		{ExitCode: 3, Done: true},
+	}}
+	gw := &Gateway{executorClient: exec, store: newTestSessionStore("unused")}
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		gw.streamWSExec(r.Context(), "gw-ws", "10.0.0.1", wsMessage{
+			Type:    "exec",
+			ID:      "e1",
+			Command: []string{"sh", "-c", "echo hello"},
+			WorkDir: "/workspace",
+		}, &wsWriter{ws: ws})
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	want := []wsMessage{
+		{Type: "output", ID: "e1", Stream: "stdout", Data: "hello\n"},
+		{Type: "output", ID: "e1", Stream: "stderr", Data: "warn\n"},
+		{Type: "exit", ID: "e1", ExitCode: 3},
+	}
+	for i, w := range want {
+		var got wsMessage
+		if err := conn.ReadJSON(&got); err != nil {
+			t.Fatalf("read message %d: %v", i, err)
+		}
+		if got.Type != w.Type || got.ID != w.ID || got.Stream != w.Stream || got.Data != w.Data || got.ExitCode != w.ExitCode {
+			t.Fatalf("message %d = %#v, want %#v", i, got, w)
+		}
+	}
+	if exec.req == nil || exec.req.WorkingDir != "/workspace" || len(exec.req.Command) != 3 {
+		t.Fatalf("exec request = %#v", exec.req)
+	}
+}
+
+func TestStreamWSExecAppliesStepPolicy(t *testing.T) {
+	exec := &streamExecutorClient{chunks: []interfaces.ExecResponse{{Done: true}}}
+	gw := &Gateway{
+		executorClient: exec,
+		store:          newTestSessionStore("unused"),
+		gwConfig:       GatewayConfig{ExecMaxOutputBytes: 1024, ExecEnvDenyList: "SECRET"},
+	}
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		out := &wsWriter{ws: ws}
+		gw.streamWSExec(r.Context(), "gw-ws", "10.0.0.1", wsMessage{Type: "exec", ID: "bad", Command: []string{"true"}, WorkDir: "../etc"}, out)
+		gw.streamWSExec(r.Context(), "gw-ws", "10.0.0.1", wsMessage{
+			Type:    "exec",
+			ID:      "e1",
+			Command: []string{"true"},
+			Env:     map[string]string{"SECRET": "x", "OK": "1"},
+		}, out)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	var got wsMessage
+	if err := conn.ReadJSON(&got); err != nil || got.Type != "error" || got.ID != "bad" {
+		t.Fatalf("first message = %#v, %v; want an error for the escaping workdir", got, err)
+	}
+	for got.Type != "exit" {
+		if err := conn.ReadJSON(&got); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+	}
+	if exec.req == nil || exec.req.MaxOutputBytes != 1024 {
+		t.Fatalf("exec request = %#v, want the gateway output cap", exec.req)
+	}
+	if _, ok := exec.req.Env["SECRET"]; ok || exec.req.Env["OK"] != "1" {
+		t.Fatalf("exec env = %v, want SECRET filtered", exec.req.Env)
+	}
+}
+
+// blockingShell is an interactive shell that produces no output until closed.
+type blockingShell struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (s *blockingShell) Send(interfaces.ShellInput) error { return nil }
+
+func (s *blockingShell) Recv() (interfaces.ShellOutput, error) {
+	<-s.closed
+	return interfaces.ShellOutput{}, io.EOF
+}
+
+func (s *blockingShell) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestShellExecsAreBoundedAndTracked(t *testing.T) {
+	store := newTestSessionStore("gw-ws")
+	executorClient := &mockclient.MockExecutorClient{
+		InteractiveShellFunc: func(ctx context.Context, podIP string) (interfaces.ShellStream, error) {
+			return &blockingShell{closed: make(chan struct{})}, nil
+		},
+		// Execs run until their context ends.
+		ExecuteStreamFunc: func(ctx context.Context, podIP string, req *interfaces.ExecRequest) (<-chan interfaces.ExecResponse, error) {
+			ch := make(chan interfaces.ExecResponse)
+			context.AfterFunc(ctx, func() { close(ch) })
+			return ch, nil
+		},
+	}
+	gw := New(nil, &operationRuntimeAllocator{}, executorClient, nil, nil, GatewayConfig{}, store)
+	srv := httptest.NewServer(SetupRoutes(gw, nil))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/sessions/gw-ws/shell", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	for i := 0; i <= maxWSExecsPerConn; i++ {
+		if err := conn.WriteJSON(wsMessage{Type: "exec", ID: fmt.Sprintf("e%d", i), Command: []string{"sleep", "60"}}); err != nil {
+			t.Fatalf("write exec %d: %v", i, err)
+		}
+	}
+	var got wsMessage
+	if err := conn.ReadJSON(&got); err != nil || got.Type != "error" || got.ID != fmt.Sprintf("e%d", maxWSExecsPerConn) {
+		t.Fatalf("message = %#v, %v; want the exec past the cap refused", got, err)
+	}
+
+	s, _ := store.Get("gw-ws")
+	// The connection itself holds the session once; each running exec adds one.
+	if n := atomic.LoadInt32(&s.activeExecs); n != maxWSExecsPerConn+1 {
+		t.Fatalf("activeExecs = %d, want %d", n, maxWSExecsPerConn+1)
+	}
+	if n := gw.inflight.Load(); n != maxWSExecsPerConn+1 {
+		t.Fatalf("inflight = %d, want %d", n, maxWSExecsPerConn+1)
+	}
+
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&s.activeExecs) != 0 || gw.inflight.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("after disconnect activeExecs = %d, inflight = %d; want 0", atomic.LoadInt32(&s.activeExecs), gw.inflight.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
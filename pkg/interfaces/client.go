@@ -3,6 +3,7 @@ package interfaces
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // FileTransferChunkSize is the standard chunk size for streaming file operations.
@@ -121,6 +122,12 @@ type ExecRequest struct {
 	Env            map[string]string
 	WorkingDir     string
 	TimeoutSeconds int32
+	// Stdin is written to the command's standard input, which is then closed.
+	Stdin string
+	// MaxOutputBytes caps the combined stdout and stderr returned; 0 means
+	// no cap. KillOnOutputLimit kills the command once the cap is reached.
+	MaxOutputBytes    int64
+	KillOnOutputLimit bool
 }
 
 // ExecResponse represents the response from command execution.
@@ -129,4 +136,9 @@ type ExecResponse struct {
 	Stderr   string
 	ExitCode int32
 	Done     bool
+	// StartLatency is the time the executor agent took from receiving the
+	// request to starting the command, when the agent reports it.
+	StartLatency time.Duration
+	// OutputTruncated reports that output past MaxOutputBytes was dropped.
+	OutputTruncated bool
 }
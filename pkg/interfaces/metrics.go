@@ -16,14 +16,21 @@ type MetricsCollector interface {
 	RecordGatewayStepDuration(stepType string, duration time.Duration)
 	IncrementGatewayStepResult(stepType, result string)
 	RecordExecutorCallDuration(method string, duration time.Duration)
+	RecordExecutorSemaphoreWait(duration time.Duration)
 	RecordRestoreDuration(duration time.Duration)
 	IncrementRestoreResult(result string)
+	RecordRestorePhaseDuration(phase string, duration time.Duration)
+	AddRestoreReplayedSteps(count int)
 	SetGatewayGoroutines(count int)
 	SetGatewaySessionsTotal(count int)
 	SetRuntimeIdleCapacity(count int)
 	SetRuntimePendingWaiters(count int)
+	SetTrajectoryQueueDepth(depth int)
+	IncrementTrajectoryDropped()
 	ResetPoolAggregateMetrics()
 	SetPoolAggregateMetrics(profile, state string, desired, ready, allocated, queued int, saturation float64)
+	IncrementImageLocalityDecision(result, reason string)
+	IncrementReconcileRequeue(controller, reason string)
 }
 
 // NoOpMetricsCollector is a no-op implementation for tests or disabled metrics.
@@ -45,12 +52,17 @@ func (n *NoOpMetricsCollector) RecordGatewayStepDuration(stepType string, durati
 func (n *NoOpMetricsCollector) IncrementGatewayStepResult(stepType, result string) {}
 func (n *NoOpMetricsCollector) RecordExecutorCallDuration(method string, duration time.Duration) {
 }
-func (n *NoOpMetricsCollector) RecordRestoreDuration(duration time.Duration) {}
-func (n *NoOpMetricsCollector) IncrementRestoreResult(result string)         {}
-func (n *NoOpMetricsCollector) SetGatewayGoroutines(count int)               {}
-func (n *NoOpMetricsCollector) SetGatewaySessionsTotal(count int)            {}
-func (n *NoOpMetricsCollector) SetRuntimeIdleCapacity(count int)             {}
-func (n *NoOpMetricsCollector) SetRuntimePendingWaiters(count int)           {}
-func (n *NoOpMetricsCollector) ResetPoolAggregateMetrics()                   {}
+func (n *NoOpMetricsCollector) RecordExecutorSemaphoreWait(duration time.Duration) {}
+func (n *NoOpMetricsCollector) RecordRestoreDuration(duration time.Duration)       {}
+func (n *NoOpMetricsCollector) IncrementRestoreResult(result string)               {}
+func (n *NoOpMetricsCollector) SetGatewayGoroutines(count int)                     {}
+func (n *NoOpMetricsCollector) SetGatewaySessionsTotal(count int)                  {}
+func (n *NoOpMetricsCollector) SetRuntimeIdleCapacity(count int)                   {}
+func (n *NoOpMetricsCollector) SetRuntimePendingWaiters(count int)                 {}
+func (n *NoOpMetricsCollector) SetTrajectoryQueueDepth(depth int)                  {}
+func (n *NoOpMetricsCollector) IncrementTrajectoryDropped()                        {}
+func (n *NoOpMetricsCollector) ResetPoolAggregateMetrics()                         {}
 func (n *NoOpMetricsCollector) SetPoolAggregateMetrics(profile, state string, desired, ready, allocated, queued int, saturation float64) {
 }
+func (n *NoOpMetricsCollector) IncrementImageLocalityDecision(result, reason string) {}
+func (n *NoOpMetricsCollector) IncrementReconcileRequeue(controller, reason string)  {}
@@ -36,6 +36,10 @@ const (
 	// remains the hot-path source for frequent activity updates.
 	IdleTimeoutAnnotation = "arl.infra.io/idle-timeout-seconds"
 
+	// MaxLifetimeAnnotation records the per-session hard lifetime ceiling in
+	// seconds, counted from session creation regardless of activity.
+	MaxLifetimeAnnotation = "arl.infra.io/max-lifetime-seconds"
+
 	// FinishedTTLAnnotation records how long terminal runtimes should be kept
 	// before they are eligible for deletion.
 	FinishedTTLAnnotation = "arl.infra.io/finished-ttl-seconds"
@@ -62,6 +66,10 @@ const (
 	PoolProfileAnnotation = "arl.infra.io/profile"
 	PoolProfileLabelKey   = PoolProfileAnnotation
 
+	// PoolMaxAllocatedAnnotation caps how many sessions may hold runtimes
+	// from a pool at once, reserving the rest of a shared pool's capacity.
+	PoolMaxAllocatedAnnotation = "arl.infra.io/max-allocated"
+
 	// ModeAnnotation records the session mode (e.g. "devbox") for recovery.
 	ModeAnnotation = "arl.infra.io/mode"
 
@@ -27,15 +27,23 @@ type PrometheusCollector struct {
 	restoreDuration     prometheus.Histogram
 	restoreResult       *prometheus.CounterVec
 
+	executorSemaphoreWait prometheus.Histogram
+	restorePhaseDuration  *prometheus.HistogramVec
+	restoreReplayedSteps  prometheus.Counter
+
 	gatewayGoroutines     prometheus.Gauge
 	gatewaySessionsTotal  prometheus.Gauge
 	runtimeIdleCapacity   prometheus.Gauge
 	runtimePendingWaiters prometheus.Gauge
+	trajectoryQueueDepth  prometheus.Gauge
+	trajectoryDropped     prometheus.Counter
 	admissionQueueDepth   *prometheus.GaugeVec
 	poolSaturation        *prometheus.GaugeVec
 	poolDesiredReplicas   *prometheus.GaugeVec
 	poolReadyReplicas     *prometheus.GaugeVec
 	poolAllocatedReplicas *prometheus.GaugeVec
+	imageLocalityDecision *prometheus.CounterVec
+	reconcileRequeue      *prometheus.CounterVec
 }
 
 // NewPrometheusCollector creates a new Prometheus metrics collector.
@@ -130,6 +138,13 @@ func NewPrometheusCollector() interfaces.MetricsCollector {
 			},
 			[]string{"method"},
 		),
+		executorSemaphoreWait: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "arl_gateway_executor_semaphore_wait_seconds",
+				Help:    "Time executor calls waited for a slot under the global concurrency limit.",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30},
+			},
+		),
 		restoreDuration: prometheus.NewHistogram(
 			prometheus.HistogramOpts{
 				Name:    "arl_gateway_restore_duration_seconds",
@@ -144,6 +159,20 @@ func NewPrometheusCollector() interfaces.MetricsCollector {
 			},
 			[]string{"result"},
 		),
+		restorePhaseDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "arl_gateway_restore_phase_duration_seconds",
+				Help:    "Time spent in each restore phase: provision (new sandbox) and replay (steps).",
+				Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+			},
+			[]string{"phase"},
+		),
+		restoreReplayedSteps: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "arl_gateway_restore_replayed_steps_total",
+				Help: "Steps replayed onto fresh sandboxes by restore operations.",
+			},
+		),
 		gatewayGoroutines: prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "arl_gateway_goroutines",
@@ -168,6 +197,18 @@ func NewPrometheusCollector() interfaces.MetricsCollector {
 				Help: "Total blocked waiters for runtime allocation.",
 			},
 		),
+		trajectoryQueueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "arl_gateway_trajectory_queue_depth",
+				Help: "Trajectory entries buffered for ClickHouse but not yet written.",
+			},
+		),
+		trajectoryDropped: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "arl_gateway_trajectory_dropped_total",
+				Help: "Trajectory entries dropped because the write queue was full.",
+			},
+		),
 		admissionQueueDepth: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "arl_gateway_admission_queue_depth",
@@ -203,6 +244,20 @@ func NewPrometheusCollector() interfaces.MetricsCollector {
 			},
 			[]string{"profile", "state"},
 		),
+		imageLocalityDecision: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "arl_gateway_image_locality_decisions_total",
+				Help: "Pool creations by whether image-locality scheduling hints were injected, and why.",
+			},
+			[]string{"result", "reason"},
+		),
+		reconcileRequeue: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "arl_gateway_reconcile_requeues_total",
+				Help: "Pools a gateway reconcile loop left for its next pass, by loop and reason.",
+			},
+			[]string{"controller", "reason"},
+		),
 	}
 
 	metrics.Registry.MustRegister(
@@ -218,17 +273,24 @@ func NewPrometheusCollector() interfaces.MetricsCollector {
 		c.gatewayStepDuration,
 		c.gatewayStepResult,
 		c.executorCallDuration,
+		c.executorSemaphoreWait,
 		c.restoreDuration,
 		c.restoreResult,
+		c.restorePhaseDuration,
+		c.restoreReplayedSteps,
 		c.gatewayGoroutines,
 		c.gatewaySessionsTotal,
 		c.runtimeIdleCapacity,
 		c.runtimePendingWaiters,
+		c.trajectoryQueueDepth,
+		c.trajectoryDropped,
 		c.admissionQueueDepth,
 		c.poolSaturation,
 		c.poolDesiredReplicas,
 		c.poolReadyReplicas,
 		c.poolAllocatedReplicas,
+		c.imageLocalityDecision,
+		c.reconcileRequeue,
 	)
 
 	return c
@@ -285,6 +347,10 @@ func (c *PrometheusCollector) RecordExecutorCallDuration(method string, duration
 	c.executorCallDuration.WithLabelValues(method).Observe(duration.Seconds())
 }
 
+func (c *PrometheusCollector) RecordExecutorSemaphoreWait(duration time.Duration) {
+	c.executorSemaphoreWait.Observe(duration.Seconds())
+}
+
 func (c *PrometheusCollector) RecordRestoreDuration(duration time.Duration) {
 	c.restoreDuration.Observe(duration.Seconds())
 }
@@ -293,6 +359,14 @@ func (c *PrometheusCollector) IncrementRestoreResult(result string) {
 	c.restoreResult.WithLabelValues(result).Inc()
 }
 
+func (c *PrometheusCollector) RecordRestorePhaseDuration(phase string, duration time.Duration) {
+	c.restorePhaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+func (c *PrometheusCollector) AddRestoreReplayedSteps(count int) {
+	c.restoreReplayedSteps.Add(float64(count))
+}
+
 func (c *PrometheusCollector) SetGatewayGoroutines(count int) {
 	c.gatewayGoroutines.Set(float64(count))
 }
@@ -309,6 +383,14 @@ func (c *PrometheusCollector) SetRuntimePendingWaiters(count int) {
 	c.runtimePendingWaiters.Set(float64(count))
 }
 
+func (c *PrometheusCollector) SetTrajectoryQueueDepth(depth int) {
+	c.trajectoryQueueDepth.Set(float64(depth))
+}
+
+func (c *PrometheusCollector) IncrementTrajectoryDropped() {
+	c.trajectoryDropped.Inc()
+}
+
 func (c *PrometheusCollector) ResetPoolAggregateMetrics() {
 	c.poolDesiredReplicas.Reset()
 	c.poolReadyReplicas.Reset()
@@ -327,6 +409,14 @@ func (c *PrometheusCollector) SetPoolAggregateMetrics(profile, state string, des
 	c.poolSaturation.WithLabelValues(profile, state).Set(saturation)
 }
 
+func (c *PrometheusCollector) IncrementImageLocalityDecision(result, reason string) {
+	c.imageLocalityDecision.WithLabelValues(metricValue(result, "unknown"), metricValue(reason, "unknown")).Inc()
+}
+
+func (c *PrometheusCollector) IncrementReconcileRequeue(controller, reason string) {
+	c.reconcileRequeue.WithLabelValues(metricValue(controller, "unknown"), metricValue(reason, "unknown")).Inc()
+}
+
 func poolMetricType(poolName string) string {
 	name := strings.ToLower(strings.TrimSpace(poolName))
 	if name == "" {
@@ -810,8 +810,16 @@ type SpawnRequest struct {
 	Stdin          bool                   `protobuf:"varint,6,opt,name=stdin,proto3" json:"stdin,omitempty"`
 	Rows           int32                  `protobuf:"varint,7,opt,name=rows,proto3" json:"rows,omitempty"`
 	Cols           int32                  `protobuf:"varint,8,opt,name=cols,proto3" json:"cols,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Written to the command's stdin, which is then closed. Ignored for PTY
+	// spawns.
+	StdinData []byte `protobuf:"bytes,9,opt,name=stdin_data,json=stdinData,proto3" json:"stdin_data,omitempty"`
+	// Caps the combined stdout and stderr bytes sent back for a non-PTY
+	// spawn; 0 means no limit. Output past the cap is discarded.
+	MaxOutputBytes uint64 `protobuf:"varint,10,opt,name=max_output_bytes,json=maxOutputBytes,proto3" json:"max_output_bytes,omitempty"`
+	// Kill the process once max_output_bytes is reached.
+	KillOnOutputLimit bool `protobuf:"varint,11,opt,name=kill_on_output_limit,json=killOnOutputLimit,proto3" json:"kill_on_output_limit,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *SpawnRequest) Reset() {
@@ -900,12 +908,36 @@ func (x *SpawnRequest) GetCols() int32 {
 	return 0
 }
 
+func (x *SpawnRequest) GetStdinData() []byte {
+	if x != nil {
+		return x.StdinData
+	}
+	return nil
+}
+
+func (x *SpawnRequest) GetMaxOutputBytes() uint64 {
+	if x != nil {
+		return x.MaxOutputBytes
+	}
+	return 0
+}
+
+func (x *SpawnRequest) GetKillOnOutputLimit() bool {
+	if x != nil {
+		return x.KillOnOutputLimit
+	}
+	return false
+}
+
 type SpawnResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProcessTag    uint32                 `protobuf:"varint,1,opt,name=process_tag,json=processTag,proto3" json:"process_tag,omitempty"`
-	Pid           int32                  `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProcessTag uint32                 `protobuf:"varint,1,opt,name=process_tag,json=processTag,proto3" json:"process_tag,omitempty"`
+	Pid        int32                  `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	// Time from the agent receiving the spawn request to the process
+	// starting, in microseconds.
+	StartLatencyUs uint64 `protobuf:"varint,3,opt,name=start_latency_us,json=startLatencyUs,proto3" json:"start_latency_us,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *SpawnResponse) Reset() {
@@ -952,6 +984,13 @@ func (x *SpawnResponse) GetPid() int32 {
 	return 0
 }
 
+func (x *SpawnResponse) GetStartLatencyUs() uint64 {
+	if x != nil {
+		return x.StartLatencyUs
+	}
+	return 0
+}
+
 type WriteInRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProcessTag    uint32                 `protobuf:"varint,1,opt,name=process_tag,json=processTag,proto3" json:"process_tag,omitempty"`
@@ -2257,11 +2296,13 @@ func (x *StderrEvent) GetData() []byte {
 }
 
 type ExitEvent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProcessTag    uint32                 `protobuf:"varint,1,opt,name=process_tag,json=processTag,proto3" json:"process_tag,omitempty"`
-	ExitCode      int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProcessTag uint32                 `protobuf:"varint,1,opt,name=process_tag,json=processTag,proto3" json:"process_tag,omitempty"`
+	ExitCode   int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	// Set when output was discarded because max_output_bytes was reached.
+	OutputTruncated bool `protobuf:"varint,3,opt,name=output_truncated,json=outputTruncated,proto3" json:"output_truncated,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ExitEvent) Reset() {
@@ -2308,6 +2349,13 @@ func (x *ExitEvent) GetExitCode() int32 {
 	return 0
 }
 
+func (x *ExitEvent) GetOutputTruncated() bool {
+	if x != nil {
+		return x.OutputTruncated
+	}
+	return false
+}
+
 type FsChangeEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	WatchId       uint32                 `protobuf:"varint,1,opt,name=watch_id,json=watchId,proto3" json:"watch_id,omitempty"`
@@ -2416,7 +2464,7 @@ const file_proto_executor_v2_proto_rawDesc = "" +
 	"\tfs_change\x18\x05 \x01(\v2\x1e.arl.executor.v2.FsChangeEventH\x00R\bfsChangeB\x06\n" +
 	"\x04kind\"\r\n" +
 	"\vPingRequest\"\x0e\n" +
-	"\fPingResponse\"\xb4\x02\n" +
+	"\fPingResponse\"\xae\x03\n" +
 	"\fSpawnRequest\x12\x18\n" +
 	"\acommand\x18\x01 \x03(\tR\acommand\x128\n" +
 	"\x03env\x18\x02 \x03(\v2&.arl.executor.v2.SpawnRequest.EnvEntryR\x03env\x12\x1f\n" +
@@ -2426,14 +2474,20 @@ const file_proto_executor_v2_proto_rawDesc = "" +
 	"\x03pty\x18\x05 \x01(\bR\x03pty\x12\x14\n" +
 	"\x05stdin\x18\x06 \x01(\bR\x05stdin\x12\x12\n" +
 	"\x04rows\x18\a \x01(\x05R\x04rows\x12\x12\n" +
-	"\x04cols\x18\b \x01(\x05R\x04cols\x1a6\n" +
+	"\x04cols\x18\b \x01(\x05R\x04cols\x12\x1d\n" +
+	"\n" +
+	"stdin_data\x18\t \x01(\fR\tstdinData\x12(\n" +
+	"\x10max_output_bytes\x18\n" +
+	" \x01(\x04R\x0emaxOutputBytes\x12/\n" +
+	"\x14kill_on_output_limit\x18\v \x01(\bR\x11killOnOutputLimit\x1a6\n" +
 	"\bEnvEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"B\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"l\n" +
 	"\rSpawnResponse\x12\x1f\n" +
 	"\vprocess_tag\x18\x01 \x01(\rR\n" +
 	"processTag\x12\x10\n" +
-	"\x03pid\x18\x02 \x01(\x05R\x03pid\"E\n" +
+	"\x03pid\x18\x02 \x01(\x05R\x03pid\x12(\n" +
+	"\x10start_latency_us\x18\x03 \x01(\x04R\x0estartLatencyUs\"E\n" +
 	"\x0eWriteInRequest\x12\x1f\n" +
 	"\vprocess_tag\x18\x01 \x01(\rR\n" +
 	"processTag\x12\x12\n" +
@@ -2509,11 +2563,12 @@ const file_proto_executor_v2_proto_rawDesc = "" +
 	"\vStderrEvent\x12\x1f\n" +
 	"\vprocess_tag\x18\x01 \x01(\rR\n" +
 	"processTag\x12\x12\n" +
-	"\x04data\x18\x02 \x01(\fR\x04data\"I\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"t\n" +
 	"\tExitEvent\x12\x1f\n" +
 	"\vprocess_tag\x18\x01 \x01(\rR\n" +
 	"processTag\x12\x1b\n" +
-	"\texit_code\x18\x02 \x01(\x05R\bexitCode\"]\n" +
+	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12)\n" +
+	"\x10output_truncated\x18\x03 \x01(\bR\x0foutputTruncated\"]\n" +
 	"\rFsChangeEvent\x12\x19\n" +
 	"\bwatch_id\x18\x01 \x01(\rR\awatchId\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12\x1d\n" +
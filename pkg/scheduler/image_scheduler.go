@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"math"
 	"sort"
 	"sync"
 
@@ -21,17 +22,35 @@ type ImageScheduler struct {
 	nodes      []string // schedulable node names
 	nodeImages map[string]map[string]struct{}
 	imageNodes map[string]map[string]struct{}
+	// nodeWeights holds each node's allocatable-capacity weight. It is only
+	// consulted when capacityWeighted is set.
+	nodeWeights      map[string]float64
+	capacityWeighted bool
 }
 
 // NewImageScheduler creates a new ImageScheduler.
 func NewImageScheduler(c client.Client) *ImageScheduler {
 	return &ImageScheduler{
-		client:     c,
-		nodeImages: make(map[string]map[string]struct{}),
-		imageNodes: make(map[string]map[string]struct{}),
+		client:      c,
+		nodeImages:  make(map[string]map[string]struct{}),
+		imageNodes:  make(map[string]map[string]struct{}),
+		nodeWeights: make(map[string]float64),
 	}
 }
 
+// NewImageSchedulerFromNodes returns a scheduler whose cache holds the
+// schedulable nodes of a one-off list, for callers that do not run the Node
+// watch but want the same selection.
+func NewImageSchedulerFromNodes(nodes []corev1.Node) *ImageScheduler {
+	s := NewImageScheduler(nil)
+	for i := range nodes {
+		if isSchedulable(&nodes[i]) {
+			s.upsertNode(&nodes[i])
+		}
+	}
+	return s
+}
+
 // Reconcile handles Node create/update/delete events to maintain the
 // cached list of schedulable nodes.
 func (s *ImageScheduler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -56,14 +75,30 @@ func (s *ImageScheduler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	return ctrl.Result{}, nil
 }
 
+// SetCapacityWeighted makes SelectNodes weight each node by its allocatable
+// CPU and memory, so larger nodes are preferred for proportionally more
+// images. Selection is unweighted by default.
+func (s *ImageScheduler) SetCapacityWeighted(enabled bool) {
+	s.mu.Lock()
+	s.capacityWeighted = enabled
+	s.mu.Unlock()
+}
+
 // SelectNodes returns the top-k preferred nodes for the given image
 // using Rendezvous hashing over the current set of schedulable nodes.
 func (s *ImageScheduler) SelectNodes(image string, k int) []string {
 	s.mu.RLock()
 	nodes := s.nodesForImageLocked(image)
+	var weights map[string]float64
+	if s.capacityWeighted {
+		weights = make(map[string]float64, len(nodes))
+		for _, node := range nodes {
+			weights[node] = s.nodeWeights[node]
+		}
+	}
 	s.mu.RUnlock()
 
-	return ComputeTopK(image, nodes, k)
+	return ComputeWeightedTopK(image, nodes, weights, k)
 }
 
 // CachedNodesForImage returns schedulable nodes that currently report the image
@@ -75,6 +110,13 @@ func (s *ImageScheduler) CachedNodesForImage(image string) []string {
 	return nodes
 }
 
+// SchedulableNodeCount returns how many nodes are currently schedulable.
+func (s *ImageScheduler) SchedulableNodeCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nodes)
+}
+
 // SetupWithManager registers this scheduler as a controller watching Node objects.
 func (s *ImageScheduler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -101,6 +143,10 @@ func (s *ImageScheduler) upsertNode(node *corev1.Node) {
 	defer s.mu.Unlock()
 
 	name := node.Name
+	if s.nodeWeights == nil {
+		s.nodeWeights = make(map[string]float64)
+	}
+	s.nodeWeights[name] = nodeCapacityWeight(node)
 	for _, n := range s.nodes {
 		if n == name {
 			s.updateNodeImagesLocked(name, nodeImageNames(node))
@@ -122,6 +168,7 @@ func (s *ImageScheduler) removeNode(name string) {
 		}
 	}
 	s.removeNodeImagesLocked(name)
+	delete(s.nodeWeights, name)
 }
 
 func (s *ImageScheduler) nodesForImageLocked(image string) []string {
@@ -163,6 +210,29 @@ func (s *ImageScheduler) removeNodeImagesLocked(nodeName string) {
 	delete(s.nodeImages, nodeName)
 }
 
+// nodeCapacityWeight is the geometric mean of a node's allocatable CPU cores
+// and memory GiB, so a node twice as large in both counts twice. A node
+// reporting only one of the two is weighted by that one, and a node
+// reporting neither gets weight 1.
+func nodeCapacityWeight(node *corev1.Node) float64 {
+	var cores, gib float64
+	if cpu, ok := node.Status.Allocatable[corev1.ResourceCPU]; ok {
+		cores = float64(cpu.MilliValue()) / 1000
+	}
+	if mem, ok := node.Status.Allocatable[corev1.ResourceMemory]; ok {
+		gib = float64(mem.Value()) / (1 << 30)
+	}
+	switch {
+	case cores > 0 && gib > 0:
+		return math.Sqrt(cores * gib)
+	case cores > 0:
+		return cores
+	case gib > 0:
+		return gib
+	}
+	return 1
+}
+
 func nodeImageNames(node *corev1.Node) map[string]struct{} {
 	images := make(map[string]struct{})
 	for _, image := range node.Status.Images {
@@ -33,6 +33,20 @@ func TestImageSchedulerFallsBackToAllSchedulableNodes(t *testing.T) {
 	}
 }
 
+func TestImageSchedulerFromNodesSkipsUnschedulableNodes(t *testing.T) {
+	cordoned := schedulableNode("node-b", "python:3.12")
+	cordoned.Spec.Unschedulable = true
+	scheduler := NewImageSchedulerFromNodes([]corev1.Node{
+		*schedulableNode("node-a", "python:3.12"),
+		*cordoned,
+	})
+
+	selected := scheduler.SelectNodes("python:3.12", 2)
+	if len(selected) != 1 || selected[0] != "node-a" {
+		t.Fatalf("SelectNodes = %#v, want only schedulable node-a", selected)
+	}
+}
+
 func schedulableNode(name string, images ...string) *corev1.Node {
 	node := &corev1.Node{}
 	node.Name = name
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"crypto/sha256"
 	"encoding/binary"
+	"math"
 	"sort"
 )
 
@@ -55,3 +56,58 @@ func hrwScore(image, node string) uint64 {
 	sum := h.Sum(nil)
 	return binary.BigEndian.Uint64(sum[:8])
 }
+
+// ComputeWeightedTopK is ComputeTopK with per-node weights: a node's chance
+// of ranking first for an image is proportional to its weight, so a node
+// with twice the weight is preferred for about twice as many images. Nodes
+// missing from weights or with a non-positive weight count as weight 1.
+// With nil weights the result is exactly ComputeTopK's.
+func ComputeWeightedTopK(image string, nodes []string, weights map[string]float64, k int) []string {
+	if weights == nil {
+		return ComputeTopK(image, nodes, k)
+	}
+	if len(nodes) == 0 || k <= 0 {
+		return nil
+	}
+	if k > len(nodes) {
+		k = len(nodes)
+	}
+
+	type scored struct {
+		name  string
+		score float64
+	}
+
+	scoredNodes := make([]scored, len(nodes))
+	for i, node := range nodes {
+		weight := weights[node]
+		if weight <= 0 {
+			weight = 1
+		}
+		scoredNodes[i] = scored{
+			name:  node,
+			score: weightedHRWScore(hrwScore(image, node), weight),
+		}
+	}
+
+	sort.Slice(scoredNodes, func(i, j int) bool {
+		if scoredNodes[i].score != scoredNodes[j].score {
+			return scoredNodes[i].score > scoredNodes[j].score
+		}
+		return scoredNodes[i].name < scoredNodes[j].name
+	})
+
+	result := make([]string, k)
+	for i := 0; i < k; i++ {
+		result[i] = scoredNodes[i].name
+	}
+	return result
+}
+
+// weightedHRWScore maps a 64-bit HRW hash to a uniform value u in (0, 1) and
+// returns -weight/ln(u), the logarithmic method for weighted rendezvous
+// hashing.
+func weightedHRWScore(hash uint64, weight float64) float64 {
+	u := (float64(hash>>11) + 0.5) / (1 << 53)
+	return -weight / math.Log(u)
+}
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestComputeWeightedTopKWithoutWeightsMatchesComputeTopK(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c", "node-d"}
+	for i := 0; i < 50; i++ {
+		image := "image-" + strconv.Itoa(i)
+		if got, want := ComputeWeightedTopK(image, nodes, nil, 2), ComputeTopK(image, nodes, 2); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: weighted with nil weights = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestComputeWeightedTopKFavorsHeavierNodesProportionally(t *testing.T) {
+	nodes := []string{"big", "small"}
+	weights := map[string]float64{"big": 3, "small": 1}
+	wins := 0
+	const images = 4000
+	for i := 0; i < images; i++ {
+		if ComputeWeightedTopK("image-"+strconv.Itoa(i), nodes, weights, 1)[0] == "big" {
+			wins++
+		}
+	}
+	// A 3:1 weight should win about 75% of images.
+	if share := float64(wins) / images; share < 0.70 || share > 0.80 {
+		t.Fatalf("big node share = %.3f, want about 0.75", share)
+	}
+}
+
+func TestNodeCapacityWeightUsesAllocatableCPUAndMemory(t *testing.T) {
+	node := &corev1.Node{}
+	node.Status.Allocatable = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}
+	if got := nodeCapacityWeight(node); got != 8 {
+		t.Fatalf("weight of 4 cores / 16Gi = %v, want 8", got)
+	}
+	if got := nodeCapacityWeight(&corev1.Node{}); got != 1 {
+		t.Fatalf("weight without allocatable = %v, want 1", got)
+	}
+}
@@ -32,6 +32,14 @@ pub type TunnelRegistry = Arc<Mutex<HashMap<u32, TunnelTarget>>>;
 pub const MSG_TYPE_REQUEST: u8 = 0x01;
 pub const MSG_TYPE_RESPONSE: u8 = 0x02;
 pub const MSG_TYPE_EVENT: u8 = 0x03;
+pub const MSG_TYPE_AUTH: u8 = 0x04;
+pub const MSG_TYPE_AUTH_CHALLENGE: u8 = 0x05;
+
+const MAX_AUTH_TOKEN_SIZE: usize = 4096;
+/// How long a finished pipe-mode process's output readers get to drain before
+/// the exit frame is sent anyway. Background children that inherited the
+/// pipes can keep them open indefinitely.
+const OUTPUT_DRAIN_GRACE: std::time::Duration = std::time::Duration::from_secs(1);
 
 struct ProcessHandle {
     child: Option<Child>,
@@ -184,6 +192,70 @@ pub fn read_request(reader: &mut impl io::Read) -> io::Result<Option<proto::Requ
     Ok(Some(req))
 }
 
+/// Per-pod credentials for the TCP listener. `identity` (the pod UID) is sent
+/// to every client in a challenge frame; the client answers with
+/// `derive_token(master, identity)`, which only the gateway and this pod know.
+pub struct TcpAuth {
+    pub identity: String,
+    pub token: String,
+}
+
+/// Derives the per-pod token as hex(HMAC-SHA256(master, identity)), matching
+/// client.DeriveExecutorToken on the gateway side.
+pub fn derive_token(master: &[u8], identity: &str) -> String {
+    const BLOCK_SIZE: usize = 64;
+    let mut key = if master.len() > BLOCK_SIZE {
+        Sha256::digest(master).to_vec()
+    } else {
+        master.to_vec()
+    };
+    key.resize(BLOCK_SIZE, 0);
+    let ipad: Vec<u8> = key.iter().map(|b| b ^ 0x36).collect();
+    let opad: Vec<u8> = key.iter().map(|b| b ^ 0x5c).collect();
+    let inner = Sha256::new()
+        .chain_update(&ipad)
+        .chain_update(identity.as_bytes())
+        .finalize();
+    let outer = Sha256::new().chain_update(&opad).chain_update(inner).finalize();
+    hex::encode(outer)
+}
+
+/// Reads the auth frame that opens an authenticated connection and reports
+/// whether it carries `token`.
+fn read_auth(reader: &mut impl io::Read, token: &str) -> io::Result<bool> {
+    let mut type_buf = [0u8; 1];
+    reader.read_exact(&mut type_buf)?;
+    if type_buf[0] != MSG_TYPE_AUTH {
+        return Ok(false);
+    }
+    let mut len_buf = [0u8; 4];
+    reader.read_exact(&mut len_buf)?;
+    let len = u32::from_be_bytes(len_buf) as usize;
+    if len > MAX_AUTH_TOKEN_SIZE {
+        return Ok(false);
+    }
+    let mut got = vec![0u8; len];
+    reader.read_exact(&mut got)?;
+    Ok(constant_time_eq(&got, token.as_bytes()))
+}
+
+/// Environment variables that configure the agent's own credentials. They are
+/// removed from spawned commands so sandbox processes do not inherit them.
+const AGENT_SECRET_ENV: &[&str] = &["ARL_EXECUTOR_TOKEN", "ARL_EXECUTOR_TOKEN_FILE"];
+
+fn strip_agent_secrets(cmd: &mut Command) {
+    for name in AGENT_SECRET_ENV {
+        cmd.env_remove(name);
+    }
+}
+
+fn constant_time_eq(a: &[u8], b: &[u8]) -> bool {
+    if a.len() != b.len() {
+        return false;
+    }
+    a.iter().zip(b).fold(0u8, |acc, (x, y)| acc | (x ^ y)) == 0
+}
+
 fn handle_conn(
     stream: std::os::unix::net::UnixStream,
     workspace: &str,
@@ -196,14 +268,67 @@ fn handle_conn(
 }
 
 /// Handle a TCP connection the same way as a Unix socket connection.
+/// When `auth` is Some, the agent first sends a challenge frame carrying its
+/// identity and the client's first frame must be an auth frame carrying the
+/// per-pod token; otherwise the connection is dropped without serving requests.
 pub fn handle_conn_tcp(
     stream: std::net::TcpStream,
     workspace: &str,
     _shutdown: watch::Receiver<bool>,
     checkpointer: Option<Arc<Checkpointer>>,
+    auth: Option<&TcpAuth>,
 ) -> io::Result<()> {
     let reader = stream.try_clone()?;
     let writer: SharedWriter = Arc::new(Mutex::new(Box::new(stream)));
+    serve_remote(reader, writer, workspace, checkpointer, auth)
+}
+
+/// Handle a TCP connection whose TLS is terminated by `tls::bridge`; the
+/// plaintext arrives on one end of a Unix socket pair.
+pub fn handle_conn_tls(
+    stream: std::os::unix::net::UnixStream,
+    workspace: &str,
+    _shutdown: watch::Receiver<bool>,
+    checkpointer: Option<Arc<Checkpointer>>,
+    auth: Option<&TcpAuth>,
+) -> io::Result<()> {
+    let reader = stream.try_clone()?;
+    let writer: SharedWriter = Arc::new(Mutex::new(Box::new(stream)));
+    serve_remote(reader, writer, workspace, checkpointer, auth)
+}
+
+fn serve_remote(
+    mut reader: impl io::Read,
+    writer: SharedWriter,
+    workspace: &str,
+    checkpointer: Option<Arc<Checkpointer>>,
+    auth: Option<&TcpAuth>,
+) -> io::Result<()> {
+    if let Some(auth) = auth {
+        match write_typed_message(&writer, MSG_TYPE_AUTH_CHALLENGE, auth.identity.as_bytes()) {
+            Ok(()) => {}
+            // TCP probes connect and close without reading anything.
+            Err(e)
+                if matches!(
+                    e.kind(),
+                    io::ErrorKind::BrokenPipe | io::ErrorKind::ConnectionReset
+                ) =>
+            {
+                return Ok(())
+            }
+            Err(e) => return Err(e),
+        }
+        match read_auth(&mut reader, &auth.token) {
+            Ok(true) => {}
+            // TCP probes connect and close without sending anything.
+            Err(e) if e.kind() == io::ErrorKind::UnexpectedEof => return Ok(()),
+            Ok(false) => {
+                log::warn!("TCP connection rejected: missing or invalid auth token");
+                return Ok(());
+            }
+            Err(e) => return Err(e),
+        }
+    }
     handle_session(reader, writer, workspace, None, checkpointer)
 }
 
@@ -235,18 +360,14 @@ pub fn handle_session(
         &checkpointer,
     );
 
-    // Cleanup on disconnect — kill by pid since wait_and_exit takes the Child.
+    // Cleanup on disconnect — kill the whole process group, since commands
+    // often fork children that would otherwise outlive the session.
     let mut procs = processes.lock().unwrap();
     for (ptag, ph) in procs.iter_mut() {
         log::info!("[cleanup] killing process_tag={ptag} pid={}", ph.pid);
+        let _ = kill_process_group(ph.pid, nix::sys::signal::Signal::SIGKILL);
         if let Some(ref mut child) = ph.child {
-            let _ = child.kill();
             let _ = child.wait();
-        } else {
-            let _ = nix::sys::signal::kill(
-                nix::unistd::Pid::from_raw(ph.pid as i32),
-                nix::sys::signal::Signal::SIGKILL,
-            );
         }
     }
     procs.clear();
@@ -352,11 +473,12 @@ fn handle_messages(
 fn handle_spawn(
     tag: u32,
     params: proto::SpawnRequest,
-    _workspace: &str,
+    workspace: &str,
     writer: &SharedWriter,
     processes: &Arc<Mutex<HashMap<u32, ProcessHandle>>>,
     checkpointer: &Option<Arc<Checkpointer>>,
 ) {
+    let received = std::time::Instant::now();
     if params.command.is_empty() {
         let _ = send_error(writer, tag, 2, "empty command".into());
         return;
@@ -365,35 +487,73 @@ fn handle_spawn(
     let workdir = if params.working_dir.is_empty() {
         "/".to_string()
     } else {
-        params.working_dir.clone()
+        match resolve_spawn_workdir(Path::new(workspace), &params.working_dir) {
+            Ok(dir) => dir,
+            Err(e) => {
+                let _ = send_error(writer, tag, 2, e);
+                return;
+            }
+        }
     };
 
     // Use the request tag as the process_tag.
     let process_tag = tag;
 
     if params.pty {
-        handle_spawn_pty(tag, process_tag, params, &workdir, writer, processes, checkpointer);
+        handle_spawn_pty(tag, process_tag, params, &workdir, writer, processes, checkpointer, received);
     } else {
-        handle_spawn_pipe(tag, process_tag, params, &workdir, writer, processes, checkpointer);
+        handle_spawn_pipe(tag, process_tag, params, &workdir, writer, processes, checkpointer, received);
+    }
+}
+
+/// Resolves a spawn working directory. Absolute paths are used as given;
+/// relative ones are joined onto the workspace and may not climb above it.
+/// The check is lexical because the directory need not exist yet.
+fn resolve_spawn_workdir(workspace: &Path, dir: &str) -> Result<String, String> {
+    if Path::new(dir).is_absolute() {
+        return Ok(dir.to_string());
+    }
+    let mut resolved = workspace.to_path_buf();
+    let mut depth = 0usize;
+    for component in Path::new(dir).components() {
+        match component {
+            std::path::Component::CurDir => {}
+            std::path::Component::Normal(s) => {
+                resolved.push(s);
+                depth += 1;
+            }
+            std::path::Component::ParentDir if depth > 0 => {
+                resolved.pop();
+                depth -= 1;
+            }
+            _ => return Err(format!("working_dir {dir:?} escapes the workspace")),
+        }
     }
+    Ok(resolved.to_string_lossy().to_string())
 }
 
 fn handle_spawn_pipe(
     tag: u32,
     process_tag: u32,
-    params: proto::SpawnRequest,
+    mut params: proto::SpawnRequest,
     workdir: &str,
     writer: &SharedWriter,
     processes: &Arc<Mutex<HashMap<u32, ProcessHandle>>>,
     checkpointer: &Option<Arc<Checkpointer>>,
+    received: std::time::Instant,
 ) {
     let mut cmd = Command::new(&params.command[0]);
     cmd.args(&params.command[1..]);
     cmd.current_dir(workdir);
+    // Own process group so timeouts and signals reach forked children too.
+    {
+        use std::os::unix::process::CommandExt;
+        cmd.process_group(0);
+    }
     cmd.stdout(Stdio::piped());
     cmd.stderr(Stdio::piped());
 
-    if params.stdin {
+    if params.stdin || !params.stdin_data.is_empty() {
         cmd.stdin(Stdio::piped());
     } else {
         cmd.stdin(Stdio::null());
@@ -402,6 +562,7 @@ fn handle_spawn_pipe(
     for (k, v) in &params.env {
         cmd.env(k, v);
     }
+    strip_agent_secrets(&mut cmd);
 
     let step = if let Some(ckpt) = checkpointer {
         let step_num = ckpt.next_step();
@@ -430,7 +591,21 @@ fn handle_spawn_pipe(
     let pid = child.id();
     let stdout = child.stdout.take().unwrap();
     let stderr = child.stderr.take().unwrap();
-    let stdin_pipe = child.stdin.take();
+    let mut stdin_pipe = child.stdin.take();
+
+    // Input sent with the request is written on its own thread, since it may
+    // exceed the pipe buffer, and the pipe is then closed so the command
+    // sees EOF.
+    if !params.stdin_data.is_empty() {
+        if let Some(mut pipe) = stdin_pipe.take() {
+            let data = std::mem::take(&mut params.stdin_data);
+            thread::spawn(move || {
+                if let Err(e) = pipe.write_all(&data) {
+                    log::warn!("[spawn] process_tag={process_tag} stdin write failed: {e}");
+                }
+            });
+        }
+    }
 
     let ph = ProcessHandle {
         child: Some(child),
@@ -446,19 +621,35 @@ fn handle_spawn_pipe(
         proto::response::Kind::Spawn(proto::SpawnResponse {
             process_tag,
             pid: pid as i32,
+            start_latency_us: received.elapsed().as_micros() as u64,
         }),
     );
 
+    let budget = Arc::new(OutputBudget::new(
+        params.max_output_bytes,
+        params.kill_on_output_limit,
+        pid,
+    ));
+
+    // Each reader reports on drained_tx once its pipe hits EOF.
+    let (drained_tx, drained_rx) = std::sync::mpsc::channel::<()>();
+
     // Spawn stdout reader
     let pt1 = process_tag;
     let w1 = writer.clone();
+    let b1 = budget.clone();
+    let d1 = drained_tx.clone();
     thread::spawn(move || {
         let mut buf = [0u8; 64 * 1024];
         let mut r = stdout;
         loop {
             match r.read(&mut buf) {
-                Ok(0) | Err(_) => return,
+                Ok(0) | Err(_) => break,
                 Ok(n) => {
+                    let n = b1.take(n);
+                    if n == 0 {
+                        continue;
+                    }
                     let _ = send_event(
                         &w1,
                         0,
@@ -470,18 +661,25 @@ fn handle_spawn_pipe(
                 }
             }
         }
+        let _ = d1.send(());
     });
 
     // Spawn stderr reader
     let pt2 = process_tag;
     let w2 = writer.clone();
+    let b2 = budget.clone();
+    let d2 = drained_tx;
     thread::spawn(move || {
         let mut buf = [0u8; 64 * 1024];
         let mut r = stderr;
         loop {
             match r.read(&mut buf) {
-                Ok(0) | Err(_) => return,
+                Ok(0) | Err(_) => break,
                 Ok(n) => {
+                    let n = b2.take(n);
+                    if n == 0 {
+                        continue;
+                    }
                     let _ = send_event(
                         &w2,
                         0,
@@ -493,6 +691,7 @@ fn handle_spawn_pipe(
                 }
             }
         }
+        let _ = d2.send(());
     });
 
     // Spawn waiter
@@ -506,6 +705,16 @@ fn handle_spawn_pipe(
     };
     thread::spawn(move || {
         let exit_code = wait_for_exit(&procs, pt3, timeout);
+        // The exit frame must follow all output and carry the final
+        // truncation state, so wait for both readers to drain the pipes.
+        let deadline = std::time::Instant::now() + OUTPUT_DRAIN_GRACE;
+        for _ in 0..2 {
+            let left = deadline.saturating_duration_since(std::time::Instant::now());
+            if drained_rx.recv_timeout(left).is_err() {
+                log::warn!("[spawn] process_tag={pt3} pipes still open after exit");
+                break;
+            }
+        }
         if let Some((step_num, ckpt, snapshot)) = step {
             match ckpt.capture_diff(step_num, &snapshot) {
                 Ok(changed) => {
@@ -516,10 +725,55 @@ fn handle_spawn_pipe(
                 }
             }
         }
-        send_exit_event(pt3, exit_code, &w3, &procs);
+        send_exit_event(pt3, exit_code, budget.truncated(), &w3, &procs);
     });
 }
 
+/// Caps the combined stdout and stderr a pipe-mode process streams back.
+/// Readers keep draining past the cap so the process never blocks on a full
+/// pipe; the excess is dropped.
+struct OutputBudget {
+    remaining: Mutex<Option<u64>>,
+    truncated: AtomicBool,
+    kill: bool,
+    pid: u32,
+}
+
+impl OutputBudget {
+    fn new(max_bytes: u64, kill: bool, pid: u32) -> Self {
+        OutputBudget {
+            remaining: Mutex::new((max_bytes > 0).then_some(max_bytes)),
+            truncated: AtomicBool::new(false),
+            kill,
+            pid,
+        }
+    }
+
+    /// Returns how many of the next `n` bytes may be forwarded. The first
+    /// time output is dropped the budget is marked truncated and, if asked,
+    /// the process group is killed.
+    fn take(&self, n: usize) -> usize {
+        let mut remaining = self.remaining.lock().unwrap();
+        let left = match remaining.as_mut() {
+            Some(left) => left,
+            None => return n,
+        };
+        let allowed = (*left).min(n as u64) as usize;
+        *left -= allowed as u64;
+        if allowed < n && !self.truncated.swap(true, Ordering::SeqCst) {
+            log::warn!("[spawn] pid={} output limit reached, discarding further output", self.pid);
+            if self.kill {
+                let _ = kill_process_group(self.pid, nix::sys::signal::Signal::SIGKILL);
+            }
+        }
+        allowed
+    }
+
+    fn truncated(&self) -> bool {
+        self.truncated.load(Ordering::SeqCst)
+    }
+}
+
 fn handle_spawn_pty(
     tag: u32,
     process_tag: u32,
@@ -528,6 +782,7 @@ fn handle_spawn_pty(
     writer: &SharedWriter,
     processes: &Arc<Mutex<HashMap<u32, ProcessHandle>>>,
     checkpointer: &Option<Arc<Checkpointer>>,
+    received: std::time::Instant,
 ) {
     let rows = if params.rows == 0 { 24 } else { params.rows as u16 };
     let cols = if params.cols == 0 { 80 } else { params.cols as u16 };
@@ -556,6 +811,7 @@ fn handle_spawn_pty(
     if !has_term && std::env::var("TERM").is_err() {
         cmd.env("TERM", "xterm-256color");
     }
+    strip_agent_secrets(&mut cmd);
 
     let step = if let Some(ckpt) = checkpointer {
         let step_num = ckpt.next_step();
@@ -625,6 +881,7 @@ fn handle_spawn_pty(
         proto::response::Kind::Spawn(proto::SpawnResponse {
             process_tag,
             pid: pid as i32,
+            start_latency_us: received.elapsed().as_micros() as u64,
         }),
     );
 
@@ -686,7 +943,7 @@ fn handle_spawn_pty(
                 }
             }
         }
-        send_exit_event(pt2, exit_code, &w2, &procs);
+        send_exit_event(pt2, exit_code, false, &w2, &procs);
     });
 }
 
@@ -713,10 +970,7 @@ fn wait_for_exit(
 
         let killer = thread::spawn(move || {
             if done_rx.recv_timeout(dur).is_err() {
-                let _ = nix::sys::signal::kill(
-                    nix::unistd::Pid::from_raw(pid as i32),
-                    nix::sys::signal::Signal::SIGKILL,
-                );
+                let _ = kill_process_group(pid, nix::sys::signal::Signal::SIGKILL);
             }
         });
 
@@ -741,6 +995,7 @@ fn wait_for_exit(
 fn send_exit_event(
     process_tag: u32,
     exit_code: i32,
+    output_truncated: bool,
     writer: &SharedWriter,
     processes: &Arc<Mutex<HashMap<u32, ProcessHandle>>>,
 ) {
@@ -753,13 +1008,14 @@ fn send_exit_event(
         procs.remove(&process_tag);
     }
 
-    log::info!("[exit] process_tag={process_tag} exit_code={exit_code}");
+    log::info!("[exit] process_tag={process_tag} exit_code={exit_code} output_truncated={output_truncated}");
     let _ = send_event(
         writer,
         0,
         proto::event::Kind::Exit(proto::ExitEvent {
             process_tag,
             exit_code,
+            output_truncated,
         }),
     );
 }
@@ -771,7 +1027,7 @@ fn wait_and_exit(
     timeout: Option<u64>,
 ) {
     let exit_code = wait_for_exit(processes, process_tag, timeout);
-    send_exit_event(process_tag, exit_code, writer, processes);
+    send_exit_event(process_tag, exit_code, false, writer, processes);
 }
 
 // ---------------------------------------------------------------------------
@@ -838,6 +1094,34 @@ fn handle_write_in(
 // signal
 // ---------------------------------------------------------------------------
 
+/// Parses a signal given by name ("SIGQUIT", "quit") or number ("3").
+/// Returns None for anything the platform does not define.
+fn parse_signal(raw: &str) -> Option<nix::sys::signal::Signal> {
+    let raw = raw.trim();
+    if let Ok(num) = raw.parse::<i32>() {
+        return nix::sys::signal::Signal::try_from(num).ok();
+    }
+    let upper = raw.to_uppercase();
+    let name = if upper.starts_with("SIG") {
+        upper
+    } else {
+        format!("SIG{upper}")
+    };
+    name.parse().ok()
+}
+
+/// Sends `sig` to the process group led by `pid`. Spawned commands lead their
+/// own group (pipe mode via `process_group(0)`, PTY mode via `setsid`), so
+/// this reaches every descendant that has not moved to another group. Falls
+/// back to signalling the pid alone if the group no longer exists.
+fn kill_process_group(pid: u32, sig: nix::sys::signal::Signal) -> nix::Result<()> {
+    let pid = nix::unistd::Pid::from_raw(pid as i32);
+    match nix::sys::signal::killpg(pid, sig) {
+        Ok(()) => Ok(()),
+        Err(_) => nix::sys::signal::kill(pid, sig),
+    }
+}
+
 fn handle_signal(
     tag: u32,
     params: proto::SignalRequest,
@@ -858,26 +1142,20 @@ fn handle_signal(
         }
     };
 
-    let sig = match params.signal.to_uppercase().as_str() {
-        "SIGTERM" => nix::sys::signal::Signal::SIGTERM,
-        "SIGKILL" => nix::sys::signal::Signal::SIGKILL,
-        "SIGINT" => nix::sys::signal::Signal::SIGINT,
-        "SIGHUP" => nix::sys::signal::Signal::SIGHUP,
-        "SIGUSR1" => nix::sys::signal::Signal::SIGUSR1,
-        "SIGUSR2" => nix::sys::signal::Signal::SIGUSR2,
-        other => {
+    let sig = match parse_signal(&params.signal) {
+        Some(sig) => sig,
+        None => {
             let _ = send_error(
                 writer,
                 tag,
                 5,
-                format!("unsupported signal: {other}"),
+                format!("unsupported signal: {}", params.signal),
             );
             return;
         }
     };
 
-    let pid = nix::unistd::Pid::from_raw(ph.pid as i32);
-    if let Err(e) = nix::sys::signal::kill(pid, sig) {
+    if let Err(e) = kill_process_group(ph.pid, sig) {
         let _ = send_error(writer, tag, 5, format!("{e}"));
         return;
     }
@@ -1679,6 +1957,67 @@ mod tests {
         assert!(got_exit, "expected exit event");
     }
 
+    #[test]
+    fn test_parse_signal() {
+        use nix::sys::signal::Signal;
+        assert_eq!(parse_signal("SIGQUIT"), Some(Signal::SIGQUIT));
+        assert_eq!(parse_signal("usr1"), Some(Signal::SIGUSR1));
+        assert_eq!(parse_signal("9"), Some(Signal::SIGKILL));
+        assert_eq!(parse_signal(" sigwinch "), Some(Signal::SIGWINCH));
+        assert_eq!(parse_signal("SIGBOGUS"), None);
+        assert_eq!(parse_signal("0"), None);
+        assert_eq!(parse_signal("999"), None);
+    }
+
+    #[test]
+    fn test_timeout_kills_process_group() {
+        let ws = tempfile::tempdir().unwrap();
+        let (sock, _tx) = start_test_agent(ws.path().to_str().unwrap());
+
+        let mut stream = UnixStream::connect(&sock).unwrap();
+        stream.set_read_timeout(Some(std::time::Duration::from_secs(10))).unwrap();
+
+        send_request_pb(&mut stream, 11, proto::request::Kind::Spawn(proto::SpawnRequest {
+            command: vec!["sh".into(), "-c".into(), "sleep 30 & echo $!; wait".into()],
+            timeout_seconds: 1,
+            ..Default::default()
+        }));
+
+        let mut child_pid: Option<i32> = None;
+        let mut got_exit = false;
+        for _ in 0..20 {
+            match read_server_msg(&mut stream) {
+                Some(ServerMsg::Event(evt)) => match &evt.kind {
+                    Some(proto::event::Kind::Stdout(so)) => {
+                        child_pid = String::from_utf8_lossy(&so.data).trim().parse().ok();
+                    }
+                    Some(proto::event::Kind::Exit(_)) => {
+                        got_exit = true;
+                        break;
+                    }
+                    _ => {}
+                },
+                Some(ServerMsg::Response(_)) => {}
+                None => break,
+            }
+        }
+        assert!(got_exit, "expected exit event after timeout");
+        let child_pid = child_pid.expect("expected background child pid on stdout");
+
+        // The backgrounded sleep shares the shell's process group and must be
+        // killed along with it rather than left orphaned.
+        let alive = |pid: i32| {
+            std::fs::read_to_string(format!("/proc/{pid}/stat"))
+                .map(|stat| !stat.contains(") Z "))
+                .unwrap_or(false)
+        };
+        let deadline = std::time::Instant::now() + std::time::Duration::from_secs(3);
+        while alive(child_pid) {
+            assert!(std::time::Instant::now() < deadline, "background child {child_pid} survived timeout");
+            thread::sleep(std::time::Duration::from_millis(50));
+        }
+    }
+
     #[test]
     fn test_spawn_with_stdin() {
         let ws = tempfile::tempdir().unwrap();
@@ -1746,6 +2085,97 @@ mod tests {
         assert!(got_exit, "expected exit event");
     }
 
+    #[test]
+    fn test_spawn_with_stdin_data() {
+        let ws = tempfile::tempdir().unwrap();
+        let (sock, _tx) = start_test_agent(ws.path().to_str().unwrap());
+
+        let mut stream = UnixStream::connect(&sock).unwrap();
+        stream
+            .set_read_timeout(Some(std::time::Duration::from_secs(5)))
+            .unwrap();
+
+        // cat only exits once stdin is closed after the data is written.
+        send_request_pb(&mut stream, 23, proto::request::Kind::Spawn(proto::SpawnRequest {
+            command: vec!["cat".into()],
+            stdin_data: b"piped input\n".to_vec(),
+            ..Default::default()
+        }));
+
+        let mut stdout = String::new();
+        let mut exit_code = None;
+        for _ in 0..30 {
+            match read_server_msg(&mut stream) {
+                Some(ServerMsg::Response(_)) => continue,
+                Some(ServerMsg::Event(evt)) => match &evt.kind {
+                    Some(proto::event::Kind::Stdout(so)) => {
+                        stdout.push_str(&String::from_utf8_lossy(&so.data));
+                    }
+                    Some(proto::event::Kind::Exit(e)) => {
+                        exit_code = Some(e.exit_code);
+                        break;
+                    }
+                    _ => {}
+                },
+                None => break,
+            }
+        }
+
+        assert_eq!(stdout, "piped input\n");
+        assert_eq!(exit_code, Some(0));
+    }
+
+    #[test]
+    fn test_resolve_spawn_workdir() {
+        let ws = Path::new("/workspace");
+        assert_eq!(resolve_spawn_workdir(ws, "/tmp").unwrap(), "/tmp");
+        assert_eq!(resolve_spawn_workdir(ws, "src/app").unwrap(), "/workspace/src/app");
+        assert_eq!(resolve_spawn_workdir(ws, "./src/../lib").unwrap(), "/workspace/lib");
+        assert_eq!(resolve_spawn_workdir(ws, ".").unwrap(), "/workspace");
+        assert!(resolve_spawn_workdir(ws, "..").is_err());
+        assert!(resolve_spawn_workdir(ws, "src/../../etc").is_err());
+    }
+
+    #[test]
+    fn test_spawn_output_limit() {
+        let ws = tempfile::tempdir().unwrap();
+        let (sock, _tx) = start_test_agent(ws.path().to_str().unwrap());
+
+        let mut stream = UnixStream::connect(&sock).unwrap();
+        stream
+            .set_read_timeout(Some(std::time::Duration::from_secs(5)))
+            .unwrap();
+
+        // yes never stops on its own; only the limit ends it.
+        send_request_pb(&mut stream, 24, proto::request::Kind::Spawn(proto::SpawnRequest {
+            command: vec!["yes".into()],
+            max_output_bytes: 1000,
+            kill_on_output_limit: true,
+            ..Default::default()
+        }));
+
+        let mut forwarded = 0;
+        let mut exit = None;
+        for _ in 0..1000 {
+            match read_server_msg(&mut stream) {
+                Some(ServerMsg::Response(_)) => continue,
+                Some(ServerMsg::Event(evt)) => match evt.kind {
+                    Some(proto::event::Kind::Stdout(so)) => forwarded += so.data.len(),
+                    Some(proto::event::Kind::Exit(e)) => {
+                        exit = Some(e);
+                        break;
+                    }
+                    _ => {}
+                },
+                None => break,
+            }
+        }
+
+        assert_eq!(forwarded, 1000);
+        let exit = exit.expect("expected exit event");
+        assert!(exit.output_truncated, "expected output_truncated on exit");
+    }
+
     #[test]
     fn test_read_file() {
         let ws = tempfile::tempdir().unwrap();
@@ -2094,4 +2524,53 @@ mod tests {
 
         assert!(!got_fs_event, "should not receive fs_change after unwatch");
     }
+
+    fn auth_frame(token: &[u8]) -> Vec<u8> {
+        let mut frame = vec![MSG_TYPE_AUTH];
+        frame.extend_from_slice(&(token.len() as u32).to_be_bytes());
+        frame.extend_from_slice(token);
+        frame
+    }
+
+    #[test]
+    fn test_derive_token() {
+        // Matches TestDeriveExecutorToken in pkg/client.
+        assert_eq!(
+            derive_token(b"master-secret", "3f2c9a1e-pod-uid"),
+            "5fde7075008096b976e34482e63a2de1e56cac91af43e13ec885aae4accae0c6"
+        );
+        assert_ne!(
+            derive_token(b"master-secret", "other-pod-uid"),
+            derive_token(b"master-secret", "3f2c9a1e-pod-uid")
+        );
+    }
+
+    #[test]
+    fn test_serve_remote_sends_challenge() {
+        let auth = TcpAuth {
+            identity: "pod-uid".into(),
+            token: "t0ken".into(),
+        };
+        let (ours, mut theirs) = std::os::unix::net::UnixStream::pair().unwrap();
+        let writer: SharedWriter = Arc::new(Mutex::new(Box::new(ours)));
+        let frame = auth_frame(b"wrong");
+        serve_remote(&frame[..], writer, "/tmp", None, Some(&auth)).unwrap();
+        let mut sent = vec![0u8; 5 + b"pod-uid".len()];
+        theirs.read_exact(&mut sent).unwrap();
+        assert_eq!(sent[0], MSG_TYPE_AUTH_CHALLENGE);
+        assert_eq!(&sent[5..], b"pod-uid");
+    }
+
+    #[test]
+    fn test_read_auth() {
+        let frame = auth_frame(b"s3cret");
+        assert!(read_auth(&mut &frame[..], "s3cret").unwrap());
+
+        let frame = auth_frame(b"wrong!");
+        assert!(!read_auth(&mut &frame[..], "s3cret").unwrap());
+
+        let mut frame = auth_frame(b"s3cret");
+        frame[0] = MSG_TYPE_REQUEST;
+        assert!(!read_auth(&mut &frame[..], "s3cret").unwrap());
+    }
 }
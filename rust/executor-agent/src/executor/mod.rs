@@ -3,3 +3,4 @@ pub mod agent;
 pub mod iroh_endpoint;
 pub mod connection;
 pub mod streams;
+pub mod tls;
@@ -0,0 +1,89 @@
+use log::{info, warn};
+use rustls::pki_types::pem::PemObject;
+use rustls::pki_types::{CertificateDer, PrivateKeyDer};
+use rustls::server::WebPkiClientVerifier;
+use rustls::{RootCertStore, ServerConfig};
+use std::io;
+use std::os::unix::net::UnixStream;
+use std::sync::Arc;
+use tokio::runtime::Handle;
+use tokio_rustls::TlsAcceptor;
+
+/// Build the mutual-TLS acceptor for the TCP listener: the agent presents
+/// `cert_path`/`key_path` and accepts only clients whose certificate chains
+/// to `ca_path`.
+pub fn acceptor(cert_path: &str, key_path: &str, ca_path: &str) -> io::Result<TlsAcceptor> {
+    let certs = load_certs(cert_path)?;
+    let key = PrivateKeyDer::from_pem_file(key_path)
+        .map_err(|e| io::Error::other(format!("load TLS key {key_path}: {e}")))?;
+    let mut roots = RootCertStore::empty();
+    for ca in load_certs(ca_path)? {
+        roots
+            .add(ca)
+            .map_err(|e| io::Error::other(format!("add TLS CA from {ca_path}: {e}")))?;
+    }
+
+    let provider = Arc::new(rustls::crypto::ring::default_provider());
+    let verifier = WebPkiClientVerifier::builder_with_provider(Arc::new(roots), provider.clone())
+        .build()
+        .map_err(|e| io::Error::other(format!("TLS client verifier: {e}")))?;
+    let config = ServerConfig::builder_with_provider(provider)
+        .with_safe_default_protocol_versions()
+        .map_err(|e| io::Error::other(format!("TLS protocol versions: {e}")))?
+        .with_client_cert_verifier(verifier)
+        .with_single_cert(certs, key)
+        .map_err(|e| io::Error::other(format!("TLS server config: {e}")))?;
+    Ok(TlsAcceptor::from(Arc::new(config)))
+}
+
+fn load_certs(path: &str) -> io::Result<Vec<CertificateDer<'static>>> {
+    let certs = CertificateDer::pem_file_iter(path)
+        .and_then(|certs| certs.collect::<Result<Vec<_>, _>>())
+        .map_err(|e| io::Error::other(format!("load TLS certificates {path}: {e}")))?;
+    if certs.is_empty() {
+        return Err(io::Error::other(format!("no TLS certificates in {path}")));
+    }
+    Ok(certs)
+}
+
+/// Terminate TLS on `stream` and return the plaintext end of a socket pair,
+/// so the blocking session handler serves the connection unchanged while a
+/// task on `handle` pumps bytes through the TLS session. A failed handshake
+/// closes the pair, which the handler sees as the client disconnecting.
+pub fn bridge(
+    stream: std::net::TcpStream,
+    acceptor: TlsAcceptor,
+    handle: &Handle,
+) -> io::Result<UnixStream> {
+    let (plain, inner) = UnixStream::pair()?;
+    stream.set_nonblocking(true)?;
+    inner.set_nonblocking(true)?;
+    handle.spawn(async move {
+        let tcp = match tokio::net::TcpStream::from_std(stream) {
+            Ok(s) => s,
+            Err(e) => {
+                warn!("TLS bridge: register TCP stream: {e}");
+                return;
+            }
+        };
+        let mut inner = match tokio::net::UnixStream::from_std(inner) {
+            Ok(s) => s,
+            Err(e) => {
+                warn!("TLS bridge: register socket pair: {e}");
+                return;
+            }
+        };
+        let mut tls = match acceptor.accept(tcp).await {
+            Ok(s) => s,
+            // Kubelet TCP probes connect and close without a handshake.
+            Err(e) => {
+                info!("TLS handshake failed: {e}");
+                return;
+            }
+        };
+        if let Err(e) = tokio::io::copy_bidirectional(&mut tls, &mut inner).await {
+            info!("TLS connection closed: {e}");
+        }
+    });
+    Ok(plain)
+}
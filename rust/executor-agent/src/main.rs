@@ -29,6 +29,63 @@ struct Cli {
     /// TCP listen port (0 = disabled)
     #[arg(long = "tcp-port", default_value_t = 0)]
     tcp_port: u16,
+
+    /// Derive this pod's TCP auth token from the master token file, write it
+    /// to --derive-token-to and exit (run from an init container)
+    #[arg(long = "derive-token-from", default_value = "")]
+    derive_token_from: String,
+
+    /// Output path for --derive-token-from
+    #[arg(long = "derive-token-to", default_value = "")]
+    derive_token_to: String,
+}
+
+/// Writes hex(HMAC-SHA256(master, ARL_POD_UID)) to `to`. The master token
+/// stays in the init container; the agent container only sees its own token.
+fn derive_token_file(from: &str, to: &str) -> Result<(), String> {
+    let uid = std::env::var("ARL_POD_UID")
+        .ok()
+        .filter(|v| !v.is_empty())
+        .ok_or("ARL_POD_UID is not set")?;
+    let master = std::fs::read_to_string(from).map_err(|e| format!("read {from}: {e}"))?;
+    let token = executor::agent::derive_token(master.trim().as_bytes(), &uid);
+    std::fs::write(to, token).map_err(|e| format!("write {to}: {e}"))?;
+    use std::os::unix::fs::PermissionsExt;
+    std::fs::set_permissions(to, std::fs::Permissions::from_mode(0o444))
+        .map_err(|e| format!("chmod {to}: {e}"))
+}
+
+/// Per-pod TCP credentials: the token derived by the init container and the
+/// pod UID it was derived from. Auth is off when ARL_EXECUTOR_TOKEN_FILE is unset.
+fn tcp_auth() -> Result<Option<executor::agent::TcpAuth>, String> {
+    let var = |name: &str| std::env::var(name).ok().filter(|v| !v.is_empty());
+    let path = match var("ARL_EXECUTOR_TOKEN_FILE") {
+        Some(path) => path,
+        // A shared token in the environment would be readable by every
+        // sandbox process; refuse to serve TCP unauthenticated instead.
+        None if var("ARL_EXECUTOR_TOKEN").is_some() => {
+            return Err("ARL_EXECUTOR_TOKEN is no longer supported; regenerate the pool template to use ARL_EXECUTOR_TOKEN_FILE".into())
+        }
+        None => return Ok(None),
+    };
+    let identity = var("ARL_POD_UID").ok_or("ARL_POD_UID is not set")?;
+    let token = std::fs::read_to_string(&path).map_err(|e| format!("read {path}: {e}"))?;
+    let token = token.trim().to_string();
+    if token.is_empty() {
+        return Err(format!("{path} is empty"));
+    }
+    Ok(Some(executor::agent::TcpAuth { identity, token }))
+}
+
+/// TLS certificate, key and client CA paths for the TCP listener, set from
+/// the mounted executor TLS secret. TLS is off unless all three are set.
+fn tls_paths() -> Option<(String, String, String)> {
+    let var = |name: &str| std::env::var(name).ok().filter(|v| !v.is_empty());
+    Some((
+        var("ARL_EXECUTOR_TLS_CERT")?,
+        var("ARL_EXECUTOR_TLS_KEY")?,
+        var("ARL_EXECUTOR_TLS_CA")?,
+    ))
 }
 
 #[tokio::main]
@@ -37,6 +94,14 @@ async fn main() {
 
     let cli = Cli::parse();
 
+    if !cli.derive_token_from.is_empty() {
+        if let Err(e) = derive_token_file(&cli.derive_token_from, &cli.derive_token_to) {
+            log::error!("Failed to derive executor token: {e}");
+            std::process::exit(1);
+        }
+        return;
+    }
+
     if let Some(parent) = cli.socket.parent() {
         if let Err(e) = std::fs::create_dir_all(parent) {
             log::error!("Failed to create socket directory: {}", e);
@@ -98,6 +163,30 @@ async fn main() {
         let tcp_workspace = workspace.clone();
         let tcp_checkpointer = checkpointer.clone();
         let tcp_port = cli.tcp_port;
+        let tcp_auth = match tcp_auth() {
+            Ok(auth) => auth.map(Arc::new),
+            Err(e) => {
+                log::error!("Failed to load TCP auth token: {e}");
+                std::process::exit(1);
+            }
+        };
+        if tcp_auth.is_some() {
+            log::info!("TCP connections require an auth token");
+        }
+        let tcp_tls = match tls_paths() {
+            Some((cert, key, ca)) => match executor::tls::acceptor(&cert, &key, &ca) {
+                Ok(acceptor) => {
+                    log::info!("TCP connections require mutual TLS");
+                    Some(acceptor)
+                }
+                Err(e) => {
+                    log::error!("Failed to load TLS configuration: {e}");
+                    std::process::exit(1);
+                }
+            },
+            None => None,
+        };
+        let runtime = tokio::runtime::Handle::current();
         Some(tokio::task::spawn_blocking(move || {
             use std::net::TcpListener;
             let addr = format!("0.0.0.0:{tcp_port}");
@@ -116,9 +205,18 @@ async fn main() {
                         log::info!("TCP connection from {peer}");
                         let ws = tcp_workspace.clone();
                         let ckpt = tcp_checkpointer.clone();
+                        let auth = tcp_auth.clone();
                         let (_tx, sd) = tokio::sync::watch::channel(false);
+                        let tls = tcp_tls.clone();
+                        let rt = runtime.clone();
                         std::thread::spawn(move || {
-                            if let Err(e) = executor::agent::handle_conn_tcp(stream, &ws, sd, ckpt) {
+                            let result = match tls {
+                                Some(acceptor) => executor::tls::bridge(stream, acceptor, &rt).and_then(|plain| {
+                                    executor::agent::handle_conn_tls(plain, &ws, sd, ckpt, auth.as_deref())
+                                }),
+                                None => executor::agent::handle_conn_tcp(stream, &ws, sd, ckpt, auth.as_deref()),
+                            };
+                            if let Err(e) = result {
                                 log::error!("TCP connection error: {e}");
                             }
                         });
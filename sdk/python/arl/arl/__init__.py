@@ -32,6 +32,8 @@ from arl.types import (
     ForkSessionResponse,
     GatewaySummary,
     GitConfig,
+    HTTPStepRequest,
+    HTTPStepResult,
     InlineToolSpec,
     LogEntry,
     ManagedSessionInfo,
@@ -45,7 +47,10 @@ from arl.types import (
     RestoreResponse,
     SessionInfo,
     SessionListItem,
+    SessionResourceUsage,
+    SessionStats,
     ShellMessage,
+    SnapshotResponse,
     SSHInfo,
     StepOutput,
     StepRequest,
@@ -91,6 +96,8 @@ __all__ = [
     "GatewayOperationTimeout",
     "GatewaySummary",
     "GitConfig",
+    "HTTPStepRequest",
+    "HTTPStepResult",
     "InlineToolSpec",
     "InteractiveShellClient",
     "IrohTransport",
@@ -106,6 +113,7 @@ __all__ = [
     "ReplayResponse",
     "ResourceRequirements",
     "RestoreResponse",
+    "SnapshotResponse",
     "SSHInfo",
     "SandboxSession",
     "SecretEnvVarRef",
@@ -113,6 +121,8 @@ __all__ = [
     "SecretTemplate",
     "SessionInfo",
     "SessionListItem",
+    "SessionResourceUsage",
+    "SessionStats",
     "SessionNotInitializedError",
     "ShellMessage",
     "SsoTokenAuth",
@@ -89,10 +89,11 @@ OPERATION_STATUS_ERROR = "error"
 class GatewayError(ArlError):
     """Error from gateway API."""
 
-    def __init__(self, status_code: int, error: str, detail: str = "") -> None:
+    def __init__(self, status_code: int, error: str, detail: str = "", code: str = "") -> None:
         self.status_code = status_code
         self.error = error
         self.detail = detail
+        self.code = code
         super().__init__(
             f"Gateway error ({status_code}): {error}" + (f" - {detail}" if detail else "")
         )
@@ -133,7 +134,7 @@ def handle_error(response: httpx.Response) -> None:
     if response.status_code >= 400:
         try:
             err = ErrorResponse.model_validate(response.json())
-            raise GatewayError(response.status_code, err.error, err.detail)
+            raise GatewayError(response.status_code, err.error, err.detail, err.code)
         except (ValueError, KeyError):
             raise GatewayError(response.status_code, response.text) from None
 
@@ -240,6 +241,8 @@ def build_create_pool_body(
     config_env: ConfigEnvSpec | dict[str, Any] | None,
     image_locality: dict[str, Any] | bool | None,
     private_containers: Iterable[PrivateContainerSpec | dict[str, Any]] | None,
+    workspace_volume: dict[str, Any] | None = None,
+    executor_probes: dict[str, Any] | None = None,
 ) -> dict[str, Any]:
     body: dict[str, Any] = {
         "name": name,
@@ -260,6 +263,10 @@ def build_create_pool_body(
     pc = serialize_private_containers(private_containers)
     if pc is not None:
         body["privateContainers"] = pc
+    if workspace_volume is not None:
+        body["workspaceVolume"] = workspace_volume
+    if executor_probes is not None:
+        body["executorProbes"] = executor_probes
     return body
 
 
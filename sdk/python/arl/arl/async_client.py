@@ -56,6 +56,8 @@ from arl.types import (
     RestoreResponse,
     SessionInfo,
     SessionListItem,
+    SessionStats,
+    SnapshotResponse,
     StepRequest,
     StepResult,
     ToolsSpec,
@@ -127,6 +129,37 @@ class AsyncGatewayClient:
         handle_error(resp)
         return SessionInfo.model_validate(resp.json())
 
+    async def run(
+        self,
+        steps: list[StepRequest | dict[str, Any]],
+        image: str | None = None,
+        *,
+        profile: str | None = "default",
+        parallel: bool = False,
+        parallelism_limit: int | None = None,
+    ) -> ExecuteResponse:
+        """Run steps in a fresh sandbox that is deleted when they finish.
+
+        The gateway creates the session, executes the steps and deletes the
+        session in one call, even when a step fails.
+        """
+        if not image and not profile:
+            raise ValueError("image or profile is required")
+        execute: dict[str, Any] = {"steps": serialize_steps(steps)}
+        if parallel:
+            execute["parallel"] = True
+            if parallelism_limit is not None:
+                execute["parallelismLimit"] = parallelism_limit
+        body = {
+            "session": build_create_session_body(
+                image, profile, None, None, None, None, None, None, None,
+            ),
+            "execute": execute,
+        }
+        resp = await self._client.post("/v1/run", json=body)
+        handle_error(resp)
+        return ExecuteResponse.model_validate(resp.json())
+
     async def get_session(self, session_id: str) -> SessionInfo:
         resp = await self._client.get(f"/v1/sessions/{session_id}")
         handle_error(resp)
@@ -166,6 +199,22 @@ class AsyncGatewayClient:
         handle_error(resp)
         return ForkSessionResponse.model_validate(resp.json())
 
+    async def update_session(
+        self,
+        session_id: str,
+        *,
+        keep_alive: bool = False,
+        idle_timeout_seconds: int | None = None,
+    ) -> SessionInfo:
+        body: dict[str, Any] = {}
+        if keep_alive:
+            body["keepAlive"] = True
+        if idle_timeout_seconds is not None:
+            body["idleTimeoutSeconds"] = idle_timeout_seconds
+        resp = await self._client.patch(f"/v1/sessions/{session_id}", json=body)
+        handle_error(resp)
+        return SessionInfo.model_validate(resp.json())
+
     async def suspend_session(self, session_id: str) -> None:
         resp = await self._client.post(f"/v1/sessions/{session_id}/suspend")
         handle_error(resp)
@@ -199,6 +248,8 @@ class AsyncGatewayClient:
         on_output: Callable[[str, str], None | Awaitable[None]] | None = None,
         recover: bool = True,
         recover_timeout: float | None = None,
+        parallel: bool = False,
+        parallelism_limit: int | None = None,
     ) -> ExecuteResponse:
         """Execute steps in a session.
 
@@ -208,10 +259,18 @@ class AsyncGatewayClient:
         never broken.
 
         ``on_output`` may be a regular or an ``async`` callable.
+
+        ``parallel=True`` runs independent steps concurrently, at most
+        ``parallelism_limit`` at a time; results keep request order.
+        Streaming calls (``on_output``) always run steps in sequence.
         """
         body: dict[str, Any] = {"steps": serialize_steps(steps)}
         if trace_id is not None:
             body["traceID"] = trace_id
+        if parallel:
+            body["parallel"] = True
+            if parallelism_limit is not None:
+                body["parallelismLimit"] = parallelism_limit
 
         if on_output is not None:
             return await self._execute_sse(
@@ -254,6 +313,15 @@ class AsyncGatewayClient:
         handle_error(resp)
         return ExecuteOperationInfo.model_validate(resp.json())
 
+    async def cancel_execute_operation(
+        self, session_id: str, operation_id: str,
+    ) -> ExecuteOperationInfo:
+        resp = await self._client.post(
+            f"/v1/sessions/{session_id}/operations/{operation_id}/cancel"
+        )
+        handle_error(resp)
+        return ExecuteOperationInfo.model_validate(resp.json())
+
     async def execute_container(
         self,
         session_id: str,
@@ -401,6 +469,11 @@ class AsyncGatewayClient:
             body, op_id, ReplayResponse, recover, recover_timeout,
         )
 
+    async def snapshot(self, session_id: str) -> SnapshotResponse:
+        resp = await self._client.post(f"/v1/sessions/{session_id}/snapshot")
+        handle_error(resp)
+        return SnapshotResponse.model_validate(resp.json())
+
     async def restore(
         self,
         session_id: str,
@@ -426,8 +499,27 @@ class AsyncGatewayClient:
         handle_error(resp)
         return validate_list(resp.json(), StepResult)
 
-    async def get_trajectory(self, session_id: str) -> str:
-        resp = await self._client.get(f"/v1/sessions/{session_id}/trajectory")
+    async def get_stats(self, session_id: str) -> SessionStats:
+        resp = await self._client.get(f"/v1/sessions/{session_id}/stats")
+        handle_error(resp)
+        return SessionStats.model_validate(resp.json())
+
+    async def get_trajectory(
+        self,
+        session_id: str,
+        *,
+        offset: int | None = None,
+        limit: int | None = None,
+        from_storage: bool = False,
+    ) -> str:
+        params: dict[str, str | int] = {}
+        if offset is not None:
+            params["offset"] = offset
+        if limit is not None:
+            params["limit"] = limit
+        if from_storage:
+            params["source"] = "storage"
+        resp = await self._client.get(f"/v1/sessions/{session_id}/trajectory", params=params)
         handle_error(resp)
         return resp.text
 
@@ -501,10 +593,13 @@ class AsyncGatewayClient:
         config_env: ConfigEnvSpec | dict[str, Any] | None = None,
         image_locality: dict[str, Any] | bool | None = None,
         private_containers: Iterable[PrivateContainerSpec | dict[str, Any]] | None = None,
+        workspace_volume: dict[str, Any] | None = None,
+        executor_probes: dict[str, Any] | None = None,
     ) -> None:
         body = build_create_pool_body(
             name, image, replicas, profile, tools, resources,
             workspace_dir, config_env, image_locality, private_containers,
+            workspace_volume, executor_probes,
         )
         resp = await self._client.post("/v1/pools", json=body)
         handle_error(resp)
@@ -538,10 +633,13 @@ class AsyncGatewayClient:
         name: str,
         replicas: int,
         resources: ResourceRequirements | None = None,
+        max_allocated: int | None = None,
     ) -> PoolInfo:
         body: dict[str, Any] = {"replicas": replicas}
         if resources is not None:
             body["resources"] = resources.model_dump(exclude_none=True)
+        if max_allocated is not None:
+            body["maxAllocated"] = max_allocated
         resp = await self._client.patch(f"/v1/pools/{name}", json=body)
         handle_error(resp)
         return PoolInfo.model_validate(resp.json())
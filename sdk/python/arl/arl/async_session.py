@@ -31,6 +31,8 @@ from arl.types import (
     ResourceRequirements,
     RestoreResponse,
     SessionInfo,
+    SessionStats,
+    SnapshotResponse,
     StepRequest,
     StepResult,
     ToolsSpec,
@@ -202,17 +204,20 @@ class AsyncSandboxSession:
         on_output: Callable[[str, str], None | Awaitable[None]] | None = None,
         recover: bool = True,
         recover_timeout: float | None = None,
+        parallel: bool = False,
+        parallelism_limit: int | None = None,
     ) -> ExecuteResponse:
         """Execute steps in the sandbox."""
         if self._session_id is None:
             raise SessionNotInitializedError()
-        iroh = await self._get_iroh()
+        iroh = None if parallel else await self._get_iroh()
         if iroh is not None:
             return await self._execute_via_iroh(steps, on_output=on_output)
         return await self._client.execute(
             self._session_id, steps, trace_id,
             operation_id=operation_id, on_output=on_output,
             recover=recover, recover_timeout=recover_timeout,
+            parallel=parallel, parallelism_limit=parallelism_limit,
         )
 
     async def get_execute_operation(self, operation_id: str) -> ExecuteOperationInfo:
@@ -221,6 +226,12 @@ class AsyncSandboxSession:
             raise SessionNotInitializedError()
         return await self._client.get_execute_operation(self._session_id, operation_id)
 
+    async def cancel_execute_operation(self, operation_id: str) -> ExecuteOperationInfo:
+        """Cancel a pending execute operation, killing the running step."""
+        if self._session_id is None:
+            raise SessionNotInitializedError()
+        return await self._client.cancel_execute_operation(self._session_id, operation_id)
+
     async def execute_container(
         self,
         container: str,
@@ -233,6 +244,17 @@ class AsyncSandboxSession:
             self._session_id, container, steps,
         )
 
+    async def snapshot(self) -> SnapshotResponse:
+        """Capture the workspace as of the latest step.
+
+        With the gateway's tarball restore backend the workspace is saved
+        and restoring the returned ID extracts it; with the replay backend
+        the ID is a step index that restore replays up to.
+        """
+        if self._session_id is None:
+            raise SessionNotInitializedError()
+        return await self._client.snapshot(self._session_id)
+
     async def restore(
         self,
         snapshot_id: str,
@@ -338,6 +360,12 @@ class AsyncSandboxSession:
             raise SessionNotInitializedError()
         return await self._client.get_history(self._session_id)
 
+    async def get_stats(self) -> SessionStats:
+        """Get step totals, durations and age for this session."""
+        if self._session_id is None:
+            raise SessionNotInitializedError()
+        return await self._client.get_stats(self._session_id)
+
     async def export_trajectory(self) -> str:
         """Export execution history as JSONL trajectory (for RL/SFT)."""
         if self._session_id is None:
@@ -360,6 +388,15 @@ class AsyncSandboxSession:
             raise SessionNotInitializedError()
         return await self._client.list_session_logs(self._session_id, tail=tail)
 
+    async def keep_alive(self, idle_timeout_seconds: int | None = None) -> SessionInfo:
+        """Restart the idle clock, optionally replacing the idle timeout."""
+        if self._session_id is None:
+            raise SessionNotInitializedError()
+        return await self._client.update_session(
+            self._session_id, keep_alive=True,
+            idle_timeout_seconds=idle_timeout_seconds,
+        )
+
     async def suspend(self) -> None:
         """Suspend the devbox session (keeps storage, terminates pod)."""
         if self._session_id is None:
@@ -57,6 +57,8 @@ from arl.types import (
     RestoreResponse,
     SessionInfo,
     SessionListItem,
+    SessionStats,
+    SnapshotResponse,
     StepRequest,
     StepResult,
     ToolsSpec,
@@ -130,6 +132,20 @@ class GatewayClient:
             private_containers=private_containers, allow_internet=allow_internet,
         ))
 
+    def run(
+        self,
+        steps: list[StepRequest | dict[str, Any]],
+        image: str | None = None,
+        *,
+        profile: str | None = "default",
+        parallel: bool = False,
+        parallelism_limit: int | None = None,
+    ) -> ExecuteResponse:
+        return self._runner.run(self._async.run(
+            steps, image, profile=profile,
+            parallel=parallel, parallelism_limit=parallelism_limit,
+        ))
+
     def get_session(self, session_id: str) -> SessionInfo:
         return self._runner.run(self._async.get_session(session_id))
 
@@ -140,6 +156,18 @@ class GatewayClient:
         """Fork a session from a historical checkpoint step."""
         return self._runner.run(self._async.fork_session(session_id, step))
 
+    def update_session(
+        self,
+        session_id: str,
+        *,
+        keep_alive: bool = False,
+        idle_timeout_seconds: int | None = None,
+    ) -> SessionInfo:
+        return self._runner.run(self._async.update_session(
+            session_id, keep_alive=keep_alive,
+            idle_timeout_seconds=idle_timeout_seconds,
+        ))
+
     def suspend_session(self, session_id: str) -> None:
         self._runner.run(self._async.suspend_session(session_id))
 
@@ -166,10 +194,13 @@ class GatewayClient:
         on_output: Callable[[str, str], None] | None = None,
         recover: bool = True,
         recover_timeout: float | None = None,
+        parallel: bool = False,
+        parallelism_limit: int | None = None,
     ) -> ExecuteResponse:
         return self._runner.run(self._async.execute(
             session_id, steps, trace_id, operation_id=operation_id,
             on_output=on_output, recover=recover, recover_timeout=recover_timeout,
+            parallel=parallel, parallelism_limit=parallelism_limit,
         ))
 
     def get_execute_operation(
@@ -179,6 +210,13 @@ class GatewayClient:
             self._async.get_execute_operation(session_id, operation_id)
         )
 
+    def cancel_execute_operation(
+        self, session_id: str, operation_id: str,
+    ) -> ExecuteOperationInfo:
+        return self._runner.run(
+            self._async.cancel_execute_operation(session_id, operation_id)
+        )
+
     def execute_container(
         self,
         session_id: str,
@@ -253,6 +291,9 @@ class GatewayClient:
             operation_id=operation_id, recover=recover, recover_timeout=recover_timeout,
         ))
 
+    def snapshot(self, session_id: str) -> SnapshotResponse:
+        return self._runner.run(self._async.snapshot(session_id))
+
     def restore(
         self,
         session_id: str,
@@ -271,8 +312,20 @@ class GatewayClient:
     def get_history(self, session_id: str) -> list[StepResult]:
         return self._runner.run(self._async.get_history(session_id))
 
-    def get_trajectory(self, session_id: str) -> str:
-        return self._runner.run(self._async.get_trajectory(session_id))
+    def get_stats(self, session_id: str) -> SessionStats:
+        return self._runner.run(self._async.get_stats(session_id))
+
+    def get_trajectory(
+        self,
+        session_id: str,
+        *,
+        offset: int | None = None,
+        limit: int | None = None,
+        from_storage: bool = False,
+    ) -> str:
+        return self._runner.run(self._async.get_trajectory(
+            session_id, offset=offset, limit=limit, from_storage=from_storage,
+        ))
 
     def list_sessions(
         self,
@@ -320,11 +373,14 @@ class GatewayClient:
         config_env: ConfigEnvSpec | dict[str, Any] | None = None,
         image_locality: dict[str, Any] | bool | None = None,
         private_containers: Iterable[PrivateContainerSpec | dict[str, Any]] | None = None,
+        workspace_volume: dict[str, Any] | None = None,
+        executor_probes: dict[str, Any] | None = None,
     ) -> None:
         self._runner.run(self._async.create_pool(
             name, image, replicas, profile, tools=tools, resources=resources,
             workspace_dir=workspace_dir, config_env=config_env,
             image_locality=image_locality, private_containers=private_containers,
+            workspace_volume=workspace_volume, executor_probes=executor_probes,
         ))
 
     def list_pools(self, *, include_stopped: bool = False) -> list[PoolInfo]:
@@ -349,9 +405,12 @@ class GatewayClient:
         name: str,
         replicas: int,
         resources: ResourceRequirements | None = None,
+        max_allocated: int | None = None,
     ) -> PoolInfo:
         return self._runner.run(
-            self._async.scale_pool(name, replicas, resources=resources)
+            self._async.scale_pool(
+                name, replicas, resources=resources, max_allocated=max_allocated,
+            )
         )
 
     def iter_pool_logs(
@@ -185,6 +185,40 @@ class InteractiveShellClient:
         msg = json.dumps({"type": "resize", "cols": cols, "rows": rows})
         self._ws.send(msg)  # type: ignore[attr-defined]
 
+    def send_exec(
+        self,
+        exec_id: str,
+        command: list[str],
+        env: dict[str, str] | None = None,
+        workdir: str = "",
+        timeout_seconds: int = 0,
+    ) -> None:
+        """Run one command over the shell socket without typing it into the PTY.
+
+        Output arrives as ``output`` messages with ``id == exec_id`` and
+        ``stream`` set to ``stdout`` or ``stderr``, followed by an ``exit``
+        message carrying the exit code. Only the gateway WebSocket supports it.
+
+        Args:
+            exec_id: Caller-chosen ID used to match the resulting messages.
+            command: Command and arguments.
+            env: Extra environment variables.
+            workdir: Working directory.
+            timeout_seconds: Command timeout; 0 uses the executor default.
+        """
+        if self._iroh_send is not None:
+            raise RuntimeError("exec is only supported over the gateway WebSocket")
+        if self._ws is None:
+            raise RuntimeError("Not connected. Call connect() first.")
+        payload: dict[str, object] = {"type": "exec", "id": exec_id, "command": command}
+        if env:
+            payload["env"] = env
+        if workdir:
+            payload["workdir"] = workdir
+        if timeout_seconds:
+            payload["timeout_seconds"] = timeout_seconds
+        self._ws.send(json.dumps(payload))  # type: ignore[attr-defined]
+
     def read_message(self, timeout: float = 1.0) -> ShellMessage | None:
         """Read the next message as a typed :class:`ShellMessage`.
 
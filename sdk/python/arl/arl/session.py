@@ -26,6 +26,8 @@ from arl.types import (
     ResourceRequirements,
     RestoreResponse,
     SessionInfo,
+    SessionStats,
+    SnapshotResponse,
     StepRequest,
     StepResult,
     ToolsSpec,
@@ -184,11 +186,14 @@ class SandboxSession:
         on_output: Callable[[str, str], None] | None = None,
         recover: bool = True,
         recover_timeout: float | None = None,
+        parallel: bool = False,
+        parallelism_limit: int | None = None,
     ) -> ExecuteResponse:
         """Execute steps in the sandbox. Returns synchronously."""
         return self._runner.run(self._async.execute(
             steps, trace_id, operation_id=operation_id, on_output=on_output,
             recover=recover, recover_timeout=recover_timeout,
+            parallel=parallel, parallelism_limit=parallelism_limit,
         ))
 
     def get_execute_operation(self, operation_id: str) -> ExecuteOperationInfo:
@@ -197,6 +202,12 @@ class SandboxSession:
             self._async.get_execute_operation(operation_id)
         )
 
+    def cancel_execute_operation(self, operation_id: str) -> ExecuteOperationInfo:
+        """Cancel a pending execute operation, killing the running step."""
+        return self._runner.run(
+            self._async.cancel_execute_operation(operation_id)
+        )
+
     def execute_container(
         self,
         container: str,
@@ -209,6 +220,15 @@ class SandboxSession:
 
     # --- Restore / replay ---
 
+    def snapshot(self) -> SnapshotResponse:
+        """Capture the workspace as of the latest step.
+
+        With the gateway's tarball restore backend the workspace is saved
+        and restoring the returned ID extracts it; with the replay backend
+        the ID is a step index that restore replays up to.
+        """
+        return self._runner.run(self._async.snapshot())
+
     def restore(
         self,
         snapshot_id: str,
@@ -285,6 +305,10 @@ class SandboxSession:
         """Get complete execution history for this session."""
         return self._runner.run(self._async.get_history())
 
+    def get_stats(self) -> SessionStats:
+        """Get step totals, durations and age for this session."""
+        return self._runner.run(self._async.get_stats())
+
     def export_trajectory(self) -> str:
         """Export execution history as JSONL trajectory (for RL/SFT)."""
         return self._runner.run(self._async.export_trajectory())
@@ -307,6 +331,10 @@ class SandboxSession:
         """Return recent session log entries."""
         return self._runner.run(self._async.get_logs(tail=tail))
 
+    def keep_alive(self, idle_timeout_seconds: int | None = None) -> SessionInfo:
+        """Restart the idle clock, optionally replacing the idle timeout."""
+        return self._runner.run(self._async.keep_alive(idle_timeout_seconds))
+
     # --- Suspend / resume ---
 
     def suspend(self) -> None:
@@ -9,6 +9,36 @@ from typing import Annotated, Literal
 from pydantic import BaseModel, Field, field_validator, model_validator
 
 
+class HTTPStepRequest(BaseModel):
+    """An HTTP request sent by the gateway to a service in the sandbox.
+
+    Attributes:
+        method: HTTP method (default: GET)
+        port: Port the service listens on in the sandbox
+        path: Request path and query string (default: /)
+        headers: Request headers
+        body: Request body
+    """
+
+    method: str | None = None
+    port: Annotated[int, Field(ge=1, le=65535)]
+    path: str | None = None
+    headers: dict[str, str] | None = None
+    body: str | None = None
+
+
+class HTTPStepResult(BaseModel):
+    """Response to an HTTP step. The body is the step's stdout.
+
+    Attributes:
+        status_code: HTTP status code, 0 when no response was received
+        headers: Response headers
+    """
+
+    status_code: int = 0
+    headers: dict[str, str] | None = None
+
+
 class StepRequest(BaseModel):
     """A single execution step request.
 
@@ -16,9 +46,21 @@ class StepRequest(BaseModel):
         name: Step identifier (must be unique within a batch)
         command: Shell command with arguments, e.g. ["echo", "hello"]
         env: Environment variables to set for this step
-        work_dir: Working directory (default: /workspace)
+        work_dir: Working directory. Relative paths are resolved against the
+            sandbox workspace and may not climb above it.
         timeout_seconds: Timeout in seconds (None = no timeout).
         timeout: Legacy timeout field accepted by the gateway.
+        memory_bytes: Virtual memory cap for the step's processes.
+        cpu_seconds: CPU time cap for the step's processes.
+        depends_on: Names of steps in the same batch that must finish
+            first. Independent steps run concurrently; a step whose
+            dependency failed is skipped. Not supported when streaming.
+        stdin: Text piped to the command's standard input, which is then
+            closed, e.g. a script for ["python", "-"].
+        max_output_bytes: Cap on the step's combined stdout and stderr.
+            Can only lower the gateway's own cap.
+        http: Send an HTTP request to a service in the sandbox instead of
+            running a command. A status of 400 or above exits 1.
     """
 
     name: str
@@ -27,6 +69,12 @@ class StepRequest(BaseModel):
     work_dir: str | None = Field(None, alias="workDir")
     timeout_seconds: Annotated[int | None, Field(gt=0)] = Field(None, alias="timeoutSeconds")
     timeout: Annotated[int | None, Field(gt=0)] = None  # Must be positive if specified
+    memory_bytes: Annotated[int | None, Field(gt=0)] = Field(None, alias="memoryBytes")
+    cpu_seconds: Annotated[int | None, Field(gt=0)] = Field(None, alias="cpuSeconds")
+    depends_on: list[str] | None = Field(None, alias="dependsOn")
+    stdin: str | None = None
+    max_output_bytes: Annotated[int | None, Field(gt=0)] = Field(None, alias="maxOutputBytes")
+    http: HTTPStepRequest | None = None
 
     model_config = {"populate_by_name": True}
 
@@ -56,6 +104,12 @@ class StepResult(BaseModel):
         duration_ms: Execution duration in milliseconds
         timestamp: Execution timestamp (ISO 8601)
         input: Original step request recorded by the gateway.
+        failure_reason: Set when a resource limit stopped the step.
+        trace_id: OpenTelemetry trace ID of the step when gateway tracing is on.
+        overhead_us: Time the executor agent took to start the command, in
+            microseconds. Included in duration_ms; 0 when not reported.
+        output_truncated: Output past the step's output cap was discarded.
+        http: Status code and headers of an HTTP step's response.
     """
 
     index: Annotated[int, Field(ge=0)]
@@ -65,6 +119,11 @@ class StepResult(BaseModel):
     duration_ms: Annotated[int, Field(ge=0)] = 0
     timestamp: datetime | None = None
     input: dict[str, object] | None = None
+    failure_reason: str = ""
+    trace_id: str = ""
+    overhead_us: Annotated[int, Field(ge=0)] = 0
+    output_truncated: bool = False
+    http: HTTPStepResult | None = None
 
 
 class ReplayResponse(BaseModel):
@@ -134,6 +193,34 @@ class ConnectionInfo(BaseModel):
     ports: list[PortInfo] = []
 
 
+class SessionResourceUsage(BaseModel):
+    """Executor container CPU time and peak memory since it started."""
+
+    cpu_seconds: float = Field(0.0, alias="cpuSeconds")
+    peak_memory_bytes: int = Field(0, alias="peakMemoryBytes")
+    sampled_at: datetime | None = Field(None, alias="sampledAt")
+
+    model_config = {"populate_by_name": True}
+
+
+class SessionStats(BaseModel):
+    """Step totals and age for a session.
+
+    ``source`` is ``"storage"`` when totals come from the trajectory store
+    and ``"history"`` when computed from the gateway's in-memory history.
+    """
+
+    session_id: str = Field(alias="sessionID")
+    source: str = ""
+    total_steps: int = Field(0, alias="totalSteps")
+    avg_duration_ms: float = Field(0.0, alias="avgDurationMs")
+    total_duration_ms: int = Field(0, alias="totalDurationMs")
+    history_steps: int = Field(0, alias="historySteps")
+    age_seconds: float = Field(0.0, alias="ageSeconds")
+
+    model_config = {"populate_by_name": True}
+
+
 class SessionInfo(BaseModel):
     """Information about an active session.
 
@@ -157,6 +244,8 @@ class SessionInfo(BaseModel):
     iroh_addr: str = Field("", alias="irohAddr")
     parent_session_id: str = Field("", alias="parentSessionId")
     fork_step: int = Field(0, alias="forkStep")
+    idle_timeout_seconds: int = Field(0, alias="idleTimeoutSeconds")
+    resource_usage: SessionResourceUsage | None = Field(None, alias="resourceUsage")
 
     model_config = {"populate_by_name": True}
 
@@ -214,6 +303,15 @@ class RestoreResponse(BaseModel):
     model_config = {"populate_by_name": True}
 
 
+class SnapshotResponse(BaseModel):
+    """Response from persisting a session's workspace snapshot."""
+
+    snapshot_id: str = Field(alias="snapshotID")
+    step: int = 0
+
+    model_config = {"populate_by_name": True}
+
+
 class ExecuteOperationInfo(BaseModel):
     """Status for an idempotent async operation (execute, restore, replay)."""
 
@@ -264,6 +362,7 @@ class PoolInfo(BaseModel):
         replicas: Desired number of warm pods
         ready_replicas: Number of ready idle pods
         allocated_replicas: Number of pods currently allocated to sessions
+        max_allocated: Cap on concurrent allocations (0 means uncapped)
         state: ARL lifecycle state for the pool
         conditions: Kubernetes status conditions
     """
@@ -275,6 +374,7 @@ class PoolInfo(BaseModel):
     replicas: Annotated[int, Field(ge=0)] = 0
     ready_replicas: Annotated[int, Field(ge=0)] = Field(0, alias="readyReplicas")
     allocated_replicas: Annotated[int, Field(ge=0)] = Field(0, alias="allocatedReplicas")
+    max_allocated: Annotated[int, Field(ge=0)] = Field(0, alias="maxAllocated")
     state: str = ""
     conditions: list[PoolCondition] = []
 
@@ -333,9 +433,14 @@ class BuildResponse(BaseModel):
 
 
 class ErrorResponse(BaseModel):
-    """Error response from the gateway."""
+    """Error response from the gateway.
+
+    ``code`` is a machine-readable classification such as ``POOL_NOT_FOUND``
+    or ``SANDBOX_TIMEOUT``; it is empty for unclassified errors.
+    """
 
     error: str
+    code: str = ""
     detail: str = ""
 
 
@@ -556,7 +661,8 @@ class ShellMessage(BaseModel):
       - output: Shell stdout/stderr data (server → client)
       - signal: Send signal to shell process (client → server, e.g. "SIGINT")
       - resize: Terminal resize event (client → server)
-      - exit: Shell process exited (server → client)
+      - exec: Run one command outside the shell (client → server)
+      - exit: Shell process or exec command exited (server → client)
       - error: Server-side error (server → client)
 
     Attributes:
@@ -566,11 +672,15 @@ class ShellMessage(BaseModel):
         rows: Terminal rows for resize messages
         cols: Terminal columns for resize messages
         exit_code: Exit code for exit messages
+        id: Exec request ID echoed on the output/exit/error messages it produces
+        stream: "stdout" or "stderr" for exec output; empty for shell output
     """
 
-    type: Literal["input", "output", "signal", "resize", "exit", "error"]
+    type: Literal["input", "output", "signal", "resize", "exec", "exit", "error"]
     data: str = ""
     signal: str = ""
     rows: Annotated[int, Field(ge=0)] = 0
     cols: Annotated[int, Field(ge=0)] = 0
     exit_code: int = 0
+    id: str = ""
+    stream: str = ""
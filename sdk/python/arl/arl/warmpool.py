@@ -63,6 +63,8 @@ class WarmPoolManager:
                       limits={cpu: 8, memory: 16Gi}.
             workspace_dir: Workspace directory mount path (default: /workspace).
             image_locality: Optional payload that enables gateway image-locality hints.
+                            Pass {"mode": "spread"} to spread pods across nodes and
+                            zones instead of concentrating them.
         """
         self._client.create_pool(
             name=name,